@@ -0,0 +1,74 @@
+//go:build js && wasm
+
+// Package frontpanels-wasm is a WebAssembly module for client-side panel
+// design pages. It previews panel specs in the browser without the Gerber
+// renderer, so it stays small. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o frontpanels.wasm ./cmd/frontpanels-wasm
+//
+// and load it with the wasm_exec.js shipped with Go. It defines a global
+// frontpanelsPreview(spec, vars) function, taking a YAML or JSON panel spec
+// and an optional object of numeric spec variables, and returning an object
+// with either an svg or an error string property.
+package main
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/prepare"
+	"github.com/jsleeio/frontpanels/pkg/render/preview"
+)
+
+// render previews a panel spec as SVG
+func render(text string, vars map[string]float64) (string, error) {
+	p, err := spec.ParseSelfContainedSpec([]byte(text), vars)
+	if err != nil {
+		return "", err
+	}
+	outline, feats, err := prepare.Features(p, nil, prepare.Options{Diagnostics: diag.Discard})
+	if err != nil {
+		return "", err
+	}
+	pv := preview.New(p, preview.DefaultOptions())
+	pv.Diagnostics = diag.Discard
+	pv.AddFeatures(outline)
+	pv.AddFeatures(feats)
+	var b strings.Builder
+	if err := pv.WriteSVG(&b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// previewFunc implements frontpanelsPreview
+func previewFunc(this js.Value, args []js.Value) interface{} {
+	result := map[string]interface{}{}
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		result["error"] = "frontpanelsPreview: need a spec string"
+		return result
+	}
+	vars := map[string]float64{}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		keys := js.Global().Get("Object").Call("keys", args[1])
+		for i := 0; i < keys.Length(); i++ {
+			name := keys.Index(i).String()
+			vars[name] = args[1].Get(name).Float()
+		}
+	}
+	svg, err := render(args[0].String(), vars)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["svg"] = svg
+	return result
+}
+
+func main() {
+	js.Global().Set("frontpanelsPreview", js.FuncOf(previewFunc))
+	// keep the Go runtime alive to serve calls from JavaScript
+	select {}
+}
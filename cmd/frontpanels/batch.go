@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+)
+
+// batchResult records the outcome of generating one panel in batch mode
+type batchResult struct {
+	specfile, name, dir string
+	width, height       float64
+	err                 error
+}
+
+// readManifest reads a list of spec filenames, one per line. Blank lines
+// and lines starting with # are ignored. Relative paths are relative to the
+// manifest itself.
+func readManifest(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	specs := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(filename), line)
+		}
+		specs = append(specs, line)
+	}
+	return specs, scanner.Err()
+}
+
+// batched reports whether panels are generated from several spec files
+func (c config) batched() bool {
+	return c.batch != "" || c.manifest != "" || len(c.specs) > 1
+}
+
+// specArgs takes the spec files given as arguments: a single file is
+// generated as with -format spec -spec file, and several as a batch.
+// Directories are searched for spec files. Subcommands taking other
+// arguments set ownArgs to leave them alone.
+func (c *config) specArgs(fs *flag.FlagSet) error {
+	if fs.NArg() == 0 || c.ownArgs {
+		return nil
+	}
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "format" || f.Name == "spec"
+	})
+	if explicit {
+		return errors.New("-format and -spec cannot be combined with spec file arguments")
+	}
+	specs := []string{}
+	for _, arg := range fs.Args() {
+		if fi, err := os.Stat(arg); err != nil || !fi.IsDir() {
+			specs = append(specs, arg)
+			continue
+		}
+		found, err := findSpecs(arg)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("%s: no spec files found", arg)
+		}
+		specs = append(specs, found...)
+	}
+	if len(specs) == 1 {
+		c.formatOptions.format, c.formatOptions.spec = "spec", specs[0]
+		return nil
+	}
+	c.specs = specs
+	return nil
+}
+
+// findSpecs returns the YAML files beneath a directory, in lexical order,
+// skipping hidden files and directories such as .frontpanels.yaml and .git
+func findSpecs(dir string) ([]string, error) {
+	specs := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); !info.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			specs = append(specs, path)
+		}
+		return nil
+	})
+	return specs, err
+}
+
+// batchSpecs returns the spec files given as arguments and selected by the
+// batch glob and manifest
+func batchSpecs(cfg config) ([]string, error) {
+	specs := append([]string{}, cfg.specs...)
+	if cfg.batch != "" {
+		matches, err := filepath.Glob(cfg.batch)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, matches...)
+	}
+	if cfg.manifest != "" {
+		listed, err := readManifest(cfg.manifest)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, listed...)
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("no spec files found")
+	}
+	return specs, nil
+}
+
+// specPanelName returns the name of the panel described by a spec file:
+// the name given in the spec, or failing that the spec filename
+func specPanelName(sp *spec.Spec, specfile string) string {
+	if sp.SpecName != "" {
+		return sp.SpecName
+	}
+	return strings.TrimSuffix(filepath.Base(specfile), filepath.Ext(specfile))
+}
+
+// loadBatch loads the spec files of a batch and names their panels by
+// specPanelName. Panels sharing a name, eg. specs of the same name in
+// different directories, are numbered as in catalogs so that they are not
+// written over each other. Results for specs that fail to load record the
+// error.
+func loadBatch(cfg config, specs []string) ([]*spec.Spec, []batchResult) {
+	loaded := make([]*spec.Spec, len(specs))
+	results := make([]batchResult, len(specs))
+	seen := map[string]int{}
+	for n, specfile := range specs {
+		res := &results[n]
+		res.specfile = specfile
+		sp, err := spec.LoadSpecWithVariables(specfile, cfg.vars)
+		if err != nil {
+			res.err = err
+			continue
+		}
+		loaded[n] = sp
+		res.name = specPanelName(sp, specfile)
+		if seen[res.name]++; seen[res.name] > 1 {
+			res.name = fmt.Sprintf("%s-%d", res.name, seen[res.name])
+		}
+		res.width, res.height = sp.Width(), sp.Height()
+		res.dir = filepath.Join(cfg.outputDir, res.name)
+	}
+	return loaded, results
+}
+
+// batchOne generates a loaded panel, the nth of the batch
+func batchOne(cfg config, sp *spec.Spec, res *batchResult, n int) {
+	if res.err != nil {
+		return
+	}
+	// serials follow the order of the specs, not the order panels happen to
+	// be generated in
+	cfg.serial = offsetSerial(cfg.serial, n)
+	res.err = generate(cfg, sp, res.name, res.dir)
+}
+
+// batch generates a panel for each selected spec file using a pool of
+// workers, then prints a summary. An error is returned if any panel failed.
+func batch(cfg config) error {
+	specs, err := batchSpecs(cfg)
+	if err != nil {
+		return err
+	}
+	loaded, results := loadBatch(cfg, specs)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range work {
+				batchOne(cfg, loaded[n], &results[n], n)
+			}
+		}()
+	}
+	for n := range specs {
+		work <- n
+	}
+	close(work)
+	wg.Wait()
+	failed := 0
+	verb := "generated"
+	if cfg.checkOnly {
+		verb = "checked"
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SPEC\tPANEL\tSIZE\tRESULT")
+	for _, res := range results {
+		if res.err != nil {
+			failed++
+			fmt.Fprintf(tw, "%s\t%s\t\tFAILED: %v\n", res.specfile, res.name, res.err)
+			continue
+		}
+		result := res.dir
+		if cfg.checkOnly {
+			result = "ok"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.2fx%.2fmm\t%s\n", res.specfile, res.name, res.width, res.height, result)
+	}
+	tw.Flush()
+	fmt.Printf("%d panels %s, %d failed\n", len(results)-failed, verb, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d panels failed", failed, len(results))
+	}
+	return nil
+}
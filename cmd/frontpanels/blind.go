@@ -0,0 +1,1799 @@
+// Package frontpanels is a CLI tool for generating panels in (currently)
+// Eurorack and its related 1U formats. It began as blind, for generating
+// blind (blank) panels, and now has subcommands for generating, checking,
+// previewing, measuring and panelizing panels; run it with -h for a list.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/components"
+	"github.com/jsleeio/frontpanels/pkg/decor"
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/fab"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/pulplogic"
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/frontpanels"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/output"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/panelize"
+	"github.com/jsleeio/frontpanels/pkg/prepare"
+	"github.com/jsleeio/frontpanels/pkg/render/composite"
+	"github.com/jsleeio/frontpanels/pkg/render/gcode"
+	"github.com/jsleeio/frontpanels/pkg/render/gerber"
+	"github.com/jsleeio/frontpanels/pkg/render/hpgl"
+	"github.com/jsleeio/frontpanels/pkg/render/laser"
+	"github.com/jsleeio/frontpanels/pkg/render/overlay"
+	"github.com/jsleeio/frontpanels/pkg/render/preview"
+	"github.com/jsleeio/frontpanels/pkg/render/terminal"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+	"github.com/jsleeio/frontpanels/pkg/retarget"
+	"github.com/jsleeio/frontpanels/pkg/sources/copper"
+	"github.com/jsleeio/frontpanels/pkg/sources/grille"
+	"github.com/jsleeio/frontpanels/pkg/sources/holes"
+	"github.com/jsleeio/frontpanels/pkg/sources/kicadpcb"
+	"github.com/jsleeio/frontpanels/pkg/sources/matrixcode"
+	panelsource "github.com/jsleeio/frontpanels/pkg/sources/panel"
+	"github.com/jsleeio/frontpanels/pkg/sources/svg"
+)
+
+type config struct {
+	formatOptions
+	name, header, footer string
+	headerStyle          textStyle
+	footerStyle          textStyle
+	textMode             features.TextMode
+	textStrokeWidth      float64
+	textPolicy           fab.TextPolicy
+	strokeFonts          strokeFonts
+	labels               labels
+	rearLabels           labels
+	copperLabels         labels
+	placeholders         placeholders
+	placeholderSide      string
+	serial, buildDate    string
+	revision             bool
+	boardThickness       float64
+	edgePlating          bool
+	castellated          bool
+	bom                  bool
+	partNumbers          components.PartNumbers
+	commit               string
+	version              string
+	timestamp            time.Time
+	dryRun               string
+	terminalOptions      terminal.Options
+	extraHoles           circles
+	components           placements
+	ledArrays            ledArrays
+	displays             displays
+	customs              customs
+	grilles              regions
+	inversions           regions
+	grilleOptions        grille.Options
+	ledArrayBracket      bool
+	componentOptions     components.Options
+	outputDir            string
+	filenameTemplate     string
+	zip                  bool
+	soldermask, paste    bool
+	annotations          bool
+	drillReport          bool
+	openscad, stl, kicad bool
+	vcvrack              bool
+	preview              bool
+	previewPNG           bool
+	previewColours       preview.Options
+	previewHardware      bool
+	previewKnobs         bool
+	compositeOptions     composite.Options
+	gcode                bool
+	gcodeOptions         gcode.Options
+	hpgl                 bool
+	hpglOptions          hpgl.Options
+	laser                bool
+	laserOptions         laser.Options
+	overlay              bool
+	overlayOptions       overlay.Options
+	thickness            float64
+	pour                 copper.Options
+	logo                 string
+	logoWidth            float64
+	artwork              string
+	artworkCutout        bool
+	artworkOptions       svg.Options
+	holes                string
+	holesOptions         holes.Options
+	pcb                  string
+	pcbOptions           kicadpcb.Options
+	pcbRules             pcbRules
+	fab                  fab.Profile
+	snapDrills           bool
+	code                 matrixcode.Options
+	decor                string
+	decorOptions         decor.Options
+	seed                 int64
+	batch, manifest      string
+	// specs are spec files given as arguments to the generate, check,
+	// preview and panelize subcommands, generated in batch mode if there
+	// is more than one
+	specs []string
+	// ownArgs leaves the arguments to the subcommand, rather than taking
+	// them as spec files
+	ownArgs bool
+	// fromFormat and fromWidth describe the panel the placement options
+	// were designed for, and mapping moves them onto the panel being
+	// generated
+	fromFormat   string
+	fromWidth    int
+	retargetMode string
+	mapping      *retarget.Mapping
+	// reporter receives diagnostics about the generated features
+	reporter diag.Reporter
+	// checkOnly renders panels in memory, writing no files, and previewOnly
+	// writes only their previews
+	checkOnly, previewOnly bool
+	// panelize places several copies of each panel on one board
+	panelize *panelize.Options
+	jobs     int
+	// watch regenerates the panel whenever its input files change
+	watch bool
+
+	panel panel.Panel
+}
+
+// variables collects name=value pairs from repeated command-line flags
+type variables map[string]float64
+
+func (v variables) String() string {
+	pairs := []string{}
+	for name, value := range v {
+		pairs = append(pairs, fmt.Sprintf("%s=%g", name, value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v variables) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, found %q", s)
+	}
+	f, err := geometry.ParseLength(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %v", name, err)
+	}
+	v[name] = f
+	return nil
+}
+
+// corners is a flag.Value overriding the finish of panel corners, singly or
+// in groups
+type corners panel.Corners
+
+func (c *corners) String() string {
+	if c == nil {
+		return ""
+	}
+	specs := []string{}
+	for _, corner := range []struct {
+		where string
+		panel.Corner
+	}{
+		{"bottom-left", c.BottomLeft},
+		{"bottom-right", c.BottomRight},
+		{"top-right", c.TopRight},
+		{"top-left", c.TopLeft},
+	} {
+		if corner.Style != panel.FormatCorner {
+			specs = append(specs, fmt.Sprintf("%s,%s,%g", corner.where, corner.Style, corner.Size))
+		}
+	}
+	return strings.Join(specs, " ")
+}
+
+func (c *corners) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) < 2 || len(fields) > 3 {
+		return fmt.Errorf("expected where,style[,size], found %q", s)
+	}
+	groups := map[string][]*panel.Corner{
+		"bottom-left":  {&c.BottomLeft},
+		"bottom-right": {&c.BottomRight},
+		"top-right":    {&c.TopRight},
+		"top-left":     {&c.TopLeft},
+		"bottom":       {&c.BottomLeft, &c.BottomRight},
+		"top":          {&c.TopLeft, &c.TopRight},
+		"all":          {&c.BottomLeft, &c.BottomRight, &c.TopRight, &c.TopLeft},
+	}
+	targets, ok := groups[strings.TrimSpace(fields[0])]
+	if !ok {
+		return fmt.Errorf("invalid corner %q (valid values: bottom-left bottom-right top-right top-left bottom top all)", fields[0])
+	}
+	corner := panel.Corner{}
+	var err error
+	if corner.Style, err = panel.ParseCornerStyle(strings.TrimSpace(fields[1])); err != nil {
+		return err
+	}
+	if len(fields) == 3 {
+		if corner.Size, err = geometry.ParseLength(fields[2]); err != nil {
+			return err
+		}
+	}
+	for _, t := range targets {
+		*t = corner
+	}
+	return nil
+}
+
+// notches is a flag.Value accumulating notches cut into the top and bottom
+// edges of a panel
+type notches []panel.Notch
+
+func (n *notches) String() string {
+	if n == nil {
+		return ""
+	}
+	specs := []string{}
+	for _, notch := range *n {
+		specs = append(specs, fmt.Sprintf("%s,%g,%g,%g", notch.Edge, notch.X, notch.Width, notch.Depth))
+	}
+	return strings.Join(specs, " ")
+}
+
+func (n *notches) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) != 4 {
+		return fmt.Errorf("expected edge,x,width,depth, found %q", s)
+	}
+	edge, err := panel.ParseEdge(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return err
+	}
+	var v [3]float64
+	for i, field := range fields[1:] {
+		if v[i], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	*n = append(*n, panel.Notch{Edge: edge, X: v[0], Width: v[1], Depth: v[2]})
+	return nil
+}
+
+// strokeFonts is a flag.Value loading Hershey stroke fonts from JHF files,
+// registering each under the name of its file without the extension
+type strokeFonts []string
+
+func (s *strokeFonts) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *strokeFonts) Set(filename string) error {
+	f, err := textpath.LoadHershey(filename)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, f.Name)
+	return nil
+}
+
+// labels is a flag.Value collecting text labels, each given as
+// "x,y,align,size,text". The text may itself contain commas.
+type labels []*features.Text
+
+func (l *labels) String() string {
+	if l == nil {
+		return ""
+	}
+	texts := []string{}
+	for _, t := range *l {
+		texts = append(texts, t.Text)
+	}
+	return strings.Join(texts, ",")
+}
+
+func (l *labels) Set(s string) error {
+	t, err := parseLabel(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, t)
+	return nil
+}
+
+// setMeta applies a feature metadata option given as "tag=name" or
+// "layer=name", reporting false for any other field
+func setMeta(f features.Feature, field string) (bool, error) {
+	name, value, _ := strings.Cut(field, "=")
+	switch name {
+	case "tag":
+		if t, ok := f.(features.Tagged); ok {
+			t.SetTag(value)
+		}
+	case "layer":
+		layer, err := features.ParseLayer(value)
+		if err != nil {
+			return true, err
+		}
+		features.Route(f, layer)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// parseLabel parses a text label given as "[tag=name,][layer=name,]
+// x,y,align,size,text". The options come first as the text may contain
+// commas; see setMeta.
+func parseLabel(s string) (*features.Text, error) {
+	const expected = "expected [tag=name,][layer=name,]x,y,align,size,text, found %q"
+	options := []string{}
+	for {
+		field, rest, ok := strings.Cut(s, ",")
+		if !ok || !strings.Contains(field, "=") {
+			break
+		}
+		options, s = append(options, field), rest
+	}
+	fields := strings.SplitN(s, ",", 5)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(expected, s)
+	}
+	var v [3]float64
+	for i, j := range []int{0, 1, 3} {
+		var err error
+		if v[i], err = geometry.ParseLength(fields[j]); err != nil {
+			return nil, err
+		}
+	}
+	align, err := features.ParseAlignment(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return nil, err
+	}
+	t := features.NewText(
+		geometry.Point{X: v[0], Y: v[1]},
+		fields[4],
+		features.WithAlignment(align),
+		features.WithSizeMM(v[2]),
+	)
+	for _, o := range options {
+		ok, err := setMeta(t, o)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown label option %q (valid values: tag layer)", o)
+		}
+	}
+	return t, nil
+}
+
+// placeholders is a flag.Value collecting placeholder text, each given as
+// "x,y,align,size,template" like labels
+type placeholders []*features.Placeholder
+
+func (p *placeholders) String() string {
+	if p == nil {
+		return ""
+	}
+	templates := []string{}
+	for _, t := range *p {
+		templates = append(templates, t.Text.Text)
+	}
+	return strings.Join(templates, ",")
+}
+
+func (p *placeholders) Set(s string) error {
+	t, err := parseLabel(s)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, &features.Placeholder{Text: *t})
+	return nil
+}
+
+// offsetSerial returns the serial number n after s, incrementing the digits
+// at the end of s and keeping any leading zeroes, eg. "FP-0099" becomes
+// "FP-0100" for n=1. Serials not ending in digits are returned unchanged.
+func offsetSerial(s string, n int) string {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	if i == len(s) || n == 0 {
+		return s
+	}
+	v, err := strconv.ParseUint(s[i:], 10, 64)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%s%0*d", s[:i], len(s)-i, v+uint64(n))
+}
+
+// circles is a flag.Value collecting cutout holes, each given as
+// "x,y,diameter[,tag=name][,layer=name]"; see setMeta
+type circles []*features.Circle
+
+func (c *circles) String() string {
+	if c == nil {
+		return ""
+	}
+	holes := []string{}
+	for _, h := range *c {
+		holes = append(holes, fmt.Sprintf("%g,%g,%g", h.Origin.X, h.Origin.Y, h.Radius*2))
+	}
+	return strings.Join(holes, " ")
+}
+
+func (c *circles) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) < 3 || len(fields) > 5 {
+		return fmt.Errorf("expected x,y,diameter[,tag=name][,layer=name], found %q", s)
+	}
+	var v [3]float64
+	for i, field := range fields[:3] {
+		var err error
+		if v[i], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	if v[2] <= 0 {
+		return fmt.Errorf("hole diameter must be greater than 0, found %q", s)
+	}
+	hole := features.NewCircle(geometry.Point{X: v[0], Y: v[1]}, v[2]/2.0)
+	hole.SetPurpose(features.Cutout)
+	for _, o := range fields[3:] {
+		ok, err := setMeta(hole, o)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("unknown hole option %q (valid values: tag layer)", o)
+		}
+	}
+	*c = append(*c, hole)
+	return nil
+}
+
+// pcbRules is a flag.Value collecting rules for cutouts from PCB
+// components, each given as "pattern=diameter[,dx,dy]"
+type pcbRules []kicadpcb.Rule
+
+func (r *pcbRules) String() string {
+	if r == nil {
+		return ""
+	}
+	rules := []string{}
+	for _, rule := range *r {
+		rules = append(rules, fmt.Sprintf("%s=%g,%g,%g", strings.TrimPrefix(rule.Pattern.String(), "(?i)"), rule.Diameter, rule.Offset.X, rule.Offset.Y))
+	}
+	return strings.Join(rules, " ")
+}
+
+func (r *pcbRules) Set(s string) error {
+	i := strings.LastIndex(s, "=")
+	if i < 1 {
+		return fmt.Errorf("expected pattern=diameter[,dx,dy], found %q", s)
+	}
+	fields := strings.Split(s[i+1:], ",")
+	if len(fields) != 1 && len(fields) != 3 {
+		return fmt.Errorf("expected pattern=diameter[,dx,dy], found %q", s)
+	}
+	var v [3]float64
+	for n, field := range fields {
+		var err error
+		if v[n], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	if v[0] <= 0 {
+		return fmt.Errorf("hole diameter must be greater than 0, found %q", s)
+	}
+	pattern, err := regexp.Compile("(?i)" + s[:i])
+	if err != nil {
+		return err
+	}
+	*r = append(*r, kicadpcb.Rule{Pattern: pattern, Diameter: v[0], Offset: geometry.Point{X: v[1], Y: v[2]}})
+	return nil
+}
+
+// placements is a flag.Value collecting panel-mounted components, each
+// given as "name[:variant][@knob[:diameter]],x,y"
+type placements []components.Placement
+
+func (p *placements) String() string {
+	if p == nil {
+		return ""
+	}
+	placed := []string{}
+	for _, c := range *p {
+		placed = append(placed, fmt.Sprintf("%s,%g,%g", c.Part(), c.Origin.X, c.Origin.Y))
+	}
+	return strings.Join(placed, " ")
+}
+
+func (p *placements) Set(s string) error {
+	fields := strings.SplitN(s, ",", 6)
+	if len(fields) != 3 && len(fields) != 6 {
+		return fmt.Errorf("expected name[:variant][@knob[:diameter]],x,y[,position,distance,label], found %q", s)
+	}
+	part, knob, hasKnob := strings.Cut(strings.TrimSpace(fields[0]), "@")
+	name, variant, _ := strings.Cut(part, ":")
+	c, err := components.Lookup(name)
+	if err != nil {
+		return err
+	}
+	var knobSize float64
+	if hasKnob {
+		knob, size, hasSize := strings.Cut(knob, ":")
+		if hasSize {
+			if knobSize, err = geometry.ParseLength(size); err != nil {
+				return err
+			}
+			if knobSize <= 0 {
+				return fmt.Errorf("knob diameter must be greater than 0, found %q", size)
+			}
+		}
+		if knob == "none" {
+			if hasSize {
+				return errors.New("a knob diameter cannot be given with @none")
+			}
+			knob = ""
+		} else if _, err := components.LookupKnob(knob); err != nil {
+			return err
+		}
+		c.Knob = knob
+	}
+	var v [2]float64
+	for i, field := range fields[1:3] {
+		if v[i], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	placed := components.Placement{Component: c, Origin: geometry.Point{X: v[0], Y: v[1]}, Variant: variant, KnobSize: knobSize}
+	if len(fields) == 6 {
+		label := &components.Label{Text: fields[5]}
+		if label.Position, err = components.ParsePosition(strings.TrimSpace(fields[3])); err != nil {
+			return err
+		}
+		if label.Distance, err = geometry.ParseLength(fields[4]); err != nil {
+			return err
+		}
+		placed.Label = label
+	}
+	*p = append(*p, placed)
+	return nil
+}
+
+// customs is a flag.Value collecting custom features, each given as
+// "kind,x,y[,name=value...]"
+type customs []features.Custom
+
+func (c *customs) String() string {
+	if c == nil {
+		return ""
+	}
+	kinds := []string{}
+	for _, f := range *c {
+		kinds = append(kinds, f.Kind())
+	}
+	return strings.Join(kinds, " ")
+}
+
+func (c *customs) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) < 3 {
+		return fmt.Errorf("expected kind,x,y[,name=value...], found %q", s)
+	}
+	kind, err := features.LookupCustomKind(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return err
+	}
+	var v [2]float64
+	for i, field := range fields[1:3] {
+		if v[i], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	params := map[string]string{}
+	for _, field := range fields[3:] {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value, found %q", field)
+		}
+		params[strings.TrimSpace(name)] = value
+	}
+	f, err := kind.New(geometry.Point{X: v[0], Y: v[1]}, params)
+	if err != nil {
+		return fmt.Errorf("%s: %v", kind.Name, err)
+	}
+	*c = append(*c, f)
+	return nil
+}
+
+// displays is a flag.Value collecting segment display windows, each given
+// as "name,x,y" with x,y the centre of the window
+type displays []components.DisplayPlacement
+
+func (d *displays) String() string {
+	if d == nil {
+		return ""
+	}
+	placed := []string{}
+	for _, p := range *d {
+		placed = append(placed, fmt.Sprintf("%s,%g,%g", p.Name, p.Origin.X, p.Origin.Y))
+	}
+	return strings.Join(placed, " ")
+}
+
+func (d *displays) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) != 3 {
+		return fmt.Errorf("expected name,x,y, found %q", s)
+	}
+	display, err := components.LookupDisplay(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return err
+	}
+	var v [2]float64
+	for i, field := range fields[1:] {
+		if v[i], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	*d = append(*d, components.DisplayPlacement{Display: display, Origin: geometry.Point{X: v[0], Y: v[1]}})
+	return nil
+}
+
+// regions is a flag.Value collecting panel regions, each given as
+// "circle,x,y,diameter" or "rect,x1,y1,x2,y2"
+type regions []features.Feature
+
+func (r *regions) String() string {
+	if r == nil {
+		return ""
+	}
+	described := []string{}
+	for _, f := range *r {
+		described = append(described, fmt.Sprint(f))
+	}
+	return strings.Join(described, " ")
+}
+
+func (r *regions) Set(s string) error {
+	fields := strings.Split(s, ",")
+	v := make([]float64, len(fields)-1)
+	for i, field := range fields[1:] {
+		var err error
+		if v[i], err = geometry.ParseLength(field); err != nil {
+			return err
+		}
+	}
+	switch strings.TrimSpace(fields[0]) {
+	case "circle":
+		if len(v) != 3 || v[2] <= 0 {
+			return fmt.Errorf("expected circle,x,y,diameter, found %q", s)
+		}
+		*r = append(*r, features.NewCircle(geometry.Point{X: v[0], Y: v[1]}, v[2]/2.0))
+	case "rect":
+		if len(v) != 4 {
+			return fmt.Errorf("expected rect,x1,y1,x2,y2, found %q", s)
+		}
+		*r = append(*r, features.NewRectangle(geometry.Point{X: v[0], Y: v[1]}, geometry.Point{X: v[2], Y: v[3]}))
+	case "poly":
+		if len(v) < 6 || len(v)%2 != 0 {
+			return fmt.Errorf("expected poly,x1,y1,x2,y2,x3,y3..., found %q", s)
+		}
+		points := []geometry.Point{}
+		for i := 0; i < len(v); i += 2 {
+			points = append(points, geometry.Point{X: v[i], Y: v[i+1]})
+		}
+		*r = append(*r, features.NewPolygon(points))
+	default:
+		return fmt.Errorf("invalid region %q (valid shapes: circle rect poly)", s)
+	}
+	return nil
+}
+
+// copies returns a copy of each region, so that panels generated in
+// parallel do not share them
+func (r regions) copies() []features.Feature {
+	copies := make([]features.Feature, 0, len(r))
+	for _, region := range r {
+		switch f := region.(type) {
+		case *features.Circle:
+			c := *f
+			copies = append(copies, &c)
+		case *features.Polygon:
+			p := *f
+			p.Points = append([]geometry.Point{}, f.Points...)
+			copies = append(copies, &p)
+		default:
+			panic(fmt.Sprintf("invalid region: %v", region))
+		}
+	}
+	return copies
+}
+
+// regionPoints returns the outline of a region
+func regionPoints(region features.Feature) []geometry.Point {
+	switch r := region.(type) {
+	case *features.Circle:
+		return geometry.Arc(r.Origin, r.Radius, 0, 360, geometry.DefaultTolerance)
+	case *features.Polygon:
+		return r.Points
+	}
+	panic(fmt.Sprintf("invalid region: %v", region))
+}
+
+// ledArrays is a flag.Value collecting LED arrays, each given as
+// "x,y,count,pitch,orientation,led" followed optionally by one label per
+// LED. The LED is a component name, or WxH for rectangular LEDs.
+type ledArrays []components.LEDArray
+
+func (l *ledArrays) String() string {
+	if l == nil {
+		return ""
+	}
+	arrays := []string{}
+	for _, a := range *l {
+		arrays = append(arrays, fmt.Sprintf("%g,%g,%d,%g,%s", a.Origin.X, a.Origin.Y, a.Count, a.Pitch, a.Orientation))
+	}
+	return strings.Join(arrays, " ")
+}
+
+func (l *ledArrays) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) < 6 {
+		return fmt.Errorf("expected x,y,count,pitch,orientation,led[,label...], found %q", s)
+	}
+	var v [3]float64
+	for i, j := range []int{0, 1, 3} {
+		var err error
+		if v[i], err = geometry.ParseLength(fields[j]); err != nil {
+			return err
+		}
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return fmt.Errorf("invalid LED count %q", fields[2])
+	}
+	a := components.NewLEDArray(geometry.Point{X: v[0], Y: v[1]}, count, v[2], components.Component{})
+	if a.Orientation, err = components.ParseOrientation(strings.TrimSpace(fields[4])); err != nil {
+		return err
+	}
+	led := strings.TrimSpace(fields[5])
+	if wh := strings.SplitN(led, "x", 2); len(wh) == 2 {
+		if a.RectSize.X, err = geometry.ParseLength(wh[0]); err != nil {
+			return err
+		}
+		if a.RectSize.Y, err = geometry.ParseLength(wh[1]); err != nil {
+			return err
+		}
+	} else if a.LED, err = components.Lookup(led); err != nil {
+		return err
+	}
+	a.Labels = fields[6:]
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	*l = append(*l, a)
+	return nil
+}
+
+// length is a flag.Value for lengths in millimetres, also accepting values
+// with unit suffixes such as "0.5in" or "300mil"
+type length struct {
+	v *float64
+}
+
+// lengthVar defines a length flag
+func lengthVar(fs *flag.FlagSet, p *float64, name string, value float64, usage string) {
+	*p = value
+	fs.Var(length{p}, name, usage)
+}
+
+func (l length) String() string {
+	if l.v == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*l.v, 'g', -1, 64)
+}
+
+func (l length) Set(s string) error {
+	v, err := geometry.ParseLength(s)
+	if err != nil {
+		return err
+	}
+	*l.v = v
+	return nil
+}
+
+// defaultName names the output files of panels not otherwise named, so that
+// they are not hidden files such as .outline.gko
+const defaultName = "panel"
+
+// configure defines the flags of the subcommands generating panels,
+// returning a function that completes the configuration once they have been
+// parsed, and constructs the panel unless there is a batch of them
+func (c *config) configure(fs *flag.FlagSet, g *globals) func() (panel.Panel, error) {
+	fs.StringVar(&c.name, "name", "", "basename for generating output filenames; defaults to the name of a spec file argument, or \""+defaultName+"\"")
+	fs.StringVar(&c.header, "header", "", "header text for panel")
+	fs.StringVar(&c.footer, "footer", "", "footer text for panel")
+	fs.Var(&c.labels, "label", "text label as [tag=name,][layer=name,]x,y,align,size,text, with the position and size of capital letters in millimetres, and an optional tag and layer hint (valid layers: auto silk-top silk-bottom mask-top mask-bottom copper-top copper-bottom outline drill); may be repeated")
+	fs.Var(&c.rearLabels, "rear-label", "text label on the rear of the panel, eg. calibration notes, given like -label in front view coordinates and mirrored to read from behind; may be repeated")
+	fs.Var(&c.copperLabels, "copper-label", "text label in exposed copper with a soldermask opening, for plated (eg. gold) legends, given like -label; may be repeated")
+	fs.Var(&c.placeholders, "placeholder", "text label as x,y,align,size,template, like -label, with {serial}, {date}, {version} and {name} substituted when rendering; may be repeated")
+	fs.StringVar(&c.placeholderSide, "placeholder-side", "top", "side of the panel to place placeholder text on (valid values: top bottom)")
+	fs.StringVar(&c.serial, "serial", os.Getenv("FRONTPANELS_SERIAL"), "serial number for {serial} placeholders; in batch mode, any digits at its end are incremented for each panel (default $FRONTPANELS_SERIAL)")
+	fs.StringVar(&c.buildDate, "build-date", os.Getenv("FRONTPANELS_DATE"), "date for {date} placeholders (default $FRONTPANELS_DATE, or today's date)")
+	timestamp := fs.String("timestamp", os.Getenv("FRONTPANELS_TIMESTAMP"), "RFC 3339 time recorded in output file headers, and the default for -build-date; fix it for byte-identical output across runs (default $FRONTPANELS_TIMESTAMP, or the current time)")
+	fs.StringVar(&c.version, "panel-version", os.Getenv("FRONTPANELS_VERSION"), "version for {version} placeholders (default $FRONTPANELS_VERSION)")
+	lengthVar(fs, &c.boardThickness, "board-thickness", 0, "overall PCB thickness recorded in the Gerber job file, in millimetres; 0 uses the panel thickness (valid values: 0.4 0.6 0.8 1.0 1.2 1.6 2.0)")
+	fs.BoolVar(&c.edgePlating, "edge-plating", false, "record in the Gerber job file that the board edges are to be plated")
+	fs.BoolVar(&c.castellated, "castellated", false, "record in the Gerber job file that the board has castellated (plated half-hole) edges")
+	fs.BoolVar(&c.revision, "revision", false, "embed the panel name, -panel-version, -build-date and -commit in Gerber and drill file comments and on the rear silkscreen, and write a manifest of the output files")
+	fs.StringVar(&c.commit, "commit", os.Getenv("FRONTPANELS_COMMIT"), "source commit recorded by -revision (default $FRONTPANELS_COMMIT, or the git commit of the spec file's directory)")
+	fs.Var(&c.extraHoles, "hole", "cutout hole as x,y,diameter[,tag=name][,layer=name], in millimetres, with an optional tag and layer hint as for -label; may be repeated")
+	fs.Var(&c.components, "component", "panel-mounted component as name[:variant][@knob[:diameter]],x,y[,position,distance,label], in millimetres, optionally labelled above, below, left or right of its nut at the given distance; the variant, eg. a potentiometer value, distinguishes parts in -bom, and the knob, or none, replaces the default knob drawn in previews and checked for collisions, with its diameter if given; may be repeated (valid names: "+strings.Join(components.Names(), " ")+"; valid knobs: "+strings.Join(components.KnobNames(), " ")+")")
+	c.componentOptions = components.DefaultOptions()
+	fs.BoolVar(&c.componentOptions.Courtyards, "courtyards", false, "outline nut and body courtyards around components on the annotations layer")
+	lengthVar(fs, &c.componentOptions.Depth, "component-depth", 0, "space behind the panel for component bodies, eg. above a PCB, in millimetres; deeper components are errors; 0 disables the check")
+	fs.Var(&c.ledArrays, "led-array", "evenly spaced LEDs as x,y,count,pitch,orientation,led[,label...], with the first LED at x,y; led is a component name or WxH for rectangular LEDs; may be repeated")
+	fs.Var(&c.customs, "custom", "custom feature as kind,x,y[,name=value...], placed at x,y in millimetres with settings specific to the kind; may be repeated (valid kinds: "+strings.Join(features.CustomKindNames(), " ")+")")
+	fs.Var(&c.displays, "display", "segment display window as name,x,y, centred on x,y in millimetres; may be repeated (valid names: "+strings.Join(components.DisplayNames(), " ")+")")
+	c.grilleOptions = grille.DefaultOptions()
+	fs.Var(&c.inversions, "invert", "region of the front silkscreen to fill, knocking out the markings lying wholly within it for reversed-out text, as circle,x,y,diameter, rect,x1,y1,x2,y2 or poly,x1,y1,x2,y2,x3,y3... in millimetres; may be repeated")
+	fs.Var(&c.grilles, "grille", "region to fill with a grid of holes, for speakers or ventilation, as circle,x,y,diameter or rect,x1,y1,x2,y2 in millimetres; may be repeated")
+	grilleLayout := fs.String("grille-layout", c.grilleOptions.Layout.String(), "arrangement of grille holes (valid values: square hex)")
+	lengthVar(fs, &c.grilleOptions.HoleDiameter, "grille-hole", c.grilleOptions.HoleDiameter, "diameter of grille holes, in millimetres")
+	lengthVar(fs, &c.grilleOptions.Pitch, "grille-pitch", c.grilleOptions.Pitch, "distance between the centres of neighbouring grille holes, in millimetres")
+	lengthVar(fs, &c.grilleOptions.EdgeClearance, "grille-clearance", c.grilleOptions.EdgeClearance, "minimum distance between grille holes and the edge of their region, in millimetres")
+	fs.BoolVar(&c.bom, "bom", false, "generate CSV and JSON bills of materials listing components, their nuts and panel mounting screws")
+	partNumbers := fs.String("bom-part-numbers", "", "CSV file of distributor part numbers for -bom, with a header row of part followed by distributor names")
+	fs.BoolVar(&c.ledArrayBracket, "led-array-bracket", false, "draw a silkscreen bracket beside each LED array")
+	lengthVar(fs, &c.componentOptions.LabelSize, "component-label-size", c.componentOptions.LabelSize, "height of capital letters in component labels, in millimetres")
+	lengthVar(fs, &c.componentOptions.Margin, "component-margin", c.componentOptions.Margin, "clearance required around component nuts and bodies, in millimetres")
+	fs.StringVar(&c.dryRun, "dry-run", "", "print the resolved panel geometry and feature layers, or a sketch of the panel, instead of writing output files (valid values: table json braille ascii)")
+	c.terminalOptions = terminal.DefaultOptions()
+	fs.IntVar(&c.terminalOptions.Columns, "sketch-columns", c.terminalOptions.Columns, "maximum width of -dry-run braille and ascii sketches, in characters")
+	fs.IntVar(&c.terminalOptions.Rows, "sketch-rows", c.terminalOptions.Rows, "maximum height of -dry-run braille and ascii sketches, in lines")
+	styleFlags(fs, "header", &c.headerStyle)
+	styleFlags(fs, "footer", &c.footerStyle)
+	c.formatOptions.define(fs)
+	fs.StringVar(&c.fromFormat, "from-format", "", "format the placement options were designed for, re-targeted to the -format panel by mapping their positions into its usable area, eg. to convert between the Intellijel and Pulplogic 1U formats (valid values: "+strings.Join(frontFormatNames(), " ")+")")
+	fs.IntVar(&c.fromWidth, "from-width", 0, "width of the -from-format panel the placement options were designed for (default -width)")
+	fs.StringVar(&c.retargetMode, "retarget-mode", retarget.Proportional.String(), "how -from-format positions are mapped: scaled to fill the usable area, or keeping their distance from its nearer edges (valid values: proportional anchored)")
+	g.define(fs)
+	fs.StringVar(&c.filenameTemplate, "filename-template", output.DefaultFilenameTemplate, "template for output filenames; {name}, {layer} and {ext} are substituted")
+	fs.BoolVar(&c.zip, "zip", false, "write all output files into a single ZIP archive instead of loose files")
+	fs.BoolVar(&c.soldermask, "soldermask", true, "generate top and bottom soldermask layers")
+	fs.BoolVar(&c.drillReport, "drill-report", true, "generate human-readable and CSV drill tables alongside the drill files")
+	fs.BoolVar(&c.openscad, "openscad", false, "generate an OpenSCAD model of the panel, for 3D-printing prototypes")
+	fs.BoolVar(&c.stl, "stl", false, "generate an STL mesh of the panel, for mechanical CAD and 3D printing")
+	fs.BoolVar(&c.kicad, "kicad", false, "generate a KiCad footprint of the whole panel, for finishing in KiCad")
+	fs.BoolVar(&c.preview, "preview", false, "generate an SVG preview of the finished front of the panel")
+	fs.BoolVar(&c.previewPNG, "preview-png", false, "generate a PNG image approximating the fabricated appearance of the front of the panel")
+	fs.BoolVar(&c.previewHardware, "preview-hardware", false, "draw mounting screws and washers, component nuts and knobs over -preview, -preview-png and -overlay, to approximate the assembled module")
+	fs.BoolVar(&c.previewKnobs, "preview-knobs", false, "outline the knobs of -component placements over -preview, -preview-png and -overlay, highlighting knobs that overlap")
+	previewColours := fs.String("preview-colours", "black", "colour preset for -preview and -preview-png, named for the soldermask colour (valid values: "+strings.Join(preview.PresetNames(), " ")+")")
+	c.compositeOptions = composite.DefaultOptions()
+	fs.Float64Var(&c.compositeOptions.Resolution, "preview-resolution", c.compositeOptions.Resolution, "resolution of -preview-png images, in pixels per millimetre")
+	lengthVar(fs, &c.compositeOptions.Tolerance, "preview-tolerance", c.compositeOptions.Tolerance, "maximum deviation of flattened curves in -preview, -preview-png and -dry-run sketches, in millimetres")
+	fs.BoolVar(&c.vcvrack, "vcvrack", false, "generate a VCV Rack panel SVG with component placeholders")
+	fs.Var(namedLength{&c.thickness, panel.MaterialThickness, panel.MaterialNames}, "thickness", "panel thickness for 3D models, G-code and checking component threads and shafts reach through the panel, in millimetres, or a material; 0 uses the spec file's thickness, the sheet thickness of laser fab profiles, or the format's, 1.6mm for PCB panels (valid values: "+strings.Join(panel.MaterialNames(), " ")+")")
+	c.gcodeOptions = gcode.DefaultOptions()
+	fs.BoolVar(&c.gcode, "gcode", false, "generate G-code engraving and profile programs for machining the panel")
+	lengthVar(fs, &c.gcodeOptions.ToolDiameter, "gcode-tool", c.gcodeOptions.ToolDiameter, "diameter of the profile cutting tool, in millimetres")
+	fs.BoolVar(&c.gcodeOptions.Compensation, "gcode-compensation", c.gcodeOptions.Compensation, "offset profile cuts by the tool radius so that cutouts are cut to size")
+	lengthVar(fs, &c.gcodeOptions.StepDown, "gcode-step-down", c.gcodeOptions.StepDown, "maximum depth of each profile pass, in millimetres")
+	lengthVar(fs, &c.gcodeOptions.EngraveDepth, "gcode-engrave-depth", c.gcodeOptions.EngraveDepth, "depth of engraved markings, in millimetres")
+	fs.Float64Var(&c.gcodeOptions.CutFeed, "gcode-feed", c.gcodeOptions.CutFeed, "profile cutting feed rate, in millimetres per minute")
+	fs.Float64Var(&c.gcodeOptions.EngraveFeed, "gcode-engrave-feed", c.gcodeOptions.EngraveFeed, "engraving feed rate, in millimetres per minute")
+	fs.Float64Var(&c.gcodeOptions.PlungeFeed, "gcode-plunge-feed", c.gcodeOptions.PlungeFeed, "plunge feed rate, in millimetres per minute")
+	fs.Float64Var(&c.gcodeOptions.SpindleSpeed, "gcode-spindle", c.gcodeOptions.SpindleSpeed, "spindle speed, in RPM")
+	c.laserOptions = laser.DefaultOptions()
+	fs.BoolVar(&c.laser, "laser", false, "generate an SVG laser cutting job, with markings on an engrave layer and holes, cutouts and the outline on a cut layer; implied by laser fab profiles")
+	lengthVar(fs, &c.laserOptions.Kerf, "laser-kerf", 0, "width of material removed by the laser beam, compensated for in -laser cuts, in millimetres; 0 uses the fab profile's kerf, or "+strconv.FormatFloat(laser.DefaultKerf, 'g', -1, 64)+"mm")
+	c.hpglOptions = hpgl.DefaultOptions()
+	fs.BoolVar(&c.hpgl, "hpgl", false, "generate an HPGL plot of the panel markings, for vinyl cutters and pen plotters")
+	fs.IntVar(&c.hpglOptions.Pen, "hpgl-pen", c.hpglOptions.Pen, "HPGL pen or tool number")
+	fs.BoolVar(&c.hpglOptions.Strokes, "hpgl-strokes", false, "draw lines along their centres for pen plotters, rather than cutting their outlines")
+	fs.BoolVar(&c.hpglOptions.Outline, "hpgl-outline", false, "include the panel outline and cutouts in the HPGL plot, for trimming and alignment")
+	fs.BoolVar(&c.hpglOptions.Mirror, "hpgl-mirror", false, "mirror the HPGL plot, for overlays applied from behind transparent material")
+	fs.BoolVar(&c.annotations, "annotations", false, "generate a drawing layer with panel dimensions, also drawn in blue on -overlay sheets and -preview, for documentation; not part of the fabrication data")
+	fs.BoolVar(&c.paste, "paste", false, "generate a top paste (stencil) layer from soldermask openings")
+	c.overlayOptions = overlay.DefaultOptions()
+	fs.BoolVar(&c.overlay, "overlay", false, "generate PDF and SVG sheets of the panel markings at exact scale, for printing onto label stock")
+	textMode := fs.String("text-mode", "filled", "how text glyphs are drawn; stroke text uses single-line fonts suited to engraving and plotting (valid values: filled stroke)")
+	fs.Var(&c.strokeFonts, "stroke-font", "Hershey single-stroke font file in JHF format, selectable for stroke text by its filename without the extension; may be repeated")
+	lengthVar(fs, &c.textStrokeWidth, "text-stroke-width", 0, "line thickness of stroke text, in millimetres; 0 selects a tenth of the text size")
+	textPolicy := fs.String("text-policy", "warn", "how text smaller than the fab's minimums is handled; copper text always fails unless scaled (valid values: warn fail scale)")
+	overlayPage := fs.String("overlay-page", "a4", "overlay paper size (valid values: a4 letter fit)")
+	fs.BoolVar(&c.overlayOptions.Mirror, "overlay-mirror", false, "mirror the overlay, for transfers applied face down")
+	fs.BoolVar(&c.overlayOptions.CropMarks, "overlay-crop-marks", c.overlayOptions.CropMarks, "draw crop marks around the overlay")
+	c.pour = copper.DefaultOptions()
+	pourSides := fs.String("copper-pour", "top", "sides to generate a copper pour on (valid values: top bottom both none)")
+	pourExtent := fs.String("copper-extent", c.pour.Extent.String(), "area covered by copper pour (valid values: inter-rail full)")
+	pourFill := fs.String("copper-fill", c.pour.Fill.String(), "copper pour fill style (valid values: solid hatched)")
+	lengthVar(fs, &c.pour.HatchPitch, "copper-hatch-pitch", c.pour.HatchPitch, "distance between hatched copper pour lines, in millimetres")
+	lengthVar(fs, &c.pour.HatchWidth, "copper-hatch-width", c.pour.HatchWidth, "thickness of hatched copper pour lines, in millimetres")
+	lengthVar(fs, &c.pour.Clearance, "copper-clearance", c.pour.Clearance, "clearance between copper pour and any holes or cutouts, in millimetres")
+	fs.StringVar(&c.logo, "logo", "", "PNG or BMP image to place on the silkscreen, centred above the bottom rail")
+	lengthVar(fs, &c.logoWidth, "logo-width", 10.0, "width of logo image, in millimetres")
+	c.artworkOptions = svg.DefaultOptions()
+	fs.StringVar(&c.artwork, "artwork", "", "SVG artwork to place on the silkscreen")
+	lengthVar(fs, &c.artworkOptions.Origin.X, "artwork-x", 0, "X position of the top-left corner of the SVG artwork, in millimetres")
+	lengthVar(fs, &c.artworkOptions.Origin.Y, "artwork-y", 0, "Y position of the top-left corner of the SVG artwork, in millimetres")
+	lengthVar(fs, &c.artworkOptions.Tolerance, "artwork-tolerance", c.artworkOptions.Tolerance, "maximum deviation of flattened SVG curves, in millimetres")
+	fs.BoolVar(&c.artworkOptions.Fill, "artwork-fill", false, "fill closed SVG paths rather than outlining them")
+	fs.BoolVar(&c.artworkCutout, "artwork-cutout", false, "use SVG artwork as board cutouts rather than silkscreen")
+	c.holesOptions = holes.DefaultOptions()
+	fs.StringVar(&c.holes, "holes", "", "CSV table of holes to add to the panel, with columns x, y, diameter and optionally purpose, label, tag and layer")
+	lengthVar(fs, &c.holesOptions.LabelSize, "holes-label-size", c.holesOptions.LabelSize, "height of capital letters in hole labels, in millimetres")
+	c.pcbOptions = kicadpcb.DefaultOptions()
+	fs.StringVar(&c.pcb, "pcb", "", "KiCad .kicad_pcb file or footprint position CSV to generate component cutouts from")
+	lengthVar(fs, &c.pcbOptions.Offset.X, "pcb-x", 0, "X position of the PCB origin on the panel, in millimetres")
+	lengthVar(fs, &c.pcbOptions.Offset.Y, "pcb-y", 0, "Y position of the PCB origin on the panel, in millimetres")
+	fs.BoolVar(&c.pcbOptions.Bottom, "pcb-bottom", false, "the bottom of the -pcb PCB faces the panel: mirror positions left to right and use components on the bottom rather than the top")
+	fs.Var(&c.pcbRules, "pcb-rule", "cutout rule for -pcb components as pattern=diameter[,dx,dy], matching footprint names and values case-insensitively, with the hole offset from the footprint origin; tried before the built-in rules; may be repeated")
+	fs.BoolVar(&c.snapDrills, "snap-drills", false, "snap hole diameters to the fab's standard drill sizes, rounding clearance holes up and routing holes larger than its largest drill with the outline, and report each substitution")
+	fs.StringVar(&c.code.Content, "code", "", "content of a QR code or Data Matrix symbol to place on the panel, eg. a build guide URL")
+	codeType := fs.String("code-type", matrixcode.QR.String(), "barcode symbology (valid values: qr datamatrix)")
+	codeLayer := fs.String("code-layer", "silkscreen", "layer to render the barcode on (valid values: silkscreen copper)")
+	lengthVar(fs, &c.code.Size, "code-size", 10.0, "width of the barcode, excluding its quiet zone, in millimetres")
+	lengthVar(fs, &c.code.Origin.X, "code-x", -1, "X position of the bottom-left corner of the barcode, in millimetres; negative values centre it horizontally")
+	lengthVar(fs, &c.code.Origin.Y, "code-y", -1, "Y position of the bottom-left corner of the barcode, in millimetres; negative values place it above the bottom rail")
+	c.decorOptions = decor.DefaultOptions()
+	fs.StringVar(&c.decor, "decor", decor.None, "decorative pattern to fill the space between the rails (valid values: "+strings.Join(decor.Names(), " ")+")")
+	lengthVar(fs, &c.decorOptions.Thickness, "decor-thickness", c.decorOptions.Thickness, "line thickness of decorative patterns, in millimetres")
+	sunburstStyle := fs.String("sunburst-style", c.decorOptions.Sunburst.Style.String(), "sunburst decoration style (valid values: rays rings)")
+	sunburstCentre := geometry.Point{X: -1, Y: -1}
+	lengthVar(fs, &sunburstCentre.X, "sunburst-x", -1, "X position of the sunburst centre, in millimetres; negative values centre it between the rails")
+	lengthVar(fs, &sunburstCentre.Y, "sunburst-y", -1, "Y position of the sunburst centre, in millimetres; negative values centre it between the rails")
+	lengthVar(fs, &c.decorOptions.Sunburst.Inner, "sunburst-inner", c.decorOptions.Sunburst.Inner, "inner radius of the sunburst, in millimetres")
+	lengthVar(fs, &c.decorOptions.Sunburst.Outer, "sunburst-outer", c.decorOptions.Sunburst.Outer, "outer radius of the sunburst, in millimetres; 0 extends it to the rails and panel edges")
+	fs.IntVar(&c.decorOptions.Sunburst.Density, "sunburst-density", c.decorOptions.Sunburst.Density, "number of sunburst rays or rings")
+	fs.Float64Var(&c.decorOptions.Sunburst.Start, "sunburst-start", c.decorOptions.Sunburst.Start, "start angle of the sunburst, in degrees anticlockwise from 3 o'clock")
+	fs.Float64Var(&c.decorOptions.Sunburst.End, "sunburst-end", c.decorOptions.Sunburst.End, "end angle of the sunburst, in degrees anticlockwise from 3 o'clock")
+	waveShape := fs.String("wave-shape", c.decorOptions.Wave.Shape.String(), "wave decoration shape (valid values: sine triangle saw lissajous)")
+	lengthVar(fs, &c.decorOptions.Wave.Amplitude, "wave-amplitude", c.decorOptions.Wave.Amplitude, "peak amplitude of the wave decoration, in millimetres; 0 fills the space between the rails")
+	fs.Float64Var(&c.decorOptions.Wave.Frequency, "wave-frequency", c.decorOptions.Wave.Frequency, "number of wave cycles along the panel")
+	fs.Float64Var(&c.decorOptions.Wave.CrossFrequency, "wave-cross-frequency", c.decorOptions.Wave.CrossFrequency, "number of Lissajous figure cycles across the panel")
+	fs.Float64Var(&c.decorOptions.Wave.Phase, "wave-phase", c.decorOptions.Wave.Phase, "phase offset of the wave decoration, in degrees")
+	fs.StringVar(&c.batch, "batch", "", "glob of YAML spec files to generate in one run, each into its own subdirectory of the output directory")
+	fs.StringVar(&c.manifest, "batch-manifest", "", "file listing YAML spec files to generate in one run, one per line")
+	fs.IntVar(&c.jobs, "jobs", 1, "number of panels to generate in parallel in batch mode")
+	fs.BoolVar(&c.watch, "watch", false, "keep running, regenerating the panel whenever its spec file, any spec it extends or includes, or its logo, artwork, hole or PCB file changes")
+	fs.Int64Var(&c.seed, "seed", 1, "seed for random decorative patterns; the same seed always produces the same output")
+	return func() (p panel.Panel, err error) {
+		c.outputDir = g.outputDir
+		c.reporter = diag.Logger{}
+		if err = c.specArgs(fs); err != nil {
+			return
+		}
+		if err = c.formatOptions.parse(); err != nil {
+			return
+		}
+		// labels given a layer hint keep the purpose and side it implies
+		for _, t := range c.rearLabels {
+			if features.LayerHint(t) == features.AutoLayer {
+				t.SetSide(features.BottomSide)
+			}
+		}
+		for _, t := range c.copperLabels {
+			if features.LayerHint(t) == features.AutoLayer {
+				t.SetPurpose(features.ExposedCopper)
+			}
+		}
+		switch c.placeholderSide {
+		case "top":
+		case "bottom":
+			for _, t := range c.placeholders {
+				t.SetSide(features.BottomSide)
+			}
+		default:
+			err = fmt.Errorf("invalid placeholder side %q (valid values: top bottom)", c.placeholderSide)
+			return
+		}
+		if *timestamp != "" {
+			if c.timestamp, err = time.Parse(time.RFC3339, *timestamp); err != nil {
+				err = fmt.Errorf("invalid timestamp %q: %v", *timestamp, err)
+				return
+			}
+		}
+		if c.buildDate == "" && !c.timestamp.IsZero() {
+			c.buildDate = c.timestamp.Format("2006-01-02")
+		}
+		if c.buildDate == "" {
+			c.buildDate = time.Now().Format("2006-01-02")
+		}
+		if *partNumbers != "" {
+			if c.partNumbers, err = components.LoadPartNumbers(*partNumbers); err != nil {
+				return
+			}
+		}
+		if c.revision && c.commit == "" {
+			c.commit = gitCommit(filepath.Dir(c.formatOptions.spec))
+		}
+		if c.grilleOptions.Layout, err = grille.ParseLayout(*grilleLayout); err != nil {
+			return
+		}
+		if err = c.pour.ParseSides(*pourSides); err != nil {
+			return
+		}
+		if c.overlayOptions.Page, err = overlay.ParsePageSize(*overlayPage); err != nil {
+			return
+		}
+		if c.outputDir == "-" && (!c.zip || c.batched()) {
+			err = errors.New("writing to standard output requires -zip, and is not supported in batch mode")
+			return
+		}
+		switch c.dryRun {
+		case "", "table", "json":
+		case "braille", "ascii":
+			if c.terminalOptions.Mode, err = terminal.ParseMode(c.dryRun); err != nil {
+				return
+			}
+			if c.terminalOptions.Columns < 1 || c.terminalOptions.Rows < 1 {
+				err = errors.New("-sketch-columns and -sketch-rows must be at least 1")
+				return
+			}
+		default:
+			err = fmt.Errorf("invalid dry-run format %q (valid values: table json braille ascii)", c.dryRun)
+			return
+		}
+		if c.previewColours, err = preview.Preset(*previewColours); err != nil {
+			return
+		}
+		c.compositeOptions.Colours = c.previewColours
+		if c.compositeOptions.Resolution <= 0 {
+			err = errors.New("-preview-resolution must be greater than 0")
+			return
+		}
+		if c.compositeOptions.Tolerance <= 0 {
+			err = errors.New("-preview-tolerance must be greater than 0")
+			return
+		}
+		c.previewColours.Tolerance = c.compositeOptions.Tolerance
+		c.terminalOptions.Tolerance = c.compositeOptions.Tolerance
+		if c.textPolicy, err = fab.ParseTextPolicy(*textPolicy); err != nil {
+			return
+		}
+		if c.textMode, err = features.ParseTextMode(*textMode); err != nil {
+			return
+		}
+		// component labels are checked as they will be drawn
+		c.componentOptions.TextMode, c.componentOptions.StrokeWidth = c.textMode, c.textStrokeWidth
+		for _, s := range []*textStyle{&c.headerStyle, &c.footerStyle} {
+			if err = textpath.ValidateFont(s.font, c.textMode); err != nil {
+				return
+			}
+			if s.purpose, err = parseFinish(s.finish); err != nil {
+				return
+			}
+		}
+		if c.pour.Extent, err = copper.ParseExtent(*pourExtent); err != nil {
+			return
+		}
+		if c.pour.Fill, err = copper.ParseFill(*pourFill); err != nil {
+			return
+		}
+		if _, err = decor.Lookup(c.decor); err != nil {
+			return
+		}
+		if c.decorOptions.Sunburst.Style, err = decor.ParseSunburstStyle(*sunburstStyle); err != nil {
+			return
+		}
+		if c.decorOptions.Wave.Shape, err = decor.ParseWaveShape(*waveShape); err != nil {
+			return
+		}
+		if sunburstCentre.X >= 0 && sunburstCentre.Y >= 0 {
+			c.decorOptions.Sunburst.Centre = &sunburstCentre
+		}
+		if c.fab, err = fab.Lookup(g.fab); err != nil {
+			return
+		}
+		if c.boardThickness != 0 {
+			if err = gerber.ValidateThickness(c.boardThickness); err != nil {
+				return
+			}
+		}
+		if c.thickness < 0 {
+			err = errors.New("thickness must not be negative")
+			return
+		}
+		if c.fab.Laser {
+			c.laser = true
+			if c.edgePlating || c.castellated || c.snapDrills {
+				err = fmt.Errorf("-edge-plating, -castellated and -snap-drills are not possible on %s panels", c.fab.Name)
+				return
+			}
+		}
+		if c.laserOptions.Kerf == 0 {
+			c.laserOptions.Kerf = c.fab.Kerf
+		}
+		if c.laserOptions.Kerf == 0 {
+			c.laserOptions.Kerf = laser.DefaultKerf
+		}
+		if c.laserOptions.Kerf < 0 {
+			err = errors.New("laser kerf must not be negative")
+			return
+		}
+		if c.fab.MetalCore && (c.edgePlating || c.castellated) {
+			err = fmt.Errorf("-edge-plating and -castellated are not possible on %s metal-core boards", c.fab.Name)
+			return
+		}
+		if c.code.Symbology, err = matrixcode.ParseSymbology(*codeType); err != nil {
+			return
+		}
+		switch *codeLayer {
+		case "silkscreen":
+			c.code.Purpose = features.Marking
+		case "copper":
+			c.code.Purpose = features.ExposedCopper
+		default:
+			err = fmt.Errorf("invalid barcode layer %q (valid values: silkscreen copper)", *codeLayer)
+			return
+		}
+		if c.artworkCutout {
+			c.artworkOptions.Purpose = features.Cutout
+		}
+		if c.watch && (c.batched() || c.outputDir == "-") {
+			err = errors.New("-watch is not supported in batch mode or when writing to standard output")
+			return
+		}
+		if c.fromFormat != "" && (c.batched() || c.ownArgs) {
+			err = errors.New("-from-format is not supported in batch mode")
+			return
+		}
+		if c.batched() || c.ownArgs {
+			if c.jobs < 1 {
+				err = errors.New("jobs must be greater than 0")
+			}
+			// panels are loaded from the spec files later
+			return
+		}
+		if p, err = c.newPanel(); err != nil {
+			return
+		}
+		if c.fromFormat != "" {
+			if err = c.retarget(p); err != nil {
+				return
+			}
+		}
+		if sp, ok := p.(*spec.Spec); ok && c.name == "" && fs.NArg() == 1 {
+			c.name = specPanelName(sp, fs.Arg(0))
+		}
+		if c.name == "" {
+			c.name = defaultName
+		}
+		return
+	}
+}
+
+// panelOutline generates the basic features for a blank panel --- an outline
+// and mounting holes
+func panelOutline(p panel.Panel) []features.Feature {
+	top := features.NewLine(panel.TopLeft(p), panel.TopRight(p), 0.1)
+	top.SetPurpose(features.Cutout)
+	bottom := features.NewLine(panel.BottomLeft(p), panel.BottomRight(p), 0.1)
+	bottom.SetPurpose(features.Cutout)
+	left := features.NewLine(panel.TopLeft(p), panel.BottomLeft(p), 0.1)
+	left.SetPurpose(features.Cutout)
+	right := features.NewLine(panel.TopRight(p), panel.BottomRight(p), 0.1)
+	right.SetPurpose(features.Cutout)
+	f := []features.Feature{top, bottom, left, right}
+	for _, centre := range p.MountingHoles() {
+		hole := features.NewCircle(centre, p.MountingHoleDiameter()/2.0)
+		hole.SetPurpose(features.Cutout)
+		f = append(f, hole)
+	}
+	return f
+}
+
+// textStyle configures the appearance and placement of header or footer
+// text
+type textStyle struct {
+	font string
+	// finish is silkscreen or copper, parsed into purpose
+	finish  string
+	purpose features.Purpose
+	// size is the height of capital letters, in millimetres
+	size float64
+	// rotation is in degrees, anticlockwise
+	rotation float64
+	// offset moves the text vertically from the mounting-hole line, in
+	// millimetres
+	offset float64
+	// left and right are additional texts aligned to the panel edges
+	left, right string
+}
+
+// styleFlags defines the flags configuring a textStyle
+func styleFlags(fs *flag.FlagSet, prefix string, s *textStyle) {
+	fs.StringVar(&s.font, prefix+"-font", "", prefix+" font; defaults to "+textpath.FontName+", or "+textpath.StrokeFontName+" for stroke text (valid values: "+strings.Join(append(textpath.Fonts(), textpath.StrokeFonts()...), " ")+")")
+	lengthVar(fs, &s.size, prefix+"-size", 4.0, "height of capital letters in "+prefix+" text, in millimetres")
+	fs.Float64Var(&s.rotation, prefix+"-rotation", 0, prefix+" text rotation, in degrees anticlockwise")
+	lengthVar(fs, &s.offset, prefix+"-offset", 0, "vertical offset of "+prefix+" text from the mounting-hole line, in millimetres; positive values move it up")
+	fs.StringVar(&s.left, prefix+"-left", "", prefix+" text aligned to the left edge of the panel")
+	fs.StringVar(&s.right, prefix+"-right", "", prefix+" text aligned to the right edge of the panel")
+	fs.StringVar(&s.finish, prefix+"-finish", "silkscreen", prefix+" text finish; copper text is exposed through the soldermask for plated legends (valid values: silkscreen copper)")
+}
+
+// parseFinish converts a text finish name to the purpose of the text
+func parseFinish(s string) (features.Purpose, error) {
+	switch s {
+	case "silkscreen":
+		return features.Marking, nil
+	case "copper":
+		return features.ExposedCopper, nil
+	}
+	return features.Marking, fmt.Errorf("invalid text finish %q (valid values: silkscreen copper)", s)
+}
+
+// textEdgeMargin is the distance between left- or right-aligned header and
+// footer text and the panel edge, in millimetres
+const textEdgeMargin = 1.5
+
+// texts generates the text features for a header or footer line
+func (s textStyle) texts(p panel.Panel, centre string, y float64) []features.Feature {
+	y += s.offset
+	place := []struct {
+		text  string
+		x     float64
+		align features.Alignment
+	}{
+		{centre, p.Width() / 2.0, features.Centre},
+		{s.left, panel.LeftX(p) + textEdgeMargin, features.CentreLeft},
+		{s.right, panel.RightX(p) - textEdgeMargin, features.CentreRight},
+	}
+	f := []features.Feature{}
+	for _, t := range place {
+		if t.text == "" {
+			continue
+		}
+		text := features.NewText(
+			geometry.Point{X: t.x, Y: y},
+			t.text,
+			features.WithAlignment(t.align),
+			features.WithSizeMM(s.size),
+			features.WithRotation(s.rotation*math.Pi/180.0),
+			features.WithFont(s.font),
+		)
+		text.SetPurpose(s.purpose)
+		f = append(f, text)
+	}
+	return f
+}
+
+// withSpec returns the configuration with the header, footer and logo of a
+// spec panel filled in where no flag gives them
+func (c config) withSpec(p panel.Panel) config {
+	sp, ok := p.(*spec.Spec)
+	if !ok {
+		return c
+	}
+	if c.header == "" {
+		c.header = sp.SpecHeader
+	}
+	if c.footer == "" {
+		c.footer = sp.SpecFooter
+	}
+	if c.logo == "" && sp.SpecLogo != "" {
+		c.logo = sp.SpecLogo
+		if sp.SpecLogoWidth > 0 {
+			c.logoWidth = sp.SpecLogoWidth
+		}
+	}
+	return c
+}
+
+func panelHeaderFooter(p panel.Panel, cfg config) []features.Feature {
+	// FIXME: figure out what to do with narrow panels — probably anything
+	//        under 6hp. Maybe align centre-right?
+	f := cfg.headerStyle.texts(p, cfg.header, p.MountingHoleTopY())
+	return append(f, cfg.footerStyle.texts(p, cfg.footer, p.MountingHoleBottomY())...)
+}
+
+// panelLogo loads an image and places it centred horizontally, just above
+// the bottom rail
+func panelLogo(p panel.Panel, filename string, width float64) ([]features.Feature, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	img, err := features.LoadImage(filename)
+	if err != nil {
+		return nil, err
+	}
+	origin := geometry.Point{
+		X: (p.Width() - width) / 2.0,
+		Y: panel.UsableArea(p).BottomLeft.Y,
+	}
+	return []features.Feature{features.NewImage(origin, img, width)}, nil
+}
+
+// panelCode generates a barcode, by default centred horizontally above the
+// bottom rail
+func panelCode(p panel.Panel, opts matrixcode.Options, profile fab.Profile) ([]features.Feature, error) {
+	if opts.Content == "" {
+		return nil, nil
+	}
+	if opts.Origin.X < 0 {
+		opts.Origin.X = (p.Width() - opts.Size) / 2.0
+	}
+	if opts.Origin.Y < 0 {
+		opts.Origin.Y = panel.UsableArea(p).BottomLeft.Y
+	}
+	return matrixcode.GenerateFeatures(opts, profile)
+}
+
+// panelThickness returns the thickness of the panel: the -thickness flag if
+// given, or else the spec file's thickness, the sheet thickness of a laser fab
+// profile, or the format's
+func (c config) panelThickness(p panel.Panel) float64 {
+	if c.thickness > 0 {
+		return c.thickness
+	}
+	if s, ok := p.(*spec.Spec); ok && s.Thickness() > 0 {
+		return s.Thickness()
+	}
+	if c.fab.Laser {
+		return c.fab.Thickness
+	}
+	return panel.Thickness(p)
+}
+
+// panelComponents checks that the placed components physically fit side by
+// side, behind the panel and within its edges, reporting any collisions
+func panelComponents(pnl panel.Panel, placed []components.Placement, opts components.Options, r diag.Reporter) error {
+	var collector diag.Collector
+	components.Check(placed, opts, &collector)
+	edges := geometry.Rect{BottomLeft: panel.BottomLeft(pnl), TopRight: panel.TopRight(pnl)}
+	components.CheckLabels(placed, edges, opts, &collector)
+	problems := collector.Diagnostics()
+	for _, d := range problems {
+		r.Report(d)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("components: %d problems with the fit of components or their labels", len(problems))
+	}
+	return nil
+}
+
+// checkText reports text too small for the fab to reproduce or extending
+// beyond the panel, failing if any small text is copper, or if there are any
+// problems at all with -text-policy fail. With -text-policy scale, small text
+// is enlarged to the fab's minimum instead.
+func checkText(feats []features.Feature, pnl panel.Panel, profile fab.Profile, policy fab.TextPolicy, r diag.Reporter) ([]features.Feature, error) {
+	var collector diag.Collector
+	if policy == fab.ScaleText {
+		feats = textpath.ScaleText(feats, profile, &collector)
+	}
+	textpath.CheckText(feats, profile, &collector)
+	textpath.CheckTextOverflow(feats, panel.Outline(pnl, geometry.DefaultTolerance), &collector)
+	failed := 0
+	for _, d := range collector.Diagnostics() {
+		r.Report(d)
+		if d.Severity == diag.Error || (policy == fab.FailText && d.Severity == diag.Warning) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return nil, fmt.Errorf("text: %d problems with text for %s", failed, profile.Name)
+	}
+	return feats, nil
+}
+
+// checkKeepouts reports cutouts and component bodies encroaching on the rail
+// keepouts of a Pulplogic tile, which has little room to spare between its
+// rails
+func checkKeepouts(tile pulplogic.Pulplogic, feats []features.Feature, placed []components.Placement, r diag.Reporter) {
+	bodies := []features.Feature{}
+	for _, p := range placed {
+		bodies = append(bodies, p.Body())
+	}
+	var collector diag.Collector
+	tile.CheckKeepouts(append(bodies, feats...), &collector)
+	for _, d := range collector.Diagnostics() {
+		r.Report(d)
+	}
+}
+
+// checkProfile checks features against the limitations of metal-core and
+// laser-cut fab profiles, failing if any cannot be made
+func checkProfile(feats []features.Feature, profile fab.Profile, r diag.Reporter) error {
+	var collector diag.Collector
+	profile.CheckMetalCore(feats, &collector)
+	profile.CheckLaser(feats, &collector)
+	failed := 0
+	for _, d := range collector.Diagnostics() {
+		r.Report(d)
+		if d.Severity == diag.Error {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d features not possible with this fab profile", profile.Name, failed)
+	}
+	return nil
+}
+
+// panelBOM lists the components, displays and mounting screws of a panel
+func panelBOM(cfg config, pnl panel.Panel) *components.BOM {
+	bom := components.NewBOM()
+	bom.AddPlacements(cfg.components...)
+	for _, a := range cfg.ledArrays {
+		bom.AddLEDArray(a)
+	}
+	bom.AddDisplays(cfg.displays...)
+	bom.AddScrews(len(pnl.MountingHoles()), pnl.MountingHoleDiameter())
+	bom.SetPartNumbers(cfg.partNumbers)
+	return bom
+}
+
+// panelHardware lists the hardware drawn over previews and overlays: screws
+// and washers at the mounting holes and the nuts and knobs of components,
+// with -preview-hardware, and knob silhouettes, with -preview-knobs
+func panelHardware(cfg config, pnl panel.Panel) []preview.Hardware {
+	knobs := []preview.Hardware{}
+	for _, p := range cfg.components {
+		if d := p.KnobDiameter(); d > 0 {
+			knobs = append(knobs, preview.Hardware{Kind: preview.Knob, Origin: p.Origin, Diameter: d})
+		}
+	}
+	hw := []preview.Hardware{}
+	if cfg.previewHardware {
+		hw = append(hw, preview.ScrewHardware(pnl.MountingHoles(), pnl.MountingHoleDiameter())...)
+		for _, p := range cfg.components {
+			if p.NutDiameter > 0 {
+				hw = append(hw, preview.Hardware{Kind: preview.Nut, Origin: p.Origin, Diameter: p.NutDiameter})
+			}
+		}
+		hw = append(hw, knobs...)
+	}
+	if cfg.previewKnobs {
+		hw = append(hw, preview.KnobSilhouettes(knobs)...)
+	}
+	return hw
+}
+
+// gitCommit returns the abbreviated commit checked out in the git working
+// tree containing dir, suffixed with "-dirty" if there are uncommitted
+// changes, or an empty string if dir is not in a git working tree
+func gitCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "describe", "--always", "--dirty", "--abbrev=12", "--exclude=*").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// dryRun prints the resolved panel geometry and the layers each feature
+// would be rendered into, without writing any output files
+func dryRun(pnl panel.Panel, feats []features.Feature, opts frontpanels.RenderOptions, cfg config) error {
+	var b bytes.Buffer
+	var err error
+	switch cfg.dryRun {
+	case "braille", "ascii":
+		fmt.Fprintf(&b, "panel %q (%s):\n", opts.Name, panel.Description(pnl))
+		err = frontpanels.PreviewTerminal(pnl, feats, opts, cfg.terminalOptions, &b)
+	default:
+		var desc *frontpanels.Description
+		if desc, err = frontpanels.Describe(pnl, feats, opts); err != nil {
+			return err
+		}
+		if cfg.dryRun == "json" {
+			err = desc.WriteJSON(&b)
+		} else {
+			err = desc.WriteTable(&b)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	// written in one piece so that panels generated in parallel do not
+	// interleave
+	_, err = os.Stdout.Write(b.Bytes())
+	return err
+}
+
+// featurer is implemented by panels with cutouts of their own beyond their
+// outline and mounting holes, such as case rear panels
+type featurer interface {
+	Features() []features.Feature
+}
+
+// setTextMode applies the text mode to every text feature, including text
+// generated by feature sources
+func setTextMode(feats []features.Feature, mode features.TextMode, strokeWidth float64) {
+	for _, f := range feats {
+		switch t := f.(type) {
+		case *features.Text:
+			features.WithMode(mode, strokeWidth)(t)
+		case *features.Placeholder:
+			features.WithMode(mode, strokeWidth)(&t.Text)
+		}
+	}
+}
+
+// panelFeatures generates the features of a panel, checking them as they
+// are generated. The decoration options, with their random source, are
+// returned for rendering any decoration zones.
+func panelFeatures(cfg config, pnl panel.Panel) ([]features.Feature, *decor.Options, error) {
+	cfg = cfg.withSpec(pnl)
+	// each panel gets its own source so that output does not depend on the
+	// order panels are generated in
+	decorOptions := cfg.decorOptions
+	decorOptions.Source = rand.NewSource(cfg.seed)
+	// inversions come first, so that their knockouts clear nothing else
+	feats := []features.Feature{}
+	for _, r := range cfg.inversions.copies() {
+		feats = append(feats, features.NewInversion(regionPoints(r)))
+	}
+	feats = append(feats, panelHeaderFooter(pnl, cfg)...)
+	if f, ok := pnl.(featurer); ok {
+		feats = append(feats, f.Features()...)
+	}
+	// text features are copied, as setTextMode and the text checks modify
+	// them, and batch panels are generated in parallel from the same config
+	for _, ls := range []labels{cfg.labels, cfg.rearLabels, cfg.copperLabels} {
+		for _, l := range ls {
+			t := *l
+			feats = append(feats, &t)
+		}
+	}
+	for _, p := range cfg.placeholders {
+		t := *p
+		feats = append(feats, &t)
+	}
+	// as are holes and regions, which preparation may resize or move
+	for _, h := range cfg.extraHoles {
+		c := *h
+		feats = append(feats, &c)
+	}
+	placed := append([]components.Placement{}, cfg.components...)
+	for _, a := range cfg.ledArrays {
+		placed = append(placed, a.Placements()...)
+	}
+	if err := panelComponents(pnl, placed, cfg.componentOptions, cfg.reporter); err != nil {
+		return nil, nil, err
+	}
+	components.CheckThickness(placed, cfg.panelThickness(pnl), cfg.reporter)
+	for _, c := range cfg.components {
+		feats = append(feats, c.Features(cfg.componentOptions)...)
+	}
+	for _, a := range cfg.ledArrays {
+		a.Bracket = cfg.ledArrayBracket
+		feats = append(feats, a.Features(cfg.componentOptions)...)
+	}
+	for _, d := range cfg.displays {
+		feats = append(feats, d.Features(geometry.DefaultTolerance)...)
+	}
+	custom := []features.Feature{}
+	for _, f := range cfg.customs {
+		custom = append(custom, f)
+	}
+	custom, err := cfg.retargeted(custom, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	// expanded here rather than when rendering, so that the checks below
+	// see the features drawing them
+	if custom, err = prepare.ExpandCustom(custom, frontpanels.KeepCustom); err != nil {
+		return nil, nil, err
+	}
+	feats = append(feats, custom...)
+	for _, r := range cfg.grilles.copies() {
+		holes, err := grille.Region(r, cfg.grilleOptions, cfg.fab)
+		if err != nil {
+			return nil, nil, err
+		}
+		feats = append(feats, holes...)
+	}
+	logo, err := panelLogo(pnl, cfg.logo, cfg.logoWidth)
+	if err != nil {
+		return nil, nil, fmt.Errorf("logo: %v", err)
+	}
+	feats = append(feats, logo...)
+	code, err := panelCode(pnl, cfg.code, cfg.fab)
+	if err != nil {
+		return nil, nil, fmt.Errorf("code: %v", err)
+	}
+	feats = append(feats, code...)
+	if cfg.artwork != "" {
+		artwork, err := svg.Load(cfg.artwork, cfg.artworkOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("artwork: %v", err)
+		}
+		if artwork, err = cfg.retargeted(artwork, true); err != nil {
+			return nil, nil, fmt.Errorf("artwork: %v", err)
+		}
+		feats = append(feats, artwork...)
+	}
+	if cfg.holes != "" {
+		table, err := holes.Load(cfg.holes, cfg.holesOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		if table, err = cfg.retargeted(table, false); err != nil {
+			return nil, nil, err
+		}
+		feats = append(feats, table...)
+	}
+	if cfg.pcb != "" {
+		comps, err := kicadpcb.Load(cfg.pcb)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pcb: %v", err)
+		}
+		opts := cfg.pcbOptions
+		opts.Rules = append(append([]kicadpcb.Rule{}, cfg.pcbRules...), opts.Rules...)
+		pcb, err := cfg.retargeted(kicadpcb.GenerateFeatures(comps, opts), false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pcb: %v", err)
+		}
+		feats = append(feats, pcb...)
+	}
+	decoration, err := decor.Generate(cfg.decor, pnl, decorOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decor: %v", err)
+	}
+	feats = append(feats, decoration...)
+	if cfg.annotations {
+		feats = append(feats, panelsource.GeneratePanelDimensions(pnl)...)
+	}
+	if tile, ok := pnl.(*pulplogic.Pulplogic); ok {
+		checkKeepouts(*tile, feats, placed, cfg.reporter)
+		if cfg.annotations {
+			feats = append(feats, pulplogic.KeepoutFeatures(*tile)...)
+		}
+	} else if cfg.mapping != nil {
+		cfg.mapping.Check(feats, cfg.reporter)
+	}
+	if cfg.holeOptions != (panel.HoleOptions{}) {
+		panel.CheckMountingHoleWeb(pnl, math.Max(panel.MinMountingHoleWeb, cfg.fab.MinHoleWeb), cfg.reporter)
+	}
+	setTextMode(feats, cfg.textMode, cfg.textStrokeWidth)
+	if feats, err = checkText(feats, pnl, cfg.fab, cfg.textPolicy, cfg.reporter); err != nil {
+		return nil, nil, err
+	}
+	if err := checkProfile(feats, cfg.fab, cfg.reporter); err != nil {
+		return nil, nil, err
+	}
+	return feats, &decorOptions, nil
+}
+
+// renderOptions returns the options for rendering a panel
+func renderOptions(cfg config, pnl panel.Panel, name string, decorOptions *decor.Options) frontpanels.RenderOptions {
+	opts := frontpanels.RenderOptions{
+		Name:             name,
+		FilenameTemplate: cfg.filenameTemplate,
+		Soldermask:       cfg.soldermask,
+		Paste:            cfg.paste,
+		DrillReport:      cfg.drillReport,
+		Annotations:      cfg.annotations,
+		OpenSCAD:         cfg.openscad,
+		STL:              cfg.stl,
+		KiCad:            cfg.kicad,
+		VCVRack:          cfg.vcvrack,
+		Thickness:        cfg.panelThickness(pnl),
+		Pour:             &cfg.pour,
+		Decorations:      decorOptions,
+		Timestamp:        cfg.timestamp,
+		Diagnostics:      cfg.reporter,
+		MetalCore:        cfg.fab.MetalCore,
+		NoBoard:          cfg.fab.Laser,
+		BoardThickness:   cfg.boardThickness,
+		EdgePlating:      cfg.edgePlating,
+		Castellated:      cfg.castellated,
+		Placeholders: map[string]string{
+			"serial":  cfg.serial,
+			"date":    cfg.buildDate,
+			"version": cfg.version,
+			"name":    name,
+		},
+	}
+	if cfg.bom {
+		opts.BOM = panelBOM(cfg, pnl)
+	}
+	if cfg.revision {
+		opts.Revision = &frontpanels.Revision{
+			Name:     name,
+			Revision: cfg.version,
+			Date:     cfg.buildDate,
+			Commit:   cfg.commit,
+		}
+	}
+	if cfg.previewHardware || cfg.previewKnobs {
+		opts.Hardware = panelHardware(cfg, pnl)
+	}
+	if cfg.preview {
+		opts.Preview = &cfg.previewColours
+	}
+	if cfg.previewPNG {
+		opts.Composite = &cfg.compositeOptions
+	}
+	if cfg.gcode {
+		opts.GCode = &cfg.gcodeOptions
+	}
+	if cfg.hpgl {
+		opts.HPGL = &cfg.hpglOptions
+	}
+	if cfg.laser {
+		opts.Laser = &cfg.laserOptions
+	}
+	if cfg.overlay {
+		opts.Overlay = &cfg.overlayOptions
+	}
+	if cfg.snapDrills {
+		opts.Drills = &cfg.fab
+	}
+	return opts
+}
+
+// generate renders a panel and writes its output files either to a
+// directory or, if requested, into a single ZIP file for sending to PCB
+// manufacturers
+func generate(cfg config, pnl panel.Panel, name, dir string) error {
+	feats, decorOptions, err := panelFeatures(cfg, pnl)
+	if err != nil {
+		return err
+	}
+	opts := renderOptions(cfg, pnl, name, decorOptions)
+	if cfg.panelize != nil {
+		arr, err := panelize.New(pnl, *cfg.panelize)
+		if err != nil {
+			return err
+		}
+		if feats, err = arr.Features(feats); err != nil {
+			return err
+		}
+		pnl = arr
+		// hardware is placed for a single copy, and is not worth repeating
+		// for a board that is cut apart before assembly
+		opts.Hardware = nil
+	}
+	if cfg.dryRun != "" {
+		return dryRun(pnl, feats, opts, cfg)
+	}
+	if cfg.checkOnly {
+		_, err := frontpanels.RenderMemory(pnl, feats, opts)
+		return err
+	}
+	var sink output.Sink = output.NewDirectory(dir)
+	if cfg.zip && dir == "-" {
+		sink = output.NewZipWriter(os.Stdout)
+	} else if cfg.zip {
+		zip, err := output.NewZip(filepath.Join(dir, name+".zip"))
+		if err != nil {
+			return err
+		}
+		sink = zip
+	}
+	opts.Output = sink
+	render := frontpanels.Render
+	if cfg.previewOnly {
+		render = frontpanels.RenderPreviews
+	}
+	if err := render(pnl, feats, opts); err != nil {
+		sink.Close()
+		return fmt.Errorf("render: %v", err)
+	}
+	return sink.Close()
+}
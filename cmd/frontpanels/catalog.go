@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/output"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// catalogPreviews is the directory of preview images, relative to the
+// catalog
+const catalogPreviews = "previews"
+
+// catalogEntry describes a panel in a catalog
+type catalogEntry struct {
+	Name   string  `json:"name"`
+	Spec   string  `json:"spec"`
+	Format string  `json:"format"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// Commit and Date are those of the last commit changing the spec file,
+	// if it is in a git working tree
+	Commit string `json:"commit,omitempty"`
+	Date   string `json:"date,omitempty"`
+	// Preview is the filename of the preview image, relative to the catalog
+	Preview string `json:"preview"`
+}
+
+// catalogTemplate is the HTML gallery of a catalog. Thumbnails share a
+// scale, so that panel widths can be compared at a glance.
+var catalogTemplate = template.Must(template.New("catalog").Funcs(template.FuncMap{
+	"pixels": func(scale, mm float64) string { return fmt.Sprintf("%.0f", scale*mm) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.panels { display: flex; flex-wrap: wrap; align-items: flex-end; gap: 2em; }
+figure { margin: 0; }
+figcaption { font-size: small; margin-top: 0.5em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{len .Panels}} panels</p>
+<div class="panels">
+{{- range .Panels}}
+<figure>
+<a href="{{.Preview}}"><img src="{{.Preview}}" alt="{{.Name}}" width="{{pixels $.Scale .Width}}" height="{{pixels $.Scale .Height}}"></a>
+<figcaption><strong>{{.Name}}</strong><br>{{.Format}}, {{printf "%.2f x %.2f mm" .Width .Height}}{{if .Commit}}<br>{{.Commit}} {{.Date}}{{end}}<br><code>{{.Spec}}</code></figcaption>
+</figure>
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+// catalogFlags defines the flags of the catalog subcommand, which indexes
+// the panels of a directory of spec files as JSON and as an HTML gallery of
+// their previews. The panel options apply to every preview.
+func catalogFlags(g *globals, fs *flag.FlagSet) func() error {
+	var cfg config
+	title := fs.String("title", "Panels", "title of the HTML gallery")
+	scale := fs.Float64("thumbnail-scale", 2, "size of the gallery thumbnails, in pixels per millimetre")
+	configured := cfg.configure(fs, g)
+	return func() error {
+		if fs.NArg() == 0 {
+			return errors.New("a directory or spec files are required")
+		}
+		if _, err := configured(); err != nil {
+			return fmt.Errorf("configure: %v", err)
+		}
+		if *scale <= 0 {
+			return errors.New("-thumbnail-scale must be greater than 0")
+		}
+		specs := []string{cfg.formatOptions.spec}
+		if cfg.batched() {
+			var err error
+			if specs, err = batchSpecs(cfg); err != nil {
+				return err
+			}
+		}
+		cfg.preview, cfg.previewOnly = true, true
+		return catalog(cfg, specs, *title, *scale)
+	}
+}
+
+// catalog writes previews of the panels described by the spec files, and
+// catalog.json and index.html files indexing them, into the output
+// directory. Spec files that do not load, eg. partial specs extended by
+// others, are skipped.
+func catalog(cfg config, specs []string, title string, scale float64) error {
+	entries := []catalogEntry{}
+	seen := map[string]int{}
+	failed := 0
+	for n, specfile := range specs {
+		sp, err := spec.LoadSpecWithVariables(specfile, cfg.vars)
+		if err != nil {
+			log.Printf("skipping %v", err)
+			continue
+		}
+		name := specPanelName(sp, specfile)
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+		panelCfg := cfg
+		panelCfg.serial = offsetSerial(cfg.serial, n)
+		if err := generate(panelCfg, sp, name, filepath.Join(cfg.outputDir, catalogPreviews)); err != nil {
+			log.Printf("%s: %v", specfile, err)
+			failed++
+			continue
+		}
+		ext := "svg"
+		if cfg.previewPNG {
+			ext = "png"
+		}
+		entry := catalogEntry{
+			Name:    name,
+			Spec:    filepath.ToSlash(specfile),
+			Format:  panel.Description(sp),
+			Width:   sp.Width(),
+			Height:  sp.Height(),
+			Preview: path.Join(catalogPreviews, output.ExpandTemplate(cfg.filenameTemplate, name, "preview", ext)),
+		}
+		entry.Commit, entry.Date = specRevision(specfile)
+		entries = append(entries, entry)
+	}
+	if err := os.MkdirAll(cfg.outputDir, 0o755); err != nil {
+		return err
+	}
+	index, err := json.MarshalIndent(struct {
+		Panels []catalogEntry `json:"panels"`
+	}{entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(cfg.outputDir, "catalog.json"), append(index, '\n'), 0o644); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(cfg.outputDir, "index.html"))
+	if err != nil {
+		return err
+	}
+	err = catalogTemplate.Execute(f, struct {
+		Title  string
+		Scale  float64
+		Panels []catalogEntry
+	}{title, scale, entries})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d panels catalogued in %s, %d failed\n", len(entries), cfg.outputDir, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d panels failed", failed)
+	}
+	return nil
+}
+
+// specRevision returns the abbreviated hash and date of the last commit
+// changing a spec file, or empty strings if it is not in a git working tree
+func specRevision(specfile string) (commit, date string) {
+	out, err := exec.Command("git", "-C", filepath.Dir(specfile), "log", "-1", "--format=%h %cs", "--", filepath.Base(specfile)).Output()
+	if err != nil {
+		return "", ""
+	}
+	commit, date, _ = strings.Cut(strings.TrimSpace(string(out)), " ")
+	return commit, date
+}
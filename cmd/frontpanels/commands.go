@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/fab"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/panelize"
+)
+
+// globals are the flags shared by the subcommands generating panels. They
+// may also be given before the subcommand name, eg.
+// frontpanels -output-dir out generate panel.yaml
+type globals struct {
+	outputDir string
+	fab       string
+	// defaults are the flag defaults read from configuration files
+	defaults defaults
+}
+
+// defaultGlobals returns the default values of the global flags
+func defaultGlobals() globals {
+	return globals{outputDir: ".", fab: fab.DefaultProfile}
+}
+
+// define registers the global flags with a flag set, defaulting to their
+// current values
+func (g *globals) define(fs *flag.FlagSet) {
+	fs.StringVar(&g.outputDir, "output-dir", g.outputDir, "directory to write output files into; created if necessary. With -zip, - writes the archive to standard output")
+	fs.StringVar(&g.fab, "fab", g.fab, "PCB fab whose capabilities generated features are checked against (valid values: "+strings.Join(fab.Names(), " ")+")")
+}
+
+// global reports whether a flag is one of the global flags, whose defaults
+// are applied before the subcommand's
+func global(name string) bool {
+	var g globals
+	fs := flag.NewFlagSet("globals", flag.ContinueOnError)
+	g.define(fs)
+	return fs.Lookup(name) != nil
+}
+
+// command is a subcommand of the CLI
+type command struct {
+	name string
+	// summary describes the subcommand in the usage message
+	summary string
+	// arguments describes the arguments of the subcommand in its usage
+	// message
+	arguments string
+	// values returns the valid arguments of the subcommand, for completion,
+	// if there is a fixed set of them
+	values func() []string
+	// flags defines the flags of the subcommand, returning a function that
+	// runs it once they have been parsed
+	flags func(g *globals, fs *flag.FlagSet) func() error
+}
+
+// commands lists the subcommands by name
+var commands = map[string]command{}
+
+// registerCommand makes a subcommand available by name
+func registerCommand(c command) {
+	commands[c.name] = c
+}
+
+// commandNames returns the names of the subcommands, sorted
+func commandNames() []string {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// panelArguments describes the arguments of the subcommands generating
+// panels
+const panelArguments = "[options] [spec.yaml...]"
+
+func init() {
+	registerCommand(command{name: "blind", summary: "generate a panel, as generate; the original name of this tool", arguments: panelArguments, flags: generateFlags})
+	registerCommand(command{name: "generate", summary: "generate the fabrication files of a panel, or of each spec file given", arguments: panelArguments, flags: generateFlags})
+	registerCommand(command{name: "check", summary: "check a panel for problems without writing any files", arguments: panelArguments, flags: checkFlags})
+	registerCommand(command{name: "preview", summary: "write only the previews of a panel", arguments: panelArguments, flags: previewFlags})
+	registerCommand(command{name: "panelize", summary: "generate several copies of a panel on one board, joined by mouse-bite tabs", arguments: panelArguments, flags: panelizeFlags})
+	registerCommand(command{name: "catalog", summary: "index the panels of a directory of spec files as JSON and an HTML gallery", arguments: "[options] directory|spec...", flags: catalogFlags})
+	registerCommand(command{name: "diff", summary: "report the geometric changes between two revisions of a panel", arguments: "[options] old new", flags: diffFlags})
+	registerCommand(command{name: "measure", summary: "print the dimensions of a panel format as JSON", arguments: "[options]", flags: measureFlags})
+	registerCommand(command{name: "completion", summary: "print a shell completion script (valid values: bash fish zsh)", arguments: "shell", values: shells, flags: completionFlags})
+	registerCommand(command{name: "order", summary: "combine bills of materials into one distributor order", arguments: "-distributor name -part-numbers file.csv [options] bom.json...", flags: orderFlags})
+}
+
+// flagSet returns the flag set of the subcommand, with its flags defined
+// and their defaults applied, and the function running the subcommand
+func (c command) flagSet(g *globals) (*flag.FlagSet, func() error, error) {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s %s\n", os.Args[0], c.name, c.arguments)
+		fs.PrintDefaults()
+	}
+	run := c.flags(g, fs)
+	if err := g.defaults.apply(fs, global); err != nil {
+		return nil, nil, err
+	}
+	return fs, run, nil
+}
+
+// run parses the arguments of the subcommand and runs it
+func (c command) run(g globals, args []string) error {
+	fs, run, err := c.flagSet(&g)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return run()
+}
+
+// known reports whether any subcommand, or the global flags, define a flag
+func known(name string) bool {
+	if global(name) {
+		return true
+	}
+	for _, c := range commands {
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		c.flags(&globals{}, fs)
+		if fs.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// panels generates the configured panel, or each panel of a batch
+func panels(cfg config, pnl panel.Panel) error {
+	if cfg.batched() {
+		return batch(cfg)
+	}
+	if cfg.watch {
+		return watch(cfg, pnl)
+	}
+	return generate(cfg, pnl, cfg.name, cfg.outputDir)
+}
+
+// generateFlags defines the flags of the generate subcommand
+func generateFlags(g *globals, fs *flag.FlagSet) func() error {
+	var cfg config
+	configured := cfg.configure(fs, g)
+	return func() error {
+		pnl, err := configured()
+		if err != nil {
+			return fmt.Errorf("configure: %v", err)
+		}
+		return panels(cfg, pnl)
+	}
+}
+
+// tally is a diag.Reporter logging diagnostics and counting them by
+// severity
+type tally struct {
+	mu     sync.Mutex
+	counts map[diag.Severity]int
+}
+
+// Report logs and counts a diagnostic
+func (t *tally) Report(d diag.Diagnostic) {
+	log.Print(d.String())
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counts == nil {
+		t.counts = map[diag.Severity]int{}
+	}
+	t.counts[d.Severity]++
+}
+
+// count returns the number of diagnostics of a severity
+func (t *tally) count(s diag.Severity) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[s]
+}
+
+// checkFlags defines the flags of the check subcommand, which renders
+// panels in memory to report every problem found along the way
+func checkFlags(g *globals, fs *flag.FlagSet) func() error {
+	var cfg config
+	failWarnings := fs.Bool("fail-on-warnings", false, "fail if there are any warnings, as well as errors")
+	configured := cfg.configure(fs, g)
+	return func() error {
+		pnl, err := configured()
+		if err != nil {
+			return fmt.Errorf("configure: %v", err)
+		}
+		t := &tally{}
+		cfg.reporter = t
+		cfg.checkOnly = true
+		if err := panels(cfg, pnl); err != nil {
+			return err
+		}
+		errs, warnings := t.count(diag.Error), t.count(diag.Warning)
+		if errs > 0 || (*failWarnings && warnings > 0) {
+			return fmt.Errorf("%d errors, %d warnings", errs, warnings)
+		}
+		fmt.Printf("ok, %d warnings\n", warnings)
+		return nil
+	}
+}
+
+// previewFlags defines the flags of the preview subcommand, which writes
+// the SVG preview, and the PNG image with -preview-png, without the
+// fabrication files
+func previewFlags(g *globals, fs *flag.FlagSet) func() error {
+	var cfg config
+	interactive := fs.Bool("terminal", false, "sketch the panel in the terminal instead of writing previews, redrawing it whenever its input files change, with commands typed on standard input to change the sketch")
+	configured := cfg.configure(fs, g)
+	return func() error {
+		pnl, err := configured()
+		if err != nil {
+			return fmt.Errorf("configure: %v", err)
+		}
+		if *interactive {
+			if cfg.batched() || cfg.watch || cfg.dryRun == "table" || cfg.dryRun == "json" {
+				return errors.New("-terminal is not supported in batch mode, or with -watch or -dry-run table or json")
+			}
+			cfg.dryRun = cfg.terminalOptions.Mode.String()
+			return sketch(cfg, pnl)
+		}
+		cfg.preview = true
+		cfg.previewOnly = true
+		return panels(cfg, pnl)
+	}
+}
+
+// panelizeFlags defines the flags of the panelize subcommand
+func panelizeFlags(g *globals, fs *flag.FlagSet) func() error {
+	var cfg config
+	opts := panelize.DefaultOptions()
+	fs.IntVar(&opts.Copies, "copies", opts.Copies, "number of copies of the panel, side by side")
+	lengthVar(fs, &opts.Tabs.Gap, "gap", opts.Tabs.Gap, "width of the routed slot between copies, in millimetres")
+	fs.IntVar(&opts.Tabs.TabCount, "tabs", opts.Tabs.TabCount, "number of mouse-bite tabs joining each pair of copies, spread along their edges")
+	lengthVar(fs, &opts.Tabs.TabWidth, "tab-width", opts.Tabs.TabWidth, "length of each tab along the panel edge, in millimetres")
+	lengthVar(fs, &opts.Tabs.HoleDiameter, "perforation-diameter", opts.Tabs.HoleDiameter, "diameter of the perforation holes along each side of a tab, in millimetres")
+	lengthVar(fs, &opts.Tabs.HolePitch, "perforation-pitch", opts.Tabs.HolePitch, "centre-to-centre distance between perforation holes, in millimetres")
+	configured := cfg.configure(fs, g)
+	return func() error {
+		pnl, err := configured()
+		if err != nil {
+			return fmt.Errorf("configure: %v", err)
+		}
+		if opts.Copies < 2 {
+			return errors.New("-copies must be at least 2")
+		}
+		cfg.panelize = &opts
+		return panels(cfg, pnl)
+	}
+}
+
+// usage prints the subcommands and global flags
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "usage: %s [global options] command [options] [arguments]\n\ncommands:\n", os.Args[0])
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range commandNames() {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, commands[name].summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nWithout a command, the options and arguments are those of generate.\n")
+	fmt.Fprintf(w, "Run %s command -h for the options of a command.\n\nglobal options:\n", os.Args[0])
+	fs.PrintDefaults()
+}
+
+func main() {
+	g := defaultGlobals()
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	g.define(fs)
+	d, err := loadDefaults()
+	if err == nil {
+		err = d.validate(known)
+	}
+	if err == nil {
+		err = d.apply(fs, nil)
+	}
+	if err != nil {
+		log.Fatalf("defaults: %v", err)
+	}
+	g.defaults = d
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		for _, c := range complete(g, os.Args[2:]) {
+			fmt.Println(c)
+		}
+		return
+	}
+	// errors are not reported here, as the arguments may be the options of
+	// the generate subcommand, given without the subcommand name as before
+	// there were subcommands
+	fs.SetOutput(io.Discard)
+	err = fs.Parse(os.Args[1:])
+	fs.SetOutput(os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		usage(fs)
+		return
+	case err == nil && fs.NArg() > 0 && fs.Arg(0) == "help":
+		usage(fs)
+		return
+	}
+	if c, ok := commands[fs.Arg(0)]; err == nil && ok {
+		if err := c.run(g, fs.Args()[1:]); err != nil {
+			log.Fatalf("%s: %v", c.name, err)
+		}
+		return
+	}
+	// spec files have an extension, so anything else is a mistyped command
+	if err == nil && fs.NArg() > 0 && filepath.Ext(fs.Arg(0)) == "" {
+		log.Fatalf("unknown command %q (valid values: %s)", fs.Arg(0), strings.Join(commandNames(), " "))
+	}
+	g = defaultGlobals()
+	g.defaults = d
+	if err := g.defaults.apply(fs, nil); err != nil {
+		log.Fatalf("defaults: %v", err)
+	}
+	if err := commands["generate"].run(g, os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
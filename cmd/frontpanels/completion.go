@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// completeCommand is the hidden subcommand run by completion scripts to
+// list the completions of the word being typed
+const completeCommand = "__complete"
+
+// completionScripts are the shell completion scripts, by shell. %[1]s is
+// the name of the program and %[2]s the name of the completion subcommand.
+// Each passes the words of the command line, up to and including the word
+// being completed, to the completion subcommand, and falls back to
+// completing filenames if it has no suggestions.
+var completionScripts = map[string]string{
+	"bash": `_%[1]s() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" %[2]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _%[1]s %[1]s
+`,
+	"zsh": `#compdef %[1]s
+_%[1]s() {
+	local -a completions
+	completions=("${(@f)$("${words[1]}" %[2]s "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${completions[1]}" ]]; then
+		compadd -a completions
+	else
+		_files
+	fi
+}
+compdef _%[1]s %[1]s
+`,
+	"fish": `function __%[1]s_complete
+	set -l words (commandline -opc)
+	set -l current (commandline -ct)
+	$words[1] %[2]s $words[2..-1] "$current" 2>/dev/null
+end
+complete -c %[1]s -a '(__%[1]s_complete)'
+`,
+}
+
+// completionFlags defines the flags of the completion subcommand, which
+// prints a shell completion script
+func completionFlags(_ *globals, fs *flag.FlagSet) func() error {
+	return func() error {
+		if fs.NArg() != 1 {
+			return fmt.Errorf("need a shell (valid values: %s)", strings.Join(shells(), " "))
+		}
+		return writeCompletion(os.Stdout, fs.Arg(0))
+	}
+}
+
+// shells returns the shells with completion scripts
+func shells() []string {
+	return []string{"bash", "fish", "zsh"}
+}
+
+// writeCompletion writes the completion script for a shell
+func writeCompletion(w io.Writer, shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("invalid shell %q (valid values: %s)", shell, strings.Join(shells(), " "))
+	}
+	_, err := fmt.Fprintf(w, script, filepath.Base(os.Args[0]), completeCommand)
+	return err
+}
+
+// validValues matches the list of values that flag usage messages end with
+var validValues = regexp.MustCompile(`\(valid values: ([^)]*)\)$`)
+
+// boolFlag is implemented by flag values that need no argument
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// takesValue reports whether a flag given without "=" takes the next word
+// as its value
+func takesValue(f *flag.Flag) bool {
+	b, ok := f.Value.(boolFlag)
+	return !ok || !b.IsBoolFlag()
+}
+
+// flagCompletions returns the flags of a flag set starting with prefix
+func flagCompletions(fs *flag.FlagSet, prefix string) []string {
+	names := []string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return prefixed(names, prefix)
+}
+
+// valueCompletions returns the values of a flag starting with prefix, for
+// flags whose usage message lists their valid values, eg. the registered
+// panel formats and fab profiles
+func valueCompletions(f *flag.Flag, prefix string) []string {
+	m := validValues.FindStringSubmatch(f.Usage)
+	if m == nil {
+		return nil
+	}
+	return prefixed(strings.Fields(m[1]), prefix)
+}
+
+// complete returns the completions of the last of the words, which follow
+// the program name on the command line. Subcommand names are completed
+// first, after any global flags, and then the subcommand's flags and the
+// values of flags listing them. An empty result leaves the shell to
+// complete filenames.
+func complete(g globals, words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	current, words := words[len(words)-1], words[:len(words)-1]
+	fs := flag.NewFlagSet("globals", flag.ContinueOnError)
+	g.define(fs)
+	var cmd *command
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if strings.HasPrefix(word, "-") {
+			// skip the value of a flag given as a separate word
+			f := fs.Lookup(strings.TrimLeft(word, "-"))
+			if f != nil && takesValue(f) {
+				i++
+			}
+			continue
+		}
+		if cmd != nil {
+			continue
+		}
+		c, ok := commands[word]
+		if !ok {
+			return nil
+		}
+		cmd = &c
+		if fs, _, _ = c.flagSet(&g); fs == nil {
+			return nil
+		}
+	}
+	if len(words) > 0 {
+		last := words[len(words)-1]
+		if f := fs.Lookup(strings.TrimLeft(last, "-")); strings.HasPrefix(last, "-") && !strings.Contains(last, "=") && f != nil && takesValue(f) {
+			return valueCompletions(f, current)
+		}
+	}
+	if strings.HasPrefix(current, "-") {
+		if name, value, ok := strings.Cut(current, "="); ok {
+			f := fs.Lookup(strings.TrimLeft(name, "-"))
+			if f == nil {
+				return nil
+			}
+			completions := []string{}
+			for _, v := range valueCompletions(f, value) {
+				completions = append(completions, name+"="+v)
+			}
+			return completions
+		}
+		return flagCompletions(fs, current)
+	}
+	if cmd != nil && cmd.values != nil {
+		return prefixed(cmd.values(), current)
+	}
+	if cmd != nil {
+		return nil
+	}
+	return prefixed(commandNames(), current)
+}
+
+// prefixed returns the values starting with prefix
+func prefixed(values []string, prefix string) []string {
+	completions := []string{}
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			completions = append(completions, v)
+		}
+	}
+	return completions
+}
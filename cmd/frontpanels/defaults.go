@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// projectDefaults is the name of the configuration file read from the
+// working directory, overriding the user's own
+const projectDefaults = ".frontpanels.yaml"
+
+// flagDefault is the default value of a flag read from a configuration
+// file. Flags that may be repeated can be given a list of values.
+type flagDefault struct {
+	filename, name string
+	values         []string
+}
+
+// defaults are flag defaults read from configuration files, mapping flag
+// names, without the leading dash, to values, eg.
+//
+//	format: intellijel
+//	fab: oshpark
+//	header-font: Jost
+//	decor: sunburst
+//	output-dir: gerbers
+//
+// Values on the command line take precedence.
+type defaults []flagDefault
+
+// defaultsFiles returns the configuration files read for flag defaults, in
+// the order they are applied: the user's, then the project's
+func defaultsFiles() []string {
+	files := []string{}
+	if dir, err := os.UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, "frontpanels", "config.yaml"))
+	}
+	return append(files, projectDefaults)
+}
+
+// loadDefaults reads the flag defaults from each configuration file that
+// exists. A flag given in a later file replaces all of its values from an
+// earlier one, so that the project's list of labels, say, is not added to
+// the user's.
+func loadDefaults() (defaults, error) {
+	d := defaults{}
+	index := map[string]int{}
+	for _, filename := range defaultsFiles() {
+		text, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		raw := yaml.MapSlice{}
+		if err := yaml.Unmarshal(text, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		for _, item := range raw {
+			fd := flagDefault{filename: filename, name: fmt.Sprint(item.Key)}
+			switch v := item.Value.(type) {
+			case []interface{}:
+				for _, value := range v {
+					fd.values = append(fd.values, fmt.Sprint(value))
+				}
+			case yaml.MapSlice, nil:
+				return nil, fmt.Errorf("%s: %s needs a value or a list of values", filename, fd.name)
+			default:
+				fd.values = []string{fmt.Sprint(v)}
+			}
+			if i, ok := index[fd.name]; ok {
+				d[i] = fd
+				continue
+			}
+			index[fd.name] = len(d)
+			d = append(d, fd)
+		}
+	}
+	return d, nil
+}
+
+// validate checks that each default is for a known flag, so that mistyped
+// names do not go unnoticed
+func (d defaults) validate(known func(name string) bool) error {
+	for _, fd := range d {
+		if !known(fd.name) {
+			return fmt.Errorf("%s: unknown option %q", fd.filename, fd.name)
+		}
+	}
+	return nil
+}
+
+// apply sets the defaults of the flags defined by a flag set, except those
+// skipped
+func (d defaults) apply(fs *flag.FlagSet, skip func(name string) bool) error {
+	for _, fd := range d {
+		if skip != nil && skip(fd.name) {
+			continue
+		}
+		f := fs.Lookup(fd.name)
+		if f == nil {
+			continue
+		}
+		for _, value := range fd.values {
+			if err := f.Value.Set(value); err != nil {
+				return fmt.Errorf("%s: %s: %v", fd.filename, fd.name, err)
+			}
+		}
+		f.DefValue = f.Value.String()
+	}
+	return nil
+}
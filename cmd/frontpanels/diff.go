@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/frontpanels"
+)
+
+// diffFlags defines the flags of the diff subcommand, which reports the
+// geometric changes between two revisions of a panel. The panel options
+// apply to both.
+func diffFlags(g *globals, fs *flag.FlagSet) func() error {
+	cfg := config{ownArgs: true}
+	exitCode := fs.Bool("exit-code", false, "fail if there are any changes, as diff(1) does")
+	configured := cfg.configure(fs, g)
+	return func() error {
+		if fs.NArg() != 2 {
+			return errors.New("two spec files, JSON descriptions or directories of output or fabrication files are required")
+		}
+		if _, err := configured(); err != nil {
+			return fmt.Errorf("configure: %v", err)
+		}
+		from, fromFiles, err := describe(cfg, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		to, toFiles, err := describe(cfg, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		// fabrication files record only the outline and drills, so the
+		// other side is compared on those alone
+		if fromFiles || toFiles {
+			from, to = from.Fabrication(), to.Fabrication()
+		}
+		changes := frontpanels.Diff(from, to)
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		if len(changes) > 0 && *exitCode {
+			return fmt.Errorf("%d changes", len(changes))
+		}
+		return nil
+	}
+}
+
+// describe returns the description of a panel given as a spec file, a JSON
+// description written by -dry-run json or -revision, or a directory of
+// output files generated with -revision. A directory without a description
+// is read as fabrication files, such as the Gerbers of a shipped revision,
+// which describe only the board outline and drills; fabrication reports
+// whether the description came from them.
+func describe(cfg config, filename string) (d *frontpanels.Description, fabrication bool, err error) {
+	if fi, err := os.Stat(filename); err == nil && fi.IsDir() {
+		matches, err := filepath.Glob(filepath.Join(filename, "*description*.json"))
+		if err != nil {
+			return nil, false, err
+		}
+		switch len(matches) {
+		case 0:
+			d, err := frontpanels.LoadFabrication(filename)
+			return d, true, err
+		case 1:
+			filename = matches[0]
+		default:
+			return nil, false, fmt.Errorf("%s: expected one description JSON file generated with -revision, found %d", filename, len(matches))
+		}
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		d, err := frontpanels.LoadDescription(filename)
+		return d, false, err
+	}
+	sp, err := spec.LoadSpecWithVariables(filename, cfg.vars)
+	if err != nil {
+		return nil, false, err
+	}
+	feats, decorOptions, err := panelFeatures(cfg, sp)
+	if err != nil {
+		return nil, false, err
+	}
+	d, err = frontpanels.Describe(sp, feats, renderOptions(cfg, sp, specPanelName(sp, filename), decorOptions))
+	return d, false, err
+}
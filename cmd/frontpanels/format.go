@@ -0,0 +1,316 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/format/eurocase"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/format/intellijel"
+	"github.com/jsleeio/frontpanels/pkg/format/joined"
+	"github.com/jsleeio/frontpanels/pkg/format/pulplogic"
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// formatOptions selects and configures the panel format. They are shared by
+// the subcommands generating panels and the measure subcommand.
+type formatOptions struct {
+	format      string
+	spec        string
+	vars        variables
+	width       int
+	rows        int
+	joinedBelow bool
+	holeOptions panel.HoleOptions
+	fit         panel.FitOptions
+	corners     corners
+	notches     notches
+	caseOptions eurocase.Options
+	// holeCount and casePower hold flag values until parse converts them
+	holeCount, casePower string
+}
+
+// define registers the format flags with a flag set
+func (f *formatOptions) define(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "eurorack", "panel format to generate (valid values: "+strings.Join(formatNames(), " ")+")")
+	fs.StringVar(&f.spec, "spec", "", "YAML panel spec file, for the spec format")
+	f.vars = variables{}
+	fs.Var(f.vars, "var", "name=value variable for expressions in the spec file; may be repeated")
+	fs.IntVar(&f.width, "width", 8, "panel width, in units appropriate for the format")
+	fs.IntVar(&f.rows, "rows", 1, "number of 3U rows spanned by a tall panel, each with its own mounting holes (eurorack)")
+	fs.BoolVar(&f.joinedBelow, "joined-1u-below", false, "place the 1U region below the 3U region, rather than above it (joined)")
+	fs.StringVar(&f.holeCount, "mounting-holes", panel.AutoHoles.String(), "number of mounting holes; auto follows the format's width threshold (valid values: auto two four)")
+	fs.Var(length{&f.holeOptions.LeftNudge}, "mounting-hole-left-nudge", "distance to move the left column of mounting holes to the right, in millimetres; negative values move it left")
+	fs.Var(length{&f.holeOptions.RightNudge}, "mounting-hole-right-nudge", "distance to move the right column of mounting holes to the right, in millimetres; negative values move it left")
+	fs.Var(namedLength{&f.holeOptions.Diameter, panel.ScrewClearance, panel.ScrewNames}, "mounting-hole-diameter", "mounting hole diameter in millimetres, replacing the format's diameter, or a screw to make clearance holes for (valid values: "+strings.Join(panel.ScrewNames(), " ")+")")
+	fs.Var(optionalLength{&f.fit.Fit}, "horizontal-fit", "total amount trimmed from the left and right edges of the panel so that it fits between its neighbours, in millimetres, replacing the format's horizontal fit")
+	fs.Var(optionalLength{&f.fit.Left}, "horizontal-fit-left", "amount trimmed from the left edge of the panel, in millimetres, replacing half of the horizontal fit")
+	fs.Var(optionalLength{&f.fit.Right}, "horizontal-fit-right", "amount trimmed from the right edge of the panel, in millimetres, replacing half of the horizontal fit")
+	fs.Var(&f.corners, "corner", "finish of panel corners as where,style[,size], overriding the format's corner radius; size is the radius, or the length cut from each edge by a 45 degree chamfer, in millimetres; may be repeated (valid places: bottom-left bottom-right top-right top-left bottom top all; valid styles: format round chamfer)")
+	fs.Var(&f.notches, "notch", "notch cut into the top or bottom panel edge as edge,x,width,depth in millimetres, with x the centre of the notch, eg. to clear sliding rail nuts; may be repeated (valid edges: bottom top)")
+	f.caseOptions = eurocase.DefaultOptions()
+	fs.IntVar(&f.caseOptions.Rows, "case-rows", f.caseOptions.Rows, "number of 3U rows, for case parts; their width is given in HP by -width")
+	fs.Var(length{&f.caseOptions.Depth}, "case-depth", "front-to-back depth of the case, in millimetres")
+	fs.Var(length{&f.caseOptions.Thickness}, "case-thickness", "thickness of the case side panels, in millimetres")
+	fs.Var(length{&f.caseOptions.RailSetback}, "case-rail-setback", "distance from the front of the case to the rail end screws, in millimetres")
+	fs.Var(length{&f.caseOptions.RailScrewDiameter}, "case-rail-screw", "clearance hole diameter for rail end screws, in millimetres")
+	fs.Var(length{&f.caseOptions.CaseScrewDiameter}, "case-screw", "clearance hole diameter for screws fixing lids and rear panels, in millimetres")
+	fs.StringVar(&f.casePower, "case-power", eurocase.NoPower.String(), "power inlet cut into the case rear panel (valid values: none iec dc)")
+}
+
+// parse converts flag values after the flag set has been parsed
+func (f *formatOptions) parse() (err error) {
+	if f.holeOptions.Count, err = panel.ParseHoleCount(f.holeCount); err != nil {
+		return err
+	}
+	f.caseOptions.Power, err = eurocase.ParsePowerEntry(f.casePower)
+	return err
+}
+
+// shaped reports whether the panel outline has corner overrides or notches
+func (f formatOptions) shaped() bool {
+	return f.corners != (corners{}) || len(f.notches) > 0
+}
+
+// namedLength is a flag.Value for lengths that may instead be given by
+// name, eg. a screw or material, looked up with lookup and listed by names
+type namedLength struct {
+	v      *float64
+	lookup func(string) (float64, error)
+	names  func() []string
+}
+
+func (n namedLength) String() string {
+	return length{n.v}.String()
+}
+
+func (n namedLength) Set(s string) error {
+	if v, err := n.lookup(s); err == nil {
+		*n.v = v
+		return nil
+	}
+	if err := (length{n.v}).Set(s); err != nil {
+		return fmt.Errorf("%v, or a name (valid values: %s)", err, strings.Join(n.names(), " "))
+	}
+	return nil
+}
+
+// optionalLength is a flag.Value for lengths that are nil unless given
+type optionalLength struct {
+	v **float64
+}
+
+func (o optionalLength) String() string {
+	if o.v == nil || *o.v == nil {
+		return ""
+	}
+	return length{*o.v}.String()
+}
+
+func (o optionalLength) Set(s string) error {
+	var v float64
+	if err := (length{&v}).Set(s); err != nil {
+		return err
+	}
+	*o.v = &v
+	return nil
+}
+
+// panelFormat is a panel format selectable with -format
+type panelFormat struct {
+	name string
+	// maxWidth is the widest panel of the format, in HP; zero for formats
+	// that ignore the width
+	maxWidth int
+	// front formats are front panels built from their width alone, that
+	// placement options can be re-targeted from with -from-format
+	front bool
+	// build constructs a panel of the format. Built-in front panel formats
+	// return their panel to be checked against any overrides; others return
+	// done, having checked that there are none.
+	build func(f formatOptions) (p panel.Panel, done bool, err error)
+}
+
+// formats lists the panel formats by name
+var formats = map[string]panelFormat{}
+
+// registerFormat adds a panel format to those selectable with -format
+func registerFormat(pf panelFormat) {
+	formats[pf.name] = pf
+}
+
+// formatNames returns the names of the panel formats, sorted
+func formatNames() []string {
+	names := []string{}
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// frontFormatNames returns the names of the front panel formats, sorted
+func frontFormatNames() []string {
+	names := []string{}
+	for _, name := range formatNames() {
+		if formats[name].front {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookupFormat returns the panel format with the given name
+func lookupFormat(name string) (panelFormat, error) {
+	pf, ok := formats[name]
+	if !ok {
+		return pf, fmt.Errorf("invalid format %q (valid values: %s)", name, strings.Join(formatNames(), " "))
+	}
+	return pf, nil
+}
+
+// fixed checks that no mounting hole, corner or notch overrides are given
+// for a format that does not support them, returning an error naming the
+// format and suggesting where else they may be set
+func (f formatOptions) fixed(what, hint string) error {
+	if f.holeOptions != (panel.HoleOptions{}) {
+		return fmt.Errorf("mounting hole overrides are not supported for %s%s", what, hint)
+	}
+	if f.fit != (panel.FitOptions{}) {
+		return fmt.Errorf("horizontal fit overrides are not supported for %s%s", what, hint)
+	}
+	if f.shaped() {
+		return fmt.Errorf("corner overrides and notches are not supported for %s%s", what, hint)
+	}
+	return nil
+}
+
+func init() {
+	registerFormat(panelFormat{
+		name:     "eurorack",
+		maxWidth: eurorack.MaxHP,
+		front:    true,
+		build: func(f formatOptions) (panel.Panel, bool, error) {
+			return &eurorack.Eurorack{HP: f.width, Rows: f.rows, Holes: f.holeOptions, Fit: f.fit, Corners: panel.Corners(f.corners), Notches: f.notches}, false, nil
+		},
+	})
+	registerFormat(panelFormat{
+		name:     "intellijel",
+		maxWidth: intellijel.MaxHP,
+		front:    true,
+		build: func(f formatOptions) (panel.Panel, bool, error) {
+			i := intellijel.NewIntellijel(f.width)
+			i.Holes = f.holeOptions
+			i.Fit = f.fit
+			i.Corners = panel.Corners(f.corners)
+			i.Notches = f.notches
+			return i, false, nil
+		},
+	})
+	registerFormat(panelFormat{
+		name:     "joined",
+		maxWidth: joined.MaxHP,
+		front:    true,
+		build: func(f formatOptions) (panel.Panel, bool, error) {
+			return &joined.Joined{HP: f.width, Below: f.joinedBelow, Holes: f.holeOptions, Fit: f.fit, Corners: panel.Corners(f.corners), Notches: f.notches}, false, nil
+		},
+	})
+	registerFormat(panelFormat{
+		name:     "pulplogic",
+		maxWidth: pulplogic.MaxHP,
+		front:    true,
+		build: func(f formatOptions) (panel.Panel, bool, error) {
+			return &pulplogic.Pulplogic{HP: f.width, Holes: f.holeOptions, Fit: f.fit, Corners: panel.Corners(f.corners), Notches: f.notches}, false, nil
+		},
+	})
+	registerFormat(panelFormat{
+		name:     "pulplogic-pcb",
+		maxWidth: pulplogic.MaxHP,
+		build: func(f formatOptions) (panel.Panel, bool, error) {
+			if err := f.fixed("the pulplogic-pcb format", ""); err != nil {
+				return nil, true, err
+			}
+			return pulplogic.NewRearPCB(f.width), true, nil
+		},
+	})
+	registerFormat(panelFormat{
+		name: "spec",
+		build: func(f formatOptions) (panel.Panel, bool, error) {
+			if f.spec == "" {
+				return nil, true, errors.New("the spec format requires a -spec file")
+			}
+			if err := f.fixed("the spec format", "; set them in the spec file"); err != nil {
+				return nil, true, err
+			}
+			p, err := spec.LoadSpecWithVariables(f.spec, f.vars)
+			return p, true, err
+		},
+	})
+	for k := eurocase.Side; k <= eurocase.Rear; k++ {
+		kind := k
+		registerFormat(panelFormat{
+			name:     "case-" + kind.String(),
+			maxWidth: eurorack.MaxHP,
+			build: func(f formatOptions) (panel.Panel, bool, error) {
+				if err := f.fixed("case parts", ""); err != nil {
+					return nil, true, err
+				}
+				opts := f.caseOptions
+				opts.HP = f.width
+				p, err := eurocase.New(kind, opts)
+				return p, true, err
+			},
+		})
+	}
+}
+
+// newPanel constructs a panel of the selected format. Width is ignored for
+// the spec format, which loads the panel from a YAML spec file instead, and
+// is otherwise limited to the widest panel of the format. Mounting hole
+// overrides apply to the built-in front panel formats only; spec files
+// describe their own mounting holes. Horizontal fit and corner overrides and
+// notches likewise apply to the built-in front panel formats only.
+func (f formatOptions) newPanel() (panel.Panel, error) {
+	pf, err := lookupFormat(f.format)
+	if err != nil {
+		return nil, err
+	}
+	if pf.maxWidth > 0 && f.width < 1 {
+		return nil, errors.New("width must be greater than 0")
+	}
+	if pf.maxWidth > 0 && f.width > pf.maxWidth {
+		return nil, fmt.Errorf("width of %dhp is wider than the %s maximum of %dhp", f.width, pf.name, pf.maxWidth)
+	}
+	if f.rows < 1 {
+		return nil, errors.New("rows must be greater than 0")
+	}
+	p, done, err := pf.build(f)
+	if err != nil || done {
+		return p, err
+	}
+	holes := f.holeOptions
+	if holes.Diameter < 0 {
+		return nil, errors.New("mounting hole diameter must not be negative")
+	}
+	if err := panel.CheckFit(p); err != nil {
+		return nil, err
+	}
+	if err := panel.CheckCorners(p, panel.Corners(f.corners), f.notches); err != nil {
+		return nil, err
+	}
+	if len(f.notches) > 0 {
+		if err := panel.CheckNotches(p, f.notches); err != nil {
+			return nil, err
+		}
+	}
+	if holes != (panel.HoleOptions{}) || f.fit != (panel.FitOptions{}) || f.shaped() {
+		if err := panel.CheckMountingHoles(p); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// metrics are the dimensions of a panel format, for consumption by external
+// layout tools and documentation generators
+type metrics struct {
+	Format               string           `json:"format"`
+	Description          string           `json:"description"`
+	Width                float64          `json:"width"`
+	Height               float64          `json:"height"`
+	HorizontalFit        float64          `json:"horizontalFit"`
+	Thickness            float64          `json:"thickness"`
+	CornerRadius         float64          `json:"cornerRadius"`
+	Outline              geometry.Rect    `json:"outline"`
+	UsableArea           geometry.Rect    `json:"usableArea"`
+	MountingHoleDiameter float64          `json:"mountingHoleDiameter"`
+	MountingHoles        []geometry.Point `json:"mountingHoles"`
+	// Offset locates the panel relative to another it is mounted behind,
+	// for formats describing rear boards
+	Offset *geometry.Point `json:"offset,omitempty"`
+	// Regions lists the usable area of each row, for formats spanning
+	// more than one
+	Regions []region `json:"regions,omitempty"`
+}
+
+// region is the usable area of one row of a panel, for JSON output
+type region struct {
+	Name       string        `json:"name"`
+	UsableArea geometry.Rect `json:"usableArea"`
+}
+
+// offsetter is implemented by formats describing a board mounted behind
+// another panel
+type offsetter interface {
+	Offset() geometry.Point
+}
+
+// measureFlags defines the flags of the measure subcommand, which prints
+// the metrics of a panel format as JSON
+func measureFlags(_ *globals, fs *flag.FlagSet) func() error {
+	var f formatOptions
+	f.define(fs)
+	return func() error {
+		return measure(f)
+	}
+}
+
+// measure prints the metrics of a panel format as JSON
+func measure(f formatOptions) error {
+	if err := f.parse(); err != nil {
+		return err
+	}
+	p, err := f.newPanel()
+	if err != nil {
+		return err
+	}
+	m := metrics{
+		Format:               f.format,
+		Description:          panel.Description(p),
+		Width:                p.Width(),
+		Height:               p.Height(),
+		HorizontalFit:        p.HorizontalFit(),
+		Thickness:            panel.Thickness(p),
+		CornerRadius:         p.CornerRadius(),
+		Outline:              geometry.Rect{BottomLeft: panel.BottomLeft(p), TopRight: panel.TopRight(p)},
+		UsableArea:           panel.UsableArea(p),
+		MountingHoleDiameter: p.MountingHoleDiameter(),
+		MountingHoles:        p.MountingHoles(),
+	}
+	if o, ok := p.(offsetter); ok {
+		offset := o.Offset()
+		m.Offset = &offset
+	}
+	if regions := panel.Regions(p); len(regions) > 1 {
+		for _, r := range regions {
+			m.Regions = append(m.Regions, region{Name: r.Name, UsableArea: r.UsableArea(p)})
+		}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/jsleeio/frontpanels/pkg/components"
+)
+
+// orderFlags defines the flags of the order subcommand, which combines the
+// bills of materials of a batch of panels into a single order-ready CSV for
+// one distributor
+func orderFlags(_ *globals, fs *flag.FlagSet) func() error {
+	distributor := fs.String("distributor", "", "distributor to order from, as named in the part numbers file header, eg. mouser or tayda")
+	partNumbers := fs.String("part-numbers", "", "CSV file of distributor part numbers, as for -bom-part-numbers")
+	kits := fs.Int("kits", 1, "number of each panel to order parts for")
+	out := fs.String("output", "-", "file to write the order CSV to; - writes to standard output")
+	return func() error {
+		return order(*distributor, *partNumbers, *kits, *out, fs.Args())
+	}
+}
+
+// order writes the order CSV for the bills of materials in files
+func order(distributor, partNumbers string, kits int, out string, files []string) error {
+	if distributor == "" || partNumbers == "" || len(files) == 0 {
+		return errors.New("-distributor, -part-numbers and at least one BOM JSON file are required")
+	}
+	if kits < 1 {
+		return errors.New("-kits must be at least 1")
+	}
+	pn, err := components.LoadPartNumbers(partNumbers)
+	if err != nil {
+		return err
+	}
+	total := components.NewBOM()
+	for _, filename := range files {
+		bom, err := components.LoadBOM(filename)
+		if err != nil {
+			return err
+		}
+		total.Merge(bom, kits)
+	}
+	total.SetPartNumbers(pn)
+	w := os.Stdout
+	if out != "-" {
+		if w, err = os.Create(out); err != nil {
+			return err
+		}
+	}
+	missing, err := total.WriteOrderCSV(w, distributor)
+	if w != os.Stdout {
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	for _, item := range missing {
+		log.Printf("no %s part number for %s (%s), quantity %d", distributor, item.Part, item.Description, item.Quantity)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package main
+
+// Custom feature kinds, and their render functions for particular output
+// formats, are registered by the init functions of the packages defining
+// them; see features.Custom. To make kinds available to -custom without
+// changing the renderers, blank-import their packages here, eg.
+//
+//	import _ "example.com/acme/panelparts"
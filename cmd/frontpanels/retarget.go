@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/retarget"
+)
+
+// retarget moves the placement options, designed for a single-row panel of
+// the -from-format format and -from-width width, onto the panel p.
+// Components, LED arrays, displays and regions move as a whole, keeping
+// their shape.
+func (c *config) retarget(p panel.Panel) error {
+	pf, err := lookupFormat(c.fromFormat)
+	if err != nil {
+		return err
+	}
+	if !pf.front {
+		return fmt.Errorf("invalid -from-format %q (valid values: %s)", c.fromFormat, strings.Join(frontFormatNames(), " "))
+	}
+	mode, err := retarget.ParseMode(c.retargetMode)
+	if err != nil {
+		return err
+	}
+	src := formatOptions{format: c.fromFormat, width: c.formatOptions.width, rows: 1}
+	if c.fromWidth != 0 {
+		src.width = c.fromWidth
+	}
+	if src.format == c.formatOptions.format && src.width == c.formatOptions.width && c.formatOptions.rows == 1 {
+		return errors.New("-from-format and -from-width describe the -format panel itself")
+	}
+	from, err := src.newPanel()
+	if err != nil {
+		return fmt.Errorf("-from-format: %v", err)
+	}
+	if c.mapping, err = retarget.New(from, p, mode); err != nil {
+		return err
+	}
+	m := c.mapping
+	for _, ls := range []labels{c.labels, c.rearLabels, c.copperLabels} {
+		for _, t := range ls {
+			t.Origin = m.Point(t.Origin)
+		}
+	}
+	for _, t := range c.placeholders {
+		t.Origin = m.Point(t.Origin)
+	}
+	for _, h := range c.extraHoles {
+		h.Origin = m.Point(h.Origin)
+	}
+	for i := range c.components {
+		c.components[i].Origin = m.Point(c.components[i].Origin)
+	}
+	for i := range c.displays {
+		c.displays[i].Origin = m.Point(c.displays[i].Origin)
+	}
+	for i, a := range c.ledArrays {
+		placed := a.Placements()
+		centre := placed[0].Origin.Add(placed[len(placed)-1].Origin).Scale(0.5)
+		c.ledArrays[i].Origin = a.Origin.Add(m.Point(centre).Sub(centre))
+	}
+	for _, rs := range []*regions{&c.grilles, &c.inversions} {
+		for i, r := range *rs {
+			moved, err := m.Group([]features.Feature{r})
+			if err != nil {
+				return err
+			}
+			(*rs)[i] = moved[0]
+		}
+	}
+	return nil
+}
+
+// retargeted moves features from sources other than the placement options,
+// such as hole tables, onto the panel if -from-format is given: together,
+// keeping their shape, or each on its own
+func (c config) retargeted(feats []features.Feature, together bool) ([]features.Feature, error) {
+	switch {
+	case c.mapping == nil:
+		return feats, nil
+	case together:
+		return c.mapping.Group(feats)
+	}
+	return c.mapping.Features(feats)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/terminal"
+)
+
+// sketchHelp lists the commands of the interactive sketch
+const sketchHelp = "commands: b braille, a ascii, + larger, - smaller, r reload, q quit; then enter"
+
+// clearScreen moves the cursor to the top left of the terminal and clears
+// it, so that each sketch replaces the last
+const clearScreen = "\x1b[H\x1b[2J"
+
+// sketchZoom is the factor each + or - command scales the sketch by
+const sketchZoom = 1.25
+
+// readLines returns a channel receiving each line read from r, closed at the
+// end of the input
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			lines <- strings.TrimSpace(s.Text())
+		}
+	}()
+	return lines
+}
+
+// zoom scales the size of a sketch, keeping it at least one character
+func zoom(opts *terminal.Options, factor float64) {
+	opts.Columns = int(float64(opts.Columns)*factor + 0.5)
+	opts.Rows = int(float64(opts.Rows)*factor + 0.5)
+	if opts.Columns < 1 {
+		opts.Columns = 1
+	}
+	if opts.Rows < 1 {
+		opts.Rows = 1
+	}
+}
+
+// sketch draws the panel in the terminal, and draws it again whenever one
+// of the files it is generated from changes or a command typed on standard
+// input changes the sketch, until the q command or the end of the input.
+// Errors are shown under the sketch rather than ending it, so that they can
+// be fixed in the editor, and the files of the last panel loaded are
+// watched meanwhile.
+func sketch(cfg config, pnl panel.Panel) error {
+	input := readLines(os.Stdin)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	files := watchedFiles(cfg, pnl)
+	status := ""
+	for {
+		fmt.Print(clearScreen)
+		if pnl != nil {
+			files = watchedFiles(cfg, pnl)
+		}
+		// taken before drawing, so that files saved meanwhile are redrawn
+		seen := modTimes(files)
+		if pnl != nil {
+			if err := generate(cfg, pnl, cfg.name, cfg.outputDir); err != nil {
+				fmt.Println(err)
+			}
+		}
+		fmt.Printf("%s\n%s\n> ", status, sketchHelp)
+		status = ""
+		reload := false
+	wait:
+		for {
+			select {
+			case <-ticker.C:
+				if changed(seen) {
+					reload = true
+					break wait
+				}
+			case line, ok := <-input:
+				switch {
+				case !ok || line == "q":
+					fmt.Println()
+					return nil
+				case line == "b":
+					cfg.terminalOptions.Mode = terminal.Braille
+				case line == "a":
+					cfg.terminalOptions.Mode = terminal.ASCII
+				case line == "+":
+					zoom(&cfg.terminalOptions, sketchZoom)
+				case line == "-":
+					zoom(&cfg.terminalOptions, 1/sketchZoom)
+				case line == "r":
+					reload = true
+				case line != "":
+					status = fmt.Sprintf("unknown command %q", line)
+				}
+				cfg.dryRun = cfg.terminalOptions.Mode.String()
+				break wait
+			}
+		}
+		if reload {
+			p, err := cfg.newPanel()
+			if err != nil {
+				status = err.Error()
+				pnl = nil
+				continue
+			}
+			pnl = p
+		}
+	}
+}
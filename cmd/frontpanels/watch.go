@@ -0,0 +1,96 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// watchInterval is how often watched files are checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// watchedFiles returns the files a panel is generated from: its spec, and
+// any specs that extends or includes, and the logo, artwork, hole and PCB
+// files named by flags or, for the logo, the spec
+func watchedFiles(cfg config, pnl panel.Panel) []string {
+	cfg = cfg.withSpec(pnl)
+	var files []string
+	if sp, ok := pnl.(*spec.Spec); ok {
+		files = append(files, sp.Files...)
+	} else if cfg.formatOptions.spec != "" {
+		files = append(files, cfg.formatOptions.spec)
+	}
+	for _, f := range []string{cfg.logo, cfg.artwork, cfg.holes, cfg.pcb} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// modTimes returns the modification time of each file. Missing files, eg.
+// while an editor replaces them, have the zero time.
+func modTimes(files []string) map[string]time.Time {
+	times := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			times[f] = fi.ModTime()
+		} else {
+			times[f] = time.Time{}
+		}
+	}
+	return times
+}
+
+// changed reports whether any of the files whose modification times were
+// seen has since been modified, created or removed
+func changed(seen map[string]time.Time) bool {
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	for f, t := range modTimes(files) {
+		if !t.Equal(seen[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForChange polls files until one of them is modified, created or
+// removed
+func waitForChange(files []string) {
+	seen := modTimes(files)
+	for !changed(seen) {
+		time.Sleep(watchInterval)
+	}
+}
+
+// watch generates a panel, then reloads and regenerates it whenever one of
+// the files it is generated from changes, until interrupted. Errors are
+// logged rather than ending the watch, so that they can be fixed in the
+// editor, and the files of the last panel loaded are watched meanwhile.
+func watch(cfg config, pnl panel.Panel) error {
+	files := watchedFiles(cfg, pnl)
+	for {
+		if pnl != nil {
+			files = watchedFiles(cfg, pnl)
+			if err := generate(cfg, pnl, cfg.name, cfg.outputDir); err != nil {
+				log.Print(err)
+			} else {
+				log.Printf("generated %s, watching %d files for changes", cfg.name, len(files))
+			}
+		}
+		waitForChange(files)
+		p, err := cfg.newPanel()
+		if err != nil {
+			log.Print(err)
+			pnl = nil
+			continue
+		}
+		pnl = p
+	}
+}
@@ -0,0 +1,176 @@
+// Package frontpanelsd is an HTTP service generating panels from YAML or
+// JSON panel specs, for powering panel design web pages. Specs are posted as
+// the request body; see the spec package for the format. Specs may not
+// extend or include other specs, as those would be read from the server.
+//
+//	POST /fab.zip      returns a ZIP archive of fabrication files
+//	POST /preview.svg  returns an SVG preview of the front of the panel
+//	GET  /healthz      returns 200 OK
+//
+// Both POST endpoints accept the query parameters name, the basename of the
+// output files, and var, a name=value variable for expressions in the spec
+// which may be repeated.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/format/spec"
+	"github.com/jsleeio/frontpanels/pkg/frontpanels"
+	"github.com/jsleeio/frontpanels/pkg/output"
+)
+
+const (
+	// maxSpecSize limits the size of posted specs, in bytes
+	maxSpecSize = 1 << 20
+
+	// maxPanelSize limits the width and height of generated panels, in
+	// millimetres, bounding the work done for a single request
+	maxPanelSize = 1000.0
+)
+
+// validName matches output basenames that are safe to use in filenames
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// request is a parsed panel generation request
+type request struct {
+	name  string
+	panel *spec.Spec
+}
+
+// parseRequest reads the spec and query parameters of a request
+func parseRequest(w http.ResponseWriter, r *http.Request) (request, error) {
+	var req request
+	vars := map[string]float64{}
+	for _, v := range r.URL.Query()["var"] {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return req, fmt.Errorf("invalid variable %q, want name=value", v)
+		}
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return req, fmt.Errorf("invalid variable %q: %v", v, err)
+		}
+		vars[parts[0]] = value
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecSize))
+	if err != nil {
+		return req, fmt.Errorf("reading spec: %v", err)
+	}
+	if req.panel, err = spec.ParseSelfContainedSpec(body, vars); err != nil {
+		return req, err
+	}
+	if req.panel.Width() <= 0 || req.panel.Height() <= 0 || req.panel.Width() > maxPanelSize || req.panel.Height() > maxPanelSize {
+		return req, fmt.Errorf("panel dimensions must be greater than 0 and at most %gmm", maxPanelSize)
+	}
+	req.name = r.URL.Query().Get("name")
+	if req.name == "" {
+		req.name = req.panel.SpecName
+	}
+	if req.name == "" {
+		req.name = "panel"
+	}
+	if !validName.MatchString(req.name) {
+		return req, errors.New("invalid name; use up to 64 letters, digits, dots, dashes and underscores")
+	}
+	return req, nil
+}
+
+// renderOptions returns the options for rendering a request, collecting
+// diagnostics rather than logging them
+func renderOptions(req request, collector *diag.Collector) frontpanels.RenderOptions {
+	opts := frontpanels.DefaultRenderOptions(req.name)
+	opts.Diagnostics = collector
+	return opts
+}
+
+// handler wraps an endpoint, restricting it to POST requests and reporting
+// errors as plain text
+func handler(fn func(w http.ResponseWriter, req request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		req, err := parseRequest(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := fn(w, req); err != nil {
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		}
+	}
+}
+
+// setWarnings reports the number of diagnostics in a response header, so
+// that clients can suggest checking the panel
+func setWarnings(w http.ResponseWriter, collector *diag.Collector) {
+	w.Header().Set("X-Frontpanels-Diagnostics", strconv.Itoa(len(collector.Diagnostics())))
+}
+
+// fabZip renders a request to a ZIP archive of fabrication files. The
+// archive is built in memory, so that errors can still be reported.
+func fabZip(w http.ResponseWriter, req request) error {
+	var collector diag.Collector
+	var buf bytes.Buffer
+	zip := output.NewZipWriter(&buf)
+	opts := renderOptions(req, &collector)
+	opts.Output = zip
+	if err := frontpanels.Render(req.panel, nil, opts); err != nil {
+		return err
+	}
+	if err := zip.Close(); err != nil {
+		return err
+	}
+	setWarnings(w, &collector)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", req.name+".zip"))
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// previewSVG renders a request to an SVG preview
+func previewSVG(w http.ResponseWriter, req request) error {
+	var collector diag.Collector
+	var buf bytes.Buffer
+	if err := frontpanels.Preview(req.panel, nil, renderOptions(req, &collector), &buf); err != nil {
+		return err
+	}
+	setWarnings(w, &collector)
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func main() {
+	listen := flag.String("listen", "localhost:8080", "address to listen on")
+	flag.Parse()
+	mux := http.NewServeMux()
+	mux.Handle("/fab.zip", handler(fabZip))
+	mux.Handle("/preview.svg", handler(previewSVG))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	server := &http.Server{
+		Addr:         *listen,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	log.Printf("listening on %s", *listen)
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,36 @@
+// Package golden is a CLI tool for checking rendered output against the
+// golden files in testdata/golden, or regenerating them after an intended
+// change. Run it from the repository root.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jsleeio/frontpanels/pkg/golden"
+)
+
+func main() {
+	opts := golden.DefaultOptions()
+	flag.StringVar(&opts.Dir, "dir", opts.Dir, "directory containing the golden files")
+	flag.Float64Var(&opts.Tolerance, "tolerance", opts.Tolerance, "maximum difference between corresponding numbers, in millimetres for coordinates")
+	flag.BoolVar(&opts.Update, "update", false, "replace the golden files with the current output instead of comparing")
+	flag.Parse()
+	cases, err := golden.Cases()
+	if err != nil {
+		log.Fatal(err)
+	}
+	diffs, err := golden.Check(cases, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	if len(diffs) > 0 {
+		fmt.Fprintf(os.Stderr, "golden: %d differences in %d cases\n", len(diffs), len(cases))
+		os.Exit(1)
+	}
+}
@@ -3,8 +3,10 @@ module github.com/jsleeio/frontpanels
 go 1.19
 
 require (
+	github.com/boombuler/barcode v1.1.0
 	github.com/gmlewis/go-fonts v0.0.12
 	github.com/gmlewis/go-gerber v0.0.6
+	golang.org/x/image v0.18.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -14,6 +16,5 @@ require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/yofu/dxf v0.0.0-20190320002657-c8b82bb2fe97 // indirect
-	golang.org/x/image v0.18.0 // indirect
 	google.golang.org/protobuf v1.33.0 // indirect
 )
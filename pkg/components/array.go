@@ -0,0 +1,234 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package components
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Orientation indicates the direction an array of components runs in
+type Orientation int
+
+// Vertical et al specify array orientations. Vertical arrays run upwards
+// from their origin, and horizontal arrays to the right.
+const (
+	Vertical   Orientation = iota // this MUST be the first item
+	Horizontal                    // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (o Orientation) String() string {
+	switch o {
+	case Vertical:
+		return "vertical"
+	case Horizontal:
+		return "horizontal"
+	}
+	panic(fmt.Sprintf("invalid Orientation value (valid range is %d..%d): %d",
+		int(Vertical), int(Horizontal), int(o)))
+}
+
+// ParseOrientation converts an orientation name, as returned by
+// Orientation.String, to an Orientation
+func ParseOrientation(s string) (Orientation, error) {
+	for o := Vertical; o <= Horizontal; o++ {
+		if o.String() == s {
+			return o, nil
+		}
+	}
+	return Vertical, fmt.Errorf("invalid orientation %q (valid values: vertical horizontal)", s)
+}
+
+// LEDArray describes a row or column of evenly spaced LEDs, such as a
+// bar-graph level meter. Distances are in millimetres.
+type LEDArray struct {
+	// Origin is the centre of the first LED
+	Origin geometry.Point
+	Count  int
+	// Pitch is the distance between the centres of neighbouring LEDs
+	Pitch float64
+	Orientation
+	// LED is the round LED used, eg. led3 or led5. It is ignored when
+	// RectSize is set.
+	LED Component
+	// RectSize is the width and height of the holes for rectangular LEDs, as
+	// they appear on the panel. The zero value selects round LEDs.
+	RectSize geometry.Point
+	// Bracket draws a silkscreen bracket along the left of a vertical array,
+	// or below a horizontal one
+	Bracket bool
+	// Labels are scale values, eg. dB or CV levels, placed beside each LED in
+	// order, opposite the bracket. Empty labels are skipped.
+	Labels []string
+	// LabelSize is the height of capital letters in labels
+	LabelSize float64
+	// Gap is the distance between the LED holes and the bracket or labels
+	Gap float64
+	// Thickness is the line thickness of the bracket
+	Thickness float64
+}
+
+// NewLEDArray initializes an LED array with the default bracket and label
+// styling
+func NewLEDArray(origin geometry.Point, count int, pitch float64, led Component) LEDArray {
+	return LEDArray{
+		Origin:    origin,
+		Count:     count,
+		Pitch:     pitch,
+		LED:       led,
+		LabelSize: 1.5,
+		Gap:       1.0,
+		Thickness: 0.2,
+	}
+}
+
+// rect indicates whether the array uses rectangular LEDs
+func (a LEDArray) rect() bool {
+	return a.RectSize != (geometry.Point{})
+}
+
+// size returns the width and height of each LED hole
+func (a LEDArray) size() geometry.Point {
+	if a.rect() {
+		return a.RectSize
+	}
+	return geometry.Point{X: a.LED.HoleDiameter, Y: a.LED.HoleDiameter}
+}
+
+// direction returns the unit vector the array runs along
+func (a LEDArray) direction() geometry.Point {
+	if a.Orientation == Horizontal {
+		return geometry.Point{X: 1}
+	}
+	return geometry.Point{Y: 1}
+}
+
+// step returns the offset from one LED centre to the next
+func (a LEDArray) step() geometry.Point {
+	return a.direction().Scale(a.Pitch)
+}
+
+// Validate returns an error if the LEDs of the array would not fit
+func (a LEDArray) Validate() error {
+	if a.Count < 1 {
+		return errors.New("components: LED array needs at least one LED")
+	}
+	if !a.rect() && a.LED.HoleDiameter <= 0 {
+		return errors.New("components: LED array needs an LED or a rectangular LED size")
+	}
+	if len(a.Labels) > a.Count {
+		return fmt.Errorf("components: LED array has %d labels for %d LEDs", len(a.Labels), a.Count)
+	}
+	along := a.size().Y
+	if a.Orientation == Horizontal {
+		along = a.size().X
+	}
+	if a.Count > 1 && a.Pitch <= along {
+		return fmt.Errorf("components: LED array pitch %.2fmm leaves no room between %.2fmm LEDs", a.Pitch, along)
+	}
+	return nil
+}
+
+// Centres returns the centre of each LED, in order
+func (a LEDArray) Centres() []geometry.Point {
+	centres := []geometry.Point{}
+	for i := 0; i < a.Count; i++ {
+		centres = append(centres, a.Origin.Add(a.step().Scale(float64(i))))
+	}
+	return centres
+}
+
+// Placements returns a placement for each round LED of the array, for
+// checking against other components. Rectangular LEDs have no placements.
+func (a LEDArray) Placements() []Placement {
+	if a.rect() {
+		return nil
+	}
+	placed := []Placement{}
+	for _, c := range a.Centres() {
+		placed = append(placed, Placement{Component: a.LED, Origin: c})
+	}
+	return placed
+}
+
+// Features generates the LED holes of the array, with its bracket and labels
+func (a LEDArray) Features(opts Options) []features.Feature {
+	feats := []features.Feature{}
+	size := a.size()
+	for _, c := range a.Centres() {
+		if !a.rect() {
+			feats = append(feats, Placement{Component: a.LED, Origin: c}.Features(opts)...)
+			continue
+		}
+		half := size.Scale(0.5)
+		hole := features.NewRectangle(c.Sub(half), c.Add(half))
+		hole.SetPurpose(features.Cutout)
+		feats = append(feats, hole)
+	}
+	first, last := a.Origin, a.Origin.Add(a.step().Scale(float64(a.Count-1)))
+	// across is the unit vector from the LEDs towards the labels, and the
+	// bracket is on the opposite side
+	across := geometry.Point{X: 1}
+	extent := size.X / 2
+	align := features.CentreLeft
+	if a.Orientation == Horizontal {
+		across = geometry.Point{Y: 1}
+		extent = size.Y / 2
+		align = features.BottomCentre
+	}
+	if a.Bracket {
+		// the bracket spine runs the length of the array, with ticks pointing
+		// back towards the first and last LEDs
+		offset := across.Scale(-(extent + a.Gap))
+		tick := across.Scale(math.Min(a.Gap, extent))
+		along := size.Y / 2
+		if a.Orientation == Horizontal {
+			along = size.X / 2
+		}
+		spineStart := first.Add(offset).Sub(a.direction().Scale(along))
+		spineEnd := last.Add(offset).Add(a.direction().Scale(along))
+		for _, l := range [][2]geometry.Point{
+			{spineStart, spineEnd},
+			{spineStart, spineStart.Add(tick)},
+			{spineEnd, spineEnd.Add(tick)},
+		} {
+			line := features.NewLine(l[0], l[1], a.Thickness)
+			line.SetPurpose(features.Marking)
+			feats = append(feats, line)
+		}
+	}
+	for i, label := range a.Labels {
+		if label == "" {
+			continue
+		}
+		origin := a.Origin.Add(a.step().Scale(float64(i))).Add(across.Scale(extent + a.Gap))
+		feats = append(feats, features.NewText(origin, label,
+			features.WithAlignment(align),
+			features.WithSizeMM(a.LabelSize),
+		))
+	}
+	return feats
+}
@@ -0,0 +1,290 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package components
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// hardware describes the loose parts listed in bills of materials alongside
+// components, by part name
+var hardware = map[string]string{
+	"nut-m6":     "M6x0.5 hex nut",
+	"nut-m7":     "M7x0.75 hex nut",
+	"nut-1/4-40": "1/4-40 hex nut",
+}
+
+// screws lists metric screw sizes for panel mounting holes, largest first
+var screws = []struct {
+	name     string
+	diameter float64
+}{
+	{"m6", 6.0}, {"m5", 5.0}, {"m4", 4.0}, {"m3", 3.0}, {"m2.5", 2.5}, {"m2", 2.0},
+}
+
+// Item is a line of a bill of materials
+type Item struct {
+	Part        string `json:"part"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	// PartNumbers are distributor part numbers for the part, keyed by
+	// distributor name
+	PartNumbers map[string]string `json:"partNumbers,omitempty"`
+}
+
+// BOM is a bill of materials listing the hardware needed to build a panel,
+// for packing lists and parts orders
+type BOM struct {
+	items map[string]*Item
+	// distributors are the names of the distributors with part numbers
+	distributors []string
+}
+
+// NewBOM constructs an empty BOM
+func NewBOM() *BOM {
+	return &BOM{items: map[string]*Item{}}
+}
+
+// Add adds a quantity of a part, combining it with any of the same part
+// already listed
+func (b *BOM) Add(part, description string, quantity int) {
+	if quantity <= 0 {
+		return
+	}
+	item, ok := b.items[part]
+	if !ok {
+		item = &Item{Part: part, Description: description}
+		b.items[part] = item
+	}
+	item.Quantity += quantity
+}
+
+// AddPlacements adds placed components and their nuts
+func (b *BOM) AddPlacements(placed ...Placement) {
+	for _, p := range placed {
+		description := p.Description
+		if p.Variant != "" {
+			description += ", " + p.Variant
+		}
+		b.Add(p.Part(), description, 1)
+		if p.Nut != "" {
+			b.Add(p.Nut, hardware[p.Nut], p.Nuts)
+		}
+	}
+}
+
+// AddLEDArray adds the LEDs of an array
+func (b *BOM) AddLEDArray(a LEDArray) {
+	if !a.rect() {
+		b.AddPlacements(a.Placements()...)
+		return
+	}
+	size := fmt.Sprintf("%gx%g", a.RectSize.X, a.RectSize.Y)
+	b.Add("led-"+size, size+"mm rectangular LED", a.Count)
+}
+
+// AddDisplays adds placed displays
+func (b *BOM) AddDisplays(placed ...DisplayPlacement) {
+	for _, p := range placed {
+		b.Add(p.Name, p.Description, 1)
+	}
+}
+
+// AddScrews adds screws for panel mounting holes of the given diameter,
+// choosing the largest metric screw that clears the hole
+func (b *BOM) AddScrews(count int, holeDiameter float64) {
+	for _, s := range screws {
+		if s.diameter < holeDiameter {
+			b.Add("screw-"+s.name, fmt.Sprintf("M%g panel mounting screw", s.diameter), count)
+			return
+		}
+	}
+}
+
+// Items returns the items of the BOM, sorted by part name
+func (b *BOM) Items() []Item {
+	items := []Item{}
+	for _, item := range b.items {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Part < items[j].Part })
+	return items
+}
+
+// Merge adds every item of another BOM, multiplying its quantity, eg. by
+// the number of kits being packed
+func (b *BOM) Merge(o *BOM, multiplier int) {
+	for _, item := range o.Items() {
+		b.Add(item.Part, item.Description, item.Quantity*multiplier)
+	}
+}
+
+// LoadBOM reads a BOM written by WriteJSON. Any part numbers are ignored.
+func LoadBOM(filename string) (*BOM, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	items := []Item{}
+	if err := json.NewDecoder(f).Decode(&items); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	b := NewBOM()
+	for _, item := range items {
+		b.Add(item.Part, item.Description, item.Quantity)
+	}
+	return b, nil
+}
+
+// PartNumbers maps part names to distributor part numbers, keyed by
+// distributor name
+type PartNumbers map[string]map[string]string
+
+// LoadPartNumbers reads distributor part numbers from a CSV file. The
+// header row names the part column "part" and each other column for a
+// distributor; each following row gives the part numbers of one part. Empty
+// cells are ignored.
+func LoadPartNumbers(filename string) (PartNumbers, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if len(records) == 0 || len(records[0]) < 2 || records[0][0] != "part" {
+		return nil, fmt.Errorf("%s: header must be part followed by distributor names", filename)
+	}
+	pn := PartNumbers{}
+	for _, record := range records[1:] {
+		for i, number := range record[1:] {
+			if number == "" {
+				continue
+			}
+			if pn[record[0]] == nil {
+				pn[record[0]] = map[string]string{}
+			}
+			pn[record[0]][records[0][i+1]] = number
+		}
+	}
+	return pn, nil
+}
+
+// Distributors returns the names of the distributors with part numbers,
+// sorted
+func (pn PartNumbers) Distributors() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, numbers := range pn {
+		for name := range numbers {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetPartNumbers sets the distributor part numbers of each item from pn.
+// Every distributor in pn gets a CSV column, even if none of the items have
+// part numbers from it.
+func (b *BOM) SetPartNumbers(pn PartNumbers) {
+	b.distributors = pn.Distributors()
+	for part, item := range b.items {
+		item.PartNumbers = pn[part]
+	}
+}
+
+// WriteCSV writes the BOM as CSV, with a column of part numbers for each
+// distributor
+func (b *BOM) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"part", "description", "quantity"}, b.distributors...)); err != nil {
+		return err
+	}
+	for _, item := range b.Items() {
+		record := []string{item.Part, item.Description, strconv.Itoa(item.Quantity)}
+		for _, d := range b.distributors {
+			record = append(record, item.PartNumbers[d])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the BOM items as JSON
+func (b *BOM) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b.Items())
+}
+
+// WriteOrderCSV writes an order for a distributor as CSV, with a row of
+// part number and quantity for each distinct part number, suitable for
+// the distributor's BOM or quick order upload. Parts sharing a part number
+// are combined. Parts without a part number from the distributor are
+// omitted, and returned so that they can be ordered some other way.
+func (b *BOM) WriteOrderCSV(w io.Writer, distributor string) ([]Item, error) {
+	missing := []Item{}
+	numbers := []string{}
+	lines := map[string]*Item{}
+	for _, item := range b.Items() {
+		number := item.PartNumbers[distributor]
+		if number == "" {
+			missing = append(missing, item)
+			continue
+		}
+		if line, ok := lines[number]; ok {
+			line.Quantity += item.Quantity
+			line.Description += "; " + item.Description
+			continue
+		}
+		line := item
+		lines[number] = &line
+		numbers = append(numbers, number)
+	}
+	sort.Strings(numbers)
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"part number", "quantity", "description"}); err != nil {
+		return nil, err
+	}
+	for _, number := range numbers {
+		if err := cw.Write([]string{number, strconv.Itoa(lines[number].Quantity), lines[number].Description}); err != nil {
+			return nil, err
+		}
+	}
+	cw.Flush()
+	return missing, cw.Error()
+}
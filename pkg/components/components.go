@@ -0,0 +1,355 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package components describes panel-mounted hardware such as jacks,
+// potentiometers, switches and LEDs, and generates the panel features needed
+// to mount it. Each component carries enough physical metadata to check that
+// neighbouring hardware physically fits side by side.
+package components
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Component describes a type of panel-mounted hardware. Distances are in
+// millimetres. Bodies and nuts are treated as circles, using their widest
+// dimension as the diameter.
+type Component struct {
+	Name        string
+	Description string
+	// HoleDiameter is the size of panel hole the component mounts in
+	HoleDiameter float64
+	// NutDiameter is the size of the nut or washer on the front of the panel,
+	// across its corners. Components without one, such as LEDs, have zero.
+	NutDiameter float64
+	// BodyDiameter is the size of the component body behind the panel
+	BodyDiameter float64
+	// Knob is the name of the knob fitted to the component by default, as
+	// drawn in previews; see LookupKnob. Components without one, such as
+	// jacks, have an empty name.
+	Knob string
+	// Depth is how far the component body extends behind the panel
+	Depth float64
+	// Nut is the bill of materials part name of the nut fixing the
+	// component to the panel, and Nuts is how many each component takes.
+	// Components without one, such as LEDs, have an empty name.
+	Nut  string
+	Nuts int
+	// Thread is the length of the threaded bushing, from the face of the
+	// body resting against the rear of the panel, and NutHeight is the
+	// thickness of each nut. Where a component takes more than one nut, the
+	// others are behind the panel, setting its height.
+	Thread, NutHeight float64
+	// Shaft is the length of the shaft from the face of the body, for
+	// components fitted with knobs
+	Shaft float64
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (c Component) String() string {
+	return fmt.Sprintf("Component(name=%s, hole=%.2f, nut=%.2f, body=%.2f, depth=%.2f)",
+		c.Name, c.HoleDiameter, c.NutDiameter, c.BodyDiameter, c.Depth)
+}
+
+// catalog lists the known components, using typical datasheet dimensions
+var catalog = map[string]Component{
+	"thonkiconn": {
+		Name:         "thonkiconn",
+		Description:  "Thonkiconn PJ398SM 3.5mm jack",
+		HoleDiameter: 6.0,
+		NutDiameter:  8.0,
+		BodyDiameter: 9.0,
+		Depth:        10.5,
+		Nut:          "nut-m6",
+		Nuts:         1,
+		Thread:       4.5,
+		NutHeight:    1.6,
+	},
+	"alpha9": {
+		Name:         "alpha9",
+		Description:  "Alpha 9mm vertical potentiometer",
+		HoleDiameter: 7.0,
+		NutDiameter:  10.9,
+		BodyDiameter: 9.7,
+		Knob:         "knurled",
+		Depth:        11.0,
+		Nut:          "nut-m7",
+		Nuts:         1,
+		Thread:       5.0,
+		NutHeight:    2.0,
+		Shaft:        15.0,
+	},
+	"alpha16": {
+		Name:         "alpha16",
+		Description:  "Alpha 16mm potentiometer",
+		HoleDiameter: 7.5,
+		NutDiameter:  12.7,
+		BodyDiameter: 16.5,
+		Knob:         "davies1900h",
+		Depth:        12.0,
+		Nut:          "nut-m7",
+		Nuts:         1,
+		Thread:       7.0,
+		NutHeight:    2.0,
+		Shaft:        15.0,
+	},
+	"toggle": {
+		Name:         "toggle",
+		Description:  "miniature toggle switch, 1/4-40 bushing",
+		HoleDiameter: 6.4,
+		NutDiameter:  10.9,
+		BodyDiameter: 8.0,
+		Depth:        13.0,
+		Nut:          "nut-1/4-40",
+		Nuts:         2,
+		Thread:       8.9,
+		NutHeight:    2.4,
+	},
+	"led3": {
+		Name:         "led3",
+		Description:  "3mm LED",
+		HoleDiameter: 3.2,
+		BodyDiameter: 3.8,
+		Depth:        5.3,
+	},
+	"led5": {
+		Name:         "led5",
+		Description:  "5mm LED",
+		HoleDiameter: 5.2,
+		BodyDiameter: 5.8,
+		Depth:        8.6,
+	},
+}
+
+// Names returns the names of all known components, sorted
+func Names() []string {
+	names := []string{}
+	for name := range catalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the component with the given name
+func Lookup(name string) (Component, error) {
+	c, ok := catalog[name]
+	if !ok {
+		return Component{}, fmt.Errorf("unknown component %q (valid values: %s)", name, strings.Join(Names(), " "))
+	}
+	return c, nil
+}
+
+// Options configures feature generation and fit checking
+type Options struct {
+	// Courtyards generates Annotation outlines around the nut, on the top
+	// side, and the body, on the bottom side, of each component
+	Courtyards bool
+	// Depth is the space behind the panel for component bodies, eg. above
+	// a PCB or in a shallow case, in millimetres. Components whose Depth
+	// exceeds it are reported by Check. Zero disables the check.
+	Depth float64
+	// Margin is the clearance added around nuts, bodies and labels, in
+	// millimetres
+	Margin float64
+	// LabelSize is the height of capital letters in labels, in millimetres
+	LabelSize float64
+	// TextMode and StrokeWidth are how labels are drawn, which sets their
+	// bounds for checking; see features.WithMode
+	TextMode    features.TextMode
+	StrokeWidth float64
+}
+
+// DefaultOptions returns the default component options
+func DefaultOptions() Options {
+	return Options{Margin: 0.5, LabelSize: 2.0}
+}
+
+// Placement is a component at a position on the panel
+type Placement struct {
+	Component
+	Origin geometry.Point
+	// Label is optional text placed beside the component
+	Label *Label
+	// Variant distinguishes otherwise identical components in bills of
+	// materials, eg. the value of a potentiometer
+	Variant string
+	// KnobSize is the diameter of the fitted knob in millimetres, eg. as
+	// measured, overriding that of its style. Zero uses the style's.
+	KnobSize float64
+}
+
+// Part returns the bill of materials part name of the placed component:
+// its name, followed by its variant if it has one, as name:variant
+func (p Placement) Part() string {
+	if p.Variant == "" {
+		return p.Name
+	}
+	return p.Name + ":" + p.Variant
+}
+
+// KnobDiameter returns the diameter of the knob fitted to the placed
+// component, or zero if it has none
+func (p Placement) KnobDiameter() float64 {
+	if p.KnobSize > 0 {
+		return p.KnobSize
+	}
+	k, err := LookupKnob(p.Knob)
+	if err != nil {
+		return 0
+	}
+	return k.Diameter
+}
+
+// Features generates the mounting hole for the placement, its label and,
+// if requested, its courtyards
+func (p Placement) Features(opts Options) []features.Feature {
+	hole := features.NewCircle(p.Origin, p.HoleDiameter/2.0)
+	hole.SetPurpose(features.Cutout)
+	feats := []features.Feature{hole}
+	if t := p.LabelText(opts); t != nil {
+		feats = append(feats, t)
+	}
+	if !opts.Courtyards {
+		return feats
+	}
+	for _, c := range []struct {
+		diameter float64
+		side     features.Side
+	}{
+		{p.NutDiameter, features.TopSide},
+		{p.BodyDiameter, features.BottomSide},
+	} {
+		if c.diameter <= 0 {
+			continue
+		}
+		feats = append(feats, courtyard(p.Origin, c.diameter/2.0+opts.Margin, c.side)...)
+	}
+	return feats
+}
+
+// CourtyardWidth is the width of the lines outlining courtyards, in
+// millimetres
+const CourtyardWidth = 0.1
+
+// courtyard returns Annotation lines outlining a circle on one side of the
+// panel
+func courtyard(centre geometry.Point, radius float64, side features.Side) []features.Feature {
+	points := geometry.Arc(centre, radius, 0, 360, geometry.DefaultTolerance)
+	feats := []features.Feature{}
+	for i := 1; i < len(points); i++ {
+		line := features.NewLine(points[i-1], points[i], CourtyardWidth)
+		line.SetPurpose(features.Annotation)
+		line.SetSide(side)
+		feats = append(feats, line)
+	}
+	return feats
+}
+
+// Body returns an Annotation circle on the rear of the panel covering the
+// component body, or its hole where that is larger, for checking the space
+// the component needs behind the panel
+func (p Placement) Body() *features.Circle {
+	body := features.NewCircle(p.Origin, p.rear())
+	body.SetPurpose(features.Annotation)
+	body.SetSide(features.BottomSide)
+	return body
+}
+
+// front returns the radius a component occupies on the front of the panel:
+// its nut, or failing that its hole
+func (c Component) front() float64 {
+	return math.Max(c.NutDiameter, c.HoleDiameter) / 2
+}
+
+// rear returns the radius a component occupies behind the panel
+func (c Component) rear() float64 {
+	return math.Max(c.BodyDiameter, c.HoleDiameter) / 2
+}
+
+// Check reports an Error diagnostic for each pair of placements whose nuts
+// or knobs, on the front of the panel, or bodies, behind it, would collide,
+// allowing for the margin, and for each placement too deep for the space
+// behind the panel
+func Check(placed []Placement, opts Options, r diag.Reporter) {
+	for _, p := range placed {
+		if opts.Depth > 0 && p.Depth > opts.Depth {
+			r.Report(diag.Diagnostic{
+				Severity: diag.Error,
+				Message: fmt.Sprintf("%s at (%.2f, %.2f): %.2fmm deep, only %.2fmm behind the panel",
+					p.Name, p.Origin.X, p.Origin.Y, p.Depth, opts.Depth),
+			})
+		}
+	}
+	for i, a := range placed {
+		for _, b := range placed[i+1:] {
+			d := a.Origin.Distance(b.Origin)
+			for _, c := range []struct {
+				what   string
+				ra, rb float64
+			}{
+				{"front", a.front(), b.front()},
+				{"rear", a.rear(), b.rear()},
+				{"knobs", a.KnobDiameter() / 2, b.KnobDiameter() / 2},
+			} {
+				if c.ra == 0 || c.rb == 0 {
+					continue
+				}
+				if need := c.ra + c.rb + 2*opts.Margin; d < need {
+					r.Report(diag.Diagnostic{
+						Severity: diag.Error,
+						Message: fmt.Sprintf("%s at (%.2f, %.2f) and %s at (%.2f, %.2f): %.2fmm apart, need %.2fmm at the %s",
+							a.Name, a.Origin.X, a.Origin.Y, b.Name, b.Origin.X, b.Origin.Y, d, need, c.what),
+					})
+				}
+			}
+		}
+	}
+}
+
+// CheckThickness reports a Warning diagnostic for each placement whose
+// bushing is too short to engage its nut fully through a panel of the given
+// thickness, or whose shaft is too short for its knob to grip
+func CheckThickness(placed []Placement, thickness float64, r diag.Reporter) {
+	for _, p := range placed {
+		if p.Thread > 0 {
+			behind := float64(p.Nuts-1) * p.NutHeight
+			if left := p.Thread - behind - thickness; left < p.NutHeight {
+				diag.Warnf(r, nil, "%s at (%.2f, %.2f): %.2fmm of thread in front of a %.2fmm panel, need %.2fmm for the nut",
+					p.Name, p.Origin.X, p.Origin.Y, left, thickness, p.NutHeight)
+			}
+		}
+		knob, err := LookupKnob(p.Knob)
+		if err != nil || p.Shaft <= 0 {
+			continue
+		}
+		if left := p.Shaft - thickness; left < knob.Grip {
+			diag.Warnf(r, nil, "%s at (%.2f, %.2f): %.2fmm of shaft in front of a %.2fmm panel, need %.2fmm for a %s knob",
+				p.Name, p.Origin.X, p.Origin.Y, left, thickness, knob.Grip, knob.Name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Display describes a segment display part or module, seen through a
+// window in the panel. Distances are in millimetres.
+type Display struct {
+	Name        string
+	Description string
+	// Window is the width and height of the panel window, including a little
+	// clearance around the face of the display
+	Window       geometry.Point
+	CornerRadius float64
+	// MountingHoles are the positions of any module mounting holes, relative
+	// to the centre of the window
+	MountingHoles        []geometry.Point
+	MountingHoleDiameter float64
+}
+
+// displays lists the known displays. Sizes are typical of the parts listed
+// and should be checked against the datasheet of the actual part used,
+// especially for modules, whose boards vary between suppliers.
+var displays = map[string]Display{
+	"7seg-056-1": {
+		Name:         "7seg-056-1",
+		Description:  "0.56in single digit 7-segment display",
+		Window:       geometry.Point{X: 13.0, Y: 19.4},
+		CornerRadius: 0.5,
+	},
+	"7seg-056-4": {
+		Name:         "7seg-056-4",
+		Description:  "0.56in four digit 7-segment display",
+		Window:       geometry.Point{X: 50.8, Y: 19.4},
+		CornerRadius: 0.5,
+	},
+	"7seg-036-4": {
+		Name:         "7seg-036-4",
+		Description:  "0.36in four digit 7-segment display",
+		Window:       geometry.Point{X: 30.5, Y: 14.4},
+		CornerRadius: 0.5,
+	},
+	"14seg-054-4": {
+		Name:         "14seg-054-4",
+		Description:  "0.54in four character 14-segment alphanumeric display",
+		Window:       geometry.Point{X: 50.4, Y: 20.4},
+		CornerRadius: 0.5,
+	},
+	"bubble-4": {
+		Name:         "bubble-4",
+		Description:  "four digit bubble display, eg. QDSP-6064",
+		Window:       geometry.Point{X: 17.8, Y: 6.4},
+		CornerRadius: 1.0,
+	},
+	"tm1637-036-4": {
+		Name:         "tm1637-036-4",
+		Description:  "TM1637 module with 0.36in four digit 7-segment display",
+		Window:       geometry.Point{X: 30.5, Y: 14.4},
+		CornerRadius: 0.5,
+		MountingHoles: []geometry.Point{
+			{X: -19.0, Y: -10.0}, {X: 19.0, Y: -10.0},
+			{X: -19.0, Y: 10.0}, {X: 19.0, Y: 10.0},
+		},
+		MountingHoleDiameter: 2.2,
+	},
+}
+
+// DisplayNames returns the names of all known displays, sorted
+func DisplayNames() []string {
+	names := []string{}
+	for name := range displays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDisplay returns the display with the given name
+func LookupDisplay(name string) (Display, error) {
+	d, ok := displays[name]
+	if !ok {
+		return Display{}, fmt.Errorf("unknown display %q (valid values: %s)", name, strings.Join(DisplayNames(), " "))
+	}
+	return d, nil
+}
+
+// DisplayPlacement is a display with its window centred on a position on
+// the panel
+type DisplayPlacement struct {
+	Display
+	Origin geometry.Point
+}
+
+// Features generates the window cutout and mounting holes for the display,
+// with the window corners flattened within tolerance
+func (p DisplayPlacement) Features(tolerance float64) []features.Feature {
+	half := p.Window.Scale(0.5)
+	window := features.NewPolygon(geometry.RoundedRect(p.Origin.Sub(half), p.Origin.Add(half), p.CornerRadius, tolerance))
+	window.SetPurpose(features.Cutout)
+	feats := []features.Feature{window}
+	for _, h := range p.MountingHoles {
+		hole := features.NewCircle(p.Origin.Add(h), p.MountingHoleDiameter/2.0)
+		hole.SetPurpose(features.Cutout)
+		feats = append(feats, hole)
+	}
+	return feats
+}
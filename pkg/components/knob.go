@@ -0,0 +1,93 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Knob describes a knob fitted to a potentiometer or encoder shaft, as
+// drawn in previews. Diameter is its widest extent, including any skirt,
+// and Grip the length of shaft it needs in front of the panel to be held
+// securely, in millimetres.
+type Knob struct {
+	Name        string
+	Description string
+	Diameter    float64
+	Grip        float64
+}
+
+// knobs lists the known knobs. Sizes are typical of the styles listed,
+// which vary between manufacturers and clones, and should be checked
+// against the knobs actually used.
+var knobs = map[string]Knob{
+	"knurled": {
+		Name:        "knurled",
+		Description: "small knurled knob for 9mm potentiometers",
+		Diameter:    11.0,
+		Grip:        8.0,
+	},
+	"davies1510": {
+		Name:        "davies1510",
+		Description: "Davies 1510 style knob",
+		Diameter:    12.0,
+		Grip:        9.0,
+	},
+	"davies1900h": {
+		Name:        "davies1900h",
+		Description: "Davies 1900H style knob",
+		Diameter:    15.5,
+		Grip:        10.0,
+	},
+	"rogan-pt1": {
+		Name:        "rogan-pt1",
+		Description: "Rogan PT-1 style pointer knob",
+		Diameter:    12.7,
+		Grip:        10.0,
+	},
+	"rogan-pt2": {
+		Name:        "rogan-pt2",
+		Description: "Rogan PT-2 style pointer knob",
+		Diameter:    19.1,
+		Grip:        11.0,
+	},
+}
+
+// KnobNames returns the names of all known knobs, sorted
+func KnobNames() []string {
+	names := []string{}
+	for name := range knobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupKnob returns the knob with the given name
+func LookupKnob(name string) (Knob, error) {
+	k, ok := knobs[name]
+	if !ok {
+		return Knob{}, fmt.Errorf("unknown knob %q (valid values: %s)", name, strings.Join(KnobNames(), " "))
+	}
+	return k, nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package components
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// Position is the side of a component that its label is placed on
+type Position int
+
+// Above et al specify label positions
+const (
+	Above Position = iota // this MUST be the first item
+	Below
+	Left
+	Right // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (p Position) String() string {
+	switch p {
+	case Above:
+		return "above"
+	case Below:
+		return "below"
+	case Left:
+		return "left"
+	case Right:
+		return "right"
+	}
+	panic(fmt.Sprintf("invalid Position value (valid range is %d..%d): %d",
+		int(Above), int(Right), int(p)))
+}
+
+// ParsePosition converts a position name, as returned by Position.String,
+// to a Position
+func ParsePosition(s string) (Position, error) {
+	for p := Above; p <= Right; p++ {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return Above, fmt.Errorf("invalid label position %q (valid values: above below left right)", s)
+}
+
+// Label is text attached to a placement, aligned to the side of the
+// component it is placed on
+type Label struct {
+	Text     string
+	Position Position
+	// Distance is the gap between the component's nut, or hole if it has no
+	// nut, and the nearest edge of the label, in millimetres
+	Distance float64
+}
+
+// LabelText returns the text feature for the placement's label, or nil if
+// it has none
+func (p Placement) LabelText(opts Options) *features.Text {
+	if p.Label == nil {
+		return nil
+	}
+	d := p.front() + p.Label.Distance
+	origin, align := p.Origin, features.BottomCentre
+	switch p.Label.Position {
+	case Above:
+		origin.Y += d
+	case Below:
+		origin.Y -= d
+		align = features.TopCentre
+	case Left:
+		origin.X -= d
+		align = features.CentreRight
+	case Right:
+		origin.X += d
+		align = features.CentreLeft
+	}
+	return features.NewText(origin, p.Label.Text, features.WithAlignment(align), features.WithSizeMM(opts.LabelSize),
+		features.WithMode(opts.TextMode, opts.StrokeWidth))
+}
+
+// box is an axis-aligned rectangle
+type box struct {
+	bl, tr geometry.Point
+}
+
+// overlaps reports whether two boxes are closer than margin
+func (b box) overlaps(o box, margin float64) bool {
+	return b.bl.X-margin < o.tr.X && o.bl.X-margin < b.tr.X &&
+		b.bl.Y-margin < o.tr.Y && o.bl.Y-margin < b.tr.Y
+}
+
+// near reports whether a circle is closer than margin to the box
+func (b box) near(centre geometry.Point, radius, margin float64) bool {
+	closest := geometry.Point{
+		X: math.Max(b.bl.X, math.Min(centre.X, b.tr.X)),
+		Y: math.Max(b.bl.Y, math.Min(centre.Y, b.tr.Y)),
+	}
+	return closest.Distance(centre) < radius+margin
+}
+
+// CheckLabels reports an Error diagnostic for each label that would overlap
+// its own component, another label or the nut of another component, or that
+// would not fit within the panel edges, allowing for the margin
+func CheckLabels(placed []Placement, edges geometry.Rect, opts Options, r diag.Reporter) {
+	boxes := make([]*box, len(placed))
+	for i, p := range placed {
+		t := p.LabelText(opts)
+		if t == nil {
+			continue
+		}
+		if p.Label.Distance < 0 {
+			r.Report(diag.Diagnostic{
+				Severity: diag.Error,
+				Message: fmt.Sprintf("label %q of %s at (%.2f, %.2f) overlaps the component by %.2fmm",
+					p.Label.Text, p.Name, p.Origin.X, p.Origin.Y, -p.Label.Distance),
+				Feature: t,
+			})
+		}
+		bl, tr, err := textpath.Bounds(t)
+		if err != nil {
+			r.Report(diag.Diagnostic{Severity: diag.Error, Message: fmt.Sprintf("label %q: %v", t.Text, err), Feature: t})
+			continue
+		}
+		boxes[i] = &box{bl, tr}
+		if bl.X-opts.Margin < edges.BottomLeft.X || bl.Y-opts.Margin < edges.BottomLeft.Y ||
+			tr.X+opts.Margin > edges.TopRight.X || tr.Y+opts.Margin > edges.TopRight.Y {
+			r.Report(diag.Diagnostic{
+				Severity: diag.Error,
+				Message: fmt.Sprintf("label %q of %s at (%.2f, %.2f) is too close to the panel edge",
+					p.Label.Text, p.Name, p.Origin.X, p.Origin.Y),
+				Feature: t,
+			})
+		}
+	}
+	for i, a := range placed {
+		if boxes[i] == nil {
+			continue
+		}
+		for j, b := range placed {
+			if i == j {
+				continue
+			}
+			what := ""
+			switch {
+			case boxes[j] != nil && j > i && boxes[i].overlaps(*boxes[j], opts.Margin):
+				what = fmt.Sprintf("label %q of %s", b.Label.Text, b.Name)
+			case boxes[i].near(b.Origin, b.front(), opts.Margin):
+				what = b.Name
+			default:
+				continue
+			}
+			r.Report(diag.Diagnostic{
+				Severity: diag.Error,
+				Message: fmt.Sprintf("label %q of %s at (%.2f, %.2f) collides with %s at (%.2f, %.2f)",
+					a.Label.Text, a.Name, a.Origin.X, a.Origin.Y, what, b.Origin.X, b.Origin.Y),
+			})
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package decor provides optional, selectable decorative patterns for the
+// otherwise empty space between a panel's mounting rails, and for filling
+// zones declared by panel specs. Decorations are always rendered as Marking
+// features.
+package decor
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+const (
+	// DefaultThickness is the default line thickness of decorations, in
+	// millimetres
+	DefaultThickness = 0.2
+
+	// DefaultMargin is the default distance kept between decorations and
+	// the panel edges and rails, in millimetres
+	DefaultMargin = 1.0
+
+	// None is the name of the decoration that generates nothing
+	None = "none"
+)
+
+// Options configures decoration generation. Distances are in millimetres.
+type Options struct {
+	// Thickness of decorative lines
+	Thickness float64
+	// Margin kept between decorations and the panel edges and rails
+	Margin float64
+	// Source of randomness for random patterns. Using a source with a fixed
+	// seed makes the output reproducible. If nil, a source with seed 1 is
+	// used.
+	Source rand.Source
+	// Sunburst configures the sunburst decoration
+	Sunburst SunburstOptions
+	// Wave configures the wave decoration
+	Wave WaveOptions
+}
+
+// random returns a random number generator drawing from the source
+func (o Options) random() *rand.Rand {
+	if o.Source == nil {
+		return rand.New(rand.NewSource(1))
+	}
+	return rand.New(o.Source)
+}
+
+// DefaultOptions returns an Options with sensible defaults
+func DefaultOptions() Options {
+	return Options{
+		Thickness: DefaultThickness,
+		Margin:    DefaultMargin,
+		Sunburst:  DefaultSunburstOptions(),
+		Wave:      DefaultWaveOptions(),
+	}
+}
+
+// Generator generates decorative features for a panel
+type Generator func(p panel.Panel, opts Options) ([]features.Feature, error)
+
+// generators lists the available decorations by name
+var generators = map[string]Generator{
+	None:           func(panel.Panel, Options) ([]features.Feature, error) { return nil, nil },
+	"random-lines": RandomLines,
+	"hatch":        Hatch,
+	"starfield":    Starfield,
+	"sunburst":     Sunburst,
+	"wave":         Wave,
+}
+
+// Names returns the names of all available decorations, sorted
+func Names() []string {
+	names := []string{}
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the named decoration generator
+func Lookup(name string) (Generator, error) {
+	g, ok := generators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown decoration %q (valid values: %s)", name, strings.Join(Names(), " "))
+	}
+	return g, nil
+}
+
+// Generate generates the named decoration for a panel
+func Generate(name string, p panel.Panel, opts Options) ([]features.Feature, error) {
+	g, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Thickness <= 0 {
+		return nil, errors.New("decor: thickness must be greater than 0")
+	}
+	return g(p, opts)
+}
+
+// Region returns the bottom-left and top-right corners of the space
+// available for decoration: between the rails, inset by the margin
+func Region(p panel.Panel, opts Options) (geometry.Point, geometry.Point) {
+	r := panel.UsableArea(p).Inset(opts.Margin)
+	return r.BottomLeft, r.TopRight
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package decor
+
+import (
+	"math/rand"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/sources/hatch"
+)
+
+// randomPoint returns a random point within the region
+func randomPoint(r *rand.Rand, bl, tr geometry.Point) geometry.Point {
+	return geometry.Point{
+		X: bl.X + r.Float64()*(tr.X-bl.X),
+		Y: bl.Y + r.Float64()*(tr.Y-bl.Y),
+	}
+}
+
+// RandomLines generates 100 lines between random points of varying thickness
+func RandomLines(p panel.Panel, opts Options) ([]features.Feature, error) {
+	bl, tr := Region(p, opts)
+	r := opts.random()
+	lines := []features.Feature{}
+	for i := 0; i < 100; i++ {
+		lines = append(lines, features.NewLine(randomPoint(r, bl, tr), randomPoint(r, bl, tr), opts.Thickness*float64(1+r.Intn(3))))
+	}
+	return lines, nil
+}
+
+// Hatch fills the region with diagonal crosshatching
+func Hatch(p panel.Panel, opts Options) ([]features.Feature, error) {
+	bl, tr := Region(p, opts)
+	return hatch.Polygon(features.NewRectangle(bl, tr).Points, hatch.Options{
+		Pattern:   hatch.Crosshatch,
+		Pitch:     2.0,
+		Angle:     45.0,
+		Thickness: opts.Thickness,
+	})
+}
+
+// Starfield scatters dots of varying size over the region, about one per
+// 20 square millimetres
+func Starfield(p panel.Panel, opts Options) ([]features.Feature, error) {
+	bl, tr := Region(p, opts)
+	r := opts.random()
+	n := int((tr.X - bl.X) * (tr.Y - bl.Y) / 20.0)
+	stars := []features.Feature{}
+	for i := 0; i < n; i++ {
+		radius := opts.Thickness / 2.0 * (1.0 + 2.0*r.Float64()*r.Float64())
+		stars = append(stars, features.NewCircle(randomPoint(r, bl, tr), radius))
+	}
+	return stars, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package decor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// SunburstStyle describes the appearance of a sunburst
+type SunburstStyle int
+
+// Rays et al specify sunburst styles
+const (
+	// Rays are straight lines radiating from the centre
+	Rays SunburstStyle = iota
+	// Rings are concentric arcs around the centre
+	Rings
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s SunburstStyle) String() string {
+	switch s {
+	case Rays:
+		return "rays"
+	case Rings:
+		return "rings"
+	}
+	panic(fmt.Sprintf("invalid SunburstStyle value (valid range is %d..%d): %d",
+		int(Rays), int(Rings), int(s)))
+}
+
+// ParseSunburstStyle converts a string as returned by SunburstStyle.String
+// to a SunburstStyle
+func ParseSunburstStyle(s string) (SunburstStyle, error) {
+	for _, style := range []SunburstStyle{Rays, Rings} {
+		if style.String() == s {
+			return style, nil
+		}
+	}
+	return Rays, fmt.Errorf("invalid sunburst style %q (valid values: rays rings)", s)
+}
+
+// SunburstOptions configures the sunburst decoration. Distances are in
+// millimetres and angles in degrees, anticlockwise from the positive X
+// axis.
+type SunburstOptions struct {
+	Style SunburstStyle
+	// Centre of the sunburst, typically the centre of a large knob. If nil,
+	// the centre of the decoration region is used.
+	Centre *geometry.Point
+	// Inner and Outer radii of the sunburst. An Outer radius of zero extends
+	// the sunburst to the edge of the decoration region.
+	Inner, Outer float64
+	// Density is the number of rays or rings
+	Density int
+	// Start and End angles limit the sunburst to a sector, eg. to follow the
+	// travel of a knob
+	Start, End float64
+}
+
+// DefaultSunburstOptions returns a SunburstOptions describing a full circle
+// of rays filling the decoration region
+func DefaultSunburstOptions() SunburstOptions {
+	return SunburstOptions{
+		Style:   Rays,
+		Inner:   1.0,
+		Density: 72,
+		End:     360.0,
+	}
+}
+
+// edgeDistance returns the distance from a point inside a rectangular
+// region to its edge, travelling in direction dir
+func edgeDistance(c, dir, bl, tr geometry.Point) float64 {
+	t := math.Inf(1)
+	if dir.X > 0 {
+		t = math.Min(t, (tr.X-c.X)/dir.X)
+	} else if dir.X < 0 {
+		t = math.Min(t, (bl.X-c.X)/dir.X)
+	}
+	if dir.Y > 0 {
+		t = math.Min(t, (tr.Y-c.Y)/dir.Y)
+	} else if dir.Y < 0 {
+		t = math.Min(t, (bl.Y-c.Y)/dir.Y)
+	}
+	return t
+}
+
+// angles returns n angles evenly spaced across the sector. Full circles do
+// not repeat the first angle at the end.
+func (o SunburstOptions) angles(n int) []float64 {
+	sweep := o.End - o.Start
+	step := sweep / float64(n)
+	if math.Abs(sweep) < 360.0 && n > 1 {
+		step = sweep / float64(n-1)
+	}
+	a := make([]float64, n)
+	for i := range a {
+		a[i] = o.Start + step*float64(i)
+	}
+	return a
+}
+
+// Sunburst generates radial lines or concentric arcs around a point, clipped
+// to the decoration region unless an outer radius is given
+func Sunburst(p panel.Panel, opts Options) ([]features.Feature, error) {
+	so := opts.Sunburst
+	if so.Density < 1 {
+		return nil, errors.New("decor: sunburst density must be at least 1")
+	}
+	if so.Inner < 0 || (so.Outer != 0 && so.Outer <= so.Inner) {
+		return nil, errors.New("decor: sunburst outer radius must be greater than inner radius")
+	}
+	bl, tr := Region(p, opts)
+	centre := bl.Add(tr).Scale(0.5)
+	if so.Centre != nil {
+		centre = *so.Centre
+	}
+	feats := []features.Feature{}
+	switch so.Style {
+	case Rays:
+		for _, a := range so.angles(so.Density) {
+			dir := geometry.Point{X: 1, Y: 0}.Rotate(a)
+			outer := so.Outer
+			if outer == 0 {
+				outer = edgeDistance(centre, dir, bl, tr)
+			}
+			if outer <= so.Inner {
+				continue
+			}
+			feats = append(feats, features.NewLine(centre.Add(dir.Scale(so.Inner)), centre.Add(dir.Scale(outer)), opts.Thickness))
+		}
+	case Rings:
+		outer := so.Outer
+		if outer == 0 {
+			outer = math.Min(math.Min(centre.X-bl.X, tr.X-centre.X), math.Min(centre.Y-bl.Y, tr.Y-centre.Y))
+		}
+		if outer <= so.Inner {
+			return nil, errors.New("decor: sunburst centre is too close to the edge of the decoration region")
+		}
+		for i := 0; i < so.Density; i++ {
+			radius := outer
+			if so.Density > 1 {
+				radius = so.Inner + (outer-so.Inner)*float64(i)/float64(so.Density-1)
+			}
+			if radius <= 0 {
+				continue
+			}
+			points := geometry.Arc(centre, radius, so.Start, so.End, geometry.DefaultTolerance)
+			for j := 1; j < len(points); j++ {
+				feats = append(feats, features.NewLine(points[j-1], points[j], opts.Thickness))
+			}
+		}
+	}
+	return feats, nil
+}
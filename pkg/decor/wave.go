@@ -0,0 +1,207 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package decor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// WaveShape describes the curve drawn by the wave decoration
+type WaveShape int
+
+// Sine et al specify wave shapes
+const (
+	Sine WaveShape = iota
+	Triangle
+	Saw
+	// Lissajous figures combine sine waves on both axes
+	Lissajous
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s WaveShape) String() string {
+	switch s {
+	case Sine:
+		return "sine"
+	case Triangle:
+		return "triangle"
+	case Saw:
+		return "saw"
+	case Lissajous:
+		return "lissajous"
+	}
+	panic(fmt.Sprintf("invalid WaveShape value (valid range is %d..%d): %d",
+		int(Sine), int(Lissajous), int(s)))
+}
+
+// ParseWaveShape converts a string as returned by WaveShape.String to a
+// WaveShape
+func ParseWaveShape(s string) (WaveShape, error) {
+	for _, shape := range []WaveShape{Sine, Triangle, Saw, Lissajous} {
+		if shape.String() == s {
+			return shape, nil
+		}
+	}
+	return Sine, fmt.Errorf("invalid wave shape %q (valid values: sine triangle saw lissajous)", s)
+}
+
+// WaveOptions configures the wave decoration. Waveforms run along the
+// longer axis of the decoration region, so they follow the length of tall,
+// narrow panels.
+type WaveOptions struct {
+	Shape WaveShape
+	// Amplitude is the peak deviation from the centre line, in millimetres.
+	// Zero fills the region.
+	Amplitude float64
+	// Frequency is the number of cycles across the region. For Lissajous
+	// figures it is the frequency along the longer axis.
+	Frequency float64
+	// CrossFrequency is the frequency along the shorter axis of Lissajous
+	// figures
+	CrossFrequency float64
+	// Phase offset of the waveform, in degrees
+	Phase float64
+}
+
+// DefaultWaveOptions returns a WaveOptions describing a sine wave of three
+// cycles filling the decoration region
+func DefaultWaveOptions() WaveOptions {
+	return WaveOptions{
+		Shape:          Sine,
+		Frequency:      3.0,
+		CrossFrequency: 2.0,
+		Phase:          0.0,
+	}
+}
+
+// samplesPerCycle is the number of line segments used per cycle of smooth
+// curves
+const samplesPerCycle = 64
+
+// frac returns the fractional part of x, in the range [0, 1)
+func frac(x float64) float64 {
+	return x - math.Floor(x)
+}
+
+// waveform returns the points of a waveform in local coordinates: X runs
+// from 0 to 1 along the region and Y from -1 to 1
+func (o WaveOptions) waveform() []geometry.Point {
+	u0 := o.Phase / 360.0
+	points := []geometry.Point{}
+	add := func(u, v float64) {
+		points = append(points, geometry.Point{X: (u - u0) / o.Frequency, Y: v})
+	}
+	switch o.Shape {
+	case Sine:
+		n := int(math.Ceil(o.Frequency * samplesPerCycle))
+		for i := 0; i <= n; i++ {
+			u := u0 + o.Frequency*float64(i)/float64(n)
+			add(u, math.Sin(2.0*math.Pi*u))
+		}
+	case Triangle:
+		tri := func(u float64) float64 { return 4.0*math.Abs(frac(u-0.25)-0.5) - 1.0 }
+		add(u0, tri(u0))
+		// corners fall on every half cycle, offset by a quarter
+		for k := math.Floor(u0*2.0-0.5) + 1; k/2.0+0.25 < u0+o.Frequency; k++ {
+			u := k/2.0 + 0.25
+			add(u, tri(u))
+		}
+		add(u0+o.Frequency, tri(u0+o.Frequency))
+	case Saw:
+		saw := func(u float64) float64 { return 2.0*frac(u+0.5) - 1.0 }
+		add(u0, saw(u0))
+		// the ramp resets half way through each cycle
+		for k := math.Floor(u0-0.5) + 1; k+0.5 < u0+o.Frequency; k++ {
+			add(k+0.5, 1.0)
+			add(k+0.5, -1.0)
+		}
+		add(u0+o.Frequency, saw(u0+o.Frequency))
+	}
+	return points
+}
+
+// lissajous returns the points of a Lissajous figure in local coordinates,
+// with X and Y both from -1 to 1
+func (o WaveOptions) lissajous() []geometry.Point {
+	n := int(math.Ceil(math.Max(o.Frequency, o.CrossFrequency) * samplesPerCycle * 2))
+	phase := o.Phase * math.Pi / 180.0
+	points := []geometry.Point{}
+	for i := 0; i <= n; i++ {
+		t := 2.0 * math.Pi * float64(i) / float64(n)
+		points = append(points, geometry.Point{
+			X: math.Sin(o.Frequency*t + phase),
+			Y: math.Sin(o.CrossFrequency * t),
+		})
+	}
+	return points
+}
+
+// Wave draws a waveform or Lissajous figure across the decoration region
+func Wave(p panel.Panel, opts Options) ([]features.Feature, error) {
+	wo := opts.Wave
+	if wo.Frequency <= 0 || (wo.Shape == Lissajous && wo.CrossFrequency <= 0) {
+		return nil, errors.New("decor: wave frequency must be greater than 0")
+	}
+	if wo.Amplitude < 0 {
+		return nil, errors.New("decor: wave amplitude must not be negative")
+	}
+	bl, tr := Region(p, opts)
+	centre := bl.Add(tr).Scale(0.5)
+	size := tr.Sub(bl)
+	// along is the unit vector of the longer axis, across the shorter
+	along, across := geometry.Point{X: 1, Y: 0}, geometry.Point{X: 0, Y: 1}
+	length, breadth := size.X, size.Y
+	if size.Y > size.X {
+		along, across = across, along.Scale(-1)
+		length, breadth = breadth, length
+	}
+	amplitude := wo.Amplitude
+	if amplitude == 0 {
+		amplitude = breadth/2.0 - opts.Thickness/2.0
+	}
+	var points []geometry.Point
+	if wo.Shape == Lissajous {
+		for _, lp := range wo.lissajous() {
+			points = append(points, centre.
+				Add(along.Scale(lp.X*(length/2.0-opts.Thickness/2.0))).
+				Add(across.Scale(lp.Y*amplitude)))
+		}
+	} else {
+		start := centre.Sub(along.Scale(length / 2.0))
+		for _, wp := range wo.waveform() {
+			points = append(points, start.Add(along.Scale(wp.X*length)).Add(across.Scale(wp.Y*amplitude)))
+		}
+	}
+	lines := []features.Feature{}
+	for i := 1; i < len(points); i++ {
+		if points[i] == points[i-1] {
+			continue
+		}
+		lines = append(lines, features.NewLine(points[i-1], points[i], opts.Thickness))
+	}
+	return lines, nil
+}
@@ -0,0 +1,385 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package decor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+	"github.com/jsleeio/frontpanels/pkg/sources/hatch"
+)
+
+// ZoneStyle selects the pattern filling a decoration zone
+type ZoneStyle int
+
+// HatchZone et al specify decoration zone styles
+const (
+	// HatchZone fills a zone with diagonal crosshatching
+	HatchZone ZoneStyle = iota // this MUST be the first item
+	// DotsZone fills a zone with a hexagonal grid of dots
+	DotsZone
+	// WaveZone fills a zone with rows of sine waves
+	WaveZone // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s ZoneStyle) String() string {
+	switch s {
+	case HatchZone:
+		return "hatch"
+	case DotsZone:
+		return "dots"
+	case WaveZone:
+		return "waveform"
+	}
+	panic(fmt.Sprintf("invalid ZoneStyle value (valid range is %d..%d): %d",
+		int(HatchZone), int(WaveZone), int(s)))
+}
+
+// ParseZoneStyle converts a style name, as returned by ZoneStyle.String, to
+// a ZoneStyle
+func ParseZoneStyle(s string) (ZoneStyle, error) {
+	for z := HatchZone; z <= WaveZone; z++ {
+		if z.String() == s {
+			return z, nil
+		}
+	}
+	return HatchZone, fmt.Errorf("invalid decoration zone style %q (valid values: hatch dots waveform)", s)
+}
+
+// DefaultZoneDensity is the default density of decoration zones, in lines,
+// rows of dots or rows of waves per centimetre
+const DefaultZoneDensity = 3.0
+
+// Zone is a region of a panel filled with a decorative pattern, eg. as
+// declared in a spec file
+type Zone struct {
+	Style ZoneStyle
+	// Density is the number of lines, rows of dots or rows of waves per
+	// centimetre. If zero, DefaultZoneDensity is used.
+	Density float64
+	// Region is the closed outline of the zone
+	Region []geometry.Point
+}
+
+// Zoner panels declare decoration zones
+type Zoner interface {
+	DecorationZones() []Zone
+}
+
+const (
+	// zoneTolerance is the maximum deviation of flattened curves bounding
+	// the decorated area, which needs no fabrication accuracy
+	zoneTolerance = 0.05
+	// dotRatio is the diameter of the dots in a DotsZone, as a fraction of
+	// their pitch
+	dotRatio = 0.4
+	// waveSamples is the number of line segments in each cycle of a
+	// WaveZone
+	waveSamples = 16
+)
+
+// Keepouter panels declare areas decorations keep clear of, beyond their
+// mounting holes and rails
+type Keepouter interface {
+	Keepouts() []geometry.Rect
+}
+
+// keepout returns the contours of an area decorations keep clear of, grown
+// by a clearance
+type keepout func(clearance float64) [][]geometry.Point
+
+// edgeStrokes returns a stroke of half width r along every edge of a region
+func edgeStrokes(region [][]geometry.Point, r float64) [][][]geometry.Point {
+	strokes := [][][]geometry.Point{}
+	for _, c := range region {
+		for i, p := range c {
+			strokes = append(strokes, [][]geometry.Point{flatten.Stroke(p, c[(i+1)%len(c)], r, zoneTolerance)})
+		}
+	}
+	return strokes
+}
+
+// grow returns a region grown by r: its union with strokes along its edges.
+// Unlike geometry.Offset, this is exact for any region, however its contours
+// are wound or however short their edges.
+func grow(region [][]geometry.Point, r float64) [][]geometry.Point {
+	if r <= 0 {
+		return region
+	}
+	return geometry.Merge(append(edgeStrokes(region, r), region)...)
+}
+
+// shrink returns a region shrunk by r, the region less strokes along its
+// edges
+func shrink(region [][]geometry.Point, r float64) [][]geometry.Point {
+	if r <= 0 {
+		return region
+	}
+	return geometry.Boolean(geometry.Difference, region, geometry.Merge(edgeStrokes(region, r)...))
+}
+
+// keepouts returns the areas decorations keep clear of: the mounting holes,
+// the panel's own keepouts, and every feature other than the panel outline
+// and annotations. Text keeps the box around its glyphs clear.
+func keepouts(p panel.Panel, feats []features.Feature) []keepout {
+	circle := func(centre geometry.Point, radius float64) keepout {
+		return func(c float64) [][]geometry.Point {
+			return [][]geometry.Point{flatten.Circle(centre, radius+c, zoneTolerance)}
+		}
+	}
+	region := func(contours [][]geometry.Point) keepout {
+		return func(c float64) [][]geometry.Point {
+			return grow(contours, c)
+		}
+	}
+	rect := func(bl, tr geometry.Point) keepout {
+		return region([][]geometry.Point{features.NewRectangle(bl, tr).Points})
+	}
+	k := []keepout{}
+	for _, h := range p.MountingHoles() {
+		k = append(k, circle(h, p.MountingHoleDiameter()/2))
+	}
+	if ko, ok := p.(Keepouter); ok {
+		for _, r := range ko.Keepouts() {
+			k = append(k, rect(r.BottomLeft, r.TopRight))
+		}
+	}
+	for _, item := range feats {
+		if item.GetPurpose() == features.Annotation {
+			continue
+		}
+		switch f := item.(type) {
+		case *features.Outline:
+			// the panel edges are kept clear separately
+		case *features.Circle:
+			k = append(k, circle(f.Origin, f.Radius))
+		case *features.Line:
+			k = append(k, func(c float64) [][]geometry.Point {
+				return [][]geometry.Point{flatten.Stroke(f.Start, f.End, f.Thickness/2+c, zoneTolerance)}
+			})
+		case *features.Text:
+			bl, tr, err := textpath.Bounds(f)
+			if err != nil {
+				continue
+			}
+			k = append(k, rect(bl, tr))
+		default:
+			contours, err := flatten.Contours(f, zoneTolerance)
+			if err != nil {
+				continue
+			}
+			k = append(k, region(contours))
+		}
+	}
+	return k
+}
+
+// area returns the part of a zone a pattern may be drawn in: inset by the
+// half width of the pattern's lines or dots, and kept margin clear of the
+// panel edges, rails and keepouts
+func (z Zone) area(p panel.Panel, k []keepout, margin, half float64) [][]geometry.Point {
+	clearance := margin + half
+	area := shrink([][]geometry.Point{panel.Outline(p, zoneTolerance)}, clearance)
+	if p.RailHeightFromMountingHole() > 0 {
+		rows := [][][]geometry.Point{}
+		for _, r := range panel.Regions(p) {
+			if u := r.UsableArea(p).Inset(clearance); u.Width() > 0 && u.Height() > 0 {
+				rows = append(rows, [][]geometry.Point{features.NewRectangle(u.BottomLeft, u.TopRight).Points})
+			}
+		}
+		area = geometry.Boolean(geometry.Intersection, area, geometry.Merge(rows...))
+	}
+	zone := shrink([][]geometry.Point{z.Region}, half)
+	area = geometry.Boolean(geometry.Intersection, area, zone)
+	grown := [][][]geometry.Point{}
+	for _, ko := range k {
+		grown = append(grown, ko(clearance))
+	}
+	return geometry.Boolean(geometry.Difference, area, geometry.Merge(grown...))
+}
+
+// bounds returns the bounding box of a region
+func bounds(region [][]geometry.Point) geometry.Rect {
+	r := geometry.Rect{
+		BottomLeft: geometry.Point{X: math.Inf(1), Y: math.Inf(1)},
+		TopRight:   geometry.Point{X: math.Inf(-1), Y: math.Inf(-1)},
+	}
+	for _, c := range region {
+		for _, p := range c {
+			r.BottomLeft = geometry.Point{X: math.Min(r.BottomLeft.X, p.X), Y: math.Min(r.BottomLeft.Y, p.Y)}
+			r.TopRight = geometry.Point{X: math.Max(r.TopRight.X, p.X), Y: math.Max(r.TopRight.Y, p.Y)}
+		}
+	}
+	return r
+}
+
+// clip returns lines of the given thickness covering the parts of the
+// segment a..b lying inside a region
+func clip(a, b geometry.Point, region [][]geometry.Point, thickness float64) []features.Feature {
+	d := b.Sub(a)
+	ts := []float64{0, 1}
+	for _, c := range region {
+		for i, p := range c {
+			e := c[(i+1)%len(c)].Sub(p)
+			den := d.X*e.Y - d.Y*e.X
+			if den == 0 {
+				continue
+			}
+			w := p.Sub(a)
+			t := (w.X*e.Y - w.Y*e.X) / den
+			u := (w.X*d.Y - w.Y*d.X) / den
+			if t > 0 && t < 1 && u >= 0 && u <= 1 {
+				ts = append(ts, t)
+			}
+		}
+	}
+	sort.Float64s(ts)
+	lines := []features.Feature{}
+	var last *features.Line
+	for i := 1; i < len(ts); i++ {
+		if ts[i]-ts[i-1] < 1e-9 || !geometry.RegionContains(region, a.Add(d.Scale((ts[i-1]+ts[i])/2))) {
+			continue
+		}
+		start, end := a.Add(d.Scale(ts[i-1])), a.Add(d.Scale(ts[i]))
+		if last != nil && last.End == start {
+			last.End = end
+			continue
+		}
+		last = features.NewLine(start, end, thickness)
+		lines = append(lines, last)
+	}
+	return lines
+}
+
+// hatchZone crosshatches a region diagonally
+func hatchZone(area [][]geometry.Point, pitch, thickness float64) ([]features.Feature, error) {
+	b := bounds(area)
+	lines, err := hatch.Polygon(features.NewRectangle(b.BottomLeft, b.TopRight).Points, hatch.Options{
+		Pattern:   hatch.Crosshatch,
+		Pitch:     pitch,
+		Angle:     45.0,
+		Thickness: thickness,
+	})
+	if err != nil {
+		return nil, err
+	}
+	f := []features.Feature{}
+	for _, item := range lines {
+		l := item.(*features.Line)
+		f = append(f, clip(l.Start, l.End, area, thickness)...)
+	}
+	return f, nil
+}
+
+// dotsZone fills a region with a hexagonal grid of dots of the given
+// radius, aligned to multiples of the pitch so that neighbouring zones line
+// up
+func dotsZone(area [][]geometry.Point, pitch, radius float64) []features.Feature {
+	b := bounds(area)
+	rowPitch := pitch * math.Sqrt(3) / 2
+	f := []features.Feature{}
+	for j := math.Ceil(b.BottomLeft.Y / rowPitch); j*rowPitch <= b.TopRight.Y; j++ {
+		offset := 0.0
+		if math.Mod(j, 2) != 0 {
+			offset = pitch / 2
+		}
+		for i := math.Ceil((b.BottomLeft.X - offset) / pitch); i*pitch+offset <= b.TopRight.X; i++ {
+			c := geometry.Point{X: i*pitch + offset, Y: j * rowPitch}
+			if geometry.RegionContains(area, c) {
+				f = append(f, features.NewCircle(c, radius))
+			}
+		}
+	}
+	return f
+}
+
+// waveZone fills a region with horizontal rows of sine waves, each a
+// quarter of the pitch in amplitude and two pitches in wavelength
+func waveZone(area [][]geometry.Point, pitch, thickness float64) []features.Feature {
+	b := bounds(area)
+	amplitude, wavelength := pitch/4, pitch*2
+	step := wavelength / waveSamples
+	f := []features.Feature{}
+	for row := math.Floor((b.BottomLeft.Y - amplitude) / pitch); row*pitch-amplitude <= b.TopRight.Y; row++ {
+		y := row * pitch
+		at := func(x float64) geometry.Point {
+			return geometry.Point{X: x, Y: y + amplitude*math.Sin(2*math.Pi*x/wavelength)}
+		}
+		for x := math.Floor(b.BottomLeft.X/step) * step; x < b.TopRight.X; x += step {
+			f = append(f, clip(at(x), at(x+step), area, thickness)...)
+		}
+	}
+	return f
+}
+
+// Zones fills decoration zones with their patterns, keeping opts.Margin
+// clear of the panel edges, rails, mounting holes and keepouts, and of the
+// features among feats other than annotations, so the panel's features may
+// be passed as they are.
+func Zones(p panel.Panel, zones []Zone, feats []features.Feature, opts Options) ([]features.Feature, error) {
+	if len(zones) == 0 {
+		return nil, nil
+	}
+	if opts.Thickness <= 0 {
+		return nil, errors.New("decor: thickness must be greater than 0")
+	}
+	k := keepouts(p, feats)
+	f := []features.Feature{}
+	for i, z := range zones {
+		if len(z.Region) < 3 {
+			return nil, fmt.Errorf("decor: zone %d: region must have at least three points", i+1)
+		}
+		density := z.Density
+		if density == 0 {
+			density = DefaultZoneDensity
+		}
+		if density < 0 {
+			return nil, fmt.Errorf("decor: zone %d: density must not be negative", i+1)
+		}
+		pitch := 10.0 / density
+		var fill []features.Feature
+		switch z.Style {
+		case HatchZone:
+			var err error
+			if fill, err = hatchZone(z.area(p, k, opts.Margin, opts.Thickness/2), pitch, opts.Thickness); err != nil {
+				return nil, err
+			}
+		case DotsZone:
+			r := pitch * dotRatio / 2
+			fill = dotsZone(z.area(p, k, opts.Margin, r), pitch, r)
+		case WaveZone:
+			fill = waveZone(z.area(p, k, opts.Margin, opts.Thickness/2), pitch, opts.Thickness)
+		}
+		if len(fill) == 0 {
+			return nil, fmt.Errorf("decor: zone %d: no room for %s decoration clear of other features and rails", i+1, z.Style)
+		}
+		f = append(f, fill...)
+	}
+	return f, nil
+}
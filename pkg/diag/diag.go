@@ -0,0 +1,124 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package diag provides a pluggable way of reporting diagnostics, such as
+// warnings about features that are probably mistakes, so that library
+// consumers can capture them programmatically and surface them in user
+// interfaces instead of having them go to the global logger.
+package diag
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+)
+
+// Severity indicates the seriousness of a diagnostic
+type Severity int
+
+// Info et al specify diagnostic severities
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	panic(fmt.Sprintf("invalid Severity value (valid range is %d..%d): %d",
+		int(Info), int(Error), int(s)))
+}
+
+// Diagnostic is a single message about the generation of a panel
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	// Feature is the feature the diagnostic relates to, if any
+	Feature features.Feature
+}
+
+// String formats the diagnostic the way it would be logged
+func (d Diagnostic) String() string {
+	return d.Severity.String() + ": " + d.Message
+}
+
+// Reporter types receive diagnostics
+type Reporter interface {
+	Report(d Diagnostic)
+}
+
+// Logger is a Reporter writing diagnostics to a log.Logger, or the standard
+// logger if nil
+type Logger struct {
+	Logger *log.Logger
+}
+
+// Report logs the diagnostic
+func (l Logger) Report(d Diagnostic) {
+	if l.Logger == nil {
+		log.Print(d.String())
+		return
+	}
+	l.Logger.Print(d.String())
+}
+
+// Collector is a Reporter retaining diagnostics for later inspection. It is
+// safe for concurrent use.
+type Collector struct {
+	mu          sync.Mutex
+	diagnostics []Diagnostic
+}
+
+// Report retains the diagnostic
+func (c *Collector) Report(d Diagnostic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// Diagnostics returns all diagnostics reported so far, in order
+func (c *Collector) Diagnostics() []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Diagnostic{}, c.diagnostics...)
+}
+
+// discard is a Reporter ignoring all diagnostics
+type discard struct{}
+
+func (discard) Report(Diagnostic) {}
+
+// Discard is a Reporter ignoring all diagnostics
+var Discard Reporter = discard{}
+
+// Warnf reports a warning about a feature, which may be nil
+func Warnf(r Reporter, f features.Feature, format string, args ...interface{}) {
+	r.Report(Diagnostic{Severity: Warning, Message: fmt.Sprintf(format, args...), Feature: f})
+}
@@ -0,0 +1,346 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package fab describes the manufacturing capabilities of PCB fabrication
+// houses, so that generated artwork can be checked against them before
+// being sent off.
+package fab
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+)
+
+// Profile describes the capabilities of a PCB fab. Distances are in
+// millimetres.
+type Profile struct {
+	Name string
+	// MinSilkscreenWidth is the narrowest silkscreen line or feature the fab
+	// will reliably reproduce
+	MinSilkscreenWidth float64
+	// MinCopperWidth is the narrowest copper trace or feature the fab will
+	// reliably reproduce
+	MinCopperWidth float64
+	// MinHoleWeb is the narrowest material the fab will leave between the
+	// edges of neighbouring drilled holes
+	MinHoleWeb float64
+	// MinSilkscreenTextHeight and MinCopperTextHeight are the smallest
+	// heights of capital letters the fab will reliably reproduce legibly
+	MinSilkscreenTextHeight, MinCopperTextHeight float64
+	// DrillSizes are the diameters of the fab's standard drills, ascending
+	DrillSizes []float64
+	// MinHoleDiameter is the smallest hole the fab will drill. Zero means
+	// any hole its smallest drill can make.
+	MinHoleDiameter float64
+	// MetalCore indicates an aluminium substrate, with a single copper
+	// layer on an insulated metal base. Such boards cannot have plated
+	// holes, or copper, soldermask or silkscreen on the rear.
+	MetalCore bool
+	// Laser indicates a sheet material, such as acrylic, cut with a laser
+	// rather than fabricated as a PCB. Holes are cut rather than drilled,
+	// markings are engraved, and there is no copper, soldermask or plating.
+	Laser bool
+	// Kerf is the width of material removed by the laser beam, and
+	// Thickness the thickness of the sheet, in millimetres, for Laser
+	// profiles
+	Kerf, Thickness float64
+}
+
+// MinFeatureWidth returns the narrowest feature the fab will reliably
+// reproduce for a given feature purpose
+func (p Profile) MinFeatureWidth(purpose features.Purpose) float64 {
+	switch purpose {
+	case features.ExposedCopper, features.MaskedCopper:
+		return p.MinCopperWidth
+	}
+	return p.MinSilkscreenWidth
+}
+
+// String satisfies the Stringer interface to aid debug printing, and names
+// the fab in text diagnostics; see textpath.Limits
+func (p Profile) String() string {
+	return p.Name
+}
+
+// MinTextHeight returns the smallest legible text height the fab will
+// reliably reproduce for a given feature purpose
+func (p Profile) MinTextHeight(purpose features.Purpose) float64 {
+	switch purpose {
+	case features.ExposedCopper, features.MaskedCopper:
+		return p.MinCopperTextHeight
+	}
+	return p.MinSilkscreenTextHeight
+}
+
+// CheckMetalCore reports a diagnostic for each feature a metal-core board
+// cannot have: an Error for plated holes and copper on the rear, which
+// cannot be made at all, and a Warning for rear markings, which will be
+// left off, and holes below the minimum diameter, which the fab may not be
+// able to make. Nothing is reported for other profiles. A copper pour on the
+// rear is rejected when the board is rendered.
+func (p Profile) CheckMetalCore(feats []features.Feature, r diag.Reporter) {
+	if !p.MetalCore {
+		return
+	}
+	for _, f := range feats {
+		purpose := f.GetPurpose()
+		if c, ok := f.(*features.Circle); ok && purpose == features.Cutout {
+			if c.Plated {
+				r.Report(diag.Diagnostic{
+					Severity: diag.Error,
+					Message:  fmt.Sprintf("plated hole at (%.2f, %.2f) is not possible on %s metal-core boards", c.Origin.X, c.Origin.Y, p.Name),
+					Feature:  f,
+				})
+			} else if c.Radius*2 < p.MinHoleDiameter {
+				diag.Warnf(r, f, "%.3fmm hole at (%.2f, %.2f) is below the %s minimum of %.3fmm", c.Radius*2, c.Origin.X, c.Origin.Y, p.Name, p.MinHoleDiameter)
+			}
+			continue
+		}
+		s, ok := f.(features.Sided)
+		if !ok || s.GetSide() != features.BottomSide {
+			continue
+		}
+		switch purpose {
+		case features.ExposedCopper, features.MaskedCopper, features.MaskOpening:
+			r.Report(diag.Diagnostic{
+				Severity: diag.Error,
+				Message:  fmt.Sprintf("%s on the rear is not possible on %s single-layer metal-core boards", purpose, p.Name),
+				Feature:  f,
+			})
+		case features.Marking:
+			diag.Warnf(r, f, "rear silkscreen is not printed on %s metal-core boards", p.Name)
+		}
+	}
+}
+
+// CheckLaser checks features against the limitations of laser-cut
+// profiles, reporting a diagnostic for each feature such panels cannot have:
+// an Error for plated holes, copper and soldermask openings, which cannot be
+// made at all, and a Warning for rear markings, which are not engraved, and
+// holes below the minimum diameter. Nothing is reported for other profiles.
+func (p Profile) CheckLaser(feats []features.Feature, r diag.Reporter) {
+	if !p.Laser {
+		return
+	}
+	for _, f := range feats {
+		purpose := f.GetPurpose()
+		switch purpose {
+		case features.Cutout:
+			c, ok := f.(*features.Circle)
+			if !ok {
+				continue
+			}
+			if c.Plated {
+				r.Report(diag.Diagnostic{
+					Severity: diag.Error,
+					Message:  fmt.Sprintf("plated hole at (%.2f, %.2f) is not possible on %s panels", c.Origin.X, c.Origin.Y, p.Name),
+					Feature:  f,
+				})
+			} else if c.Radius*2 < p.MinHoleDiameter {
+				diag.Warnf(r, f, "%.3fmm hole at (%.2f, %.2f) is below the %s minimum of %.3fmm", c.Radius*2, c.Origin.X, c.Origin.Y, p.Name, p.MinHoleDiameter)
+			}
+		case features.ExposedCopper, features.MaskedCopper, features.MaskOpening:
+			r.Report(diag.Diagnostic{
+				Severity: diag.Error,
+				Message:  fmt.Sprintf("%s is not possible on %s panels", purpose, p.Name),
+				Feature:  f,
+			})
+		case features.Marking:
+			if s, ok := f.(features.Sided); ok && s.GetSide() == features.BottomSide {
+				diag.Warnf(r, f, "rear markings are not engraved on %s panels", p.Name)
+			}
+		}
+	}
+}
+
+// aluminium returns a metal-core variant of a profile, named for it with an
+// -aluminium suffix. Metal-core silkscreen is printed more coarsely, and
+// holes are drilled through the aluminium base, so minimum sizes and webs
+// are more conservative than for FR4.
+func aluminium(p Profile) Profile {
+	p.Name += "-aluminium"
+	p.MetalCore = true
+	p.MinSilkscreenWidth = math.Max(p.MinSilkscreenWidth, 0.2)
+	p.MinSilkscreenTextHeight = math.Max(p.MinSilkscreenTextHeight, 1.2)
+	p.MinHoleDiameter = math.Max(p.MinHoleDiameter, 1.0)
+	p.MinHoleWeb = math.Max(p.MinHoleWeb, 1.0)
+	return p
+}
+
+// drillRack returns drill sizes from smallest to largest in steps of step,
+// rounded to the nearest micron
+func drillRack(smallest, largest, step float64) []float64 {
+	sizes := []float64{}
+	for i := 0; smallest+float64(i)*step <= largest+step/2; i++ {
+		sizes = append(sizes, math.Round((smallest+float64(i)*step)*1000)/1000)
+	}
+	return sizes
+}
+
+// SnapDrill returns the standard drill size for a hole of the given
+// diameter. Clearance holes are rounded up so that hardware still fits;
+// plated holes are rounded to the nearest size. The second result is false
+// if the profile has no drill sizes, or the hole is larger than the largest
+// drill.
+func (p Profile) SnapDrill(diameter float64, plated bool) (float64, bool) {
+	const epsilon = 1e-6
+	if len(p.DrillSizes) == 0 || diameter > p.DrillSizes[len(p.DrillSizes)-1]+epsilon {
+		return diameter, false
+	}
+	i := sort.SearchFloat64s(p.DrillSizes, diameter-epsilon)
+	if plated && i > 0 && diameter-p.DrillSizes[i-1] < p.DrillSizes[i]-diameter {
+		i--
+	}
+	return p.DrillSizes[i], true
+}
+
+// SnapDrills returns a copy of the features with the diameters of Cutout
+// circles replaced by standard drill sizes, reporting an Info diagnostic for
+// each substitution. Holes too large to drill are routed with the outline
+// instead, as fabs mill them, which is also reported; an error is returned
+// for plated holes too large to drill, as the routed outline is not plated.
+// Holes already routed with the outline are left alone.
+func (p Profile) SnapDrills(feats []features.Feature, r diag.Reporter) ([]features.Feature, error) {
+	snapped := make([]features.Feature, len(feats))
+	for i, f := range feats {
+		snapped[i] = f
+		c, ok := f.(*features.Circle)
+		if !ok || c.Purpose != features.Cutout || features.LayerHint(c) == features.OutlineLayer {
+			continue
+		}
+		d, ok := p.SnapDrill(c.Radius*2, c.Plated)
+		if !ok && c.Plated {
+			return nil, fmt.Errorf("%.3fmm plated hole at (%.2f, %.2f) is larger than the largest %s drill", c.Radius*2, c.Origin.X, c.Origin.Y, p.Name)
+		}
+		if !ok {
+			r.Report(diag.Diagnostic{
+				Severity: diag.Info,
+				Message:  fmt.Sprintf("%.3fmm hole at (%.2f, %.2f) is larger than the largest %s drill, routing it with the outline", c.Radius*2, c.Origin.X, c.Origin.Y, p.Name),
+				Feature:  f,
+			})
+			hole := *c
+			features.Route(&hole, features.OutlineLayer)
+			snapped[i] = &hole
+			continue
+		}
+		if math.Abs(d-c.Radius*2) < 1e-6 {
+			continue
+		}
+		r.Report(diag.Diagnostic{
+			Severity: diag.Info,
+			Message:  fmt.Sprintf("%.3fmm hole at (%.2f, %.2f) drilled at the %s standard size of %.3fmm", c.Radius*2, c.Origin.X, c.Origin.Y, p.Name, d),
+			Feature:  f,
+		})
+		hole := *c
+		hole.Radius = d / 2
+		snapped[i] = &hole
+	}
+	return snapped, nil
+}
+
+// DefaultProfile is the name of the profile used when none is specified
+const DefaultProfile = "jlcpcb"
+
+// profiles lists the known fabs, using their published capabilities for
+// standard 2-layer boards. The smallest and largest drills of each rack
+// follow the fab's published limits on drilled hole sizes; larger holes are
+// milled. The sizes in between are not published, so the racks assume a
+// drill every 0.05mm, or 0.1mm for OSH Park, which is close enough for
+// rounding clearance holes up. Check them against the fab's capabilities
+// page before relying on -snap-drills for press-fit parts.
+var profiles = map[string]Profile{
+	"jlcpcb": {
+		Name:               "jlcpcb",
+		MinSilkscreenWidth: 0.153,
+		MinCopperWidth:     0.127,
+		// JLCPCB quote 0.5mm between the edges of holes
+		MinHoleWeb: 0.5,
+		// JLCPCB quote 1mm for silkscreen and 0.8mm for copper text
+		MinSilkscreenTextHeight: 1.0,
+		MinCopperTextHeight:     0.8,
+		DrillSizes:              drillRack(0.3, 6.3, 0.05),
+	},
+	"pcbway": {
+		Name:               "pcbway",
+		MinSilkscreenWidth: 0.15,
+		MinCopperWidth:     0.127,
+		// PCBWay publish no minimum between unplated holes, so the web
+		// is a conservative guess
+		MinHoleWeb:              0.5,
+		MinSilkscreenTextHeight: 0.8,
+		MinCopperTextHeight:     0.8,
+		DrillSizes:              drillRack(0.2, 6.3, 0.05),
+	},
+	"oshpark": {
+		Name:               "oshpark",
+		MinSilkscreenWidth: 0.127,
+		MinCopperWidth:     0.1524,
+		// nor do OSH Park
+		MinHoleWeb:              0.5,
+		MinSilkscreenTextHeight: 0.8,
+		MinCopperTextHeight:     1.0,
+		DrillSizes:              drillRack(0.3, 6.3, 0.1),
+	},
+	// laser-cut acrylic has no drills or copper; its minimums are for
+	// engraved markings and for webs that will not crack. The kerf is
+	// typical of CO2 lasers, and should be measured on the machine used.
+	"laser-acrylic": {
+		Name:                    "laser-acrylic",
+		MinSilkscreenWidth:      0.1,
+		MinHoleWeb:              1.5,
+		MinSilkscreenTextHeight: 1.5,
+		MinHoleDiameter:         1.0,
+		Laser:                   true,
+		Kerf:                    0.2,
+		Thickness:               3.0,
+	},
+}
+
+// init adds aluminium variants of the fabs offering metal-core boards
+func init() {
+	for _, name := range []string{"jlcpcb", "pcbway"} {
+		p := aluminium(profiles[name])
+		profiles[p.Name] = p
+	}
+}
+
+// Names returns the names of all known profiles, sorted
+func Names() []string {
+	names := []string{}
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the named profile
+func Lookup(name string) (Profile, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown fab profile %q (valid values: %s)", name, strings.Join(Names(), " "))
+	}
+	return p, nil
+}
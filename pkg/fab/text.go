@@ -0,0 +1,63 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package fab
+
+import "fmt"
+
+// TextPolicy selects how text too small for a fab to reproduce is handled
+type TextPolicy int
+
+// WarnText et al specify text policies. WarnText is the zero-value/default.
+const (
+	// WarnText reports small silkscreen text as a Warning and small copper
+	// text as an Error, as textpath.CheckText does
+	WarnText TextPolicy = iota // this MUST be the first item
+	// FailText treats all small text as an Error
+	FailText
+	// ScaleText enlarges small text and thickens thin strokes to the fab's
+	// minimums, with a Warning for each, as textpath.ScaleText does
+	ScaleText // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (tp TextPolicy) String() string {
+	switch tp {
+	case WarnText:
+		return "warn"
+	case FailText:
+		return "fail"
+	case ScaleText:
+		return "scale"
+	}
+	panic(fmt.Sprintf("invalid TextPolicy value (valid range is %d..%d): %d",
+		int(WarnText), int(ScaleText), int(tp)))
+}
+
+// ParseTextPolicy converts a text policy name, as returned by
+// TextPolicy.String, to a TextPolicy
+func ParseTextPolicy(s string) (TextPolicy, error) {
+	for tp := WarnText; tp <= ScaleText; tp++ {
+		if tp.String() == s {
+			return tp, nil
+		}
+	}
+	return WarnText, fmt.Errorf("invalid text policy %q (valid values: warn fail scale)", s)
+}
@@ -32,7 +32,13 @@ import (
 type Circle struct {
 	Origin geometry.Point
 	Radius float64
+	// Plated indicates that a Cutout circle should be drilled as a plated
+	// through-hole. Mounting holes and most panel holes should NOT be plated,
+	// hence the zero value.
+	Plated bool
 	Purpose
+	Side
+	Meta
 }
 
 // NewCircle initializes a new Circle object
@@ -53,8 +59,29 @@ func (c *Circle) SetPurpose(purpose Purpose) {
 	c.Purpose = purpose
 }
 
+// Accept passes the circle to the visitor
+func (c *Circle) Accept(v Visitor) {
+	v.VisitCircle(c)
+}
+
+// SetPlated sets whether a Cutout circle is drilled as a plated
+// through-hole
+func (c *Circle) SetPlated(plated bool) {
+	c.Plated = plated
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (c *Circle) GetSide() Side {
+	return c.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (c *Circle) SetSide(side Side) {
+	c.Side = side
+}
+
 // String satisfies the Stringer interface to aid debug printing
 func (c *Circle) String() string {
-	return fmt.Sprintf("Circle(x=%.2f, y=%.2f, r=%.2f, purpose=%s)",
-		c.Origin.X, c.Origin.Y, c.Radius, c.Purpose.String())
+	return fmt.Sprintf("Circle(x=%.2f, y=%.2f, r=%.2f, plated=%t, purpose=%s)",
+		c.Origin.X, c.Origin.Y, c.Radius, c.Plated, c.Purpose.String())
 }
@@ -39,7 +39,19 @@ const (
 	Marking Purpose = iota // this MUST be the first item
 	// Cutout features are intended to be used to create a hole/void in a
 	// panel
-	Cutout // this MUST be the last item
+	Cutout
+	// MaskOpening features are intended to be used to create openings in the
+	// soldermask, eg. to expose copper for aesthetic purposes
+	MaskOpening
+	// ExposedCopper features are rendered as copper with a matching opening
+	// in the soldermask, resulting in shiny (gold, silver) graphics
+	ExposedCopper
+	// MaskedCopper features are rendered as copper underneath the
+	// soldermask, resulting in a subtly different tint to the bare substrate
+	MaskedCopper
+	// Annotation features document the panel, eg. dimensions for mechanical
+	// drawings. They are excluded from fabrication output.
+	Annotation // this MUST be the last item
 )
 
 // String satisfies the Stringer interface to aid debug printing
@@ -49,15 +61,36 @@ func (p Purpose) String() string {
 		return "marking"
 	case Cutout:
 		return "cutout"
+	case MaskOpening:
+		return "mask-opening"
+	case ExposedCopper:
+		return "exposed-copper"
+	case MaskedCopper:
+		return "masked-copper"
+	case Annotation:
+		return "annotation"
 	}
 	panic(fmt.Sprintf("invalid Purpose value (valid range is %d..%d): %d",
-		int(Marking), int(Cutout), int(p)))
+		int(Marking), int(Annotation), int(p)))
 }
 
-// Feature interface. Intentionally small.
+// ParsePurpose converts a purpose name, as returned by Purpose.String, to a
+// Purpose
+func ParsePurpose(s string) (Purpose, error) {
+	for p := Marking; p <= Annotation; p++ {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return Marking, fmt.Errorf("invalid purpose %q (valid values: marking cutout mask-opening exposed-copper masked-copper annotation)", s)
+}
+
+// Feature interface. Intentionally small. Accept passes the feature to the
+// Visitor method for its type.
 type Feature interface {
 	GetPurpose() Purpose
 	SetPurpose(Purpose)
+	Accept(Visitor)
 }
 
 // Alignment specifies an alignment relative to a feature, typically the
@@ -103,3 +136,43 @@ func (a Alignment) String() string {
 	panic(fmt.Sprintf("invalid Alignment value (valid range is %d..%d): %d",
 		int(TopLeft), int(BottomRight), int(a)))
 }
+
+// ParseAlignment converts an alignment name, as returned by
+// Alignment.String, to an Alignment
+func ParseAlignment(s string) (Alignment, error) {
+	for a := TopLeft; a <= BottomRight; a++ {
+		if a.String() == s {
+			return a, nil
+		}
+	}
+	return TopLeft, fmt.Errorf("invalid alignment %q (valid values: top-left top-centre top-right centre-left centre centre-right bottom-left bottom-centre bottom-right)", s)
+}
+
+// Side indicates which side of the panel a feature is applied to. Cutout
+// features go all the way through the panel, so their Side is irrelevant.
+type Side int
+
+// TopSide et al specify panel sides. Top is the visible front of the panel,
+// and is intentionally the zero-value/default.
+const (
+	TopSide Side = iota // this MUST be the first item
+	BottomSide
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s Side) String() string {
+	switch s {
+	case TopSide:
+		return "top"
+	case BottomSide:
+		return "bottom"
+	}
+	panic(fmt.Sprintf("invalid Side value (valid range is %d..%d): %d",
+		int(TopSide), int(BottomSide), int(s)))
+}
+
+// Sided features can be applied to either side of the panel
+type Sided interface {
+	GetSide() Side
+	SetSide(Side)
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Custom is implemented by feature types defined outside this package, eg.
+// a company's standard logo block, so that they can be placed on panels
+// without changes to the renderers. Accept should call Visitor.VisitCustom.
+//
+// Expand returns standard features drawing the custom feature. Renderers
+// draw the expansion, unless a render function for the feature's Kind has
+// been registered with the renderer, eg. with
+// gerber.RegisterCustomRenderer. Features of kinds without a registered
+// render function are expanded before being prepared, so their expansions
+// are snapped, knocked out and checked like other features; expansions of
+// the other kinds are not prepared, so should not contain placeholders or
+// stroke text.
+type Custom interface {
+	Feature
+	Kind() string
+	Expand() ([]Feature, error)
+}
+
+// CustomKind describes a kind of custom feature, so that it can be placed
+// by name
+type CustomKind struct {
+	Name        string
+	Description string
+	// New constructs a feature of this kind at origin. Params are settings
+	// specific to the kind, given as name=value pairs on the command line.
+	New func(origin geometry.Point, params map[string]string) (Custom, error)
+}
+
+// customKinds are the registered custom feature kinds, by name
+var customKinds = map[string]CustomKind{}
+
+// RegisterCustomKind makes a kind of custom feature available by name. It
+// is intended to be called from the init function of the package defining
+// the kind.
+func RegisterCustomKind(k CustomKind) {
+	customKinds[k.Name] = k
+}
+
+// CustomKindNames returns the names of the registered custom feature
+// kinds, sorted
+func CustomKindNames() []string {
+	names := []string{}
+	for name := range customKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupCustomKind returns a registered custom feature kind by name
+func LookupCustomKind(name string) (CustomKind, error) {
+	if k, ok := customKinds[name]; ok {
+		return k, nil
+	}
+	return CustomKind{}, fmt.Errorf("unknown custom feature kind %q (valid values: %s)", name, strings.Join(CustomKindNames(), " "))
+}
+
+// ExpandCustom returns the features with each Custom feature replaced by
+// its expansion, recursively, for renderers that only draw standard
+// features. Custom features that cannot be expanded are passed to failed,
+// and dropped.
+func ExpandCustom(feats []Feature, failed func(Custom, error)) []Feature {
+	expanded := make([]Feature, 0, len(feats))
+	for _, f := range feats {
+		c, ok := f.(Custom)
+		if !ok {
+			expanded = append(expanded, f)
+			continue
+		}
+		sub, err := c.Expand()
+		if err != nil {
+			failed(c, err)
+			continue
+		}
+		expanded = append(expanded, ExpandCustom(sub, failed)...)
+	}
+	return expanded
+}
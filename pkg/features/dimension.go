@@ -0,0 +1,182 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package features encapsulate information about features on a panel, such as
+// drill holes (Circle), legend text (Text), and so on.
+package features
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+const (
+	// DefaultDimensionTextSize is the default height of capital letters in
+	// dimension text, in millimetres
+	DefaultDimensionTextSize = 1.5
+
+	// dimensionGap separates extension lines from the measured points, and
+	// text from the dimension line, in millimetres
+	dimensionGap = 0.5
+	// dimensionOvershoot is the distance extension lines extend past the
+	// dimension line, in millimetres
+	dimensionOvershoot = 1.0
+	// arrowLength and arrowWidth size the arrowheads, in millimetres
+	arrowLength = 1.5
+	arrowWidth  = 0.6
+)
+
+// Dimension describes a linear dimension between two points, as found on
+// mechanical drawings: extension lines, a dimension line with arrowheads,
+// and text showing the distance
+type Dimension struct {
+	Start, End geometry.Point
+	// Offset is the distance of the dimension line from the measured points.
+	// Positive values are to the left when looking from Start to End.
+	Offset    float64
+	Thickness float64
+	// TextSize is the height of capital letters in the text, in millimetres
+	TextSize float64
+	// Text overrides the automatically generated distance text
+	Text string
+	Purpose
+	Side
+	Meta
+}
+
+// NewDimension initializes a new Dimension object with the Annotation
+// purpose
+func NewDimension(start, end geometry.Point, offset float64) *Dimension {
+	return &Dimension{
+		Start:     start,
+		End:       end,
+		Offset:    offset,
+		Thickness: 0.1,
+		TextSize:  DefaultDimensionTextSize,
+		Purpose:   Annotation,
+	}
+}
+
+// GetPurpose returns the intended purpose of this feature
+func (d *Dimension) GetPurpose() Purpose {
+	return d.Purpose
+}
+
+// SetPurpose sets the purpose for a dimension feature
+func (d *Dimension) SetPurpose(purpose Purpose) {
+	d.Purpose = purpose
+}
+
+// Accept passes the dimension to the visitor
+func (d *Dimension) Accept(v Visitor) {
+	v.VisitDimension(d)
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (d *Dimension) GetSide() Side {
+	return d.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (d *Dimension) SetSide(side Side) {
+	d.Side = side
+}
+
+// Distance returns the distance between the measured points, in millimetres
+func (d *Dimension) Distance() float64 {
+	return d.Start.Distance(d.End)
+}
+
+// Label returns the dimension text: the distance to two decimal places with
+// trailing zeroes removed, unless overridden
+func (d *Dimension) Label() string {
+	if d.Text != "" {
+		return d.Text
+	}
+	return strconv.FormatFloat(math.Round(d.Distance()*100)/100, 'f', -1, 64)
+}
+
+// arrowhead returns a triangle with its tip at p, pointing in direction dir
+func arrowhead(p, dir geometry.Point) *Polygon {
+	base := p.Sub(dir.Scale(arrowLength))
+	side := dir.Perpendicular().Scale(arrowWidth / 2)
+	return NewPolygon([]geometry.Point{p, base.Add(side), base.Sub(side)})
+}
+
+// Features decomposes the dimension into lines, arrowheads and text with
+// the same purpose and side, for renderers without native support
+func (d *Dimension) Features() []Feature {
+	if d.Start == d.End {
+		return nil
+	}
+	dir := d.End.Sub(d.Start).Unit()
+	// left-hand normal, towards positive offsets
+	normal := dir.Perpendicular().Scale(-1)
+	if d.Offset < 0 {
+		normal = normal.Scale(-1)
+	}
+	offset := math.Abs(d.Offset)
+	a := d.Start.Add(normal.Scale(offset))
+	b := d.End.Add(normal.Scale(offset))
+	feats := []Feature{NewLine(a, b, d.Thickness)}
+	if offset > dimensionGap {
+		for _, p := range []geometry.Point{d.Start, d.End} {
+			feats = append(feats, NewLine(
+				p.Add(normal.Scale(dimensionGap)),
+				p.Add(normal.Scale(offset+dimensionOvershoot)),
+				d.Thickness))
+		}
+	}
+	if d.Distance() > arrowLength*2 {
+		feats = append(feats, arrowhead(a, dir.Scale(-1)), arrowhead(b, dir))
+	}
+	// keep text reading left to right or bottom to top
+	angle := math.Atan2(dir.Y, dir.X)
+	if angle <= -math.Pi/2 || angle > math.Pi/2 {
+		angle += math.Pi
+	}
+	up := geometry.Point{X: -math.Sin(angle), Y: math.Cos(angle)}
+	align := BottomCentre
+	if up.X*normal.X+up.Y*normal.Y < 0 {
+		align = TopCentre
+	}
+	feats = append(feats, NewText(a.Add(b).Scale(0.5).Add(normal.Scale(dimensionGap)), d.Label(),
+		WithSizeMM(d.TextSize), WithRotation(angle), WithAlignment(align)))
+	for _, f := range feats {
+		f.SetPurpose(d.Purpose)
+		if s, ok := f.(Sided); ok {
+			s.SetSide(d.Side)
+		}
+		if t, ok := f.(Tagged); ok {
+			t.SetTag(d.Tag)
+			t.SetLayer(d.Layer)
+		}
+	}
+	return feats
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (d *Dimension) String() string {
+	return fmt.Sprintf("Dimension(x1=%.2f, y1=%.2f, x2=%.2f, y2=%.2f, offset=%.2f, purpose=%s)",
+		d.Start.X, d.Start.Y, d.End.X, d.End.Y, d.Offset, d.Purpose.String())
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package features encapsulate information about features on a panel, such as
+// drill holes (Circles), legend text (Text), and so on.
+package features
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	// PNG and BMP are the supported image formats
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+const (
+	// DefaultImageThreshold is the default luminance threshold (0..1) below
+	// which a pixel is considered "ink"
+	DefaultImageThreshold = 0.5
+)
+
+// Image describes a monochrome bitmap feature, eg. a logo. Each pixel is
+// either ink or not-ink; how ink is rendered depends on the Purpose.
+type Image struct {
+	// Origin is the bottom-left corner of the image
+	Origin geometry.Point
+	// PixelSize is the width (and height) of each pixel, in millimetres
+	PixelSize float64
+	// Ink holds the thresholded bitmap, indexed [row][column] with row 0 at
+	// the TOP of the image, as is conventional for raster images
+	Ink [][]bool
+	Purpose
+	Side
+	Meta
+
+	threshold float64
+	dither    bool
+	invert    bool
+}
+
+// ImageOptionFunc functions mutate an Image structure before thresholding
+type ImageOptionFunc func(*Image)
+
+// WithThreshold is an Image option function that sets the luminance
+// threshold (0..1) below which pixels are considered ink
+func WithThreshold(threshold float64) ImageOptionFunc {
+	return func(i *Image) {
+		i.threshold = threshold
+	}
+}
+
+// WithDither is an Image option function that enables Floyd-Steinberg
+// dithering, for rendering greyscale images
+func WithDither() ImageOptionFunc {
+	return func(i *Image) {
+		i.dither = true
+	}
+}
+
+// WithInvert is an Image option function that makes light pixels ink,
+// rather than dark pixels
+func WithInvert() ImageOptionFunc {
+	return func(i *Image) {
+		i.invert = true
+	}
+}
+
+// LoadImage reads a PNG or BMP file
+func LoadImage(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// NewImage creates a new Image feature from a raster image, scaled to the
+// given width in millimetres. Fully transparent pixels are never ink.
+func NewImage(origin geometry.Point, img image.Image, width float64, options ...ImageOptionFunc) *Image {
+	bounds := img.Bounds()
+	if bounds.Dx() < 1 || bounds.Dy() < 1 {
+		panic("image must have at least one pixel")
+	}
+	if width <= 0.0 {
+		panic("image width must be a positive value")
+	}
+	i := &Image{
+		Origin:    origin,
+		PixelSize: width / float64(bounds.Dx()),
+		threshold: DefaultImageThreshold,
+	}
+	for _, opt := range options {
+		opt(i)
+	}
+	// luminance and opacity, 0..1, for each pixel
+	lum := make([][]float64, bounds.Dy())
+	opaque := make([][]bool, bounds.Dy())
+	for y := range lum {
+		lum[y] = make([]float64, bounds.Dx())
+		opaque[y] = make([]bool, bounds.Dx())
+		for x := range lum[y] {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			_, _, _, a := c.RGBA()
+			lum[y][x] = float64(color.GrayModel.Convert(c).(color.Gray).Y) / 255.0
+			if i.invert {
+				lum[y][x] = 1.0 - lum[y][x]
+			}
+			opaque[y][x] = a > 0
+		}
+	}
+	i.Ink = make([][]bool, bounds.Dy())
+	for y := range lum {
+		i.Ink[y] = make([]bool, bounds.Dx())
+		for x := range lum[y] {
+			ink := lum[y][x] < i.threshold
+			i.Ink[y][x] = ink && opaque[y][x]
+			if !i.dither {
+				continue
+			}
+			// Floyd-Steinberg: distribute quantisation error to neighbours
+			quantised := 1.0
+			if ink {
+				quantised = 0.0
+			}
+			e := lum[y][x] - quantised
+			spread := func(dx, dy int, weight float64) {
+				if y+dy < len(lum) && x+dx >= 0 && x+dx < len(lum[y]) {
+					lum[y+dy][x+dx] += e * weight
+				}
+			}
+			spread(1, 0, 7.0/16.0)
+			spread(-1, 1, 3.0/16.0)
+			spread(0, 1, 5.0/16.0)
+			spread(1, 1, 1.0/16.0)
+		}
+	}
+	return i
+}
+
+// Width returns the width of the image, in millimetres
+func (i *Image) Width() float64 {
+	return float64(len(i.Ink[0])) * i.PixelSize
+}
+
+// Height returns the height of the image, in millimetres
+func (i *Image) Height() float64 {
+	return float64(len(i.Ink)) * i.PixelSize
+}
+
+// Runs returns the ink of the image as a set of rectangles, one for each
+// horizontal run of ink pixels, described by their bottom-left and
+// top-right corners. This is far more compact than one rectangle per pixel.
+func (i *Image) Runs() [][2]geometry.Point {
+	runs := [][2]geometry.Point{}
+	rows := len(i.Ink)
+	for y, row := range i.Ink {
+		bottom := i.Origin.Y + float64(rows-y-1)*i.PixelSize
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			runs = append(runs, [2]geometry.Point{
+				{X: i.Origin.X + float64(start)*i.PixelSize, Y: bottom},
+				{X: i.Origin.X + float64(x)*i.PixelSize, Y: bottom + i.PixelSize},
+			})
+		}
+	}
+	return runs
+}
+
+// GetPurpose returns the intended purpose of this feature
+func (i *Image) GetPurpose() Purpose {
+	return i.Purpose
+}
+
+// SetPurpose sets the purpose for an image feature
+func (i *Image) SetPurpose(purpose Purpose) {
+	i.Purpose = purpose
+}
+
+// Accept passes the image to the visitor
+func (i *Image) Accept(v Visitor) {
+	v.VisitImage(i)
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (i *Image) GetSide() Side {
+	return i.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (i *Image) SetSide(side Side) {
+	i.Side = side
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (i *Image) String() string {
+	return fmt.Sprintf("Image(x=%.2f, y=%.2f, w=%.2f, h=%.2f, purpose=%s)",
+		i.Origin.X, i.Origin.Y, i.Width(), i.Height(), i.Purpose.String())
+}
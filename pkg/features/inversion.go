@@ -0,0 +1,82 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package features encapsulate information about features on a panel, such as
+// drill holes (Circles), legend text (Text), and so on.
+package features
+
+import (
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Inversion describes a filled, closed region with features knocked out of
+// it, for reversed-out text and other "dark zone" graphics. The knockouts
+// take the purpose and side of the inversion, whatever their own, and
+// leave the region unfilled wherever they would have been drawn.
+//
+// prepare.Features moves the features lying wholly within the region, with
+// the same purpose, side and layer hint as the inversion, into Knockouts.
+type Inversion struct {
+	Region    []geometry.Point
+	Knockouts []Feature
+	Purpose
+	Side
+	Meta
+}
+
+// NewInversion initializes a new Inversion object with no knockouts
+func NewInversion(region []geometry.Point) *Inversion {
+	if len(region) < 3 {
+		panic("inversion region must have at least three points")
+	}
+	return &Inversion{Region: region}
+}
+
+// GetPurpose returns the intended purpose of this feature
+func (i *Inversion) GetPurpose() Purpose {
+	return i.Purpose
+}
+
+// SetPurpose sets the purpose for an inversion feature
+func (i *Inversion) SetPurpose(purpose Purpose) {
+	i.Purpose = purpose
+}
+
+// Accept passes the inversion to the visitor
+func (i *Inversion) Accept(v Visitor) {
+	v.VisitInversion(i)
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (i *Inversion) GetSide() Side {
+	return i.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (i *Inversion) SetSide(side Side) {
+	i.Side = side
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (i *Inversion) String() string {
+	return fmt.Sprintf("Inversion(region=%v, knockouts=%d, purpose=%s)", i.Region, len(i.Knockouts), i.Purpose.String())
+}
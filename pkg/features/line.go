@@ -33,6 +33,8 @@ type Line struct {
 	Start, End geometry.Point
 	Thickness  float64
 	Purpose
+	Side
+	Meta
 }
 
 // NewLine initializes a new Line object
@@ -53,6 +55,21 @@ func (l *Line) SetPurpose(purpose Purpose) {
 	l.Purpose = purpose
 }
 
+// Accept passes the line to the visitor
+func (l *Line) Accept(v Visitor) {
+	v.VisitLine(l)
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (l *Line) GetSide() Side {
+	return l.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (l *Line) SetSide(side Side) {
+	l.Side = side
+}
+
 // String satisfies the Stringer interface to aid debug printing
 func (l *Line) String() string {
 	return fmt.Sprintf("Line(x1=%.2f, y1=%.2f, x2=%.2f, y2=%.2f, thickness=%.2f, purpose=%s)",
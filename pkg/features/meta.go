@@ -0,0 +1,169 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package features
+
+import "fmt"
+
+// Layer is an explicit hint naming the output layer a feature is rendered
+// into. Most features leave it unset and are routed according to their
+// Purpose and Side; the hint is for finer control than those allow, such as
+// a circle in the outline rather than the drill file.
+type Layer int
+
+// AutoLayer et al specify layer hints. AutoLayer is the zero value, routing
+// by Purpose and Side.
+const (
+	AutoLayer Layer = iota // this MUST be the first item
+	SilkTopLayer
+	SilkBottomLayer
+	MaskTopLayer
+	MaskBottomLayer
+	CopperTopLayer
+	CopperBottomLayer
+	OutlineLayer
+	DrillLayer // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (l Layer) String() string {
+	switch l {
+	case AutoLayer:
+		return "auto"
+	case SilkTopLayer:
+		return "silk-top"
+	case SilkBottomLayer:
+		return "silk-bottom"
+	case MaskTopLayer:
+		return "mask-top"
+	case MaskBottomLayer:
+		return "mask-bottom"
+	case CopperTopLayer:
+		return "copper-top"
+	case CopperBottomLayer:
+		return "copper-bottom"
+	case OutlineLayer:
+		return "outline"
+	case DrillLayer:
+		return "drill"
+	}
+	panic(fmt.Sprintf("invalid Layer value (valid range is %d..%d): %d",
+		int(AutoLayer), int(DrillLayer), int(l)))
+}
+
+// ParseLayer converts a layer hint name, as returned by Layer.String, to a
+// Layer
+func ParseLayer(s string) (Layer, error) {
+	for l := AutoLayer; l <= DrillLayer; l++ {
+		if l.String() == s {
+			return l, nil
+		}
+	}
+	return AutoLayer, fmt.Errorf("invalid layer %q (valid values: auto silk-top silk-bottom mask-top mask-bottom copper-top copper-bottom outline drill)", s)
+}
+
+// Purpose returns the purpose implied by a layer hint, for renderers that
+// only consider the purpose
+func (l Layer) Purpose() Purpose {
+	switch l {
+	case MaskTopLayer, MaskBottomLayer:
+		return MaskOpening
+	case CopperTopLayer, CopperBottomLayer:
+		return MaskedCopper
+	case OutlineLayer, DrillLayer:
+		return Cutout
+	}
+	return Marking
+}
+
+// Side returns the side of the panel implied by a layer hint
+func (l Layer) Side() Side {
+	switch l {
+	case SilkBottomLayer, MaskBottomLayer, CopperBottomLayer:
+		return BottomSide
+	}
+	return TopSide
+}
+
+// Meta holds optional metadata common to all features: a free-form tag, eg.
+// to identify the features generated for one component, and a layer hint
+type Meta struct {
+	Tag   string
+	Layer Layer
+}
+
+// GetTag returns the tag of a feature
+func (m *Meta) GetTag() string {
+	return m.Tag
+}
+
+// SetTag sets the tag of a feature
+func (m *Meta) SetTag(tag string) {
+	m.Tag = tag
+}
+
+// GetLayer returns the layer hint of a feature
+func (m *Meta) GetLayer() Layer {
+	return m.Layer
+}
+
+// SetLayer sets the layer hint of a feature
+func (m *Meta) SetLayer(layer Layer) {
+	m.Layer = layer
+}
+
+// Tagged features carry a tag and layer hint
+type Tagged interface {
+	GetTag() string
+	SetTag(string)
+	GetLayer() Layer
+	SetLayer(Layer)
+}
+
+// Tag returns the tag of a feature, or an empty string for features without
+// one
+func Tag(f Feature) string {
+	if t, ok := f.(Tagged); ok {
+		return t.GetTag()
+	}
+	return ""
+}
+
+// LayerHint returns the layer hint of a feature, or AutoLayer for features
+// without one
+func LayerHint(f Feature) Layer {
+	if t, ok := f.(Tagged); ok {
+		return t.GetLayer()
+	}
+	return AutoLayer
+}
+
+// Route sets the layer hint of a feature, along with the purpose and side
+// it implies so that renderers without layer hint support still draw the
+// feature sensibly
+func Route(f Feature, layer Layer) {
+	if t, ok := f.(Tagged); ok {
+		t.SetLayer(layer)
+	}
+	f.SetPurpose(layer.Purpose())
+	if s, ok := f.(Sided); ok {
+		s.SetSide(layer.Side())
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package features
+
+import (
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Outline describes the outer boundary of a panel as a single closed
+// contour, so that it can be written as one connected path rather than as
+// independent lines. The closing edge from the last point back to the first
+// is implied. Curves, such as rounded corners, are flattened into points.
+type Outline struct {
+	Points []geometry.Point
+	Purpose
+	Meta
+}
+
+// NewOutline initializes a new Outline object with the Cutout purpose
+func NewOutline(points []geometry.Point) *Outline {
+	if len(points) < 3 {
+		panic("outline must have at least three points")
+	}
+	return &Outline{Points: points, Purpose: Cutout}
+}
+
+// Edges returns the edges of the outline as start/end point pairs,
+// including the closing edge
+func (o *Outline) Edges() [][2]geometry.Point {
+	edges := [][2]geometry.Point{}
+	for i, pt := range o.Points {
+		edges = append(edges, [2]geometry.Point{pt, o.Points[(i+1)%len(o.Points)]})
+	}
+	return edges
+}
+
+// GetPurpose returns the intended purpose of this feature
+func (o *Outline) GetPurpose() Purpose {
+	return o.Purpose
+}
+
+// SetPurpose sets the purpose for an outline feature
+func (o *Outline) SetPurpose(purpose Purpose) {
+	o.Purpose = purpose
+}
+
+// Accept passes the outline to the visitor
+func (o *Outline) Accept(v Visitor) {
+	v.VisitOutline(o)
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (o *Outline) String() string {
+	return fmt.Sprintf("Outline(points=%v, purpose=%s)", o.Points, o.Purpose.String())
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package features
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// placeholderField matches the fields of a placeholder template, eg.
+// {serial}
+var placeholderField = regexp.MustCompile(`\{([a-z][a-z0-9_-]*)\}`)
+
+// Placeholder describes a text feature whose text is a template. Fields such
+// as {serial}, {date} and {version} are substituted with values supplied
+// when the panel is rendered, so that each panel of a batch can be stamped
+// with its own serial number.
+type Placeholder struct {
+	Text
+}
+
+// NewPlaceholder initializes a new Placeholder object. The options are the
+// same as for Text.
+func NewPlaceholder(origin geometry.Point, template string, options ...TextOptionFunc) *Placeholder {
+	return &Placeholder{Text: *NewText(origin, template, options...)}
+}
+
+// Accept passes the placeholder to the visitor. Placeholders are normally
+// resolved to Text before rendering; see ResolvePlaceholders.
+func (p *Placeholder) Accept(v Visitor) {
+	v.VisitPlaceholder(p)
+}
+
+// Fields returns the names of the fields used in the template, sorted
+func (p *Placeholder) Fields() []string {
+	seen := map[string]bool{}
+	fields := []string{}
+	for _, m := range placeholderField.FindAllStringSubmatch(p.Text.Text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			fields = append(fields, m[1])
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Resolve returns a Text feature with the fields of the template substituted
+// from values. An error is returned if any field has no value.
+func (p *Placeholder) Resolve(values map[string]string) (*Text, error) {
+	missing := []string{}
+	for _, field := range p.Fields() {
+		if _, ok := values[field]; !ok {
+			missing = append(missing, "{"+field+"}")
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("placeholder %q: no value for %s", p.Text.Text, strings.Join(missing, " "))
+	}
+	t := p.Text
+	t.Text = placeholderField.ReplaceAllStringFunc(t.Text, func(field string) string {
+		return values[field[1:len(field)-1]]
+	})
+	return &t, nil
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (p Placeholder) String() string {
+	return "Placeholder" + strings.TrimPrefix(p.Text.String(), "Text")
+}
+
+// ResolvePlaceholders returns the features with each Placeholder replaced by
+// the Text feature it resolves to. Other features are returned unchanged.
+func ResolvePlaceholders(feats []Feature, values map[string]string) ([]Feature, error) {
+	resolved := make([]Feature, 0, len(feats))
+	for _, f := range feats {
+		if p, ok := f.(*Placeholder); ok {
+			t, err := p.Resolve(values)
+			if err != nil {
+				return nil, err
+			}
+			f = t
+		}
+		resolved = append(resolved, f)
+	}
+	return resolved, nil
+}
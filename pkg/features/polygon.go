@@ -0,0 +1,97 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package features encapsulate information about features on a panel, such as
+// drill holes (Circles), legend text (Text), and so on.
+package features
+
+import (
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Polygon describes a filled, closed region. The closing edge from the last
+// point back to the first is implied.
+type Polygon struct {
+	Points []geometry.Point
+	Purpose
+	Side
+	Meta
+}
+
+// NewPolygon initializes a new Polygon object
+func NewPolygon(points []geometry.Point) *Polygon {
+	if len(points) < 3 {
+		panic("polygon must have at least three points")
+	}
+	return &Polygon{Points: points}
+}
+
+// NewRectangle initializes a new Polygon object describing an axis-aligned
+// rectangle with the given opposite corners
+func NewRectangle(a, b geometry.Point) *Polygon {
+	return NewPolygon([]geometry.Point{
+		{X: a.X, Y: a.Y},
+		{X: b.X, Y: a.Y},
+		{X: b.X, Y: b.Y},
+		{X: a.X, Y: b.Y},
+	})
+}
+
+// Edges returns the edges of the polygon as start/end point pairs, including
+// the closing edge
+func (p *Polygon) Edges() [][2]geometry.Point {
+	edges := [][2]geometry.Point{}
+	for i, pt := range p.Points {
+		edges = append(edges, [2]geometry.Point{pt, p.Points[(i+1)%len(p.Points)]})
+	}
+	return edges
+}
+
+// GetPurpose returns the intended purpose of this feature
+func (p *Polygon) GetPurpose() Purpose {
+	return p.Purpose
+}
+
+// SetPurpose sets the purpose for a polygon feature
+func (p *Polygon) SetPurpose(purpose Purpose) {
+	p.Purpose = purpose
+}
+
+// Accept passes the polygon to the visitor
+func (p *Polygon) Accept(v Visitor) {
+	v.VisitPolygon(p)
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (p *Polygon) GetSide() Side {
+	return p.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (p *Polygon) SetSide(side Side) {
+	p.Side = side
+}
+
+// String satisfies the Stringer interface to aid debug printing
+func (p *Polygon) String() string {
+	return fmt.Sprintf("Polygon(points=%v, purpose=%s)", p.Points, p.Purpose.String())
+}
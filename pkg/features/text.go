@@ -34,17 +34,65 @@ const (
 	DefaultTextSize = 14.0 // units: points. So about 4.93mm
 )
 
+// TextMode indicates how the glyphs of a text feature are drawn
+type TextMode int
+
+// FilledText et al specify text modes. Filled text is the zero-value/default.
+const (
+	// FilledText draws glyphs as filled outlines, as for silkscreen
+	FilledText TextMode = iota // this MUST be the first item
+	// StrokeText draws glyphs as single lines using a stroke font, as for
+	// engraving and pen plotters
+	StrokeText // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (m TextMode) String() string {
+	switch m {
+	case FilledText:
+		return "filled"
+	case StrokeText:
+		return "stroke"
+	}
+	panic(fmt.Sprintf("invalid TextMode value (valid range is %d..%d): %d",
+		int(FilledText), int(StrokeText), int(m)))
+}
+
+// ParseTextMode converts a text mode name, as returned by TextMode.String,
+// to a TextMode
+func ParseTextMode(s string) (TextMode, error) {
+	for m := FilledText; m <= StrokeText; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return FilledText, fmt.Errorf("invalid text mode %q (valid values: filled stroke)", s)
+}
+
 // Text describes a text feature
 type Text struct {
 	Origin geometry.Point
 	Alignment
 	Purpose
+	Side
+	Meta
 	Text string
-	// Size somehow describes the size of the text. Specific units not defined
-	// here but probably safest to use points.
+	// Size is the font size of the text, in points. This is the size of the
+	// font's em square, so capital letters are somewhat smaller.
 	Size float64
+	// CapHeight is the height of capital letters, in millimetres. When
+	// non-zero it takes precedence over Size.
+	CapHeight float64
 	// Radians. 0 for normal orientation.
 	Rotate float64
+	// Font is the name of the font to render the text with. If empty, the
+	// default font of the renderer is used.
+	Font string
+	// Mode selects filled or stroke glyphs. Stroke text needs a stroke font.
+	Mode TextMode
+	// StrokeWidth is the line thickness of stroke text, in millimetres. If
+	// zero, it is proportional to the height of the text.
+	StrokeWidth float64
 }
 
 // TextOptionFunc functions mutate a Text structure
@@ -57,10 +105,20 @@ func WithAlignment(align Alignment) TextOptionFunc {
 	}
 }
 
-// WithSize is a Text option function that sets size for a text feature
+// WithSize is a Text option function that sets the font size for a text
+// feature, in points
 func WithSize(size float64) TextOptionFunc {
 	return func(t *Text) {
 		t.Size = size
+		t.CapHeight = 0
+	}
+}
+
+// WithSizeMM is a Text option function that sets the height of capital
+// letters for a text feature, in millimetres
+func WithSizeMM(capHeight float64) TextOptionFunc {
+	return func(t *Text) {
+		t.CapHeight = capHeight
 	}
 }
 
@@ -72,12 +130,28 @@ func WithRotation(r float64) TextOptionFunc {
 	}
 }
 
+// WithFont is a Text option function that sets the font for a text feature
+func WithFont(name string) TextOptionFunc {
+	return func(t *Text) {
+		t.Font = name
+	}
+}
+
+// WithMode is a Text option function that sets the text mode, and for
+// stroke text the line thickness in millimetres
+func WithMode(mode TextMode, strokeWidth float64) TextOptionFunc {
+	return func(t *Text) {
+		t.Mode = mode
+		t.StrokeWidth = strokeWidth
+	}
+}
+
 // NewText creates a new Text feature
 func NewText(origin geometry.Point, text string, options ...TextOptionFunc) *Text {
 	t := &Text{
 		Origin: origin,
 		Text:   text,
-		Size:   14.0, // default of
+		Size:   DefaultTextSize,
 	}
 	for _, opt := range options {
 		opt(t)
@@ -96,8 +170,27 @@ func (t *Text) SetPurpose(purpose Purpose) {
 	t.Purpose = purpose
 }
 
+// Accept passes the text to the visitor
+func (t *Text) Accept(v Visitor) {
+	v.VisitText(t)
+}
+
+// GetSide returns the side of the panel this feature is applied to
+func (t *Text) GetSide() Side {
+	return t.Side
+}
+
+// SetSide sets the side of the panel this feature is applied to
+func (t *Text) SetSide(side Side) {
+	t.Side = side
+}
+
 // String satisfies the Stringer interface to aid debug printing
 func (t Text) String() string {
-	return fmt.Sprintf("Text(x=%.2f, y=%.2f, size=%.2f, align=%s, purpose=%s, text=%q)",
-		t.Origin.X, t.Origin.Y, t.Size, t.Alignment.String(), t.Purpose.String(), t.Text)
+	size := fmt.Sprintf("%.2fpt", t.Size)
+	if t.CapHeight > 0 {
+		size = fmt.Sprintf("%.2fmm", t.CapHeight)
+	}
+	return fmt.Sprintf("Text(x=%.2f, y=%.2f, size=%s, align=%s, mode=%s, purpose=%s, text=%q)",
+		t.Origin.X, t.Origin.Y, size, t.Alignment.String(), t.Mode.String(), t.Purpose.String(), t.Text)
 }
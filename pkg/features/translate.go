@@ -0,0 +1,128 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package features
+
+import (
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// translator copies each feature it visits, moved by an offset
+type translator struct {
+	offset geometry.Point
+	moved  []Feature
+	err    error
+}
+
+// points returns a copy of the points, moved by the offset
+func (t *translator) points(pts []geometry.Point) []geometry.Point {
+	moved := make([]geometry.Point, len(pts))
+	for i, p := range pts {
+		moved[i] = p.Add(t.offset)
+	}
+	return moved
+}
+
+func (t *translator) VisitLine(l *Line) {
+	c := *l
+	c.Start, c.End = l.Start.Add(t.offset), l.End.Add(t.offset)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitCircle(ci *Circle) {
+	c := *ci
+	c.Origin = ci.Origin.Add(t.offset)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitPolygon(p *Polygon) {
+	c := *p
+	c.Points = t.points(p.Points)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitOutline(o *Outline) {
+	c := *o
+	c.Points = t.points(o.Points)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitText(tx *Text) {
+	c := *tx
+	c.Origin = tx.Origin.Add(t.offset)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitPlaceholder(p *Placeholder) {
+	c := *p
+	c.Origin = p.Origin.Add(t.offset)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitImage(i *Image) {
+	c := *i
+	c.Origin = i.Origin.Add(t.offset)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitDimension(d *Dimension) {
+	c := *d
+	c.Start, c.End = d.Start.Add(t.offset), d.End.Add(t.offset)
+	t.moved = append(t.moved, &c)
+}
+
+func (t *translator) VisitInversion(i *Inversion) {
+	c := *i
+	c.Region = t.points(i.Region)
+	knockouts, err := Translate(i.Knockouts, t.offset)
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	c.Knockouts = knockouts
+	t.moved = append(t.moved, &c)
+}
+
+// VisitCustom translates the expansion of a custom feature, as its own
+// position is private to its kind
+func (t *translator) VisitCustom(c Custom) {
+	sub, err := c.Expand()
+	if err == nil {
+		sub, err = Translate(sub, t.offset)
+	}
+	if err != nil {
+		if t.err == nil {
+			t.err = fmt.Errorf("%s: %v", c.Kind(), err)
+		}
+		return
+	}
+	t.moved = append(t.moved, sub...)
+}
+
+// Translate returns copies of the features moved by an offset, eg. to
+// place several copies of a panel side by side. Custom features are
+// replaced by their translated expansions. The features themselves are
+// not modified.
+func Translate(feats []Feature, offset geometry.Point) ([]Feature, error) {
+	t := &translator{offset: offset, moved: make([]Feature, 0, len(feats))}
+	Walk(feats, t)
+	return t.moved, t.err
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package features
+
+// Visitor receives features according to their type, by double dispatch
+// through Feature.Accept. Consumers implement every method, so that a
+// feature type added to this package fails to compile in each consumer
+// until it decides how to render the new type, rather than falling through
+// to an "unsupported feature" warning at run time.
+type Visitor interface {
+	VisitLine(*Line)
+	VisitCircle(*Circle)
+	VisitPolygon(*Polygon)
+	VisitOutline(*Outline)
+	VisitText(*Text)
+	VisitPlaceholder(*Placeholder)
+	VisitImage(*Image)
+	VisitDimension(*Dimension)
+	VisitInversion(*Inversion)
+	VisitCustom(Custom)
+}
+
+// Walk passes each feature to the visitor, in order
+func Walk(feats []Feature, v Visitor) {
+	for _, f := range feats {
+		f.Accept(v)
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package eurocase describes the flat parts of a Eurorack case --- side
+// panels, lids and rear panels --- as panels, so that they can be generated
+// with the same features pipeline as front panels.
+package eurocase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+const (
+	// RowPitch is the height of one 3U row of a Eurorack case, in
+	// millimetres
+	RowPitch = eurorack.RowPitch3U
+
+	// RailScrewOffset is the height of the rail end screws above the bottom
+	// of each row. The screws are assumed to be in line with the panel
+	// mounting holes, which suits common Z-rails; check this against the
+	// datasheet of the rails actually used.
+	RailScrewOffset = (RowPitch-eurorack.PanelHeight3U)/2 + eurorack.MountingHoleBottomY3U
+
+	// CaseScrewInset is the distance of lid and rear panel screws from the
+	// ends of the edge they fix to
+	CaseScrewInset = 15.0
+)
+
+// Kind indicates which case part to generate
+type Kind int
+
+// Side et al specify case parts
+const (
+	// Side panels hold the ends of the rails. One design serves both sides.
+	Side Kind = iota
+	// Lid panels cover the top or bottom of the case, fixed to the edges of
+	// the side panels
+	Lid
+	// Rear panels close the back of the case, fixed to the back edges of the
+	// side panels, and carry the power entry
+	Rear
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (k Kind) String() string {
+	switch k {
+	case Side:
+		return "side"
+	case Lid:
+		return "lid"
+	case Rear:
+		return "rear"
+	}
+	panic(fmt.Sprintf("invalid Kind value (valid range is %d..%d): %d",
+		int(Side), int(Rear), int(k)))
+}
+
+// ParseKind converts a case part name, as returned by Kind.String, to a Kind
+func ParseKind(s string) (Kind, error) {
+	for k := Side; k <= Rear; k++ {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return Side, fmt.Errorf("invalid case part %q (valid values: side lid rear)", s)
+}
+
+// PowerEntry indicates the power inlet cut into the rear panel
+type PowerEntry int
+
+// NoPower et al specify power inlets
+const (
+	NoPower PowerEntry = iota
+	// IEC is a screw-mounted IEC C14 mains inlet
+	IEC
+	// DC is a panel-mounted DC barrel jack, for external power supplies
+	DC
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (p PowerEntry) String() string {
+	switch p {
+	case NoPower:
+		return "none"
+	case IEC:
+		return "iec"
+	case DC:
+		return "dc"
+	}
+	panic(fmt.Sprintf("invalid PowerEntry value (valid range is %d..%d): %d",
+		int(NoPower), int(DC), int(p)))
+}
+
+// ParsePowerEntry converts a power inlet name, as returned by
+// PowerEntry.String, to a PowerEntry
+func ParsePowerEntry(s string) (PowerEntry, error) {
+	for p := NoPower; p <= DC; p++ {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return NoPower, fmt.Errorf("invalid power entry %q (valid values: none iec dc)", s)
+}
+
+// Options describes the case. Distances are in millimetres.
+type Options struct {
+	// Rows is the number of 3U rows
+	Rows int
+	// HP is the usable width of each row
+	HP int
+	// Depth is the front-to-back depth of the case
+	Depth float64
+	// Thickness is the thickness of the side panel material
+	Thickness float64
+	// RailSetback is the distance from the front edge of the side panels to
+	// the rail end screws
+	RailSetback float64
+	// RailScrewDiameter is the clearance hole size for the rail end screws
+	RailScrewDiameter float64
+	// CaseScrewDiameter is the clearance hole size for the screws fixing lids
+	// and the rear panel to the side panels
+	CaseScrewDiameter float64
+	// Power is the power inlet cut into the rear panel
+	Power PowerEntry
+}
+
+// DefaultOptions returns options for a single row 84HP case, with M4 rail
+// screws and 12mm plywood sides
+func DefaultOptions() Options {
+	return Options{
+		Rows:              1,
+		HP:                84,
+		Depth:             60.0,
+		Thickness:         12.0,
+		RailSetback:       6.0,
+		RailScrewDiameter: 4.3,
+		CaseScrewDiameter: 3.5,
+	}
+}
+
+// Part implements the panel.Panel interface and encapsulates the physical
+// characteristics of a Eurorack case part
+type Part struct {
+	Kind
+	Options
+}
+
+// New constructs a new case part, returning an error if the options could
+// not describe a case
+func New(kind Kind, opts Options) (*Part, error) {
+	if opts.Rows < 1 {
+		return nil, errors.New("eurocase: rows must be greater than 0")
+	}
+	if opts.HP < 1 {
+		return nil, errors.New("eurocase: width must be greater than 0")
+	}
+	if opts.Depth <= 2*CaseScrewInset || opts.Depth <= opts.RailSetback {
+		return nil, fmt.Errorf("eurocase: depth of %.2fmm is too shallow", opts.Depth)
+	}
+	if opts.Thickness <= 0 {
+		return nil, errors.New("eurocase: thickness must be greater than 0")
+	}
+	return &Part{Kind: kind, Options: opts}, nil
+}
+
+// Name returns the name of the panel format
+func (p Part) Name() string {
+	return "case-" + p.Kind.String()
+}
+
+// Units returns the unit the panel width is given in
+func (p Part) Units() string {
+	return "hp"
+}
+
+// WidthUnits returns the usable width of the case in Units, rather than the
+// width of the part
+func (p Part) WidthUnits() float64 {
+	return float64(p.HP)
+}
+
+// innerWidth returns the distance between the side panels
+func (p Part) innerWidth() float64 {
+	return eurorack.HP * float64(p.HP)
+}
+
+// Width returns the width of the part, in millimetres. Side panels are as
+// wide as the case is deep; lids and rear panels span the side panels.
+func (p Part) Width() float64 {
+	if p.Kind == Side {
+		return p.Depth
+	}
+	return p.innerWidth() + 2*p.Thickness
+}
+
+// Height returns the height of the part, in millimetres
+func (p Part) Height() float64 {
+	if p.Kind == Lid {
+		return p.Depth
+	}
+	return RowPitch * float64(p.Rows)
+}
+
+// MountingHoleDiameter returns the screw clearance hole size for the part
+func (p Part) MountingHoleDiameter() float64 {
+	if p.Kind == Side {
+		return p.RailScrewDiameter
+	}
+	return p.CaseScrewDiameter
+}
+
+// MountingHoles returns the screw hole locations for the part: the rail end
+// screws of side panels, or the screws fixing lids and rear panels to the
+// edges of the side panels
+func (p Part) MountingHoles() []geometry.Point {
+	holes := []geometry.Point{}
+	if p.Kind == Side {
+		for row := 0; row < p.Rows; row++ {
+			base := RowPitch * float64(row)
+			holes = append(holes,
+				geometry.Point{X: p.RailSetback, Y: base + RailScrewOffset},
+				geometry.Point{X: p.RailSetback, Y: base + RowPitch - RailScrewOffset},
+			)
+		}
+		return holes
+	}
+	for _, x := range []float64{p.Thickness / 2, p.Width() - p.Thickness/2} {
+		holes = append(holes,
+			geometry.Point{X: x, Y: CaseScrewInset},
+			geometry.Point{X: x, Y: p.Height() - CaseScrewInset},
+		)
+	}
+	return holes
+}
+
+// HorizontalFit indicates the panel tolerance adjustment for the part. Case
+// parts are cut to size.
+func (p Part) HorizontalFit() float64 {
+	return 0.0
+}
+
+// CornerRadius indicates the corner radius for the part
+func (p Part) CornerRadius() float64 {
+	return 0.0
+}
+
+// RailHeightFromMountingHole is zero, as case parts have no rails in front
+// of them
+func (p Part) RailHeightFromMountingHole() float64 {
+	return 0.0
+}
+
+// MountingHoleTopY returns the Y coordinate for the top row of screw holes
+func (p Part) MountingHoleTopY() float64 {
+	y := math.Inf(-1)
+	for _, h := range p.MountingHoles() {
+		y = math.Max(y, h.Y)
+	}
+	return y
+}
+
+// MountingHoleBottomY returns the Y coordinate for the bottom row of screw
+// holes
+func (p Part) MountingHoleBottomY() float64 {
+	y := math.Inf(1)
+	for _, h := range p.MountingHoles() {
+		y = math.Min(y, h.Y)
+	}
+	return y
+}
+
+// HeaderLocation returns the location of the header text
+func (p Part) HeaderLocation() geometry.Point {
+	return geometry.Point{X: p.Width() / 2, Y: p.MountingHoleTopY()}
+}
+
+// FooterLocation returns the location of the footer text
+func (p Part) FooterLocation() geometry.Point {
+	return geometry.Point{X: p.Width() / 2, Y: p.MountingHoleBottomY()}
+}
+
+// Features returns the cutouts specific to the part, beyond its outline and
+// screw holes: the power inlet of a rear panel, centred on the panel. Sizes
+// are typical of common parts and should be checked against the part used.
+func (p Part) Features() []features.Feature {
+	if p.Kind != Rear {
+		return nil
+	}
+	centre := geometry.Point{X: p.Width() / 2, Y: p.Height() / 2}
+	switch p.Power {
+	case IEC:
+		// screw-mounted C14 inlet: body cutout with fixing holes either side
+		half := geometry.Point{X: 27.5 / 2, Y: 19.5 / 2}
+		body := features.NewRectangle(centre.Sub(half), centre.Add(half))
+		body.SetPurpose(features.Cutout)
+		feats := []features.Feature{body}
+		for _, dx := range []float64{-20.0, 20.0} {
+			hole := features.NewCircle(centre.Add(geometry.Point{X: dx}), 3.2/2)
+			hole.SetPurpose(features.Cutout)
+			feats = append(feats, hole)
+		}
+		return feats
+	case DC:
+		jack := features.NewCircle(centre, 11.0/2)
+		jack.SetPurpose(features.Cutout)
+		return []features.Feature{jack}
+	}
+	return nil
+}
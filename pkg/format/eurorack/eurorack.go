@@ -23,7 +23,10 @@
 package eurorack
 
 import (
+	"fmt"
+
 	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
 )
 
 const (
@@ -32,6 +35,11 @@ const (
 	// latter does not use lipped rails
 	PanelHeight3U = 128.5
 
+	// RowPitch3U is the height of one 3U rack row, in millimetres. Panels
+	// spanning several rows are pitched at this, not at their own height, so
+	// that the mounting holes of each row line up with its rails
+	RowPitch3U = 133.35
+
 	// ExtraMountingHolesThreshold represents the panel width threshold beyond
 	// which additional mounting holes are required
 	ExtraMountingHolesThreshold = 8
@@ -53,7 +61,11 @@ const (
 	MountingHoleDiameter = 3.2
 
 	// HP represents horizontal pitch in a Eurorack frame, in millimetres
-	HP = 5.08
+	HP = geometry.HP
+
+	// MaxHP represents the widest panel accepted, in HP: a full row of the
+	// widest cases, twice the 84HP of a 19-inch rack
+	MaxHP = 168
 
 	// HorizontalFit indicates the panel tolerance adjustment for the format
 	HorizontalFit = 0.25
@@ -62,6 +74,10 @@ const (
 	// believe in such things.
 	CornerRadius = 0.0
 
+	// PanelThickness is the thickness of a panel made as a PCB, in
+	// millimetres. Aluminium panels are usually 2mm.
+	PanelThickness = 1.6
+
 	// RailHeightFromMountingHole is used to determine how much space exists.
 	// See discussion in github.com/jsleeio/pkg/panel. 5mm is a good safe
 	// figure for all known-used Eurorack rail types
@@ -72,6 +88,17 @@ const (
 // characteristics of a Eurorack panel
 type Eurorack struct {
 	HP int
+	// Rows is the number of 3U rows spanned by a tall panel, with mounting
+	// holes for each row. Zero is treated as one.
+	Rows int
+	// Holes overrides the mounting hole count and positions
+	Holes panel.HoleOptions
+	// Fit overrides the horizontal fit of the left and right edges
+	Fit panel.FitOptions
+	// Corners overrides the format's corner radius for individual corners
+	Corners panel.Corners
+	// Notches are cut into the top and bottom edges of the panel
+	Notches []panel.Notch
 }
 
 // NewEurorack constructs a new Eurorack object
@@ -79,6 +106,53 @@ func NewEurorack(hp int) *Eurorack {
 	return &Eurorack{HP: hp}
 }
 
+// rows returns the number of 3U rows spanned by the panel
+func (e Eurorack) rows() int {
+	if e.Rows < 1 {
+		return 1
+	}
+	return e.Rows
+}
+
+// Regions returns each 3U row spanned by the panel, from bottom to top
+func (e Eurorack) Regions() []panel.Region {
+	regions := []panel.Region{}
+	for r := 0; r < e.rows(); r++ {
+		y := float64(r) * RowPitch3U
+		regions = append(regions, panel.Region{
+			Name:                       fmt.Sprintf("row %d", r+1),
+			MountingHoleBottomY:        MountingHoleBottomY3U + y,
+			MountingHoleTopY:           MountingHoleTopY3U + y,
+			RailHeightFromMountingHole: RailHeightFromMountingHole,
+		})
+	}
+	return regions
+}
+
+// Name returns the name of the panel format, including the number of rows
+// for tall panels
+func (e Eurorack) Name() string {
+	if e.rows() > 1 {
+		return fmt.Sprintf("eurorack %dx3U", e.rows())
+	}
+	return "eurorack"
+}
+
+// Units returns the unit the panel width is given in
+func (e Eurorack) Units() string {
+	return "hp"
+}
+
+// Thickness returns the thickness of the panel, in millimetres
+func (e Eurorack) Thickness() float64 {
+	return PanelThickness
+}
+
+// WidthUnits returns the panel width in Units
+func (e Eurorack) WidthUnits() float64 {
+	return float64(e.HP)
+}
+
 // Width returns the width of a Eurorack panel, in millimetres
 func (e Eurorack) Width() float64 {
 	if e.HP == 1 {
@@ -90,19 +164,20 @@ func (e Eurorack) Width() float64 {
 	return HP * float64(e.HP)
 }
 
-// Height returns the height of a Eurorack panel, in millimetres
+// Height returns the height of a Eurorack panel, in millimetres. Tall
+// panels also cover the gaps between the rows they span.
 func (e Eurorack) Height() float64 {
-	return PanelHeight3U
+	return RowPitch3U*float64(e.rows()-1) + PanelHeight3U
 }
 
-// MountingHoleDiameter returns the Eurorack system mounting hole size, in
-// millimetres
+// MountingHoleDiameter returns the Eurorack system mounting hole size, or
+// its override, in millimetres
 func (e Eurorack) MountingHoleDiameter() float64 {
-	return MountingHoleDiameter
+	return e.Holes.HoleDiameter(MountingHoleDiameter)
 }
 
 // MountingHoles generates a set of Point objects representing the mounting
-// hole locations of a Eurorack panel
+// hole locations of a Eurorack panel, repeated for each row it spans
 func (e Eurorack) MountingHoles() []geometry.Point {
 	lhsx := MountingHolesLeftOffset
 	// special case; 1HP Eurorack panels are narrower than MountingHolesLeftOffset.
@@ -116,21 +191,18 @@ func (e Eurorack) MountingHoles() []geometry.Point {
 	if e.HP == 1 {
 		lhsx = e.Width() / 2.0
 	}
-	holes := []geometry.Point{
-		geometry.Point{X: lhsx, Y: MountingHoleBottomY3U},
-		geometry.Point{X: lhsx, Y: MountingHoleTopY3U},
-	}
 	// mounting holes for wider panels
-	if e.HP > ExtraMountingHolesThreshold {
-		rhsx := MountingHolesLeftOffset + HP*(float64(e.HP-3))
-		holes = append(holes, geometry.Point{X: rhsx, Y: MountingHoleBottomY3U})
-		holes = append(holes, geometry.Point{X: rhsx, Y: MountingHoleTopY3U})
+	rhsx := MountingHolesLeftOffset + HP*(float64(e.HP-3))
+	wide := e.HP > ExtraMountingHolesThreshold
+	holes := []geometry.Point{}
+	for _, r := range e.Regions() {
+		holes = append(holes, e.Holes.Columns(lhsx, rhsx, wide, r.MountingHoleBottomY, r.MountingHoleTopY)...)
 	}
 	return holes
 }
 
-// HorizontalFit indicates the panel tolerance adjustment for the format
-func (e Eurorack) HorizontalFit() float64 {
+// fit returns the format's own panel tolerance adjustment
+func (e Eurorack) fit() float64 {
 	if e.HP == 1 {
 		// Special case: 1hp panels according to the Doepfer specification should
 		// be 5.00mm wide, and at this size, we don't have much room for error.
@@ -140,6 +212,24 @@ func (e Eurorack) HorizontalFit() float64 {
 	return HorizontalFit
 }
 
+// HorizontalFit indicates the panel tolerance adjustment for the format, or
+// its override
+func (e Eurorack) HorizontalFit() float64 {
+	return e.Fit.Total(e.fit())
+}
+
+// EdgeFits returns the amounts trimmed from the left and right edges of the
+// panel, which differ only if overridden
+func (e Eurorack) EdgeFits() (left, right float64) {
+	return e.Fit.Edges(e.fit())
+}
+
+// Outline returns the outline of the panel with its corner overrides and
+// notches, or nil if it has neither, selecting the default rectangle
+func (e Eurorack) Outline() []geometry.Point {
+	return panel.ShapedOutline(e, e.Corners, e.Notches)
+}
+
 // CornerRadius indicates the corner radius for the format
 func (e Eurorack) CornerRadius() float64 {
 	return CornerRadius
@@ -151,9 +241,9 @@ func (e Eurorack) RailHeightFromMountingHole() float64 {
 }
 
 // MountingHoleTopY returns the Y coordinate for the top row of mounting
-// holes
+// holes, in the uppermost 3U row
 func (e Eurorack) MountingHoleTopY() float64 {
-	return MountingHoleTopY3U
+	return MountingHoleTopY3U + RowPitch3U*float64(e.rows()-1)
 }
 
 // MountingHoleBottomY returns the Y coordinate for the bottom row of
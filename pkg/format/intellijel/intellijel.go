@@ -24,6 +24,7 @@ package intellijel
 import (
 	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
 	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
 )
 
 // based on https://intellijel.com/support/1u-technical-specifications/
@@ -32,6 +33,9 @@ const (
 	// millimetres
 	PanelHeight1U = 39.65
 
+	// RowPitch1U is the height of one 1U rack row, in millimetres
+	RowPitch1U = 44.45
+
 	// ExtraMountingHolesThreshold represents the panel width threshold beyond
 	// which additional mounting holes are required
 	ExtraMountingHolesThreshold = 6
@@ -55,6 +59,10 @@ const (
 	// HP represents horizontal pitch in a Eurorack frame, in millimetres
 	HP = eurorack.HP
 
+	// MaxHP represents the widest panel accepted, in HP: the 1U row of
+	// Intellijel's widest cases
+	MaxHP = 104
+
 	// HorizontalFit indicates the panel tolerance adjustment for the format
 	HorizontalFit = 0.25
 
@@ -62,6 +70,10 @@ const (
 	// believe in such things.
 	CornerRadius = 0.0
 
+	// PanelThickness is the thickness of a panel made as a PCB, in
+	// millimetres
+	PanelThickness = 1.6
+
 	// RailHeightFromMountingHole is used to determine how much space exists.
 	// See discussion in github.com/jsleeio/pkg/panel. 5mm is a good safe
 	// figure for all known-used Eurorack rail types
@@ -72,6 +84,14 @@ const (
 // characteristics of a Intellijel panel
 type Intellijel struct {
 	HP int
+	// Holes overrides the mounting hole count and positions
+	Holes panel.HoleOptions
+	// Fit overrides the horizontal fit of the left and right edges
+	Fit panel.FitOptions
+	// Corners overrides the format's corner radius for individual corners
+	Corners panel.Corners
+	// Notches are cut into the top and bottom edges of the panel
+	Notches []panel.Notch
 }
 
 // NewIntellijel constructs a new Intellijel object
@@ -79,6 +99,26 @@ func NewIntellijel(hp int) *Intellijel {
 	return &Intellijel{HP: hp}
 }
 
+// Name returns the name of the panel format
+func (i Intellijel) Name() string {
+	return "intellijel"
+}
+
+// Units returns the unit the panel width is given in
+func (i Intellijel) Units() string {
+	return "hp"
+}
+
+// Thickness returns the thickness of the panel, in millimetres
+func (i Intellijel) Thickness() float64 {
+	return PanelThickness
+}
+
+// WidthUnits returns the panel width in Units
+func (i Intellijel) WidthUnits() float64 {
+	return float64(i.HP)
+}
+
 // Width returns the width of a Intellijel panel, in millimetres
 func (i Intellijel) Width() float64 {
 	if i.HP == 1 {
@@ -95,10 +135,10 @@ func (i Intellijel) Height() float64 {
 	return PanelHeight1U
 }
 
-// MountingHoleDiameter returns the Intellijel system mounting hole size, in
-// millimetres
+// MountingHoleDiameter returns the Intellijel system mounting hole size, or
+// its override, in millimetres
 func (i Intellijel) MountingHoleDiameter() float64 {
-	return MountingHoleDiameter
+	return i.Holes.HoleDiameter(MountingHoleDiameter)
 }
 
 // MountingHoles generates a set of Point objects representing the mounting
@@ -116,20 +156,13 @@ func (i Intellijel) MountingHoles() []geometry.Point {
 	if i.HP == 1 {
 		lhsx = i.Width() / 2.0
 	}
-	holes := []geometry.Point{
-		{X: lhsx, Y: MountingHoleBottomY1U},
-		{X: lhsx, Y: MountingHoleTopY1U},
-	}
-	if i.HP > ExtraMountingHolesThreshold {
-		rhsx := MountingHolesLeftOffset + HP*(float64(i.HP-3))
-		holes = append(holes, geometry.Point{X: rhsx, Y: MountingHoleBottomY1U})
-		holes = append(holes, geometry.Point{X: rhsx, Y: MountingHoleTopY1U})
-	}
-	return holes
+	rhsx := MountingHolesLeftOffset + HP*(float64(i.HP-3))
+	wide := i.HP > ExtraMountingHolesThreshold
+	return i.Holes.Columns(lhsx, rhsx, wide, MountingHoleBottomY1U, MountingHoleTopY1U)
 }
 
-// HorizontalFit indicates the panel tolerance adjustment for the format
-func (i Intellijel) HorizontalFit() float64 {
+// fit returns the format's own panel tolerance adjustment
+func (i Intellijel) fit() float64 {
 	if i.HP == 1 {
 		// Special case: 1hp panels according to the Doepfer specification should
 		// be 5.00mm wide, and at this size, we don't have much room for error.
@@ -139,6 +172,24 @@ func (i Intellijel) HorizontalFit() float64 {
 	return HorizontalFit
 }
 
+// HorizontalFit indicates the panel tolerance adjustment for the format, or
+// its override
+func (i Intellijel) HorizontalFit() float64 {
+	return i.Fit.Total(i.fit())
+}
+
+// EdgeFits returns the amounts trimmed from the left and right edges of the
+// panel, which differ only if overridden
+func (i Intellijel) EdgeFits() (left, right float64) {
+	return i.Fit.Edges(i.fit())
+}
+
+// Outline returns the outline of the panel with its corner overrides and
+// notches, or nil if it has neither, selecting the default rectangle
+func (i Intellijel) Outline() []geometry.Point {
+	return panel.ShapedOutline(i, i.Corners, i.Notches)
+}
+
 // CornerRadius indicates the corner radius for the format
 func (i Intellijel) CornerRadius() float64 {
 	return CornerRadius
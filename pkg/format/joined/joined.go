@@ -0,0 +1,214 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package joined implements a composite panel format, stacking a Eurorack 3U
+// region and an Intellijel 1U region into one physical panel, as fits rows of
+// Intellijel's 7U cases
+package joined
+
+import (
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/format/intellijel"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+const (
+	// Gap3U and Gap1U are the spaces left above and below a 3U or 1U panel
+	// within its rack row, in millimetres. The joined panel also covers the
+	// gaps between its two rows.
+	Gap3U = (eurorack.RowPitch3U - eurorack.PanelHeight3U) / 2
+	Gap1U = (intellijel.RowPitch1U - intellijel.PanelHeight1U) / 2
+
+	// PanelHeight represents the total height of a joined panel, in
+	// millimetres: two adjacent rack rows, less the outer gaps
+	PanelHeight = eurorack.PanelHeight3U + Gap3U + Gap1U + intellijel.PanelHeight1U
+
+	// CornerRadius indicates the corner radius for the format
+	CornerRadius = 0.0
+
+	// PanelThickness is the thickness of a panel made as a PCB, in
+	// millimetres
+	PanelThickness = eurorack.PanelThickness
+
+	// MaxHP represents the widest panel accepted, in HP, limited by the 1U
+	// region
+	MaxHP = intellijel.MaxHP
+)
+
+// Joined implements the panel.Panel interface and encapsulates the physical
+// characteristics of a panel spanning a 3U row and an adjacent 1U row
+type Joined struct {
+	HP int
+	// Below places the 1U region below the 3U region, rather than above it
+	Below bool
+	// Holes overrides the mounting hole count and positions of both regions
+	Holes panel.HoleOptions
+	// Fit overrides the horizontal fit of the left and right edges
+	Fit panel.FitOptions
+	// Corners overrides the format's corner radius for individual corners
+	Corners panel.Corners
+	// Notches are cut into the top and bottom edges of the panel
+	Notches []panel.Notch
+}
+
+// NewJoined constructs a new Joined object with the 1U region above the 3U
+// region
+func NewJoined(hp int) *Joined {
+	return &Joined{HP: hp}
+}
+
+// rows returns the 3U and 1U panels making up the joined panel, and the Y
+// offset of each from the bottom edge
+func (j Joined) rows() (threeU panel.Panel, threeUY float64, oneU panel.Panel, oneUY float64) {
+	threeU = &eurorack.Eurorack{HP: j.HP, Holes: j.Holes}
+	oneU = &intellijel.Intellijel{HP: j.HP, Holes: j.Holes}
+	if j.Below {
+		return threeU, oneU.Height() + Gap1U + Gap3U, oneU, 0
+	}
+	return threeU, 0, oneU, threeU.Height() + Gap3U + Gap1U
+}
+
+// Regions returns the 3U and 1U regions of the panel, from bottom to top
+func (j Joined) Regions() []panel.Region {
+	threeU, threeUY, oneU, oneUY := j.rows()
+	regions := []panel.Region{
+		region("3U", threeU, threeUY),
+		region("1U", oneU, oneUY),
+	}
+	if j.Below {
+		regions[0], regions[1] = regions[1], regions[0]
+	}
+	return regions
+}
+
+// region describes a row panel offset by y as a panel.Region
+func region(name string, p panel.Panel, y float64) panel.Region {
+	return panel.Region{
+		Name:                       name,
+		MountingHoleBottomY:        p.MountingHoleBottomY() + y,
+		MountingHoleTopY:           p.MountingHoleTopY() + y,
+		RailHeightFromMountingHole: p.RailHeightFromMountingHole(),
+	}
+}
+
+// Name returns the name of the panel format
+func (j Joined) Name() string {
+	return "joined"
+}
+
+// Units returns the unit the panel width is given in
+func (j Joined) Units() string {
+	return "hp"
+}
+
+// Thickness returns the thickness of the panel, in millimetres
+func (j Joined) Thickness() float64 {
+	return PanelThickness
+}
+
+// WidthUnits returns the panel width in Units
+func (j Joined) WidthUnits() float64 {
+	return float64(j.HP)
+}
+
+// Width returns the width of a joined panel, in millimetres
+func (j Joined) Width() float64 {
+	return eurorack.Eurorack{HP: j.HP}.Width()
+}
+
+// Height returns the height of a joined panel, in millimetres
+func (j Joined) Height() float64 {
+	return PanelHeight
+}
+
+// MountingHoleDiameter returns the mounting hole size, or its override, in
+// millimetres. Both formats use the same screws.
+func (j Joined) MountingHoleDiameter() float64 {
+	return j.Holes.HoleDiameter(eurorack.MountingHoleDiameter)
+}
+
+// MountingHoles generates a set of Point objects representing the mounting
+// hole locations of both regions of the panel
+func (j Joined) MountingHoles() []geometry.Point {
+	threeU, threeUY, oneU, oneUY := j.rows()
+	holes := []geometry.Point{}
+	for _, h := range threeU.MountingHoles() {
+		holes = append(holes, h.Add(geometry.Point{Y: threeUY}))
+	}
+	for _, h := range oneU.MountingHoles() {
+		holes = append(holes, h.Add(geometry.Point{Y: oneUY}))
+	}
+	return holes
+}
+
+// HorizontalFit indicates the panel tolerance adjustment for the format, or
+// its override
+func (j Joined) HorizontalFit() float64 {
+	return eurorack.Eurorack{HP: j.HP, Fit: j.Fit}.HorizontalFit()
+}
+
+// EdgeFits returns the amounts trimmed from the left and right edges of the
+// panel, which differ only if overridden
+func (j Joined) EdgeFits() (left, right float64) {
+	return eurorack.Eurorack{HP: j.HP, Fit: j.Fit}.EdgeFits()
+}
+
+// Outline returns the outline of the panel with its corner overrides and
+// notches, or nil if it has neither, selecting the default rectangle
+func (j Joined) Outline() []geometry.Point {
+	return panel.ShapedOutline(j, j.Corners, j.Notches)
+}
+
+// CornerRadius indicates the corner radius for the format
+func (j Joined) CornerRadius() float64 {
+	return CornerRadius
+}
+
+// RailHeightFromMountingHole is used to calculate space between rails. Both
+// formats share Eurorack rails.
+func (j Joined) RailHeightFromMountingHole() float64 {
+	return eurorack.RailHeightFromMountingHole
+}
+
+// MountingHoleTopY returns the Y coordinate for the top row of mounting
+// holes of the upper region
+func (j Joined) MountingHoleTopY() float64 {
+	regions := j.Regions()
+	return regions[len(regions)-1].MountingHoleTopY
+}
+
+// MountingHoleBottomY returns the Y coordinate for the bottom row of
+// mounting holes of the lower region
+func (j Joined) MountingHoleBottomY() float64 {
+	return j.Regions()[0].MountingHoleBottomY
+}
+
+// HeaderLocation returns the location of the header text, aligned with the
+// top mounting screws
+func (j Joined) HeaderLocation() geometry.Point {
+	return geometry.Point{X: j.Width() / 2, Y: j.MountingHoleTopY()}
+}
+
+// FooterLocation returns the location of the footer text, aligned with the
+// bottom mounting screws
+func (j Joined) FooterLocation() geometry.Point {
+	return geometry.Point{X: j.Width() / 2, Y: j.MountingHoleBottomY()}
+}
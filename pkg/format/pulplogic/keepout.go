@@ -0,0 +1,153 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package pulplogic
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// KeepoutLineThickness is the line thickness of the keepout boundaries
+// drawn by KeepoutFeatures, in millimetres, as for dimensions
+const KeepoutLineThickness = 0.1
+
+// Keepouts returns the bands along the bottom and top of a tile where it
+// sits on the rails, extending RailHeightFromMountingHole beyond the
+// mounting hole centres. Hardware behind the tile must stay clear of them.
+func (p Pulplogic) Keepouts() []geometry.Rect {
+	bl, tr := panel.BottomLeft(p), panel.TopRight(p)
+	usable := panel.UsableArea(p)
+	return []geometry.Rect{
+		{BottomLeft: bl, TopRight: geometry.Point{X: tr.X, Y: usable.BottomLeft.Y}},
+		{BottomLeft: geometry.Point{X: bl.X, Y: usable.TopRight.Y}, TopRight: tr},
+	}
+}
+
+// PCBArea returns the area of the tile covered by the recommended maximum
+// PCB behind it, as described by RearPCB
+func (p Pulplogic) PCBArea() geometry.Rect {
+	pcb := RearPCB{HP: p.HP}
+	return geometry.Rect{
+		BottomLeft: pcb.Offset(),
+		TopRight:   pcb.Offset().Add(geometry.Point{X: pcb.Width(), Y: pcb.Height()}),
+	}
+}
+
+// rectangle returns Annotation lines around a rectangle
+func rectangle(r geometry.Rect) []features.Feature {
+	corners := []geometry.Point{r.BottomLeft, {X: r.TopRight.X, Y: r.BottomLeft.Y}, r.TopRight, {X: r.BottomLeft.X, Y: r.TopRight.Y}}
+	feats := []features.Feature{}
+	for i, c := range corners {
+		line := features.NewLine(c, corners[(i+1)%len(corners)], KeepoutLineThickness)
+		line.SetPurpose(features.Annotation)
+		feats = append(feats, line)
+	}
+	return feats
+}
+
+// KeepoutFeatures generates Annotation features documenting the vertical
+// budget of a tile: the inner edges of the rail keepouts, and the outline of
+// the recommended PCB area between them
+func KeepoutFeatures(p Pulplogic) []features.Feature {
+	feats := []features.Feature{}
+	k := p.Keepouts()
+	for _, y := range []float64{k[0].TopRight.Y, k[1].BottomLeft.Y} {
+		line := features.NewLine(geometry.Point{X: panel.LeftX(p), Y: y}, geometry.Point{X: panel.RightX(p), Y: y}, KeepoutLineThickness)
+		line.SetPurpose(features.Annotation)
+		feats = append(feats, line)
+	}
+	return append(feats, rectangle(p.PCBArea())...)
+}
+
+// extent returns the vertical extent of a cutout or of an annotation on the
+// rear of a tile, such as a component body courtyard, and false for other
+// features and those whose extent is not known
+func extent(f features.Feature) (bottom, top float64, ok bool) {
+	rear := false
+	if s, sided := f.(features.Sided); sided {
+		rear = s.GetSide() == features.BottomSide && f.GetPurpose() == features.Annotation
+	}
+	if f.GetPurpose() != features.Cutout && !rear {
+		return 0, 0, false
+	}
+	switch v := f.(type) {
+	case *features.Circle:
+		return v.Origin.Y - v.Radius, v.Origin.Y + v.Radius, true
+	case *features.Line:
+		return math.Min(v.Start.Y, v.End.Y) - v.Thickness/2, math.Max(v.Start.Y, v.End.Y) + v.Thickness/2, true
+	case *features.Polygon:
+		bottom, top = math.Inf(1), math.Inf(-1)
+		for _, pt := range v.Points {
+			bottom, top = math.Min(bottom, pt.Y), math.Max(top, pt.Y)
+		}
+		return bottom, top, true
+	}
+	return 0, 0, false
+}
+
+// describe returns a short description of a feature for diagnostics
+func describe(f features.Feature) string {
+	c, ok := f.(*features.Circle)
+	switch {
+	case ok && c.Purpose == features.Cutout:
+		return fmt.Sprintf("%.2fmm hole at (%.2f, %.2f)", c.Radius*2, c.Origin.X, c.Origin.Y)
+	case ok:
+		return fmt.Sprintf("%.2fmm rear courtyard at (%.2f, %.2f)", c.Radius*2, c.Origin.X, c.Origin.Y)
+	}
+	return fmt.Sprint(f)
+}
+
+// CheckKeepouts reports a Warning for each cutout and each annotation on the
+// rear of a tile that encroaches on its rail keepouts, such as the hole or
+// body courtyard of a component too close to the top or bottom edge. The
+// mounting holes are expected there, and are not reported, and only the
+// first of several circles sharing a centre, such as a component's hole and
+// body, is reported.
+func (p Pulplogic) CheckKeepouts(feats []features.Feature, r diag.Reporter) {
+	k := p.Keepouts()
+	seen := map[geometry.Point]bool{}
+	for _, h := range p.MountingHoles() {
+		seen[h] = true
+	}
+	for _, f := range feats {
+		bottom, top, ok := extent(f)
+		if !ok || (bottom >= k[0].TopRight.Y && top <= k[1].BottomLeft.Y) {
+			continue
+		}
+		if c, ok := f.(*features.Circle); ok {
+			if seen[c.Origin] {
+				continue
+			}
+			seen[c.Origin] = true
+		}
+		if bottom < k[0].TopRight.Y {
+			diag.Warnf(r, f, "%s encroaches %.2fmm into the bottom rail keepout of the %s", describe(f), k[0].TopRight.Y-bottom, panel.Description(p))
+		}
+		if top > k[1].BottomLeft.Y {
+			diag.Warnf(r, f, "%s encroaches %.2fmm into the top rail keepout of the %s", describe(f), top-k[1].BottomLeft.Y, panel.Description(p))
+		}
+	}
+}
@@ -24,12 +24,13 @@ package pulplogic
 import (
 	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
 	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
 )
 
 // based on http://pulplogic.com/1u_tiles/
 
 const (
-	inch = 25.4
+	inch = geometry.Inch
 
 	// PanelHeight1U represents the total height of a Pulplogic panel, in
 	// millimetres
@@ -62,6 +63,10 @@ const (
 	// HP represents horizontal pitch in a Eurorack frame, in millimetres
 	HP = eurorack.HP
 
+	// MaxHP represents the widest tile accepted, in HP: a full 19-inch rack
+	// row
+	MaxHP = 84
+
 	// HorizontalFit indicates the panel tolerance adjustment for the format
 	HorizontalFit = eurorack.HorizontalFit
 
@@ -69,6 +74,10 @@ const (
 	// believe in such things.
 	CornerRadius = 0.0
 
+	// PanelThickness is the thickness of a panel made as a PCB, in
+	// millimetres
+	PanelThickness = eurorack.PanelThickness
+
 	// RailHeightFromMountingHole is used to determine how much space exists.
 	// See discussion in github.com/jsleeio/pkg/panel.
 	//
@@ -83,6 +92,14 @@ const (
 // characteristics of a Pulplogic panel
 type Pulplogic struct {
 	HP int
+	// Holes overrides the mounting hole count and positions
+	Holes panel.HoleOptions
+	// Fit overrides the horizontal fit of the left and right edges
+	Fit panel.FitOptions
+	// Corners overrides the format's corner radius for individual corners
+	Corners panel.Corners
+	// Notches are cut into the top and bottom edges of the panel
+	Notches []panel.Notch
 }
 
 // NewPulplogic constructs a new Pulplogic object
@@ -90,6 +107,26 @@ func NewPulplogic(hp int) *Pulplogic {
 	return &Pulplogic{HP: hp}
 }
 
+// Name returns the name of the panel format
+func (p Pulplogic) Name() string {
+	return "pulplogic"
+}
+
+// Units returns the unit the panel width is given in
+func (p Pulplogic) Units() string {
+	return "hp"
+}
+
+// Thickness returns the thickness of the panel, in millimetres
+func (p Pulplogic) Thickness() float64 {
+	return PanelThickness
+}
+
+// WidthUnits returns the panel width in Units
+func (p Pulplogic) WidthUnits() float64 {
+	return float64(p.HP)
+}
+
 // Width returns the width of a Pulplogic panel, in millimetres
 func (p Pulplogic) Width() float64 {
 	if p.HP == 1 {
@@ -106,10 +143,10 @@ func (p Pulplogic) Height() float64 {
 	return PanelHeight1U
 }
 
-// MountingHoleDiameter returns thp Pulplogic system mounting hole size, in
-// millimetres
+// MountingHoleDiameter returns thp Pulplogic system mounting hole size, or
+// its override, in millimetres
 func (p Pulplogic) MountingHoleDiameter() float64 {
-	return MountingHoleDiameter
+	return p.Holes.HoleDiameter(MountingHoleDiameter)
 }
 
 // MountingHoles generates a set of Point objects representing the mounting
@@ -127,20 +164,13 @@ func (p Pulplogic) MountingHoles() []geometry.Point {
 	if p.HP == 1 {
 		lhsx = p.Width() / 2.0
 	}
-	holes := []geometry.Point{
-		{X: lhsx, Y: MountingHoleBottomY1U},
-		{X: lhsx, Y: MountingHoleTopY1U},
-	}
-	if p.HP > ExtraMountingHolesThreshold {
-		rhsx := p.Width() - MountingHolesRightOffset
-		holes = append(holes, geometry.Point{X: rhsx, Y: MountingHoleBottomY1U})
-		holes = append(holes, geometry.Point{X: rhsx, Y: MountingHoleTopY1U})
-	}
-	return holes
+	rhsx := p.Width() - MountingHolesRightOffset
+	wide := p.HP > ExtraMountingHolesThreshold
+	return p.Holes.Columns(lhsx, rhsx, wide, MountingHoleBottomY1U, MountingHoleTopY1U)
 }
 
-// HorizontalFit indicates the panel tolerance adjustment for the format
-func (p Pulplogic) HorizontalFit() float64 {
+// fit returns the format's own panel tolerance adjustment
+func (p Pulplogic) fit() float64 {
 	if p.HP == 1 {
 		// Special case: 1hp panels according to the Doepfer specification should
 		// be 5.00mm wide, and at this size, we don't have much room for error.
@@ -150,6 +180,24 @@ func (p Pulplogic) HorizontalFit() float64 {
 	return HorizontalFit
 }
 
+// HorizontalFit indicates the panel tolerance adjustment for the format, or
+// its override
+func (p Pulplogic) HorizontalFit() float64 {
+	return p.Fit.Total(p.fit())
+}
+
+// EdgeFits returns the amounts trimmed from the left and right edges of the
+// panel, which differ only if overridden
+func (p Pulplogic) EdgeFits() (left, right float64) {
+	return p.Fit.Edges(p.fit())
+}
+
+// Outline returns the outline of the panel with its corner overrides and
+// notches, or nil if it has neither, selecting the default rectangle
+func (p Pulplogic) Outline() []geometry.Point {
+	return panel.ShapedOutline(p, p.Corners, p.Notches)
+}
+
 // CornerRadius indicates the corner radius for the format
 func (p Pulplogic) CornerRadius() float64 {
 	return CornerRadius
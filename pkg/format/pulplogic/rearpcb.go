@@ -0,0 +1,164 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package pulplogic
+
+import (
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+const (
+	// RearPCBMaxHeight represents the recommended maximum height of a PCB
+	// mounted behind a Pulplogic tile, in millimetres. It fits between the
+	// rail keepout areas; see RailHeightFromMountingHole.
+	RearPCBMaxHeight = 1.130 * inch
+
+	// RearPCBSideClearance represents the gap left between each side of a
+	// rear PCB and the edge of the tile, so that PCBs behind adjacent tiles
+	// do not touch, in millimetres
+	RearPCBSideClearance = 0.5
+
+	// RearPCBHoleInset represents the distance of the rear PCB mounting
+	// hole centres from its top and bottom edges, in millimetres
+	RearPCBHoleInset = 0.125 * inch
+
+	// RearPCBHoleDiameter represents the diameter of a rear PCB mounting
+	// hole, sized for M3 standoffs, in millimetres
+	RearPCBHoleDiameter = 3.2
+)
+
+// RearPCB implements the panel.Panel interface and encapsulates the
+// recommended outline and mounting hole positions of a circuit board behind a
+// Pulplogic tile. Coordinates are relative to the bottom-left corner of the
+// board; Offset locates it behind the tile.
+type RearPCB struct {
+	HP int
+}
+
+// NewRearPCB constructs a new RearPCB object for a tile of the given width
+func NewRearPCB(hp int) *RearPCB {
+	return &RearPCB{HP: hp}
+}
+
+// tile returns the tile the board is mounted behind
+func (r RearPCB) tile() Pulplogic {
+	return Pulplogic{HP: r.HP}
+}
+
+// Offset returns the position of the bottom-left corner of the board in the
+// coordinates of the tile, with the board centred between the rails
+func (r RearPCB) Offset() geometry.Point {
+	return geometry.Point{
+		X: panel.LeftX(r.tile()) + RearPCBSideClearance,
+		Y: (PanelHeight1U - RearPCBMaxHeight) / 2,
+	}
+}
+
+// Name returns the name of the panel format
+func (r RearPCB) Name() string {
+	return "pulplogic-pcb"
+}
+
+// Units returns the unit the panel width is given in
+func (r RearPCB) Units() string {
+	return "hp"
+}
+
+// Thickness returns the thickness of the board, in millimetres
+func (r RearPCB) Thickness() float64 {
+	return PanelThickness
+}
+
+// WidthUnits returns the width of the tile the board is mounted behind, in
+// Units
+func (r RearPCB) WidthUnits() float64 {
+	return float64(r.HP)
+}
+
+// Width returns the width of the board, in millimetres
+func (r RearPCB) Width() float64 {
+	t := r.tile()
+	return panel.RightX(t) - panel.LeftX(t) - 2*RearPCBSideClearance
+}
+
+// Height returns the height of the board, in millimetres
+func (r RearPCB) Height() float64 {
+	return RearPCBMaxHeight
+}
+
+// MountingHoleDiameter returns the board mounting hole size, in millimetres
+func (r RearPCB) MountingHoleDiameter() float64 {
+	return RearPCBHoleDiameter
+}
+
+// MountingHoles generates a set of Point objects representing the board
+// mounting hole locations: a centred column for narrow tiles, or a column
+// near each side for wide tiles
+func (r RearPCB) MountingHoles() []geometry.Point {
+	lhsx := r.Width() / 2
+	rhsx := r.Width() - RearPCBHoleInset
+	wide := r.HP > ExtraMountingHolesThreshold
+	if wide {
+		lhsx = RearPCBHoleInset
+	}
+	return panel.HoleOptions{}.Columns(lhsx, rhsx, wide, r.MountingHoleBottomY(), r.MountingHoleTopY())
+}
+
+// HorizontalFit indicates the board tolerance adjustment. The side clearance
+// is already accounted for in Width.
+func (r RearPCB) HorizontalFit() float64 {
+	return 0
+}
+
+// CornerRadius indicates the corner radius of the board
+func (r RearPCB) CornerRadius() float64 {
+	return CornerRadius
+}
+
+// RailHeightFromMountingHole returns the keepout distance around the board
+// mounting holes, leaving room for standoffs and their nuts
+func (r RearPCB) RailHeightFromMountingHole() float64 {
+	return RearPCBHoleInset
+}
+
+// MountingHoleTopY returns the Y coordinate for the top row of board
+// mounting holes
+func (r RearPCB) MountingHoleTopY() float64 {
+	return r.Height() - RearPCBHoleInset
+}
+
+// MountingHoleBottomY returns the Y coordinate for the bottom row of board
+// mounting holes
+func (r RearPCB) MountingHoleBottomY() float64 {
+	return RearPCBHoleInset
+}
+
+// HeaderLocation returns the location of the header text, aligned with the
+// top mounting holes
+func (r RearPCB) HeaderLocation() geometry.Point {
+	return geometry.Point{X: r.Width() / 2.0, Y: r.MountingHoleTopY()}
+}
+
+// FooterLocation returns the location of the footer text, aligned with the
+// bottom mounting holes
+func (r RearPCB) FooterLocation() geometry.Point {
+	return geometry.Point{X: r.Width() / 2.0, Y: r.MountingHoleBottomY()}
+}
@@ -0,0 +1,260 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Expr is a numeric spec value, written either as a plain number or as a
+// string containing an arithmetic expression, eg. "width/2 + 2hp". A number
+// immediately followed by a name multiplies them, so units can be written
+// naturally.
+type Expr string
+
+// UnmarshalYAML accepts both numbers and strings
+func (e *Expr) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	switch x := v.(type) {
+	case nil:
+		*e = ""
+	case int:
+		*e = Expr(strconv.Itoa(x))
+	case float64:
+		// not in exponent form, which expressions cannot parse
+		*e = Expr(strconv.FormatFloat(x, 'f', -1, 64))
+	case string:
+		*e = Expr(x)
+	default:
+		return fmt.Errorf("expected number or expression, found %v", v)
+	}
+	return nil
+}
+
+// env resolves names in expressions. Named expressions are evaluated on
+// first use, so they may refer to each other in any order.
+type env struct {
+	exprs     map[string]Expr
+	values    map[string]float64
+	resolving map[string]bool
+}
+
+func newEnv() *env {
+	e := &env{
+		exprs:     map[string]Expr{},
+		values:    map[string]float64{},
+		resolving: map[string]bool{},
+	}
+	// unit names are predefined for every spec
+	for name, v := range geometry.Units {
+		e.values[name] = v
+	}
+	return e
+}
+
+// lookup returns the value of a name
+func (e *env) lookup(name string) (float64, error) {
+	if v, ok := e.values[name]; ok {
+		return v, nil
+	}
+	x, ok := e.exprs[name]
+	if !ok {
+		return 0, fmt.Errorf("undefined name %q", name)
+	}
+	if e.resolving[name] {
+		return 0, fmt.Errorf("%q is defined in terms of itself", name)
+	}
+	e.resolving[name] = true
+	defer delete(e.resolving, name)
+	v, err := e.eval(x)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", name, err)
+	}
+	e.values[name] = v
+	return v, nil
+}
+
+// eval evaluates an expression. Empty expressions evaluate to zero.
+func (e *env) eval(x Expr) (float64, error) {
+	if strings.TrimSpace(string(x)) == "" {
+		return 0, nil
+	}
+	p := &exprParser{s: string(x), env: e}
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.skip(); p.i < len(p.s) {
+		return 0, fmt.Errorf("unexpected %q in expression %q", p.s[p.i:], p.s)
+	}
+	return v, nil
+}
+
+// exprParser is a recursive descent parser for arithmetic expressions:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = ("+" | "-") unary | primary
+//	primary = number [ name ] | name | "(" expr ")"
+type exprParser struct {
+	s   string
+	i   int
+	env *env
+}
+
+func (p *exprParser) skip() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// peek returns the next non-space character, or 0 at the end
+func (p *exprParser) peek() byte {
+	if p.skip(); p.i < len(p.s) {
+		return p.s[p.i]
+	}
+	return 0
+}
+
+func (p *exprParser) expr() (float64, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.i++
+		w, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if c == '+' {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+	return v, nil
+}
+
+func (p *exprParser) term() (float64, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for c := p.peek(); c == '*' || c == '/'; c = p.peek() {
+		p.i++
+		w, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		if c == '*' {
+			v *= w
+		} else {
+			if w == 0 {
+				return 0, fmt.Errorf("division by zero in expression %q", p.s)
+			}
+			v /= w
+		}
+	}
+	return v, nil
+}
+
+func (p *exprParser) unary() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.i++
+		v, err := p.unary()
+		return -v, err
+	case '+':
+		p.i++
+		return p.unary()
+	}
+	return p.primary()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c)
+}
+
+// name reads a name, if there is one at the current position
+func (p *exprParser) name() string {
+	start := p.i
+	if p.i < len(p.s) && isNameChar(p.s[p.i]) && !isDigit(p.s[p.i]) {
+		for p.i < len(p.s) && isNameChar(p.s[p.i]) {
+			p.i++
+		}
+	}
+	return p.s[start:p.i]
+}
+
+func (p *exprParser) primary() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.i++
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing ) in expression %q", p.s)
+		}
+		p.i++
+		return v, nil
+	case isDigit(c) || c == '.':
+		start := p.i
+		for p.i < len(p.s) && (isDigit(p.s[p.i]) || p.s[p.i] == '.') {
+			p.i++
+		}
+		v, err := strconv.ParseFloat(p.s[start:p.i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q in expression %q", p.s[start:p.i], p.s)
+		}
+		// a name directly following a number is a multiplier, eg. 2hp
+		if name := p.name(); name != "" {
+			u, err := p.env.lookup(name)
+			if err != nil {
+				return 0, err
+			}
+			v *= u
+		}
+		return v, nil
+	}
+	name := p.name()
+	if name == "" {
+		if c == 0 {
+			return 0, fmt.Errorf("unexpected end of expression %q", p.s)
+		}
+		return 0, fmt.Errorf("unexpected %q in expression %q", c, p.s)
+	}
+	return p.env.lookup(name)
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package spec
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TestEval checks the evaluation of expressions, including their
+// precedence, units and names
+func TestEval(t *testing.T) {
+	for _, tc := range []struct {
+		x    Expr
+		want float64
+	}{
+		{"", 0},
+		{"  ", 0},
+		{"12.5", 12.5},
+		{".5", 0.5},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"12 / 3 / 2", 2},
+		{"2 * 3 / 4", 1.5},
+		{"-3", -3},
+		{"--3", 3},
+		{"+3", 3},
+		{"-(1 + 2) * 2", -6},
+		{"2 - -1", 3},
+		{"4 * -2", -8},
+		{"2hp", 10.16},
+		{"1in", 25.4},
+		{"2cm + 3mm", 23},
+		{"100mil", 2.54},
+		{"72pt", 25.4},
+		{"hp * 2", 10.16},
+		{"width / 2", 20},
+		{"half + 1", 21},
+		{"width / 2 + 2hp", 30.16},
+		{"2width", 80},
+	} {
+		e := newEnv()
+		e.exprs["width"] = "40"
+		e.exprs["half"] = "width / 2"
+		got, err := e.eval(tc.x)
+		if err != nil {
+			t.Errorf("eval(%q): %v", tc.x, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("eval(%q) = %v, want %v", tc.x, got, tc.want)
+		}
+	}
+}
+
+// TestEvalErrors checks that malformed expressions are rejected with an
+// explanation
+func TestEvalErrors(t *testing.T) {
+	for _, tc := range []struct {
+		x    Expr
+		want string
+	}{
+		{"1 +", "unexpected end"},
+		{"(1 + 2", "missing )"},
+		{"1 + 2)", "unexpected \")\""},
+		{"1 / 0", "division by zero"},
+		{"1 / (2 - 2)", "division by zero"},
+		{"depth * 2", "undefined name \"depth\""},
+		{"3furlongs", "undefined name \"furlongs\""},
+		{"1..2", "invalid number"},
+		{"1 # 2", "unexpected"},
+		{"loop + 1", "defined in terms of itself"},
+	} {
+		e := newEnv()
+		e.exprs["loop"] = "other * 2"
+		e.exprs["other"] = "loop"
+		_, err := e.eval(tc.x)
+		if err == nil {
+			t.Errorf("eval(%q) succeeded, want an error", tc.x)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("eval(%q) = %q, want an error containing %q", tc.x, err, tc.want)
+		}
+	}
+}
+
+// TestExprUnmarshal checks that YAML numbers of any size are read as
+// expressions that evaluate to the same number
+func TestExprUnmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		yaml string
+		want float64
+	}{
+		{"v: 3", 3},
+		{"v: 12.5", 12.5},
+		{"v: -0.25", -0.25},
+		{"v: 0.00001", 0.00001},
+		{"v: 1e-7", 1e-7},
+		{"v: 2.5e+21", 2.5e21},
+		{"v: '2hp + 1'", 11.16},
+		{"v:", 0},
+	} {
+		var doc struct {
+			V Expr `yaml:"v"`
+		}
+		if err := yaml.Unmarshal([]byte(tc.yaml), &doc); err != nil {
+			t.Errorf("%s: %v", tc.yaml, err)
+			continue
+		}
+		got, err := newEnv().eval(doc.V)
+		if err != nil {
+			t.Errorf("%s: %v", tc.yaml, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-9*math.Max(1, math.Abs(tc.want)) {
+			t.Errorf("%s: got %v, want %v", tc.yaml, got, tc.want)
+		}
+	}
+}
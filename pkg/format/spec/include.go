@@ -0,0 +1,153 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package spec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A spec may be based on other specs, so that a family of panels can share
+// common settings and override only what differs. A spec names a single
+// parent with extends, and any number of further specs with include:
+//
+//	extends: base.yaml
+//	include: [rails.yaml, holes.yaml]
+//
+// The parent is applied first, then each include in order, then the spec
+// itself, so a base spec can give the header, footer, logo and decorations
+// shared by the family. Variables are merged by name, so a spec may override
+// a single variable of its parent; any other value, including the list of
+// mounting holes, replaces the inherited one outright. Relative paths are
+// resolved against the directory of the spec naming them.
+
+// maxIncludeDepth limits the nesting of extends and include, as a backstop
+// against runaway chains of generated specs
+const maxIncludeDepth = 32
+
+// resolve reads the spec in yamltext, which was read from filename, and
+// returns it merged over its extends and include specs. Seen holds the
+// absolute paths of the specs currently being resolved, to detect cycles.
+func resolve(filename string, yamltext []byte, seen []string) (rawSpec, error) {
+	var raw rawSpec
+	if len(seen) > maxIncludeDepth {
+		return raw, fmt.Errorf("%s: extends and include nested more than %d deep", filename, maxIncludeDepth)
+	}
+	if err := yaml.Unmarshal(yamltext, &raw); err != nil {
+		return raw, fmt.Errorf("%s: %v", filename, err)
+	}
+	// the logo is inherited along with the spec, so it is found relative
+	// to the spec naming it like extends and include
+	if raw.Logo != nil && raw.Logo.File != "" && !filepath.IsAbs(raw.Logo.File) {
+		raw.Logo.File = filepath.Join(filepath.Dir(filename), raw.Logo.File)
+	}
+	parents := raw.Include
+	if raw.Extends != "" {
+		parents = append([]string{raw.Extends}, parents...)
+	}
+	base := rawSpec{}
+	files := []string{filename}
+	for _, p := range parents {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(filename), p)
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return raw, err
+		}
+		for _, s := range seen {
+			if s == abs {
+				return raw, fmt.Errorf("%s: %s includes itself", filename, p)
+			}
+		}
+		text, err := ioutil.ReadFile(p)
+		if err != nil {
+			return raw, fmt.Errorf("%s: %v", filename, err)
+		}
+		parent, err := resolve(p, text, append(seen[:len(seen):len(seen)], abs))
+		if err != nil {
+			return raw, err
+		}
+		base.merge(parent)
+		files = append(files, parent.files...)
+	}
+	base.merge(raw)
+	base.files = files
+	return base, nil
+}
+
+// merge overrides the values of r with those set in src, merging variables
+// by name
+func (r *rawSpec) merge(src rawSpec) {
+	if src.Name != "" {
+		r.Name = src.Name
+	}
+	if len(src.Variables) > 0 && r.Variables == nil {
+		r.Variables = map[string]Expr{}
+	}
+	for name, x := range src.Variables {
+		r.Variables[name] = x
+	}
+	for _, f := range []struct {
+		dst *Expr
+		src Expr
+	}{
+		{&r.Width, src.Width},
+		{&r.Height, src.Height},
+		{&r.MountingHoleDiameter, src.MountingHoleDiameter},
+		{&r.HorizontalFit, src.HorizontalFit},
+		{&r.HorizontalFitLeft, src.HorizontalFitLeft},
+		{&r.HorizontalFitRight, src.HorizontalFitRight},
+		{&r.CornerRadius, src.CornerRadius},
+		{&r.Thickness, src.Thickness},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	if src.MountingHoles != nil {
+		r.MountingHoles = src.MountingHoles
+	}
+	if src.Outline != nil {
+		r.Outline = src.Outline
+	}
+	if src.Corners != nil {
+		r.Corners = src.Corners
+	}
+	if src.Notches != nil {
+		r.Notches = src.Notches
+	}
+	if src.Decorations != nil {
+		r.Decorations = src.Decorations
+	}
+	if src.Header != "" {
+		r.Header = src.Header
+	}
+	if src.Footer != "" {
+		r.Footer = src.Footer
+	}
+	if src.Logo != nil {
+		r.Logo = src.Logo
+	}
+}
@@ -20,17 +20,28 @@
 
 // Package spec provides a mechanism for specifying custom panel formats, eg.
 // to match an off-the-shelf jiffybox or other enclosure. Support is included
-// for reading a spec from a YAML file.
+// for reading a spec from a YAML file, in which numeric values may be
+// arithmetic expressions referring to variables and panel dimensions, and
+// which may extend or include other spec files. A spec may give an explicit
+// outline, eg. with notches for case hinges, replacing the rectangle, or
+// else rounded or chamfered corners overriding the corner radius, rectangular
+// notches to cut into the top and bottom edges, and zones of the panel to
+// fill with decorative patterns.
 package spec
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"math"
+	"path/filepath"
 	"sort"
 
-	"gopkg.in/yaml.v2"
-
+	"github.com/jsleeio/frontpanels/pkg/decor"
 	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+
+	"gopkg.in/yaml.v2"
 )
 
 // Spec implements the panel.Panel interface and encapsulates the physical
@@ -43,27 +54,430 @@ type Spec struct {
 	SpecMountingHoleDiameter float64          `yaml:"mountingHoleDiameter"`
 	SpecHorizontalFit        float64          `yaml:"horizontalFit"`
 	SpecCornerRadius         float64          `yaml:"cornerRadius"`
+	// SpecThickness is the thickness of the panel material, or zero for
+	// panel.DefaultThickness
+	SpecThickness float64 `yaml:"thickness"`
+	// SpecFit trims the left and right edges by different amounts, rather
+	// than half of SpecHorizontalFit from each
+	SpecFit panel.FitOptions `yaml:"-"`
+	// SpecOutline, if set, replaces the rectangular outline
+	SpecOutline []geometry.Point `yaml:"outline"`
+	// SpecDecorations are zones filled with decorative patterns
+	SpecDecorations []decor.Zone `yaml:"decorations"`
+	// SpecHeader and SpecFooter are the header and footer text of the
+	// panel, used unless others are given when generating it
+	SpecHeader string `yaml:"header"`
+	SpecFooter string `yaml:"footer"`
+	// SpecLogo is the path of a logo image, relative to the working
+	// directory, and SpecLogoWidth its width, or zero for the default. Like
+	// the header and footer, it is used unless another is given.
+	SpecLogo      string  `yaml:"-"`
+	SpecLogoWidth float64 `yaml:"-"`
+	// Files are the spec file and the specs it extends or includes, in the
+	// order they were read, eg. for watching them for changes
+	Files []string `yaml:"-"`
+}
+
+// rawSpec is a spec as written in YAML, with numeric values as unevaluated
+// expressions
+type rawSpec struct {
+	Extends              string          `yaml:"extends"`
+	Include              []string        `yaml:"include"`
+	Name                 string          `yaml:"name"`
+	Variables            map[string]Expr `yaml:"variables"`
+	Width                Expr            `yaml:"width"`
+	Height               Expr            `yaml:"height"`
+	MountingHoles        []rawPoint      `yaml:"mountingHoles"`
+	MountingHoleDiameter Expr            `yaml:"mountingHoleDiameter"`
+	HorizontalFit        Expr            `yaml:"horizontalFit"`
+	HorizontalFitLeft    Expr            `yaml:"horizontalFitLeft"`
+	HorizontalFitRight   Expr            `yaml:"horizontalFitRight"`
+	CornerRadius         Expr            `yaml:"cornerRadius"`
+	Thickness            Expr            `yaml:"thickness"`
+	Outline              []rawPoint      `yaml:"outline"`
+	Corners              *rawCorners     `yaml:"corners"`
+	Notches              []rawNotch      `yaml:"notches"`
+	Decorations          []rawZone       `yaml:"decorations"`
+	Header               string          `yaml:"header"`
+	Footer               string          `yaml:"footer"`
+	Logo                 *rawLogo        `yaml:"logo"`
+	// files are the spec files read in resolving the spec
+	files []string
+}
+
+type rawPoint struct {
+	X Expr `yaml:"x"`
+	Y Expr `yaml:"y"`
+}
+
+// rawCorners overrides the finish of individual corners of the panel
+type rawCorners struct {
+	BottomLeft  *rawCorner `yaml:"bottomLeft"`
+	BottomRight *rawCorner `yaml:"bottomRight"`
+	TopRight    *rawCorner `yaml:"topRight"`
+	TopLeft     *rawCorner `yaml:"topLeft"`
+}
+
+type rawCorner struct {
+	Style string `yaml:"style"`
+	Size  Expr   `yaml:"size"`
+}
+
+// rawNotch is a notch cut into the top or bottom edge of the panel
+type rawNotch struct {
+	Edge  string `yaml:"edge"`
+	X     Expr   `yaml:"x"`
+	Width Expr   `yaml:"width"`
+	Depth Expr   `yaml:"depth"`
+}
+
+// rawLogo is a logo image, with a path relative to the spec naming it
+type rawLogo struct {
+	File  string `yaml:"file"`
+	Width Expr   `yaml:"width"`
+}
+
+// rawZone is a decoration zone, given either as the opposite corners of a
+// rectangle or as the points of a polygon
+type rawZone struct {
+	Style   string     `yaml:"style"`
+	Density Expr       `yaml:"density"`
+	Rect    []rawPoint `yaml:"rect"`
+	Polygon []rawPoint `yaml:"polygon"`
 }
 
 // LoadSpec constructs a new Spec object according to a YAML file definition
 func LoadSpec(filename string) (*Spec, error) {
+	return LoadSpecWithVariables(filename, nil)
+}
+
+// LoadSpecWithVariables constructs a new Spec object according to a YAML
+// file definition. The given variables override any of the same name
+// defined in the file, so one spec can be reused across panel sizes.
+func LoadSpecWithVariables(filename string, vars map[string]float64) (*Spec, error) {
 	yamltext, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	var sp Spec
-	if err := yaml.Unmarshal(yamltext, &sp); err != nil {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
 		return nil, err
 	}
-	if len(sp.SpecMountingHoles) < 1 {
+	return parseSpec(filename, yamltext, []string{abs}, vars)
+}
+
+// ParseSpec constructs a new Spec object from YAML text. Expressions may
+// refer to variables, to the panel dimensions width, height,
+// mountingHoleDiameter, horizontalFit, cornerRadius and thickness, and to
+// the units mm, cm, in, mil, pt and hp. Relative paths given to extends and
+// include are resolved against the working directory.
+func ParseSpec(yamltext []byte, vars map[string]float64) (*Spec, error) {
+	return parseSpec("<spec>", yamltext, nil, vars)
+}
+
+// ParseSelfContainedSpec constructs a new Spec object from YAML text as
+// ParseSpec does, but returns an error if the spec extends or includes other
+// specs. Use it for specs from untrusted sources, which could otherwise read
+// local files.
+func ParseSelfContainedSpec(yamltext []byte, vars map[string]float64) (*Spec, error) {
+	var raw rawSpec
+	if err := yaml.Unmarshal(yamltext, &raw); err != nil {
+		return nil, fmt.Errorf("LoadSpec: %v", err)
+	}
+	if raw.Extends != "" || len(raw.Include) > 0 {
+		return nil, errors.New("LoadSpec: extends and include are not supported here")
+	}
+	if raw.Logo != nil {
+		return nil, errors.New("LoadSpec: logo is not supported here")
+	}
+	return ParseSpec(yamltext, vars)
+}
+
+// parseSpec constructs a new Spec object from YAML text read from filename,
+// after merging in any specs it extends or includes
+func parseSpec(filename string, yamltext []byte, seen []string, vars map[string]float64) (*Spec, error) {
+	raw, err := resolve(filename, yamltext, seen)
+	if err != nil {
+		return nil, fmt.Errorf("LoadSpec: %v", err)
+	}
+	if len(raw.MountingHoles) < 1 {
 		return nil, errors.New("LoadSpec: need at least one mounting hole")
 	}
+	e := newEnv()
+	// units would silently take the place of variables of the same name
+	for name, x := range raw.Variables {
+		if _, ok := geometry.Units[name]; ok {
+			return nil, fmt.Errorf("LoadSpec: variable %q has the name of a unit", name)
+		}
+		e.exprs[name] = x
+	}
+	for name, v := range vars {
+		if _, ok := geometry.Units[name]; ok {
+			return nil, fmt.Errorf("LoadSpec: variable %q has the name of a unit", name)
+		}
+		e.values[name] = v
+	}
+	e.exprs["width"] = raw.Width
+	e.exprs["height"] = raw.Height
+	e.exprs["mountingHoleDiameter"] = raw.MountingHoleDiameter
+	e.exprs["horizontalFit"] = raw.HorizontalFit
+	e.exprs["cornerRadius"] = raw.CornerRadius
+	e.exprs["thickness"] = raw.Thickness
+	sp := Spec{SpecName: raw.Name, SpecHeader: raw.Header, SpecFooter: raw.Footer, Files: raw.files}
+	if raw.Logo != nil {
+		if raw.Logo.File == "" {
+			return nil, errors.New("LoadSpec: logo: file must be given")
+		}
+		sp.SpecLogo = raw.Logo.File
+		if raw.Logo.Width != "" {
+			if sp.SpecLogoWidth, err = e.eval(raw.Logo.Width); err != nil {
+				return nil, fmt.Errorf("LoadSpec: logo: width: %v", err)
+			}
+			if sp.SpecLogoWidth <= 0 {
+				return nil, errors.New("LoadSpec: logo: width must be greater than 0")
+			}
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value *float64
+	}{
+		{"width", &sp.SpecWidth},
+		{"height", &sp.SpecHeight},
+		{"mountingHoleDiameter", &sp.SpecMountingHoleDiameter},
+		{"horizontalFit", &sp.SpecHorizontalFit},
+		{"cornerRadius", &sp.SpecCornerRadius},
+		{"thickness", &sp.SpecThickness},
+	} {
+		v, err := e.lookup(f.name)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: %v", err)
+		}
+		*f.value = v
+	}
+	for _, f := range []struct {
+		name  string
+		x     Expr
+		value **float64
+	}{
+		{"horizontalFitLeft", raw.HorizontalFitLeft, &sp.SpecFit.Left},
+		{"horizontalFitRight", raw.HorizontalFitRight, &sp.SpecFit.Right},
+	} {
+		if f.x == "" {
+			continue
+		}
+		v, err := e.eval(f.x)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: %s: %v", f.name, err)
+		}
+		*f.value = &v
+	}
+	if sp.SpecThickness < 0 {
+		return nil, errors.New("LoadSpec: thickness must not be negative")
+	}
+	if err := panel.CheckFit(sp); err != nil {
+		return nil, fmt.Errorf("LoadSpec: %v", err)
+	}
+	for i, h := range raw.MountingHoles {
+		x, err := e.eval(h.X)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: mounting hole %d: x: %v", i+1, err)
+		}
+		y, err := e.eval(h.Y)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: mounting hole %d: y: %v", i+1, err)
+		}
+		sp.SpecMountingHoles = append(sp.SpecMountingHoles, geometry.Point{X: x, Y: y})
+	}
 	sort.Slice(sp.SpecMountingHoles, func(i, j int) bool {
 		return sp.SpecMountingHoles[i].Y < sp.SpecMountingHoles[j].Y
 	})
+	if raw.Outline != nil {
+		if len(raw.Outline) < 3 {
+			return nil, errors.New("LoadSpec: outline needs at least three points")
+		}
+		// the rectangle they shape is replaced, so they would be ignored
+		if sp.SpecCornerRadius != 0 || sp.SpecHorizontalFit != 0 || sp.SpecFit.Left != nil || sp.SpecFit.Right != nil {
+			return nil, errors.New("LoadSpec: outline cannot be combined with cornerRadius or horizontalFit; give the outline as fitted and rounded")
+		}
+		for i, pt := range raw.Outline {
+			x, err := e.eval(pt.X)
+			if err != nil {
+				return nil, fmt.Errorf("LoadSpec: outline point %d: x: %v", i+1, err)
+			}
+			y, err := e.eval(pt.Y)
+			if err != nil {
+				return nil, fmt.Errorf("LoadSpec: outline point %d: y: %v", i+1, err)
+			}
+			sp.SpecOutline = append(sp.SpecOutline, geometry.Point{X: x, Y: y})
+		}
+	}
+	notches := []panel.Notch{}
+	for i, n := range raw.Notches {
+		notch, err := n.evaluate(e)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: notch %d: %v", i+1, err)
+		}
+		notches = append(notches, notch)
+	}
+	if raw.Corners != nil {
+		if sp.SpecOutline != nil {
+			return nil, errors.New("LoadSpec: corners cannot be combined with an outline")
+		}
+		corners, err := raw.Corners.evaluate(e)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: %v", err)
+		}
+		if err := panel.CheckCorners(sp, corners, notches); err != nil {
+			return nil, fmt.Errorf("LoadSpec: %v", err)
+		}
+		sp.SpecOutline = corners.Contour(sp, geometry.DefaultTolerance)
+	}
+	if raw.Notches != nil {
+		if err := panel.CheckNotches(sp, notches); err != nil {
+			return nil, fmt.Errorf("LoadSpec: %v", err)
+		}
+		outline := sp.SpecOutline
+		if outline == nil {
+			outline = geometry.RoundedRect(panel.BottomLeft(sp), panel.TopRight(sp), sp.SpecCornerRadius, geometry.DefaultTolerance)
+		}
+		if sp.SpecOutline, err = panel.CutNotches(outline, notches); err != nil {
+			return nil, fmt.Errorf("LoadSpec: %v", err)
+		}
+	}
+	if sp.SpecOutline != nil {
+		if err := panel.CheckMountingHoles(sp); err != nil {
+			return nil, fmt.Errorf("LoadSpec: %v", err)
+		}
+	}
+	for i, z := range raw.Decorations {
+		zone, err := z.evaluate(e)
+		if err != nil {
+			return nil, fmt.Errorf("LoadSpec: decoration %d: %v", i+1, err)
+		}
+		sp.SpecDecorations = append(sp.SpecDecorations, zone)
+	}
 	return &sp, nil
 }
 
+// evaluate converts raw corner overrides to panel.Corners
+func (c rawCorners) evaluate(e *env) (panel.Corners, error) {
+	corners := panel.Corners{}
+	for _, f := range []struct {
+		name   string
+		raw    *rawCorner
+		corner *panel.Corner
+	}{
+		{"bottomLeft", c.BottomLeft, &corners.BottomLeft},
+		{"bottomRight", c.BottomRight, &corners.BottomRight},
+		{"topRight", c.TopRight, &corners.TopRight},
+		{"topLeft", c.TopLeft, &corners.TopLeft},
+	} {
+		if f.raw == nil {
+			continue
+		}
+		var err error
+		if f.corner.Style, err = panel.ParseCornerStyle(f.raw.Style); err != nil {
+			return corners, fmt.Errorf("%s corner: %v", f.name, err)
+		}
+		if f.corner.Size, err = e.eval(f.raw.Size); err != nil {
+			return corners, fmt.Errorf("%s corner: size: %v", f.name, err)
+		}
+	}
+	return corners, nil
+}
+
+// evaluate converts a raw notch to a panel.Notch
+func (n rawNotch) evaluate(e *env) (panel.Notch, error) {
+	notch := panel.Notch{}
+	var err error
+	if notch.Edge, err = panel.ParseEdge(n.Edge); err != nil {
+		return notch, err
+	}
+	for _, f := range []struct {
+		name  string
+		expr  Expr
+		value *float64
+	}{
+		{"x", n.X, &notch.X},
+		{"width", n.Width, &notch.Width},
+		{"depth", n.Depth, &notch.Depth},
+	} {
+		if *f.value, err = e.eval(f.expr); err != nil {
+			return notch, fmt.Errorf("%s: %v", f.name, err)
+		}
+	}
+	return notch, nil
+}
+
+// evaluate converts a raw decoration zone to a decor.Zone
+func (z rawZone) evaluate(e *env) (decor.Zone, error) {
+	zone := decor.Zone{}
+	var err error
+	if zone.Style, err = decor.ParseZoneStyle(z.Style); err != nil {
+		return zone, err
+	}
+	if zone.Density, err = e.eval(z.Density); err != nil {
+		return zone, fmt.Errorf("density: %v", err)
+	}
+	raw, name := z.Polygon, "polygon"
+	switch {
+	case z.Rect != nil && z.Polygon != nil:
+		return zone, errors.New("give either rect or polygon, not both")
+	case z.Rect != nil:
+		if len(z.Rect) != 2 {
+			return zone, errors.New("rect needs two opposite corners")
+		}
+		raw, name = z.Rect, "rect"
+	case len(z.Polygon) < 3:
+		return zone, errors.New("polygon needs at least three points")
+	}
+	points := []geometry.Point{}
+	for i, pt := range raw {
+		x, err := e.eval(pt.X)
+		if err != nil {
+			return zone, fmt.Errorf("%s point %d: x: %v", name, i+1, err)
+		}
+		y, err := e.eval(pt.Y)
+		if err != nil {
+			return zone, fmt.Errorf("%s point %d: y: %v", name, i+1, err)
+		}
+		points = append(points, geometry.Point{X: x, Y: y})
+	}
+	if z.Rect != nil {
+		a, b := points[0], points[1]
+		points = []geometry.Point{
+			{X: math.Min(a.X, b.X), Y: math.Min(a.Y, b.Y)},
+			{X: math.Max(a.X, b.X), Y: math.Min(a.Y, b.Y)},
+			{X: math.Max(a.X, b.X), Y: math.Max(a.Y, b.Y)},
+			{X: math.Min(a.X, b.X), Y: math.Max(a.Y, b.Y)},
+		}
+	}
+	zone.Region = points
+	return zone, nil
+}
+
+// DecorationZones returns the zones of a Spec panel filled with decorative
+// patterns
+func (s Spec) DecorationZones() []decor.Zone {
+	return s.SpecDecorations
+}
+
+// Name returns the name of the panel format
+func (s Spec) Name() string {
+	return "spec"
+}
+
+// Units returns the unit the panel width is given in
+func (s Spec) Units() string {
+	return "mm"
+}
+
+// WidthUnits returns the panel width in Units
+func (s Spec) WidthUnits() float64 {
+	return s.Width()
+}
+
 // Width returns the width of a Spec panel, in millimetres
 func (s Spec) Width() float64 {
 	return s.SpecWidth
@@ -86,9 +500,16 @@ func (s Spec) MountingHoles() []geometry.Point {
 	return s.SpecMountingHoles
 }
 
-// HorizontalFit indicates the panel tolerance adjustment for the format
+// HorizontalFit indicates the panel tolerance adjustment for the format: the
+// sum of horizontalFitLeft and horizontalFitRight, where given
 func (s Spec) HorizontalFit() float64 {
-	return s.SpecHorizontalFit
+	return s.SpecFit.Total(s.SpecHorizontalFit)
+}
+
+// EdgeFits returns the amounts trimmed from the left and right edges of the
+// panel, each half of horizontalFit unless given individually
+func (s Spec) EdgeFits() (left, right float64) {
+	return s.SpecFit.Edges(s.SpecHorizontalFit)
 }
 
 // CornerRadius indicates the corner radius for the format, as would be useful
@@ -97,6 +518,18 @@ func (s Spec) CornerRadius() float64 {
 	return s.SpecCornerRadius
 }
 
+// Thickness returns the thickness of the panel material, in millimetres, or
+// zero if the spec does not give one
+func (s Spec) Thickness() float64 {
+	return s.SpecThickness
+}
+
+// Outline returns the custom outline of a Spec panel, or nil for the
+// default rectangle
+func (s Spec) Outline() []geometry.Point {
+	return s.SpecOutline
+}
+
 // RailHeightFromMountingHole doesn't really directly apply to YAML-spec
 // panels where the keepout area is more likely to be a ring around each
 // mounting hole, with the thickness of the ring likely varying with the
@@ -0,0 +1,266 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package frontpanels
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/gerber"
+	"github.com/jsleeio/frontpanels/pkg/sources/fabfiles"
+)
+
+// FeatureDescription describes a feature and where it would be rendered
+type FeatureDescription struct {
+	Type    string `json:"type"`
+	Purpose string `json:"purpose"`
+	Side    string `json:"side,omitempty"`
+	// Tag and LayerHint are the feature's optional metadata
+	Tag       string `json:"tag,omitempty"`
+	LayerHint string `json:"layerHint,omitempty"`
+	// Layers are the Gerber layers and drill files the feature is rendered
+	// into
+	Layers []string `json:"layers"`
+	// Detail is the feature's own description, including its coordinates
+	Detail string `json:"detail"`
+	// Position is the origin of circles and text, Diameter the diameter of
+	// circles and Text the text of text features, for comparing panels
+	Position *geometry.Point `json:"position,omitempty"`
+	Diameter float64         `json:"diameter,omitempty"`
+	Text     string          `json:"text,omitempty"`
+	// Points are the vertices of outlines, polygons and inversion regions,
+	// or the ends of lines, and Width the thickness of lines
+	Points []geometry.Point `json:"points,omitempty"`
+	Width  float64          `json:"width,omitempty"`
+}
+
+// Description is the resolved geometry of a panel and its features, for
+// checking before generating output files
+type Description struct {
+	Name                 string               `json:"name"`
+	Format               string               `json:"format"`
+	Width                float64              `json:"width"`
+	Height               float64              `json:"height"`
+	HorizontalFit        float64              `json:"horizontalFit"`
+	CornerRadius         float64              `json:"cornerRadius"`
+	MountingHoleDiameter float64              `json:"mountingHoleDiameter"`
+	MountingHoles        []geometry.Point     `json:"mountingHoles"`
+	Features             []FeatureDescription `json:"features"`
+}
+
+// Describe resolves a panel and its features as Render would, without
+// rendering anything
+func Describe(p panel.Panel, feats []features.Feature, opts RenderOptions) (*Description, error) {
+	outline, feats, err := prepare(p, feats, opts)
+	if err != nil {
+		return nil, err
+	}
+	board, err := newBoard(p, nil, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return describe(p, outline, feats, board, opts), nil
+}
+
+// describe describes prepared panel outline features, the board's copper
+// pour and additional features, and the layers of the board they are
+// rendered into
+func describe(p panel.Panel, outline, feats []features.Feature, board *gerber.Board, opts RenderOptions) *Description {
+	d := &Description{
+		Name:                 opts.Name,
+		Format:               panel.Description(p),
+		Width:                p.Width(),
+		Height:               p.Height(),
+		HorizontalFit:        p.HorizontalFit(),
+		CornerRadius:         p.CornerRadius(),
+		MountingHoleDiameter: p.MountingHoleDiameter(),
+		MountingHoles:        p.MountingHoles(),
+	}
+	all := append(append(append([]features.Feature{}, outline...), board.Pour()...), feats...)
+	for _, f := range all {
+		fd := FeatureDescription{
+			Type:    featureType(f),
+			Purpose: f.GetPurpose().String(),
+			Layers:  board.Destinations(f),
+			Detail:  fmt.Sprint(f),
+		}
+		if s, ok := f.(features.Sided); ok && f.GetPurpose() != features.Cutout {
+			fd.Side = s.GetSide().String()
+		}
+		fd.Tag = features.Tag(f)
+		if hint := features.LayerHint(f); hint != features.AutoLayer {
+			fd.LayerHint = hint.String()
+		}
+		switch f := f.(type) {
+		case *features.Circle:
+			origin := f.Origin
+			fd.Position, fd.Diameter = &origin, 2*f.Radius
+		case *features.Text:
+			origin := f.Origin
+			fd.Position, fd.Text = &origin, f.Text
+		case *features.Line:
+			fd.Points, fd.Width = []geometry.Point{f.Start, f.End}, f.Thickness
+		case *features.Polygon:
+			fd.Points = f.Points
+		case *features.Outline:
+			fd.Points = f.Points
+		case *features.Inversion:
+			fd.Points = f.Region
+		}
+		d.Features = append(d.Features, fd)
+	}
+	return d
+}
+
+// featureType returns the kind of a custom feature, or the lower-cased name
+// of the type of any other feature, whether or not it is a pointer
+func featureType(f features.Feature) string {
+	if c, ok := f.(features.Custom); ok {
+		return c.Kind()
+	}
+	t := reflect.TypeOf(f)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.ToLower(t.Name())
+}
+
+// LoadDescription reads a description written by WriteJSON
+func LoadDescription(filename string) (*Description, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d := &Description{}
+	if err := json.NewDecoder(f).Decode(d); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return d, nil
+}
+
+// drillLayers are the names of the drill files holes are drilled by, as
+// given by gerber.Board.Destinations
+var drillLayers = map[bool]string{true: "drill-pth", false: "drill-npth"}
+
+// drillDescription describes a drilled hole as Fabrication does
+func drillDescription(position geometry.Point, diameter float64, plated bool) FeatureDescription {
+	return FeatureDescription{
+		Type:     "circle",
+		Purpose:  features.Cutout.String(),
+		Layers:   []string{drillLayers[plated]},
+		Detail:   fmt.Sprintf("Drill(x=%.2f, y=%.2f, d=%.2f, plated=%v)", position.X, position.Y, diameter, plated),
+		Position: &position,
+		Diameter: diameter,
+	}
+}
+
+// LoadFabrication describes a panel from a directory of its fabrication
+// files, eg. the Gerbers of a revision already sent to a fab: the size of
+// its board outline, and the holes drilled by its drill files. Compare it
+// with the Fabrication of another description.
+func LoadFabrication(dir string) (*Description, error) {
+	drills, outline, err := fabfiles.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := &Description{Width: outline.Width(), Height: outline.Height()}
+	for _, h := range drills {
+		d.Features = append(d.Features, drillDescription(h.Position, h.Diameter, h.Plated))
+	}
+	return d, nil
+}
+
+// Fabrication returns the part of a description that fabrication files
+// record, as LoadFabrication does: the size of the board outline, and the
+// drilled holes, including the mounting holes
+func (d *Description) Fabrication() *Description {
+	f := &Description{Width: d.Width - 2*d.HorizontalFit, Height: d.Height}
+	extents := geometry.Rect{
+		BottomLeft: geometry.Point{X: math.Inf(1), Y: math.Inf(1)},
+		TopRight:   geometry.Point{X: math.Inf(-1), Y: math.Inf(-1)},
+	}
+	for _, fd := range d.Features {
+		for _, layer := range fd.Layers {
+			switch {
+			case layer == drillLayers[true] || layer == drillLayers[false]:
+				if fd.Position != nil {
+					f.Features = append(f.Features, drillDescription(*fd.Position, fd.Diameter, layer == drillLayers[true]))
+				}
+			case layer == "outline" && fd.Type == "outline":
+				for _, p := range fd.Points {
+					extents.BottomLeft = geometry.Point{X: math.Min(extents.BottomLeft.X, p.X), Y: math.Min(extents.BottomLeft.Y, p.Y)}
+					extents.TopRight = geometry.Point{X: math.Max(extents.TopRight.X, p.X), Y: math.Max(extents.TopRight.Y, p.Y)}
+				}
+			}
+		}
+	}
+	// descriptions written before points were recorded fall back to the
+	// panel size less its horizontal fit
+	if extents.Width() >= 0 {
+		f.Width, f.Height = extents.Width(), extents.Height()
+	}
+	return f
+}
+
+// WriteJSON writes the description as indented JSON
+func (d *Description) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteTable writes the description as human-readable tables
+func (d *Description) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "panel %q (%s): %.3f x %.3f mm, horizontal fit %.3f mm, corner radius %.3f mm\n",
+		d.Name, d.Format, d.Width, d.Height, d.HorizontalFit, d.CornerRadius)
+	fmt.Fprintf(tw, "\nMOUNTING HOLE\tX\tY\tDIAMETER\n")
+	for i, h := range d.MountingHoles {
+		fmt.Fprintf(tw, "%d\t%.3f\t%.3f\t%.3f\n", i+1, h.X, h.Y, d.MountingHoleDiameter)
+	}
+	fmt.Fprintf(tw, "\nTYPE\tPURPOSE\tSIDE\tTAG\tLAYERS\tDETAIL\n")
+	for _, f := range d.Features {
+		side := f.Side
+		if side == "" {
+			side = "-"
+		}
+		tag := f.Tag
+		if tag == "" {
+			tag = "-"
+		}
+		layers := strings.Join(f.Layers, ",")
+		if layers == "" {
+			layers = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Type, f.Purpose, side, tag, layers, f.Detail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package frontpanels
+
+import (
+	"testing"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// hole is a value-type custom feature expanding to a cutout circle
+type hole struct{}
+
+func (hole) GetPurpose() features.Purpose { return features.Cutout }
+func (hole) SetPurpose(features.Purpose)  {}
+func (h hole) Accept(v features.Visitor)  { v.VisitCustom(h) }
+func (hole) Kind() string                 { return "test-hole" }
+func (hole) Expand() ([]features.Feature, error) {
+	c := features.NewCircle(geometry.Point{X: 20, Y: 60}, 3)
+	c.SetPurpose(features.Cutout)
+	return []features.Feature{c}, nil
+}
+
+// TestDescribeCustom checks that value-type custom features are described,
+// and expanded into standard features when no renderer draws them itself
+func TestDescribeCustom(t *testing.T) {
+	opts := DefaultRenderOptions("custom")
+	opts.Diagnostics = diag.Discard
+	d, err := Describe(eurorack.NewEurorack(8), []features.Feature{hole{}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := d.Features[len(d.Features)-1]
+	if last.Type != "circle" || last.Diameter != 6 {
+		t.Errorf("got %s feature of diameter %v, want the expanded 6mm circle", last.Type, last.Diameter)
+	}
+	if got := featureType(hole{}); got != "test-hole" {
+		t.Errorf("featureType(hole{}) = %q, want %q", got, "test-hole")
+	}
+}
+
+// TestDescribePour checks that the copper pour is described along with the
+// copper layer it is rendered into
+func TestDescribePour(t *testing.T) {
+	opts := DefaultRenderOptions("pour")
+	opts.Diagnostics = diag.Discard
+	d, err := Describe(eurorack.NewEurorack(8), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range d.Features {
+		if f.Purpose == features.MaskedCopper.String() {
+			if len(f.Layers) != 1 || f.Layers[0] != "copper-top" {
+				t.Errorf("pour described on layers %v, want [copper-top]", f.Layers)
+			}
+			return
+		}
+	}
+	t.Error("copper pour not described")
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package frontpanels
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// diffTolerance is the smallest difference in a position or dimension that
+// Diff reports, in millimetres
+const diffTolerance = 0.001
+
+// Change is a difference between two descriptions of a panel, eg. between
+// a shipped revision and the next
+type Change struct {
+	// Kind is one of changed, moved, resized, added or removed
+	Kind string
+	// Subject names what changed, eg. "width" or "cutout circle"
+	Subject string
+	// From and To describe the subject before and after the change. From is
+	// empty for additions, and To for removals.
+	From, To string
+}
+
+// String returns the change as a line of human-readable text
+func (c Change) String() string {
+	switch c.Kind {
+	case "added":
+		return fmt.Sprintf("added %s: %s", c.Subject, c.To)
+	case "removed":
+		return fmt.Sprintf("removed %s: %s", c.Subject, c.From)
+	}
+	return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Subject, c.From, c.To)
+}
+
+// Diff returns the geometric changes from one description of a panel to
+// another: changes to the panel's dimensions and mounting holes, then
+// features that moved, were resized or otherwise changed, were removed or
+// were added. Circles and text keep their identity when moved, resized or
+// edited in place; other changed features are reported as removed and
+// added. The revision text written with RenderOptions.Revision is ignored.
+func Diff(from, to *Description) []Change {
+	changes := []Change{}
+	if from.Name != to.Name {
+		changes = append(changes, Change{Kind: "changed", Subject: "name", From: fmt.Sprintf("%q", from.Name), To: fmt.Sprintf("%q", to.Name)})
+	}
+	if from.Format != to.Format {
+		changes = append(changes, Change{Kind: "changed", Subject: "format", From: from.Format, To: to.Format})
+	}
+	for _, d := range []struct {
+		subject  string
+		from, to float64
+	}{
+		{"width", from.Width, to.Width},
+		{"height", from.Height, to.Height},
+		{"horizontal fit", from.HorizontalFit, to.HorizontalFit},
+		{"corner radius", from.CornerRadius, to.CornerRadius},
+		{"mounting hole diameter", from.MountingHoleDiameter, to.MountingHoleDiameter},
+	} {
+		if !near(d.from, d.to) {
+			changes = append(changes, Change{Kind: "changed", Subject: d.subject, From: millimetres(d.from), To: millimetres(d.to)})
+		}
+	}
+	for i := 0; i < len(from.MountingHoles) || i < len(to.MountingHoles); i++ {
+		subject := fmt.Sprintf("mounting hole %d", i+1)
+		switch {
+		case i >= len(to.MountingHoles):
+			changes = append(changes, Change{Kind: "removed", Subject: subject, From: point(from.MountingHoles[i])})
+		case i >= len(from.MountingHoles):
+			changes = append(changes, Change{Kind: "added", Subject: subject, To: point(to.MountingHoles[i])})
+		case !samePoint(from.MountingHoles[i], to.MountingHoles[i]):
+			changes = append(changes, Change{Kind: "moved", Subject: subject, From: point(from.MountingHoles[i]), To: point(to.MountingHoles[i])})
+		}
+	}
+	return append(changes, diffFeatures(from.Features, to.Features)...)
+}
+
+// diffFeatures returns the changes from one list of features to another.
+// Unchanged features are set aside first, then features of the same kind
+// at the same position are paired as changed in place, and those with the
+// same diameter or text as moved.
+func diffFeatures(from, to []FeatureDescription) []Change {
+	fromUsed, toUsed := make([]bool, len(from)), make([]bool, len(to))
+	for i, f := range from {
+		fromUsed[i] = f.Tag == revisionTag
+	}
+	for j, f := range to {
+		toUsed[j] = f.Tag == revisionTag
+	}
+	pair := func(match func(a, b FeatureDescription) bool, change func(a, b FeatureDescription) Change) []Change {
+		changes := []Change{}
+		for i, a := range from {
+			if fromUsed[i] {
+				continue
+			}
+			for j, b := range to {
+				if !toUsed[j] && a.sameKind(b) && match(a, b) {
+					fromUsed[i], toUsed[j] = true, true
+					if change != nil {
+						changes = append(changes, change(a, b))
+					}
+					break
+				}
+			}
+		}
+		return changes
+	}
+	pair(FeatureDescription.same, nil)
+	changes := pair(
+		func(a, b FeatureDescription) bool {
+			return a.Position != nil && b.Position != nil && samePoint(*a.Position, *b.Position)
+		},
+		func(a, b FeatureDescription) Change {
+			subject := a.subject() + " at " + point(*a.Position)
+			switch {
+			case !near(a.Diameter, b.Diameter):
+				return Change{Kind: "resized", Subject: subject, From: millimetres(a.Diameter), To: millimetres(b.Diameter)}
+			case a.Text != b.Text:
+				return Change{Kind: "changed", Subject: subject, From: fmt.Sprintf("%q", a.Text), To: fmt.Sprintf("%q", b.Text)}
+			}
+			return Change{Kind: "changed", Subject: subject, From: a.Detail, To: b.Detail}
+		})
+	changes = append(changes, pair(
+		func(a, b FeatureDescription) bool {
+			return a.Position != nil && b.Position != nil && near(a.Diameter, b.Diameter) && a.Text == b.Text
+		},
+		func(a, b FeatureDescription) Change {
+			subject := a.subject()
+			if a.Text != "" {
+				subject += fmt.Sprintf(" %q", a.Text)
+			} else {
+				subject += " " + millimetres(a.Diameter)
+			}
+			return Change{Kind: "moved", Subject: subject, From: point(*a.Position), To: point(*b.Position)}
+		})...)
+	for i, a := range from {
+		if !fromUsed[i] {
+			changes = append(changes, Change{Kind: "removed", Subject: a.subject(), From: a.Detail})
+		}
+	}
+	for j, b := range to {
+		if !toUsed[j] {
+			changes = append(changes, Change{Kind: "added", Subject: b.subject(), To: b.Detail})
+		}
+	}
+	return changes
+}
+
+// sameKind reports whether two features are of the same type and purpose,
+// on the same side, with the same metadata
+func (f FeatureDescription) sameKind(o FeatureDescription) bool {
+	return f.Type == o.Type && f.Purpose == o.Purpose && f.Side == o.Side && f.Tag == o.Tag && f.LayerHint == o.LayerHint
+}
+
+// same reports whether two features are unchanged. Features with points
+// are compared by them, within diffTolerance, and others by their detail.
+func (f FeatureDescription) same(o FeatureDescription) bool {
+	if (f.Position == nil) != (o.Position == nil) || (f.Position != nil && !samePoint(*f.Position, *o.Position)) {
+		return false
+	}
+	if len(f.Points) != len(o.Points) || (f.Points == nil && f.Detail != o.Detail) {
+		return false
+	}
+	for i := range f.Points {
+		if !samePoint(f.Points[i], o.Points[i]) {
+			return false
+		}
+	}
+	return f.sameKind(o) && f.Text == o.Text && near(f.Diameter, o.Diameter) && near(f.Width, o.Width) &&
+		strings.Join(f.Layers, ",") == strings.Join(o.Layers, ",")
+}
+
+// subject names a feature by its side, purpose and type, eg. "rear marking
+// text", and its tag, if any
+func (f FeatureDescription) subject() string {
+	s := f.Purpose + " " + f.Type
+	if f.Side == "bottom" {
+		s = "rear " + s
+	}
+	if f.Tag != "" {
+		s += " [" + f.Tag + "]"
+	}
+	return s
+}
+
+// near reports whether two lengths are within diffTolerance
+func near(a, b float64) bool {
+	return math.Abs(a-b) < diffTolerance
+}
+
+// samePoint reports whether two points are within diffTolerance on both
+// axes
+func samePoint(a, b geometry.Point) bool {
+	return near(a.X, b.X) && near(a.Y, b.Y)
+}
+
+// millimetres formats a length for a change
+func millimetres(v float64) string {
+	return fmt.Sprintf("%.3f mm", v)
+}
+
+// point formats a position for a change
+func point(p geometry.Point) string {
+	return fmt.Sprintf("(%.3f, %.3f)", p.X, p.Y)
+}
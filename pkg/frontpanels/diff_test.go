@@ -0,0 +1,93 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package frontpanels
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// window returns a rectangular cutout
+func window(top float64) []features.Feature {
+	w := features.NewRectangle(geometry.Point{X: 10, Y: 40}, geometry.Point{X: 30, Y: top})
+	w.SetPurpose(features.Cutout)
+	return []features.Feature{w}
+}
+
+// TestDiffPoints checks that features without a position are compared by
+// their points
+func TestDiffPoints(t *testing.T) {
+	opts := DefaultRenderOptions("diff")
+	opts.Diagnostics = diag.Discard
+	p := eurorack.NewEurorack(8)
+	from, err := Describe(p, window(60), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := Describe(p, window(61), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(from, from); len(changes) != 0 {
+		t.Errorf("got %v comparing a panel with itself, want no changes", changes)
+	}
+	if changes := Diff(from, to); len(changes) != 2 {
+		t.Errorf("got %v, want the window removed and added", changes)
+	}
+}
+
+// TestLoadFabrication checks that the fabrication files of a panel describe
+// the same outline and drills as the panel itself
+func TestLoadFabrication(t *testing.T) {
+	opts := DefaultRenderOptions("fab")
+	opts.Diagnostics = diag.Discard
+	p := eurorack.NewEurorack(10)
+	c := features.NewCircle(geometry.Point{X: 20, Y: 60}, 3)
+	c.SetPurpose(features.Cutout)
+	feats := append(window(60), c)
+	files, err := RenderMemory(p, feats, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for filename, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, filename), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fab, err := LoadFabrication(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Describe(p, feats, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(d.Fabrication(), fab); len(changes) != 0 {
+		t.Errorf("got %v, want no changes", changes)
+	}
+}
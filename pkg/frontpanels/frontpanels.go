@@ -0,0 +1,466 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package frontpanels is the library entry point for generating panels
+// programmatically. It renders a panel and any additional features to
+// caller-provided writers rather than files on disk, so that web services
+// and tests can generate panels in memory.
+package frontpanels
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/components"
+	"github.com/jsleeio/frontpanels/pkg/decor"
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/fab"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/output"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	preparer "github.com/jsleeio/frontpanels/pkg/prepare"
+	"github.com/jsleeio/frontpanels/pkg/render/composite"
+	"github.com/jsleeio/frontpanels/pkg/render/gcode"
+	"github.com/jsleeio/frontpanels/pkg/render/gerber"
+	"github.com/jsleeio/frontpanels/pkg/render/hpgl"
+	"github.com/jsleeio/frontpanels/pkg/render/kicad"
+	"github.com/jsleeio/frontpanels/pkg/render/laser"
+	"github.com/jsleeio/frontpanels/pkg/render/openscad"
+	"github.com/jsleeio/frontpanels/pkg/render/overlay"
+	"github.com/jsleeio/frontpanels/pkg/render/preview"
+	"github.com/jsleeio/frontpanels/pkg/render/stl"
+	"github.com/jsleeio/frontpanels/pkg/render/terminal"
+	"github.com/jsleeio/frontpanels/pkg/render/vcvrack"
+	"github.com/jsleeio/frontpanels/pkg/sources/copper"
+)
+
+// RenderOptions configures rendering
+type RenderOptions struct {
+	// Name is the basename used for generating filenames
+	Name string
+	// FilenameTemplate is used to generate output filenames. See
+	// output.ExpandTemplate.
+	FilenameTemplate string
+	// Soldermask indicates whether soldermask layers should be generated
+	Soldermask bool
+	// Paste indicates whether a top paste layer should be generated
+	Paste bool
+	// DrillReport indicates whether human-readable and CSV drill tables
+	// should be generated
+	DrillReport bool
+	// Annotations indicates whether a drawing layer should be generated for
+	// Annotation features, such as dimensions, and whether they are drawn
+	// on overlay sheets and SVG previews
+	Annotations bool
+	// OpenSCAD indicates whether an OpenSCAD model of the panel plate and
+	// its cutouts should be generated, for 3D-printing prototypes
+	OpenSCAD bool
+	// STL indicates whether a finely tessellated STL mesh of the panel plate
+	// and its cutouts should be generated, for mechanical CAD
+	STL bool
+	// KiCad indicates whether a KiCad footprint of the whole panel should be
+	// generated
+	KiCad bool
+	// VCVRack indicates whether a VCV Rack panel SVG should be generated
+	VCVRack bool
+	// GCode configures G-code engraving and profile programs for machining
+	// the panel. If nil, no G-code is generated. Its thickness is replaced
+	// by Thickness.
+	GCode *gcode.Options
+	// Laser configures an SVG job for cutting the panel from sheet material
+	// with a laser cutter. If nil, no laser job is generated.
+	Laser *laser.Options
+	// NoBoard omits the Gerber, drill and job files, for panels that are not
+	// made as PCBs
+	NoBoard bool
+	// HPGL configures an HPGL plot of the panel markings, for cutting
+	// overlays and stencils. If nil, no HPGL is generated.
+	HPGL *hpgl.Options
+	// Overlay configures PDF and SVG sheets of the panel markings, for
+	// printing onto label stock. If nil, no overlay is generated.
+	Overlay *overlay.Options
+	// Preview configures an SVG preview of the finished front of the panel.
+	// If nil, no preview is generated.
+	Preview *preview.Options
+	// Composite configures a PNG image approximating the fabricated
+	// appearance of the front of the panel. If nil, no image is generated.
+	Composite *composite.Options
+	// Hardware is drawn over the SVG preview, PNG image and overlay sheets,
+	// so that they approximate the assembled module. It is not included in
+	// fab outputs.
+	Hardware []preview.Hardware
+	// MetalCore renders a single-layer aluminium board, with no rear
+	// copper, soldermask or silkscreen. See fab.Profile.CheckMetalCore.
+	MetalCore bool
+	// BoardThickness is the overall PCB thickness recorded in the Gerber job
+	// file, in millimetres. If zero, the panel thickness is used, with a
+	// warning if it is not one of gerber.BoardThicknesses.
+	BoardThickness float64
+	// EdgePlating and Castellated record plated board edges or plated
+	// half-holes in the Gerber job file
+	EdgePlating, Castellated bool
+	// Thickness of the panel, in millimetres, for 3D models, G-code and the
+	// Gerber job file. If zero, the panel's own thickness is used; see
+	// panel.Thickness.
+	Thickness float64
+	// Pour configures the copper pour. If nil, there is no pour.
+	Pour *copper.Options
+	// Drills is the fab profile whose standard drill sizes hole diameters are
+	// snapped to. If nil, holes are drilled at the requested sizes.
+	Drills *fab.Profile
+	// Placeholders holds the values substituted into the fields of
+	// Placeholder features, keyed by field name without braces
+	Placeholders map[string]string
+	// Decorations configures the patterns filling the decoration zones
+	// declared by the panel, eg. in a spec file. If nil, the defaults are
+	// used.
+	Decorations *decor.Options
+	// BOM is the hardware bill of materials for the panel. If set, it is
+	// written as CSV and JSON.
+	BOM *components.BOM
+	// Revision identifies the spec the panel was generated from. If set, it
+	// is written into Gerber and drill file comments, as text on the rear
+	// silkscreen, and into a JSON manifest listing every output file, and
+	// the panel is described as by Describe, for comparing later revisions
+	// against.
+	Revision *Revision
+	// Timestamp is recorded in output file headers. If zero, the current
+	// time is used.
+	Timestamp time.Time
+	// Diagnostics receives warnings about the features being rendered. If
+	// nil, they are written to the standard logger.
+	Diagnostics diag.Reporter
+	// Output receives the output files. Use output.Writers to supply an
+	// io.Writer per file, or output.NewMemory to collect them in memory.
+	Output output.Sink
+}
+
+// DefaultRenderOptions returns a RenderOptions matching the defaults of the
+// command-line tools, without an output sink
+func DefaultRenderOptions(name string) RenderOptions {
+	pour := copper.DefaultOptions()
+	return RenderOptions{
+		Name:             name,
+		FilenameTemplate: output.DefaultFilenameTemplate,
+		Soldermask:       true,
+		DrillReport:      true,
+		Pour:             &pour,
+	}
+}
+
+// Board renders a panel outline, mounting holes, copper pour and any
+// additional features into a Gerber board, without writing anything
+func Board(p panel.Panel, feats []features.Feature, opts RenderOptions) (*gerber.Board, error) {
+	outline, feats, err := prepare(p, feats, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newBoard(p, outline, feats, opts)
+}
+
+// newBoard renders prepared panel outline features and additional features
+// into a Gerber board
+func newBoard(p panel.Panel, outline, feats []features.Feature, opts RenderOptions) (*gerber.Board, error) {
+	board := gerber.NewBoard(opts.Name, p)
+	if opts.FilenameTemplate != "" {
+		board.FilenameTemplate = opts.FilenameTemplate
+	}
+	board.Soldermask = opts.Soldermask
+	board.Paste = opts.Paste
+	board.DrillReport = opts.DrillReport
+	board.Annotations = opts.Annotations
+	board.CreationDate = opts.Timestamp
+	board.MetalCore = opts.MetalCore
+	board.EdgePlating = opts.EdgePlating
+	board.Castellated = opts.Castellated
+	if opts.Revision != nil {
+		applyRevision(board, *opts.Revision)
+	}
+	if opts.Diagnostics != nil {
+		board.Diagnostics = opts.Diagnostics
+	}
+	board.Thickness = opts.BoardThickness
+	if board.Thickness == 0 {
+		board.Thickness = thickness(p, opts)
+		if err := gerber.ValidateThickness(board.Thickness); err != nil && !opts.NoBoard {
+			diag.Warnf(board.Diagnostics, nil, "%v: recording it in the Gerber job file anyway", err)
+		}
+	}
+	board.AddFeatures(outline)
+	if opts.Pour != nil {
+		if opts.MetalCore && opts.Pour.Bottom {
+			return nil, errors.New("frontpanels: metal-core boards cannot have a copper pour on the rear")
+		}
+		pour, err := copper.GenerateFeatures(p, *opts.Pour)
+		if err != nil {
+			return nil, err
+		}
+		board.AddPour(pour, opts.Pour.Clearance)
+	}
+	board.AddFeatures(feats)
+	return board, nil
+}
+
+// thickness returns the thickness of the panel: opts.Thickness if given, or
+// else its own
+func thickness(p panel.Panel, opts RenderOptions) float64 {
+	if opts.Thickness > 0 {
+		return opts.Thickness
+	}
+	return panel.Thickness(p)
+}
+
+// prepare generates the panel outline features and prepares them and the
+// additional features for rendering. See prepare.Features.
+func prepare(p panel.Panel, feats []features.Feature, opts RenderOptions) (outline, prepared []features.Feature, err error) {
+	if opts.Revision != nil {
+		var r diag.Reporter = diag.Logger{}
+		if opts.Diagnostics != nil {
+			r = opts.Diagnostics
+		}
+		feats = append(append([]features.Feature{}, feats...), opts.Revision.Features(p, feats, r)...)
+	}
+	return preparer.Features(p, feats, preparer.Options{
+		Drills:       opts.Drills,
+		Placeholders: opts.Placeholders,
+		Decorations:  opts.Decorations,
+		Diagnostics:  opts.Diagnostics,
+		KeepCustom:   KeepCustom,
+	})
+}
+
+// KeepCustom reports whether a custom feature is rendered by a render
+// function registered for its kind with the Gerber, KiCad or VCV Rack
+// renderers, rather than expanded and prepared like standard features
+func KeepCustom(c features.Custom) bool {
+	kind := c.Kind()
+	return gerber.HasCustomRenderer(kind) || kicad.HasCustomRenderer(kind) || vcvrack.HasCustomRenderer(kind)
+}
+
+// filename returns the output filename for a non-Gerber output
+func (opts RenderOptions) filename(layer, ext string) string {
+	template := opts.FilenameTemplate
+	if template == "" {
+		template = output.DefaultFilenameTemplate
+	}
+	return output.ExpandTemplate(template, opts.Name, layer, ext)
+}
+
+// Render renders a panel as Board does, then writes every output file to
+// the output sink. The sink is not closed.
+func Render(p panel.Panel, feats []features.Feature, opts RenderOptions) error {
+	if opts.Output == nil {
+		return errors.New("frontpanels: no output sink")
+	}
+	outline, feats, err := prepare(p, feats, opts)
+	if err != nil {
+		return err
+	}
+	board, err := newBoard(p, outline, feats, opts)
+	if err != nil {
+		return err
+	}
+	files := []output.File{}
+	if !opts.NoBoard {
+		files = board.Files()
+	}
+	thickness := thickness(p, opts)
+	if opts.OpenSCAD {
+		model := openscad.NewModel(p)
+		model.Thickness = thickness
+		model.Diagnostics = board.Diagnostics
+		model.AddFeatures(outline)
+		model.AddFeatures(feats)
+		files = append(files, output.File{Filename: opts.filename("model", "scad"), Write: model.WriteSCAD})
+	}
+	if opts.KiCad {
+		fp := kicad.NewFootprint(opts.Name, p)
+		fp.Diagnostics = board.Diagnostics
+		fp.AddFeatures(outline)
+		fp.AddFeatures(feats)
+		files = append(files, output.File{Filename: opts.filename("footprint", "kicad_mod"), Write: fp.WriteKicadMod})
+	}
+	if opts.STL {
+		model := stl.NewModel(p, thickness)
+		model.Diagnostics = board.Diagnostics
+		model.AddFeatures(outline)
+		model.AddFeatures(feats)
+		files = append(files, output.File{Filename: opts.filename("model", "stl"), Write: model.WriteSTL})
+	}
+	if opts.VCVRack {
+		vp := vcvrack.NewPanel(p)
+		vp.Diagnostics = board.Diagnostics
+		vp.AddFeatures(feats)
+		files = append(files, output.File{Filename: opts.filename("vcvrack", "svg"), Write: vp.WriteSVG})
+	}
+	if opts.GCode != nil {
+		prog := gcode.NewProgram(p, *opts.GCode)
+		prog.Thickness = thickness
+		prog.Diagnostics = board.Diagnostics
+		prog.AddFeatures(feats)
+		files = append(files,
+			output.File{Filename: opts.filename("engrave", "nc"), Write: prog.WriteEngraving},
+			output.File{Filename: opts.filename("profile", "nc"), Write: prog.WriteProfile},
+		)
+	}
+	if opts.Laser != nil {
+		job := laser.NewJob(p, *opts.Laser)
+		job.Diagnostics = board.Diagnostics
+		job.AddFeatures(outline)
+		job.AddFeatures(feats)
+		files = append(files, output.File{Filename: opts.filename("laser", "svg"), Write: job.WriteSVG})
+	}
+	if opts.HPGL != nil {
+		plot := hpgl.NewPlot(p, *opts.HPGL)
+		plot.Diagnostics = board.Diagnostics
+		plot.AddFeatures(outline)
+		plot.AddFeatures(feats)
+		files = append(files, output.File{Filename: opts.filename("overlay", "plt"), Write: plot.WriteHPGL})
+	}
+	if opts.Overlay != nil {
+		sheetOptions := *opts.Overlay
+		sheetOptions.Annotations = opts.Annotations
+		sheet := overlay.NewSheet(p, sheetOptions)
+		sheet.Diagnostics = board.Diagnostics
+		sheet.AddFeatures(feats)
+		colours := preview.DefaultOptions()
+		if opts.Preview != nil {
+			colours = *opts.Preview
+		}
+		sheet.AddHardware(opts.Hardware, colours)
+		sheet.CheckPage()
+		files = append(files,
+			output.File{Filename: opts.filename("overlay", "pdf"), Write: sheet.WritePDF},
+			output.File{Filename: opts.filename("overlay", "svg"), Write: sheet.WriteSVG},
+		)
+	}
+	files = append(files, previewFiles(p, outline, feats, opts, board.Diagnostics)...)
+	if opts.BOM != nil {
+		files = append(files,
+			output.File{Filename: opts.filename("bom", "csv"), Write: opts.BOM.WriteCSV},
+			output.File{Filename: opts.filename("bom", "json"), Write: opts.BOM.WriteJSON},
+		)
+	}
+	if opts.Revision != nil {
+		desc := describe(p, outline, feats, board, opts)
+		files = append(files, output.File{Filename: opts.filename("description", "json"), Write: desc.WriteJSON})
+		files = withManifest(files, board, *opts.Revision, p, opts.filename("manifest", "json"))
+	}
+	return output.WriteFiles(opts.Output, files)
+}
+
+// previewFiles returns the SVG preview and PNG image selected by the
+// options, if any
+func previewFiles(p panel.Panel, outline, feats []features.Feature, opts RenderOptions, r diag.Reporter) []output.File {
+	files := []output.File{}
+	if opts.Preview != nil {
+		colours := *opts.Preview
+		colours.Annotations = opts.Annotations
+		pv := preview.New(p, colours)
+		pv.Diagnostics = r
+		pv.AddFeatures(outline)
+		pv.AddFeatures(feats)
+		pv.AddHardware(opts.Hardware)
+		files = append(files, output.File{Filename: opts.filename("preview", "svg"), Write: pv.WriteSVG})
+	}
+	if opts.Composite != nil {
+		c := composite.New(p, *opts.Composite)
+		c.Diagnostics = r
+		c.AddFeatures(outline)
+		c.AddFeatures(feats)
+		c.AddHardware(opts.Hardware)
+		files = append(files, output.File{Filename: opts.filename("preview", "png"), Write: c.WritePNG})
+	}
+	return files
+}
+
+// RenderPreviews writes only the SVG preview and PNG image selected by the
+// options to the output sink, without the fabrication files. The sink is
+// not closed.
+func RenderPreviews(p panel.Panel, feats []features.Feature, opts RenderOptions) error {
+	if opts.Output == nil {
+		return errors.New("frontpanels: no output sink")
+	}
+	outline, feats, err := prepare(p, feats, opts)
+	if err != nil {
+		return err
+	}
+	var r diag.Reporter = diag.Logger{}
+	if opts.Diagnostics != nil {
+		r = opts.Diagnostics
+	}
+	return output.WriteFiles(opts.Output, previewFiles(p, outline, feats, opts, r))
+}
+
+// Preview writes an SVG preview of the finished front of a panel, without
+// rendering any other output. The preview colours are taken from
+// opts.Preview, or the defaults if it is nil.
+func Preview(p panel.Panel, feats []features.Feature, opts RenderOptions, w io.Writer) error {
+	outline, feats, err := prepare(p, feats, opts)
+	if err != nil {
+		return err
+	}
+	colours := preview.DefaultOptions()
+	if opts.Preview != nil {
+		colours = *opts.Preview
+	}
+	colours.Annotations = opts.Annotations
+	pv := preview.New(p, colours)
+	if opts.Diagnostics != nil {
+		pv.Diagnostics = opts.Diagnostics
+	}
+	pv.AddFeatures(outline)
+	pv.AddFeatures(feats)
+	pv.AddHardware(opts.Hardware)
+	return pv.WriteSVG(w)
+}
+
+// PreviewTerminal writes a character-cell sketch of the finished front of a
+// panel, for viewing in a terminal, without rendering any other output
+func PreviewTerminal(p panel.Panel, feats []features.Feature, opts RenderOptions, topts terminal.Options, w io.Writer) error {
+	outline, feats, err := prepare(p, feats, opts)
+	if err != nil {
+		return err
+	}
+	t := terminal.New(p, topts)
+	if opts.Diagnostics != nil {
+		t.Diagnostics = opts.Diagnostics
+	}
+	t.AddFeatures(outline)
+	t.AddFeatures(feats)
+	return t.Write(w)
+}
+
+// RenderMemory renders a panel as Render does, returning the contents of
+// each output file keyed by filename. Any output sink in the options is
+// ignored.
+func RenderMemory(p panel.Panel, feats []features.Feature, opts RenderOptions) (map[string][]byte, error) {
+	mem := output.NewMemory()
+	opts.Output = mem
+	if err := Render(p, feats, opts); err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for filename, buf := range mem.Files {
+		files[filename] = buf.Bytes()
+	}
+	return files, nil
+}
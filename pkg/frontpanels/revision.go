@@ -0,0 +1,284 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package frontpanels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/output"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/gerber"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// RevisionTextSize is the height of capital letters in the revision text
+// on the rear silkscreen, in millimetres
+const RevisionTextSize = 1.2
+
+// revisionTag tags the revision text, which Diff ignores as it changes with
+// every revision
+const revisionTag = "revision"
+
+// Revision identifies the spec a panel was generated from, so that
+// fabricated panels can be traced back to it. Empty fields are omitted.
+type Revision struct {
+	Name     string `json:"name"`
+	Revision string `json:"revision,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+}
+
+// fields returns the labelled non-empty fields of the revision
+func (r Revision) fields() [][2]string {
+	fields := [][2]string{}
+	for _, f := range [][2]string{{"name", r.Name}, {"revision", r.Revision}, {"date", r.Date}, {"commit", r.Commit}} {
+		if f[1] != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Comments returns the revision as lines of "label: value", for file
+// header comments
+func (r Revision) Comments() []string {
+	lines := []string{}
+	for _, f := range r.fields() {
+		lines = append(lines, f[0]+": "+f[1])
+	}
+	return lines
+}
+
+// String returns the non-empty fields of the revision separated by spaces,
+// with the revision prefixed by "rev "
+func (r Revision) String() string {
+	words := []string{}
+	for _, f := range r.fields() {
+		if f[0] == "revision" {
+			f[1] = "rev " + f[1]
+		}
+		words = append(words, f[1])
+	}
+	return strings.Join(words, " ")
+}
+
+// revisionClearance is the space kept between the revision text and any
+// cutout or rear feature, in millimetres
+const revisionClearance = 0.5
+
+// revisionStep is the pitch of the positions tried for the revision text,
+// in millimetres
+const revisionStep = 0.5
+
+// Features returns the revision as text on the rear silkscreen, as near the
+// centre of the usable area of the panel as it fits clear of the panel's
+// mounting holes and of the cutouts and rear features among feats. On
+// panels taller than they are wide the text runs up the panel. Text that
+// fits nowhere is left at the centre, and a Warning reported.
+func (r Revision) Features(p panel.Panel, feats []features.Feature, rep diag.Reporter) []features.Feature {
+	area := panel.UsableArea(p)
+	// spec panels listing their bottom mounting holes first have their
+	// usable area upside down
+	area = geometry.Rect{
+		BottomLeft: geometry.Point{X: math.Min(area.BottomLeft.X, area.TopRight.X), Y: math.Min(area.BottomLeft.Y, area.TopRight.Y)},
+		TopRight:   geometry.Point{X: math.Max(area.BottomLeft.X, area.TopRight.X), Y: math.Max(area.BottomLeft.Y, area.TopRight.Y)},
+	}
+	rotation := 0.0
+	if area.Height() > area.Width() {
+		rotation = math.Pi / 2
+	}
+	t := features.NewText(area.Centre(), r.String(),
+		features.WithAlignment(features.Centre),
+		features.WithSizeMM(RevisionTextSize),
+		features.WithRotation(rotation))
+	t.SetSide(features.BottomSide)
+	t.SetTag(revisionTag)
+	bl, tr, err := textpath.Bounds(t)
+	if err != nil {
+		return []features.Feature{t}
+	}
+	// the glyphs of rotated text are not centred on its origin, so the text
+	// is centred by its bounds
+	centring := area.Centre().Sub(geometry.Rect{BottomLeft: bl, TopRight: tr}.Centre())
+	t.Origin = t.Origin.Add(centring)
+	box := geometry.Rect{BottomLeft: bl.Add(centring), TopRight: tr.Add(centring)}.Inset(-revisionClearance)
+	if offset, ok := clearOffset(area, box, obstacles(p, feats)); ok {
+		t.Origin = t.Origin.Add(offset)
+	} else {
+		diag.Warnf(rep, t, "revision text %q overlaps cutouts or rear features wherever it is placed", t.Text)
+	}
+	return []features.Feature{t}
+}
+
+// obstacles returns the bounding boxes of the mounting holes of a panel
+// and of the cutouts and rear features among feats, other than annotations
+func obstacles(p panel.Panel, feats []features.Feature) []geometry.Rect {
+	boxes := []geometry.Rect{}
+	r := p.MountingHoleDiameter() / 2
+	for _, h := range p.MountingHoles() {
+		boxes = append(boxes, geometry.Rect{BottomLeft: h.Sub(geometry.Point{X: r, Y: r}), TopRight: h.Add(geometry.Point{X: r, Y: r})})
+	}
+	for _, f := range feats {
+		purpose := f.GetPurpose()
+		s, sided := f.(features.Sided)
+		rear := sided && s.GetSide() == features.BottomSide
+		if purpose == features.Annotation || (purpose != features.Cutout && !rear) {
+			continue
+		}
+		contours, err := flatten.Contours(f, flatten.Coarse)
+		if err != nil || len(contours) == 0 {
+			continue
+		}
+		box := geometry.Rect{BottomLeft: contours[0][0], TopRight: contours[0][0]}
+		for _, c := range contours {
+			for _, pt := range c {
+				box.BottomLeft = geometry.Point{X: math.Min(box.BottomLeft.X, pt.X), Y: math.Min(box.BottomLeft.Y, pt.Y)}
+				box.TopRight = geometry.Point{X: math.Max(box.TopRight.X, pt.X), Y: math.Max(box.TopRight.Y, pt.Y)}
+			}
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes
+}
+
+// clearOffset returns the shortest offset, in steps of revisionStep, that
+// moves a box to lie within an area without overlapping any obstacle, and
+// false if there is none
+func clearOffset(area, box geometry.Rect, obstacles []geometry.Rect) (geometry.Point, bool) {
+	offsets := []geometry.Point{}
+	for x := math.Ceil((area.BottomLeft.X - box.BottomLeft.X) / revisionStep); x*revisionStep <= area.TopRight.X-box.TopRight.X; x++ {
+		for y := math.Ceil((area.BottomLeft.Y - box.BottomLeft.Y) / revisionStep); y*revisionStep <= area.TopRight.Y-box.TopRight.Y; y++ {
+			offsets = append(offsets, geometry.Point{X: x * revisionStep, Y: y * revisionStep})
+		}
+	}
+	sort.SliceStable(offsets, func(i, j int) bool {
+		return offsets[i].Distance(geometry.Point{}) < offsets[j].Distance(geometry.Point{})
+	})
+	for _, o := range offsets {
+		moved := geometry.Rect{BottomLeft: box.BottomLeft.Add(o), TopRight: box.TopRight.Add(o)}
+		clear := true
+		for _, b := range obstacles {
+			if moved.BottomLeft.X < b.TopRight.X && b.BottomLeft.X < moved.TopRight.X &&
+				moved.BottomLeft.Y < b.TopRight.Y && b.BottomLeft.Y < moved.TopRight.Y {
+				clear = false
+				break
+			}
+		}
+		if clear {
+			return o, true
+		}
+	}
+	return geometry.Point{}, false
+}
+
+// applyRevision records the revision in the headers of the Gerber and drill
+// files of a board
+func applyRevision(board *gerber.Board, r Revision) {
+	board.Revision = r.Revision
+	board.Comments = r.Comments()
+}
+
+// manifestFile describes an output file listed in a manifest
+type manifestFile struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// manifestBoard describes the board specification in a manifest
+type manifestBoard struct {
+	Thickness   float64 `json:"thickness"`
+	MetalCore   bool    `json:"metalCore,omitempty"`
+	EdgePlating bool    `json:"edgePlating,omitempty"`
+	Castellated bool    `json:"castellated,omitempty"`
+}
+
+// manifest describes a panel and the output files generated for it
+type manifest struct {
+	Revision
+	Format             string         `json:"format"`
+	GenerationSoftware string         `json:"generationSoftware"`
+	Board              manifestBoard  `json:"board"`
+	Files              []manifestFile `json:"files"`
+}
+
+// withManifest returns the files followed by a JSON manifest listing the
+// revision, the board specification of the job file, and the size and
+// SHA-256 digest of each file. The digests are taken as the files are
+// written, so the manifest must be written last.
+func withManifest(files []output.File, board *gerber.Board, r Revision, p panel.Panel, filename string) []output.File {
+	m := &manifest{
+		Revision:           r,
+		Format:             panel.Description(p),
+		GenerationSoftware: gerber.GenerationSoftware,
+		Board: manifestBoard{
+			Thickness:   board.Thickness,
+			MetalCore:   board.MetalCore,
+			EdgePlating: board.EdgePlating,
+			Castellated: board.Castellated,
+		},
+		Files: make([]manifestFile, len(files)),
+	}
+	if m.Board.Thickness == 0 {
+		m.Board.Thickness = gerber.DefaultBoardThickness
+	}
+	wrapped := []output.File{}
+	for i, file := range files {
+		entry, write := &m.Files[i], file.Write
+		entry.Filename = file.Filename
+		wrapped = append(wrapped, output.File{Filename: file.Filename, Write: func(w io.Writer) error {
+			h := sha256.New()
+			counter := &countWriter{}
+			if err := write(io.MultiWriter(w, h, counter)); err != nil {
+				return err
+			}
+			entry.Size = counter.n
+			entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+			return nil
+		}})
+	}
+	return append(wrapped, output.File{Filename: filename, Write: func(w io.Writer) error {
+		sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Filename < m.Files[j].Filename })
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	}})
+}
+
+// countWriter counts the bytes written to it
+type countWriter struct {
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package frontpanels
+
+import (
+	"testing"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// TestRevisionClearOfCutouts checks that the revision text moves off a
+// cutout at the centre of the panel
+func TestRevisionClearOfCutouts(t *testing.T) {
+	p := eurorack.NewEurorack(8)
+	c := features.NewCircle(panel.UsableArea(p).Centre(), 4)
+	c.SetPurpose(features.Cutout)
+	feats := Revision{Revision: "r1"}.Features(p, []features.Feature{c}, diag.Discard)
+	bl, tr, err := textpath.Bounds(feats[0].(*features.Text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	centre := c.Origin
+	if bl.X < centre.X+4 && centre.X-4 < tr.X && bl.Y < centre.Y+4 && centre.Y-4 < tr.Y {
+		t.Errorf("revision text from %v to %v overlaps the cutout at %v", bl, tr, centre)
+	}
+}
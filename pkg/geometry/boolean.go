@@ -0,0 +1,387 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// booleanTolerance is the distance, in millimetres, within which points
+	// are treated as lying on a segment when splitting segments at their
+	// intersections
+	booleanTolerance = 1e-9
+	// booleanProbe is the distance either side of a segment at which the
+	// inputs are sampled to decide whether it bounds the result
+	booleanProbe = 1e-6
+)
+
+// Operation is a boolean operation combining two regions
+type Operation int
+
+// Union et al specify boolean operations
+const (
+	// Union covers the area covered by either region
+	Union Operation = iota // this MUST be the first item
+	// Intersection covers the area covered by both regions
+	Intersection
+	// Difference covers the area of the first region not covered by the
+	// second
+	Difference
+	// Xor covers the area covered by exactly one of the regions
+	Xor // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (o Operation) String() string {
+	switch o {
+	case Union:
+		return "union"
+	case Intersection:
+		return "intersection"
+	case Difference:
+		return "difference"
+	case Xor:
+		return "xor"
+	}
+	panic(fmt.Sprintf("invalid Operation value (valid range is %d..%d): %d",
+		int(Union), int(Xor), int(o)))
+}
+
+// ParseOperation converts an operation name, as returned by
+// Operation.String, to an Operation
+func ParseOperation(s string) (Operation, error) {
+	for o := Union; o <= Xor; o++ {
+		if o.String() == s {
+			return o, nil
+		}
+	}
+	return Union, fmt.Errorf("invalid boolean operation %q (valid values: union intersection difference xor)", s)
+}
+
+// apply combines whether a point is inside each region
+func (o Operation) apply(a, b bool) bool {
+	switch o {
+	case Union:
+		return a || b
+	case Intersection:
+		return a && b
+	case Difference:
+		return a && !b
+	}
+	return a != b
+}
+
+// segment is a directed edge of a contour
+type segment struct {
+	a, b Point
+}
+
+// RegionContains reports whether a point lies inside a region made up of
+// closed contours, by the even-odd rule, so that contours nested within
+// others are holes
+func RegionContains(region [][]Point, p Point) bool {
+	inside := false
+	for _, c := range region {
+		if Contains(c, p) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segments returns the non-degenerate edges of closed contours
+func segments(contours [][]Point) []segment {
+	segs := []segment{}
+	for _, c := range contours {
+		for i, a := range c {
+			if b := c[(i+1)%len(c)]; a != b {
+				segs = append(segs, segment{a, b})
+			}
+		}
+	}
+	return segs
+}
+
+// onSegment returns the parameter of the point of s nearest p, and whether
+// p lies on s within booleanTolerance
+func onSegment(s segment, p Point) (float64, bool) {
+	d := s.b.Sub(s.a)
+	t := (p.Sub(s.a).X*d.X + p.Sub(s.a).Y*d.Y) / (d.X*d.X + d.Y*d.Y)
+	if t < 0 || t > 1 {
+		return t, false
+	}
+	return t, s.a.Add(d.Scale(t)).Distance(p) <= booleanTolerance
+}
+
+// split splits segments wherever they intersect or touch another, so that
+// segments only meet at their ends. Points where segments meet are shared
+// exactly, so that they can be joined into contours.
+func split(segs []segment) []segment {
+	cuts := make([][]Point, len(segs))
+	// cut adds p to segment i unless it is one of its ends
+	cut := func(i int, p Point) {
+		if p != segs[i].a && p != segs[i].b {
+			cuts[i] = append(cuts[i], p)
+		}
+	}
+	for i, p := range segs {
+		for j := i + 1; j < len(segs); j++ {
+			q := segs[j]
+			if math.Max(p.a.X, p.b.X) < math.Min(q.a.X, q.b.X)-booleanTolerance ||
+				math.Max(q.a.X, q.b.X) < math.Min(p.a.X, p.b.X)-booleanTolerance ||
+				math.Max(p.a.Y, p.b.Y) < math.Min(q.a.Y, q.b.Y)-booleanTolerance ||
+				math.Max(q.a.Y, q.b.Y) < math.Min(p.a.Y, p.b.Y)-booleanTolerance {
+				continue
+			}
+			// ends of either segment lying on the other, which covers
+			// touching and overlapping collinear segments
+			touched := false
+			for _, e := range []struct {
+				s, on int
+				p     Point
+			}{{j, i, q.a}, {j, i, q.b}, {i, j, p.a}, {i, j, p.b}} {
+				if _, ok := onSegment(segs[e.on], e.p); ok {
+					cut(e.on, e.p)
+					touched = true
+				}
+			}
+			if touched {
+				continue
+			}
+			// proper crossings
+			d1, d2 := p.b.Sub(p.a), q.b.Sub(q.a)
+			denom := d1.X*d2.Y - d1.Y*d2.X
+			if denom == 0 {
+				continue
+			}
+			w := q.a.Sub(p.a)
+			t := (w.X*d2.Y - w.Y*d2.X) / denom
+			u := (w.X*d1.Y - w.Y*d1.X) / denom
+			if t > 0 && t < 1 && u > 0 && u < 1 {
+				x := p.a.Add(d1.Scale(t))
+				cut(i, x)
+				cut(j, x)
+			}
+		}
+	}
+	result := []segment{}
+	for i, s := range segs {
+		points := append([]Point{s.a}, cuts[i]...)
+		sort.SliceStable(points[1:], func(m, n int) bool {
+			tm, _ := onSegment(s, points[1+m])
+			tn, _ := onSegment(s, points[1+n])
+			return tm < tn
+		})
+		points = append(points, s.b)
+		for k := 0; k+1 < len(points); k++ {
+			if points[k] != points[k+1] {
+				result = append(result, segment{points[k], points[k+1]})
+			}
+		}
+	}
+	return result
+}
+
+// less orders points lexicographically
+func less(p, q Point) bool {
+	return p.X < q.X || (p.X == q.X && p.Y < q.Y)
+}
+
+// undirected returns a segment with its ends in a canonical order, so that
+// coincident segments compare equal whatever their direction
+func undirected(s segment) segment {
+	if less(s.b, s.a) {
+		return segment{s.b, s.a}
+	}
+	return s
+}
+
+// Boolean combines two regions, each made up of closed contours filled by
+// the even-odd rule, returning the contours of the result. Outer contours
+// run anticlockwise and holes clockwise, so that the result fills correctly
+// by either the even-odd or non-zero rule.
+func Boolean(op Operation, a, b [][]Point) [][]Point {
+	return combine([][][]Point{a, b}, func(inside []bool) bool {
+		return op.apply(inside[0], inside[1])
+	})
+}
+
+// Merge returns the union of any number of regions
+func Merge(regions ...[][]Point) [][]Point {
+	return combine(regions, func(inside []bool) bool {
+		for _, in := range inside {
+			if in {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Islands splits a region returned by Boolean or Merge into its separate
+// filled areas, each an outer contour followed by the holes directly within
+// it. Areas within holes are islands of their own.
+func Islands(region [][]Point) [][][]Point {
+	islands := [][][]Point{}
+	holes := [][]Point{}
+	for _, c := range region {
+		if len(c) < 3 {
+			continue
+		}
+		if SignedArea(c) > 0 {
+			islands = append(islands, [][]Point{c})
+		} else {
+			holes = append(holes, c)
+		}
+	}
+	for _, h := range holes {
+		// a hole belongs to the smallest outer contour containing it
+		best := -1
+		for i, island := range islands {
+			if Contains(island[0], h[0]) && (best < 0 || SignedArea(island[0]) < SignedArea(islands[best][0])) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			islands[best] = append(islands[best], h)
+		}
+	}
+	return islands
+}
+
+// combine returns the contours of the area for which rule returns true,
+// given whether a point is inside each region. Every edge of the regions is
+// split where it meets another, and kept as an edge of the result if the
+// result lies on exactly one side of it.
+func combine(regions [][][]Point, rule func(inside []bool) bool) [][]Point {
+	all := []segment{}
+	for _, r := range regions {
+		all = append(all, segments(r)...)
+	}
+	// regions are skipped by bounding box, as offsetting combines many
+	// small regions
+	bounds := make([]Rect, len(regions))
+	for i, r := range regions {
+		bounds[i] = Rect{BottomLeft: Point{X: math.Inf(1), Y: math.Inf(1)}, TopRight: Point{X: math.Inf(-1), Y: math.Inf(-1)}}
+		for _, c := range r {
+			for _, p := range c {
+				bounds[i].BottomLeft = Point{X: math.Min(bounds[i].BottomLeft.X, p.X), Y: math.Min(bounds[i].BottomLeft.Y, p.Y)}
+				bounds[i].TopRight = Point{X: math.Max(bounds[i].TopRight.X, p.X), Y: math.Max(bounds[i].TopRight.Y, p.Y)}
+			}
+		}
+	}
+	inside := make([]bool, len(regions))
+	at := func(p Point) bool {
+		for i, r := range regions {
+			inside[i] = bounds[i].Contains(p) && RegionContains(r, p)
+		}
+		return rule(inside)
+	}
+	seen := map[segment]bool{}
+	kept := []segment{}
+	for _, s := range split(all) {
+		key := undirected(s)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		mid := s.a.Add(s.b).Scale(0.5)
+		// Perpendicular is the right-hand normal
+		n := s.b.Sub(s.a).Perpendicular().Unit().Scale(booleanProbe)
+		left, right := at(mid.Sub(n)), at(mid.Add(n))
+		switch {
+		case left && !right:
+			kept = append(kept, s)
+		case right && !left:
+			kept = append(kept, segment{s.b, s.a})
+		}
+	}
+	return chain(kept)
+}
+
+// chain joins directed segments end to end into closed contours. Where
+// several contours meet at a point, the sharpest left turn is taken, so
+// that contours touching at a corner stay separate.
+func chain(segs []segment) [][]Point {
+	from := map[Point][]int{}
+	for i, s := range segs {
+		from[s.a] = append(from[s.a], i)
+	}
+	used := make([]bool, len(segs))
+	contours := [][]Point{}
+	for start := range segs {
+		if used[start] {
+			continue
+		}
+		contour := []Point{}
+		i := start
+		for !used[i] {
+			used[i] = true
+			s := segs[i]
+			contour = append(contour, s.a)
+			if s.b == segs[start].a {
+				break
+			}
+			in := s.b.Sub(s.a)
+			best, bestAngle := -1, math.Inf(-1)
+			for _, j := range from[s.b] {
+				if used[j] {
+					continue
+				}
+				out := segs[j].b.Sub(segs[j].a)
+				angle := math.Atan2(in.X*out.Y-in.Y*out.X, in.X*out.X+in.Y*out.Y)
+				if angle > bestAngle {
+					best, bestAngle = j, angle
+				}
+			}
+			if best < 0 {
+				contour = nil
+				break
+			}
+			i = best
+		}
+		if contour = simplify(contour); len(contour) >= 3 {
+			contours = append(contours, contour)
+		}
+	}
+	return contours
+}
+
+// simplify removes points lying on the straight line between their
+// neighbours
+func simplify(contour []Point) []Point {
+	for removed := true; removed && len(contour) >= 3; {
+		removed = false
+		for i := 0; i < len(contour) && len(contour) >= 3; i++ {
+			prev := contour[(i+len(contour)-1)%len(contour)]
+			next := contour[(i+1)%len(contour)]
+			if distanceToSegment(contour[i], prev, next) <= booleanTolerance {
+				contour = append(contour[:i:i], contour[i+1:]...)
+				removed = true
+				i--
+			}
+		}
+	}
+	return contour
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+// square returns an anticlockwise square contour
+func square(x0, y0, x1, y1 float64) []Point {
+	return []Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+}
+
+// area returns the filled area of a region whose holes run clockwise
+func area(region [][]Point) float64 {
+	a := 0.0
+	for _, c := range region {
+		a += SignedArea(c)
+	}
+	return a
+}
+
+// TestBoolean checks the area and number of contours of each operation on
+// disjoint, overlapping, contained and touching squares
+func TestBoolean(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		a, b     [][]Point
+		op       Operation
+		area     float64
+		contours int
+	}{
+		{"disjoint", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(5, 5, 7, 7)}, Union, 8, 2},
+		{"disjoint", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(5, 5, 7, 7)}, Intersection, 0, 0},
+		{"disjoint", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(5, 5, 7, 7)}, Difference, 4, 1},
+		{"disjoint", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(5, 5, 7, 7)}, Xor, 8, 2},
+		{"overlapping", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(1, 1, 3, 3)}, Union, 7, 1},
+		{"overlapping", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(1, 1, 3, 3)}, Intersection, 1, 1},
+		{"overlapping", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(1, 1, 3, 3)}, Difference, 3, 1},
+		{"overlapping", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(1, 1, 3, 3)}, Xor, 6, 2},
+		{"contained", [][]Point{square(0, 0, 4, 4)}, [][]Point{square(1, 1, 2, 2)}, Union, 16, 1},
+		{"contained", [][]Point{square(0, 0, 4, 4)}, [][]Point{square(1, 1, 2, 2)}, Intersection, 1, 1},
+		{"contained", [][]Point{square(0, 0, 4, 4)}, [][]Point{square(1, 1, 2, 2)}, Difference, 15, 2},
+		{"contained", [][]Point{square(1, 1, 2, 2)}, [][]Point{square(0, 0, 4, 4)}, Difference, 0, 0},
+		{"edge-touching", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(2, 0, 4, 2)}, Union, 8, 1},
+		{"edge-touching", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(2, 0, 4, 2)}, Intersection, 0, 0},
+		{"edge-touching", [][]Point{square(0, 0, 2, 2)}, [][]Point{square(2, 0, 4, 2)}, Difference, 4, 1},
+		{"corner-touching", [][]Point{square(0, 0, 1, 1)}, [][]Point{square(1, 1, 2, 2)}, Union, 2, 2},
+		{"holed", [][]Point{square(0, 0, 4, 4), square(1, 1, 3, 3)}, [][]Point{square(2, -1, 5, 5)}, Difference, 6, 1},
+		{"holed", [][]Point{square(0, 0, 4, 4), square(1, 1, 3, 3)}, [][]Point{square(2, -1, 5, 5)}, Intersection, 6, 1},
+	} {
+		got := Boolean(tc.op, tc.a, tc.b)
+		if math.Abs(area(got)-tc.area) > 1e-9 || len(got) != tc.contours {
+			t.Errorf("%s %s: got %d contours of area %v, want %d of area %v", tc.name, tc.op, len(got), area(got), tc.contours, tc.area)
+		}
+	}
+}
+
+// TestBooleanHoles checks that holes in a result run clockwise inside
+// anticlockwise outer contours, and are assigned to their islands
+func TestBooleanHoles(t *testing.T) {
+	got := Boolean(Difference, [][]Point{square(0, 0, 10, 10)}, [][]Point{square(1, 1, 3, 3), square(5, 5, 8, 8)})
+	if len(got) != 3 {
+		t.Fatalf("got %d contours, want an outline and 2 holes", len(got))
+	}
+	outer, holes := 0, 0
+	for _, c := range got {
+		if SignedArea(c) > 0 {
+			outer++
+		} else {
+			holes++
+		}
+	}
+	if outer != 1 || holes != 2 {
+		t.Errorf("got %d anticlockwise and %d clockwise contours, want 1 and 2", outer, holes)
+	}
+	islands := Islands(got)
+	if len(islands) != 1 || len(islands[0]) != 3 {
+		t.Fatalf("got %d islands, want 1 with 2 holes", len(islands))
+	}
+	if !RegionContains(got, Point{X: 4, Y: 4}) || RegionContains(got, Point{X: 2, Y: 2}) || RegionContains(got, Point{X: 6, Y: 6}) {
+		t.Error("result does not cover the outline less the holes")
+	}
+	// an island within a hole is an island of its own
+	nested := Merge(got, [][]Point{square(6, 6, 7, 7)})
+	if islands := Islands(nested); len(islands) != 2 {
+		t.Errorf("got %d islands, want 2 with one inside a hole", len(islands))
+	}
+}
+
+// TestMerge checks the union of several regions at once
+func TestMerge(t *testing.T) {
+	got := Merge([][]Point{square(0, 0, 2, 2)}, [][]Point{square(1, 0, 3, 2)}, [][]Point{square(2, 0, 4, 2)}, [][]Point{square(10, 10, 11, 11)})
+	if math.Abs(area(got)-9) > 1e-9 || len(got) != 2 {
+		t.Errorf("got %d contours of area %v, want 2 of area 9", len(got), area(got))
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import "math"
+
+// DefaultTolerance is the default maximum deviation, in millimetres, between
+// a curve and the straight segments approximating it
+const DefaultTolerance = 0.01
+
+// maxSubdivisions limits recursion when flattening degenerate curves
+const maxSubdivisions = 16
+
+// distanceToSegment returns the distance of p from the line segment a..b
+func distanceToSegment(p, a, b Point) float64 {
+	ab := b.Sub(a)
+	l2 := ab.X*ab.X + ab.Y*ab.Y
+	if l2 == 0 {
+		return p.Distance(a)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*ab.X+(p.Y-a.Y)*ab.Y)/l2))
+	return p.Distance(a.Add(ab.Scale(t)))
+}
+
+// CubicBezier flattens a cubic Bezier curve into a series of points, such
+// that no point on the curve is further than tolerance from the resulting
+// polyline. The first and last points are p0 and p3.
+func CubicBezier(p0, p1, p2, p3 Point, tolerance float64) []Point {
+	return append([]Point{p0}, cubic(p0, p1, p2, p3, tolerance, 0)...)
+}
+
+// cubic recursively subdivides a cubic Bezier curve until each piece is
+// flat enough, returning all points except the first
+func cubic(p0, p1, p2, p3 Point, tolerance float64, depth int) []Point {
+	flat := math.Max(distanceToSegment(p1, p0, p3), distanceToSegment(p2, p0, p3))
+	if flat <= tolerance || depth >= maxSubdivisions {
+		return []Point{p3}
+	}
+	// de Casteljau subdivision at t=0.5
+	mid := func(a, b Point) Point { return a.Add(b).Scale(0.5) }
+	p01, p12, p23 := mid(p0, p1), mid(p1, p2), mid(p2, p3)
+	p012, p123 := mid(p01, p12), mid(p12, p23)
+	p0123 := mid(p012, p123)
+	return append(cubic(p0, p01, p012, p0123, tolerance, depth+1),
+		cubic(p0123, p123, p23, p3, tolerance, depth+1)...)
+}
+
+// QuadraticBezier flattens a quadratic Bezier curve into a series of
+// points. See CubicBezier.
+func QuadraticBezier(p0, p1, p2 Point, tolerance float64) []Point {
+	// degree elevation to an equivalent cubic
+	c1 := p0.Add(p1.Sub(p0).Scale(2.0 / 3.0))
+	c2 := p2.Add(p1.Sub(p2).Scale(2.0 / 3.0))
+	return CubicBezier(p0, c1, c2, p2, tolerance)
+}
+
+// ArcSegments returns the number of straight segments needed to approximate
+// an arc of the given radius and sweep (in degrees) within tolerance
+func ArcSegments(radius, sweep, tolerance float64) int {
+	if radius <= tolerance || tolerance <= 0 {
+		return int(math.Max(1, math.Ceil(math.Abs(sweep)/90.0)))
+	}
+	// maximum angle subtended by a chord whose sagitta is the tolerance
+	step := 2.0 * math.Acos(1.0-tolerance/radius) * 180.0 / math.Pi
+	return int(math.Max(1, math.Ceil(math.Abs(sweep)/step)))
+}
+
+// Arc flattens a circular arc into a series of points. Angles are in
+// degrees, anticlockwise from the positive X axis; the arc runs from start
+// to end, so a negative sweep is clockwise.
+func Arc(centre Point, radius, start, end, tolerance float64) []Point {
+	return EllipticalArc(centre, radius, radius, 0, start, end, tolerance)
+}
+
+// EllipticalArc flattens an arc of an ellipse with radii rx and ry, rotated
+// by phi degrees, into a series of points. See Arc.
+func EllipticalArc(centre Point, rx, ry, phi, start, end, tolerance float64) []Point {
+	n := ArcSegments(math.Max(rx, ry), end-start, tolerance)
+	points := make([]Point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		theta := (start + (end-start)*float64(i)/float64(n)) * math.Pi / 180.0
+		p := Point{X: rx * math.Cos(theta), Y: ry * math.Sin(theta)}.Rotate(phi)
+		points = append(points, centre.Add(p))
+	}
+	return points
+}
+
+// RoundedRect returns the anticlockwise contour of a rectangle spanning
+// bottomLeft to topRight, with corners of the given radius flattened within
+// tolerance. A radius of zero or less gives square corners.
+func RoundedRect(bottomLeft, topRight Point, radius, tolerance float64) []Point {
+	bl, tr, r := bottomLeft, topRight, radius
+	if r <= 0 {
+		return []Point{bl, {X: tr.X, Y: bl.Y}, tr, {X: bl.X, Y: tr.Y}}
+	}
+	contour := []Point{}
+	for _, c := range []struct {
+		centre Point
+		start  float64
+	}{
+		{Point{X: tr.X - r, Y: bl.Y + r}, -90},
+		{Point{X: tr.X - r, Y: tr.Y - r}, 0},
+		{Point{X: bl.X + r, Y: tr.Y - r}, 90},
+		{Point{X: bl.X + r, Y: bl.Y + r}, 180},
+	} {
+		// each arc's final point is the next arc's first, give or take
+		// rounding, so it is dropped
+		points := Arc(c.centre, r, c.start, c.start+90, tolerance)
+		contour = append(contour, points[:len(points)-1]...)
+	}
+	return contour
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxMiter limits the length of mitred corners, as a multiple of the offset
+// distance, so that very sharp corners do not produce long spikes
+const MaxMiter = 4.0
+
+// Join is the shape of the corners of an offset contour, where offsetting
+// opens a gap between the offset edges
+type Join int
+
+// RoundJoin et al specify joins
+const (
+	// RoundJoin fills gaps with circular arcs, as traced by the centre of a
+	// round tool following the contour
+	RoundJoin Join = iota // this MUST be the first item
+	// MiterJoin extends the offset edges until they meet, up to MaxMiter
+	// times the offset distance from the corner
+	MiterJoin // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (j Join) String() string {
+	switch j {
+	case RoundJoin:
+		return "round"
+	case MiterJoin:
+		return "miter"
+	}
+	panic(fmt.Sprintf("invalid Join value (valid range is %d..%d): %d",
+		int(RoundJoin), int(MiterJoin), int(j)))
+}
+
+// ParseJoin converts a join name, as returned by Join.String, to a Join
+func ParseJoin(s string) (Join, error) {
+	for j := RoundJoin; j <= MiterJoin; j++ {
+		if j.String() == s {
+			return j, nil
+		}
+	}
+	return RoundJoin, fmt.Errorf("invalid join %q (valid values: round miter)", s)
+}
+
+// Offset grows a region, made up of closed contours filled by the even-odd
+// rule, by a distance, or shrinks it for negative distances. Round joins
+// are flattened within tolerance. Like Boolean, outer contours of the
+// result run anticlockwise and holes clockwise; shrinking may split a
+// region into several, or remove it entirely.
+//
+// The region is combined with a band swept along its boundary: a rectangle
+// either side of each edge, and a join at each corner. Parts of the region
+// too narrow to survive shrinking are therefore removed rather than turned
+// inside out.
+func Offset(region [][]Point, distance float64, join Join, tolerance float64) [][]Point {
+	if distance == 0 {
+		return Boolean(Union, region, nil)
+	}
+	d := math.Abs(distance)
+	cleaned := make([][]Point, 0, len(region))
+	for _, c := range region {
+		if c = dedupe(c); len(c) > 0 {
+			cleaned = append(cleaned, c)
+		}
+	}
+	region = cleaned
+	band := [][][]Point{region}
+	for _, c := range region {
+		n := len(c)
+		for i, p := range c {
+			next := c[(i+1)%n]
+			if p.Distance(next) <= booleanTolerance {
+				continue
+			}
+			normal := next.Sub(p).Perpendicular().Unit().Scale(d)
+			band = append(band, [][]Point{{p.Add(normal), next.Add(normal), next.Sub(normal), p.Sub(normal)}})
+			if join == RoundJoin {
+				band = append(band, [][]Point{Arc(p, d, 0, 360, tolerance)})
+			} else if corner := miter(region, c, i, distance); corner != nil {
+				band = append(band, [][]Point{corner})
+			}
+		}
+	}
+	return combine(band, func(inside []bool) bool {
+		swept := false
+		for _, in := range inside[1:] {
+			swept = swept || in
+		}
+		if distance > 0 {
+			return inside[0] || swept
+		}
+		return inside[0] && !swept
+	})
+}
+
+// dedupe returns a contour without points lying within booleanTolerance of
+// the point before them, including a closing point nearly duplicating the
+// first, as full circle arcs end with. The normals of such short edges are
+// meaningless, so they are left out of the swept band.
+func dedupe(c []Point) []Point {
+	out := make([]Point, 0, len(c))
+	for _, p := range c {
+		if len(out) > 0 && p.Distance(out[len(out)-1]) <= booleanTolerance {
+			continue
+		}
+		out = append(out, p)
+	}
+	for len(out) > 1 && out[len(out)-1].Distance(out[0]) <= booleanTolerance {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// miter returns the mitred corner filling the gap between the edges offset
+// from the corner at point i of a contour of a region, on the side away
+// from the region for positive distances and into it for negative ones, or
+// nil if there is no gap on that side
+func miter(region [][]Point, c []Point, i int, distance float64) []Point {
+	n := len(c)
+	p, prev, next := c[i], c[(i+n-1)%n], c[(i+1)%n]
+	if p.Distance(prev) <= booleanTolerance || p.Distance(next) <= booleanTolerance {
+		return nil
+	}
+	e1, e2 := p.Sub(prev), next.Sub(p)
+	n1, n2 := e1.Perpendicular().Unit(), e2.Perpendicular().Unit()
+	// turn the normals to the side being offset into, found by probing
+	// beside the edge into the corner
+	probe := p.Add(prev).Scale(0.5).Add(n1.Scale(booleanProbe))
+	if RegionContains(region, probe) == (distance > 0) {
+		n1, n2 = n1.Scale(-1), n2.Scale(-1)
+	}
+	// there is only a gap where the contour turns away from that side
+	turn := e1.X*e2.Y - e1.Y*e2.X
+	side := e1.X*n1.Y - e1.Y*n1.X
+	if (turn > 0) == (side > 0) {
+		return nil
+	}
+	d := math.Abs(distance)
+	m := n1.Add(n2)
+	dot := 1 + n1.X*n2.X + n1.Y*n2.Y
+	if dot < 1e-9 {
+		// the contour doubles back on itself
+		return nil
+	}
+	m = m.Scale(d / dot)
+	if l := m.Length(); l > MaxMiter*d {
+		m = m.Scale(MaxMiter * d / l)
+	}
+	return []Point{p, p.Add(n1.Scale(d)), p.Add(m), p.Add(n2.Scale(d))}
+}
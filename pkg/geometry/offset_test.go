@@ -0,0 +1,39 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import "testing"
+
+// TestOffsetClosedArc checks that contours whose closing point nearly
+// duplicates their first, as full circle arcs have, can be offset
+func TestOffsetClosedArc(t *testing.T) {
+	for _, radius := range []float64{3, 20} {
+		circle := Arc(Point{}, radius, 0, 360, 0.05)
+		for j := RoundJoin; j <= MiterJoin; j++ {
+			for _, distance := range []float64{0.1, -0.1} {
+				got := Offset([][]Point{circle}, distance, j, 0.05)
+				if len(got) != 1 {
+					t.Errorf("radius %v, %s join, distance %v: got %d contours, want 1", radius, j, distance, len(got))
+				}
+			}
+		}
+	}
+}
@@ -22,13 +22,65 @@
 // RadialPointGenerator, and basic primitive types like Point
 package geometry
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 // Point defines a single metric coordinate in a 2D space.
 type Point struct {
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 func (p Point) String() string {
 	return fmt.Sprint("(", p.X, ",", p.Y, ")")
 }
+
+// Add returns the vector sum of two points
+func (p Point) Add(q Point) Point {
+	return Point{X: p.X + q.X, Y: p.Y + q.Y}
+}
+
+// Sub returns the vector difference of two points
+func (p Point) Sub(q Point) Point {
+	return Point{X: p.X - q.X, Y: p.Y - q.Y}
+}
+
+// Scale returns the point multiplied by a scalar factor
+func (p Point) Scale(f float64) Point {
+	return Point{X: p.X * f, Y: p.Y * f}
+}
+
+// Length returns the distance of the point from the origin
+func (p Point) Length() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Distance returns the distance between two points
+func (p Point) Distance(q Point) float64 {
+	return p.Sub(q).Length()
+}
+
+// Unit returns a vector of length 1 pointing in the same direction as p. The
+// zero vector is returned unchanged.
+func (p Point) Unit() Point {
+	l := p.Length()
+	if l == 0 {
+		return p
+	}
+	return p.Scale(1 / l)
+}
+
+// Perpendicular returns the vector rotated 90 degrees clockwise, ie. the
+// right-hand normal when travelling in the direction of p
+func (p Point) Perpendicular() Point {
+	return Point{X: p.Y, Y: -p.X}
+}
+
+// Rotate returns the point rotated about the origin by an angle in degrees,
+// anticlockwise
+func (p Point) Rotate(degrees float64) Point {
+	sin, cos := math.Sincos(degrees * math.Pi / 180.0)
+	return Point{X: p.X*cos - p.Y*sin, Y: p.X*sin + p.Y*cos}
+}
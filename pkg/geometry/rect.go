@@ -0,0 +1,58 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+// Rect defines an axis-aligned rectangle by its bottom-left and top-right
+// corners
+type Rect struct {
+	BottomLeft Point `json:"bottomLeft"`
+	TopRight   Point `json:"topRight"`
+}
+
+// Width returns the X-dimension size of the rectangle
+func (r Rect) Width() float64 {
+	return r.TopRight.X - r.BottomLeft.X
+}
+
+// Height returns the Y-dimension size of the rectangle
+func (r Rect) Height() float64 {
+	return r.TopRight.Y - r.BottomLeft.Y
+}
+
+// Centre returns the centre point of the rectangle
+func (r Rect) Centre() Point {
+	return r.BottomLeft.Add(r.TopRight).Scale(0.5)
+}
+
+// Contains indicates whether a point lies within the rectangle, including
+// its edges
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.BottomLeft.X && p.X <= r.TopRight.X && p.Y >= r.BottomLeft.Y && p.Y <= r.TopRight.Y
+}
+
+// Inset returns the rectangle shrunk by d on every side. Negative values
+// grow it.
+func (r Rect) Inset(d float64) Rect {
+	return Rect{
+		BottomLeft: r.BottomLeft.Add(Point{X: d, Y: d}),
+		TopRight:   r.TopRight.Sub(Point{X: d, Y: d}),
+	}
+}
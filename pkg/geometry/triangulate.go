@@ -0,0 +1,243 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// SignedArea returns the area enclosed by a polygon: positive if its points
+// run anticlockwise, negative if clockwise
+func SignedArea(points []Point) float64 {
+	a := 0.0
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
+
+// Contains reports whether a point lies inside a polygon, by the even-odd
+// rule. Points exactly on an edge may be reported either way.
+func Contains(polygon []Point, p Point) bool {
+	inside := false
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// EdgeDistance returns the distance from a point to the nearest edge of a
+// polygon
+func EdgeDistance(polygon []Point, p Point) float64 {
+	d := math.Inf(1)
+	for i, a := range polygon {
+		d = math.Min(d, distanceToSegment(p, a, polygon[(i+1)%len(polygon)]))
+	}
+	return d
+}
+
+// reversed returns a copy of the points in reverse order
+func reversed(points []Point) []Point {
+	r := make([]Point, len(points))
+	for i, p := range points {
+		r[len(points)-1-i] = p
+	}
+	return r
+}
+
+// cross returns the z component of the cross product of (b-a) and (c-a)
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// segmentsCross indicates whether two line segments intersect at a point
+// interior to both
+func segmentsCross(a, b, c, d Point) bool {
+	d1, d2 := cross(c, d, a), cross(c, d, b)
+	d3, d4 := cross(a, b, c), cross(a, b, d)
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) &&
+		((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
+
+// visible indicates whether the segment a..b crosses none of the edges of
+// the given polygons
+func visible(a, b Point, polygons ...[]Point) bool {
+	for _, poly := range polygons {
+		for i, p := range poly {
+			q := poly[(i+1)%len(poly)]
+			if p == a || p == b || q == a || q == b {
+				continue
+			}
+			if segmentsCross(a, b, p, q) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bridge merges a hole into a polygon by cutting a zero-width channel from
+// the hole's rightmost vertex to the nearest visible polygon vertex
+func bridge(poly, hole []Point, others [][]Point) ([]Point, error) {
+	m := 0
+	for i, p := range hole {
+		if p.X > hole[m].X {
+			m = i
+		}
+	}
+	candidates := make([]int, len(poly))
+	for i := range candidates {
+		candidates[i] = i
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return poly[candidates[i]].Distance(hole[m]) < poly[candidates[j]].Distance(hole[m])
+	})
+	for _, v := range candidates {
+		if !visible(hole[m], poly[v], append([][]Point{poly, hole}, others...)...) {
+			continue
+		}
+		merged := make([]Point, 0, len(poly)+len(hole)+2)
+		merged = append(merged, poly[:v+1]...)
+		merged = append(merged, hole[m:]...)
+		merged = append(merged, hole[:m+1]...)
+		merged = append(merged, poly[v:]...)
+		return merged, nil
+	}
+	return nil, errors.New("geometry: cannot connect hole to outline; holes may overlap")
+}
+
+// inTriangle indicates whether p lies inside or on the anticlockwise
+// triangle a, b, c
+func inTriangle(p, a, b, c Point) bool {
+	return cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0
+}
+
+// Triangulate divides a polygon with holes into triangles by ear clipping.
+// The holes must lie within the outline and must not overlap each other.
+// Triangles are returned with their points anticlockwise.
+func Triangulate(outline []Point, holes [][]Point) ([][3]Point, error) {
+	if len(outline) < 3 {
+		return nil, errors.New("triangulate: outline needs at least three points")
+	}
+	poly, err := Bridge(outline, holes)
+	if err != nil {
+		return nil, err
+	}
+	return clipEars(poly)
+}
+
+// Bridge merges holes into an outline by cutting a zero-width channel to
+// each, returning a single weakly simple anticlockwise polygon covering the
+// outline less its holes, as Gerber regions and ear clipping require
+func Bridge(outline []Point, holes [][]Point) ([]Point, error) {
+	poly := outline
+	if SignedArea(poly) < 0 {
+		poly = reversed(poly)
+	}
+	cw := [][]Point{}
+	for _, h := range holes {
+		if len(h) < 3 {
+			continue
+		}
+		if SignedArea(h) > 0 {
+			h = reversed(h)
+		}
+		cw = append(cw, h)
+	}
+	// merge holes from right to left, so that each bridge only has to avoid
+	// holes already merged or still to its left
+	sort.Slice(cw, func(i, j int) bool {
+		return maxX(cw[i]) > maxX(cw[j])
+	})
+	for i, h := range cw {
+		var err error
+		if poly, err = bridge(poly, h, cw[i+1:]); err != nil {
+			return nil, err
+		}
+	}
+	return poly, nil
+}
+
+func maxX(points []Point) float64 {
+	x := math.Inf(-1)
+	for _, p := range points {
+		x = math.Max(x, p.X)
+	}
+	return x
+}
+
+// clipEars triangulates a simple (or weakly simple, after bridging)
+// anticlockwise polygon
+func clipEars(poly []Point) ([][3]Point, error) {
+	idx := make([]int, len(poly))
+	for i := range idx {
+		idx[i] = i
+	}
+	tris := [][3]Point{}
+	// failures counts vertices examined since the last ear was clipped; once
+	// every vertex has been examined without success, there are no ears
+	for i, failures := 0, 0; len(idx) > 3; {
+		if failures > len(idx) {
+			return nil, errors.New("triangulate: polygon is not simple")
+		}
+		i %= len(idx)
+		a := poly[idx[(i+len(idx)-1)%len(idx)]]
+		b := poly[idx[i]]
+		c := poly[idx[(i+1)%len(idx)]]
+		area := cross(a, b, c)
+		if area == 0 {
+			// degenerate vertex, drop it without emitting a triangle
+			idx = append(idx[:i], idx[i+1:]...)
+			failures = 0
+			continue
+		}
+		ear := area > 0
+		for _, j := range idx {
+			if !ear {
+				break
+			}
+			p := poly[j]
+			if p != a && p != b && p != c && inTriangle(p, a, b, c) {
+				ear = false
+			}
+		}
+		if !ear {
+			i++
+			failures++
+			continue
+		}
+		tris = append(tris, [3]Point{a, b, c})
+		idx = append(idx[:i], idx[i+1:]...)
+		failures = 0
+	}
+	if len(idx) == 3 {
+		if t := [3]Point{poly[idx[0]], poly[idx[1]], poly[idx[2]]}; cross(t[0], t[1], t[2]) > 0 {
+			tris = append(tris, t)
+		}
+	}
+	return tris, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MM et al are units of length, expressed in millimetres, the unit used for
+// all coordinates and sizes. Multiply by them to convert a value to
+// millimetres, and divide to convert back, eg. 300*Mil or size/Pt.
+const (
+	MM   = 1.0
+	CM   = 10.0
+	Inch = 25.4
+	Mil  = Inch / 1000.0
+	// Pt is the typographic point, used for text sizes
+	Pt = Inch / 72.0
+	// HP is the Eurorack horizontal pitch
+	HP = 5.08
+)
+
+// Units maps unit suffixes to their lengths in millimetres
+var Units = map[string]float64{
+	"mm":  MM,
+	"cm":  CM,
+	"in":  Inch,
+	"mil": Mil,
+	"pt":  Pt,
+	"hp":  HP,
+}
+
+// unitNames returns the unit suffixes, sorted, for error messages
+func unitNames() string {
+	names := []string{}
+	for name := range Units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// PointsToMM converts a text size in points to millimetres
+func PointsToMM(pt float64) float64 {
+	return pt * Pt
+}
+
+// MMToPoints converts a length in millimetres to points
+func MMToPoints(mm float64) float64 {
+	return mm / Pt
+}
+
+// ParseLength parses a length with an optional unit suffix, eg. "1.5in",
+// "300mil" or "3hp", returning it in millimetres. Values without a suffix
+// are already in millimetres.
+func ParseLength(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && s[i-1] >= 'a' && s[i-1] <= 'z' {
+		i--
+	}
+	number, suffix := strings.TrimSpace(s[:i]), s[i:]
+	unit := MM
+	if suffix != "" {
+		var ok bool
+		if unit, ok = Units[suffix]; !ok {
+			return 0, fmt.Errorf("invalid unit %q in length %q (valid units: %s)", suffix, s, unitNames())
+		}
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %q", s)
+	}
+	return v * unit, nil
+}
+
+// FormatNumber formats a coordinate or size compactly for text output
+// formats, rounded to the given number of decimal places, with no trailing
+// zeros or negative zero
+func FormatNumber(v float64, places int) string {
+	scale := math.Pow(10, float64(places))
+	s := strconv.FormatFloat(math.Round(v*scale)/scale, 'f', -1, 64)
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+// TestFormatNumber checks the rounding and trimming of formatted numbers
+func TestFormatNumber(t *testing.T) {
+	for _, tc := range []struct {
+		v      float64
+		places int
+		want   string
+	}{
+		{0, 4, "0"},
+		{12.5, 4, "12.5"},
+		{3, 4, "3"},
+		{1.23456789, 4, "1.2346"},
+		{1.23456789, 6, "1.234568"},
+		{-0.00001, 4, "0"},
+		{-2.25, 4, "-2.25"},
+		{1e-5, 6, "0.00001"},
+		{123456.7, 4, "123456.7"},
+	} {
+		if got := FormatNumber(tc.v, tc.places); got != tc.want {
+			t.Errorf("FormatNumber(%v, %d) = %q, want %q", tc.v, tc.places, got, tc.want)
+		}
+	}
+}
+
+// TestParseLength checks each unit suffix, and the rejection of malformed
+// lengths and unknown units
+func TestParseLength(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want float64
+	}{
+		{"12.5", 12.5},
+		{"0", 0},
+		{".5", 0.5},
+		{"3mm", 3},
+		{"1.5cm", 15},
+		{"1in", 25.4},
+		{"300mil", 7.62},
+		{"72pt", 25.4},
+		{"2hp", 10.16},
+		{"  4  ", 4},
+		{" 2 hp ", 10.16},
+		{"\t1in\n", 25.4},
+		{"-1.5", -1.5},
+		{"-2hp", -10.16},
+		{"+3mm", 3},
+		{"1e3mil", 25.4},
+	} {
+		got, err := ParseLength(tc.s)
+		if err != nil {
+			t.Errorf("ParseLength(%q): %v", tc.s, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("ParseLength(%q) = %v, want %v", tc.s, got, tc.want)
+		}
+	}
+	for _, s := range []string{"", "mm", "abc", "3furlongs", "3MM", "1.2.3", "3 m m", "mm3", "--1", "2e"} {
+		if got, err := ParseLength(s); err == nil {
+			t.Errorf("ParseLength(%q) = %v, want an error", s, got)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package golden renders a canonical panel for each format and width of
+// interest and compares the output files against golden copies, within a
+// numeric tolerance, so that changes to the rendering pipeline can be checked
+// for unintended differences.
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/eurocase"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/format/intellijel"
+	"github.com/jsleeio/frontpanels/pkg/format/joined"
+	"github.com/jsleeio/frontpanels/pkg/format/pulplogic"
+	"github.com/jsleeio/frontpanels/pkg/frontpanels"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+const (
+	// DefaultDir is the default location of the golden files, relative to
+	// the repository root. Each case has its own subdirectory.
+	DefaultDir = "testdata/golden"
+
+	// DefaultTolerance is the default maximum difference between numbers in
+	// output and golden files, in millimetres for coordinates
+	DefaultTolerance = 0.001
+)
+
+// Case is a canonical panel to render
+type Case struct {
+	// Name identifies the case, and names its golden file directory
+	Name  string
+	Panel panel.Panel
+	// Features are rendered in addition to the panel outline and mounting
+	// holes
+	Features []features.Feature
+}
+
+// cutout returns a feature with the Cutout purpose
+func cutout(f features.Feature) features.Feature {
+	f.SetPurpose(features.Cutout)
+	return f
+}
+
+// withPurpose returns a feature with the given purpose
+func withPurpose(f features.Feature, purpose features.Purpose) features.Feature {
+	f.SetPurpose(purpose)
+	return f
+}
+
+// moduleFeatures returns the features of a typical module panel HP wide,
+// exercising jack, pot and window cutouts, filled and stroke text, lines,
+// copper, soldermask openings and a reversed-out label
+func moduleFeatures(hp int) []features.Feature {
+	w := float64(hp) * eurorack.HP
+	pt := func(x, y float64) geometry.Point { return geometry.Point{X: x, Y: y} }
+	return []features.Feature{
+		cutout(features.NewCircle(pt(w/4, 20), 3.1)),
+		cutout(features.NewCircle(pt(3*w/4, 20), 3.1)),
+		cutout(features.NewCircle(pt(w/2, 70), 3.5)),
+		cutout(features.NewRectangle(pt(w/4, 90), pt(3*w/4, 108))),
+		features.NewText(pt(w/2, 118), "FILTER", features.WithAlignment(features.Centre), features.WithSizeMM(3)),
+		features.NewText(pt(w/2, 50), "CUTOFF", features.WithAlignment(features.Centre), features.WithSizeMM(2), features.WithMode(features.StrokeText, 0.2)),
+		features.NewLine(pt(w/4, 60), pt(3*w/4, 60), 0.3),
+		withPurpose(features.NewText(pt(w/2, 40), "v1", features.WithAlignment(features.Centre), features.WithSizeMM(2)), features.ExposedCopper),
+		withPurpose(features.NewCircle(pt(w/4, 40), 1.5), features.MaskOpening),
+		features.NewInversion(features.NewRectangle(pt(3*w/4-5, 27), pt(3*w/4+5, 33)).Points),
+		features.NewText(pt(3*w/4, 30), "OUT", features.WithAlignment(features.Centre), features.WithSizeMM(2)),
+	}
+}
+
+// Cases returns the canonical panels: each built-in format at widths
+// exercising its special cases, such as 1HP panels and the extra mounting
+// hole thresholds, and module panels with typical features
+func Cases() ([]Case, error) {
+	cases := []Case{}
+	for _, hp := range []int{1, 4, 8, 10, 42} {
+		cases = append(cases, Case{Name: fmt.Sprintf("eurorack-%dhp", hp), Panel: eurorack.NewEurorack(hp)})
+	}
+	cases = append(cases, Case{Name: "eurorack-2x3u-8hp", Panel: &eurorack.Eurorack{HP: 8, Rows: 2}})
+	for _, hp := range []int{1, 4, 8, 14, 30} {
+		cases = append(cases, Case{Name: fmt.Sprintf("intellijel-%dhp", hp), Panel: intellijel.NewIntellijel(hp)})
+	}
+	for _, hp := range []int{1, 4, 8} {
+		cases = append(cases, Case{Name: fmt.Sprintf("pulplogic-%dhp", hp), Panel: pulplogic.NewPulplogic(hp)})
+	}
+	for _, hp := range []int{4, 8} {
+		cases = append(cases, Case{Name: fmt.Sprintf("pulplogic-pcb-%dhp", hp), Panel: pulplogic.NewRearPCB(hp)})
+	}
+	cases = append(cases,
+		Case{Name: "joined-8hp", Panel: joined.NewJoined(8)},
+		Case{Name: "joined-below-10hp", Panel: &joined.Joined{HP: 10, Below: true}},
+		Case{Name: "eurorack-12hp-module", Panel: eurorack.NewEurorack(12), Features: moduleFeatures(12)},
+		Case{Name: "pulplogic-12hp-module", Panel: pulplogic.NewPulplogic(12), Features: moduleFeatures(12)},
+	)
+	for k := eurocase.Side; k <= eurocase.Rear; k++ {
+		part, err := eurocase.New(k, eurocase.DefaultOptions())
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, Case{Name: "case-" + k.String(), Panel: part})
+	}
+	return cases, nil
+}
+
+// Timestamp is recorded in the headers of rendered files, so that they are
+// identical across runs
+var Timestamp = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Render renders a case and its features to Gerber and VCV Rack SVG files,
+// returning the contents of each keyed by filename
+func Render(c Case) (map[string][]byte, error) {
+	opts := frontpanels.DefaultRenderOptions(c.Name)
+	opts.VCVRack = true
+	opts.Timestamp = Timestamp
+	opts.Diagnostics = diag.Discard
+	return frontpanels.RenderMemory(c.Panel, c.Features, opts)
+}
+
+// Options configures comparison against golden files
+type Options struct {
+	// Dir is the directory containing a subdirectory of golden files for
+	// each case
+	Dir string
+	// Tolerance is the maximum difference between corresponding numbers
+	Tolerance float64
+	// Ignore matches lines that are expected to differ between runs, such
+	// as timestamps. Matching lines are skipped in both files.
+	Ignore []*regexp.Regexp
+	// Update writes the rendered files as the new golden files instead of
+	// comparing them
+	Update bool
+}
+
+// DefaultOptions returns the default comparison options
+func DefaultOptions() Options {
+	return Options{
+		Dir:       DefaultDir,
+		Tolerance: DefaultTolerance,
+	}
+}
+
+// Check renders every case and compares it against its golden files, or
+// replaces them if opts.Update is set. It returns a description of each
+// difference found.
+func Check(cases []Case, opts Options) ([]string, error) {
+	diffs := []string{}
+	for _, c := range cases {
+		files, err := Render(c)
+		if err != nil {
+			return nil, fmt.Errorf("golden: %s: %v", c.Name, err)
+		}
+		dir := filepath.Join(opts.Dir, c.Name)
+		if opts.Update {
+			if err := update(dir, files); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		diffs = append(diffs, compareDir(c.Name, dir, files, opts)...)
+	}
+	return diffs, nil
+}
+
+// update replaces the golden files in dir
+func update(dir string, files map[string][]byte) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("golden: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("golden: %v", err)
+	}
+	for filename, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, filename), data, 0644); err != nil {
+			return fmt.Errorf("golden: %v", err)
+		}
+	}
+	return nil
+}
+
+// compareDir compares rendered files against the golden files in dir,
+// including any golden files no longer rendered
+func compareDir(name, dir string, files map[string][]byte, opts Options) []string {
+	diffs := []string{}
+	filenames := []string{}
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		want, err := os.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: %s: no golden file", name, filename))
+			continue
+		}
+		if err := Compare(filename, files[filename], want, opts); err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: %s: %v", name, filename, err))
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return append(diffs, fmt.Sprintf("%s: %v", name, err))
+	}
+	for _, e := range entries {
+		if _, ok := files[e.Name()]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: %s: golden file no longer rendered", name, e.Name()))
+		}
+	}
+	return diffs
+}
+
+// number matches a number, with the character before it if any
+var number = regexp.MustCompile(`(^|[^0-9.])(-?[0-9]+(\.[0-9]+)?)`)
+
+// gerberFormat matches a Gerber format specification, capturing the number
+// of decimal places in coordinates
+var gerberFormat = regexp.MustCompile(`^%FSLAX[0-9]([0-9])Y`)
+
+// Compare compares an output file against its golden copy line by line.
+// Lines must match exactly apart from numbers, which may differ by up to the
+// tolerance. Gerber coordinates are scaled to millimetres first, as declared
+// by the file's format specification.
+func Compare(filename string, got, want []byte, opts Options) error {
+	gotLines := lines(got, opts.Ignore)
+	wantLines := lines(want, opts.Ignore)
+	if len(gotLines) != len(wantLines) {
+		return fmt.Errorf("got %d lines, want %d", len(gotLines), len(wantLines))
+	}
+	scale := 1.0
+	for i := range gotLines {
+		if m := gerberFormat.FindStringSubmatch(wantLines[i]); m != nil {
+			decimals, _ := strconv.Atoi(m[1])
+			scale = math.Pow(10, -float64(decimals))
+		}
+		if err := compareLine(gotLines[i], wantLines[i], scale, opts.Tolerance); err != nil {
+			return fmt.Errorf("line %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// lines splits a file into lines, dropping those matching any of ignore
+func lines(data []byte, ignore []*regexp.Regexp) []string {
+	kept := []string{}
+	for _, line := range strings.Split(string(bytes.TrimRight(data, "\n")), "\n") {
+		skip := false
+		for _, re := range ignore {
+			if re.MatchString(line) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// compareLine compares two lines, allowing numbers to differ within the
+// tolerance. Integers following X, Y, I or J are Gerber coordinates and are
+// multiplied by coordinateScale first.
+func compareLine(got, want string, coordinateScale, tolerance float64) error {
+	gotNumbers := number.FindAllStringSubmatch(got, -1)
+	wantNumbers := number.FindAllStringSubmatch(want, -1)
+	if number.ReplaceAllString(got, "${1}#") != number.ReplaceAllString(want, "${1}#") || len(gotNumbers) != len(wantNumbers) {
+		return fmt.Errorf("got %q, want %q", got, want)
+	}
+	for i := range gotNumbers {
+		g, _ := strconv.ParseFloat(gotNumbers[i][2], 64)
+		w, _ := strconv.ParseFloat(wantNumbers[i][2], 64)
+		if strings.ContainsAny(wantNumbers[i][1], "XYIJ") && wantNumbers[i][3] == "" {
+			g *= coordinateScale
+			w *= coordinateScale
+		}
+		if math.Abs(g-w) > tolerance {
+			return fmt.Errorf("got %q, want %q (%s differs from %s by more than %g)", got, want, gotNumbers[i][2], wantNumbers[i][2], tolerance)
+		}
+	}
+	return nil
+}
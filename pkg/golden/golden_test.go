@@ -0,0 +1,81 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package golden
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "replace the golden files with the current output instead of comparing")
+
+func TestGolden(t *testing.T) {
+	cases, err := Cases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultOptions()
+	opts.Dir = filepath.Join("..", "..", DefaultDir)
+	opts.Update = *updateGolden
+	diffs, err := Check(cases, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		t.Error(d)
+	}
+}
+
+// TestRegionsClosed checks that every filled region in the rendered Gerber
+// files, such as a copper pour, draws back to its first point before it
+// ends, as fabs reject or misread open regions
+func TestRegionsClosed(t *testing.T) {
+	cases, err := Cases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		files, err := Render(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, data := range files {
+			start, last, inRegion := "", "", false
+			for _, line := range strings.Split(string(data), "\n") {
+				switch {
+				case line == "G36*":
+					start, last, inRegion = "", "", true
+				case line == "G37*":
+					if last != start {
+						t.Errorf("%s: region starting at %s ends at %s", name, start, last)
+					}
+					inRegion = false
+				case inRegion && start == "" && strings.HasSuffix(line, "D02*"):
+					start = strings.TrimSuffix(line, "D02*")
+				case inRegion && strings.HasSuffix(line, "D01*"):
+					last = strings.TrimSuffix(line, "D01*")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package output provides destinations ("sinks") for generated files, such
+// as a directory on disk or a ZIP archive ready for uploading to a fab.
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultFilenameTemplate is used to name output files unless otherwise
+// configured. See ExpandTemplate.
+const DefaultFilenameTemplate = "{name}.{layer}.{ext}"
+
+// ExpandTemplate generates a filename from a template. The placeholders
+// {name}, {layer} and {ext} are replaced with the design basename, the
+// layer name (eg. "outline", "drill-npth") and the filename extension (eg.
+// "gko", "drl") respectively. Templates may include directory separators.
+func ExpandTemplate(template, name, layer, ext string) string {
+	return strings.NewReplacer("{name}", name, "{layer}", layer, "{ext}", ext).Replace(template)
+}
+
+// File is anything that can be written to a named output file
+type File struct {
+	Filename string
+	Write    func(io.Writer) error
+}
+
+// Sink types receive named output files
+type Sink interface {
+	// Create returns a writer for a new file in the sink
+	Create(filename string) (io.WriteCloser, error)
+	// Close finalises the sink. No further files may be created.
+	Close() error
+}
+
+// WriteFiles writes each file to the sink, in order. The sink is not closed.
+// An error is returned, and nothing is written, if any two files share a
+// filename --- most likely due to a filename template without {layer}.
+func WriteFiles(sink Sink, files []File) error {
+	seen := map[string]bool{}
+	for _, file := range files {
+		if seen[file.Filename] {
+			return fmt.Errorf("duplicate output filename: %s", file.Filename)
+		}
+		seen[file.Filename] = true
+	}
+	for _, file := range files {
+		w, err := sink.Create(file.Filename)
+		if err != nil {
+			return err
+		}
+		if err := file.Write(w); err != nil {
+			w.Close()
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Directory is a Sink writing loose files into a directory
+type Directory struct {
+	Path string
+}
+
+// NewDirectory constructs a new Directory sink
+func NewDirectory(path string) *Directory {
+	return &Directory{Path: path}
+}
+
+// Create creates a new file in the directory, creating the directory
+// itself and any intermediate directories as required
+func (d *Directory) Create(filename string) (io.WriteCloser, error) {
+	path := filepath.Join(d.Path, filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// Close satisfies the Sink interface. There is nothing to do.
+func (d *Directory) Close() error {
+	return nil
+}
+
+// Zip is a Sink writing all files into a single ZIP archive
+type Zip struct {
+	// f is the archive file, or nil if the archive is written to a
+	// caller-provided writer
+	f  *os.File
+	zw *zip.Writer
+}
+
+// NewZip creates a new ZIP archive sink, creating any intermediate
+// directories as required
+func NewZip(filename string) (*Zip, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &Zip{f: f, zw: zip.NewWriter(f)}, nil
+}
+
+// NewZipWriter creates a new ZIP archive sink writing to w, eg. an HTTP
+// response. Closing the sink finishes the archive but does not close w.
+func NewZipWriter(w io.Writer) *Zip {
+	return &Zip{zw: zip.NewWriter(w)}
+}
+
+// zipEntry adapts a ZIP archive member to io.WriteCloser. Members are
+// finished implicitly when the next one is created.
+type zipEntry struct {
+	io.Writer
+}
+
+func (zipEntry) Close() error {
+	return nil
+}
+
+// Create adds a new file to the archive
+func (z *Zip) Create(filename string) (io.WriteCloser, error) {
+	w, err := z.zw.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return zipEntry{w}, nil
+}
+
+// Close writes the ZIP central directory and closes the archive file, if
+// the sink created one
+func (z *Zip) Close() error {
+	err := z.zw.Close()
+	if z.f == nil {
+		return err
+	}
+	if err != nil {
+		z.f.Close()
+		return err
+	}
+	return z.f.Close()
+}
+
+// Memory is a Sink collecting files in memory, eg. for serving over HTTP
+// without touching the disk
+type Memory struct {
+	// Files maps filenames to file contents
+	Files map[string]*bytes.Buffer
+}
+
+// NewMemory constructs a new, empty Memory sink
+func NewMemory() *Memory {
+	return &Memory{Files: map[string]*bytes.Buffer{}}
+}
+
+// memoryFile adapts a buffer to io.WriteCloser
+type memoryFile struct {
+	*bytes.Buffer
+}
+
+func (memoryFile) Close() error {
+	return nil
+}
+
+// Create adds a new, empty file to the sink
+func (m *Memory) Create(filename string) (io.WriteCloser, error) {
+	buf := &bytes.Buffer{}
+	m.Files[filename] = buf
+	return memoryFile{buf}, nil
+}
+
+// Close satisfies the Sink interface. There is nothing to do.
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Writers is a Sink obtaining a writer for each file from a function, for
+// callers that manage their own destinations. Writers that also implement
+// io.Closer are closed once their file has been written.
+type Writers func(filename string) (io.Writer, error)
+
+// writerFile adapts an io.Writer to io.WriteCloser
+type writerFile struct {
+	io.Writer
+}
+
+func (w writerFile) Close() error {
+	if c, ok := w.Writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Create obtains the writer for a new file
+func (fn Writers) Create(filename string) (io.WriteCloser, error) {
+	w, err := fn(filename)
+	if err != nil {
+		return nil, err
+	}
+	return writerFile{w}, nil
+}
+
+// Close satisfies the Sink interface. There is nothing to do.
+func (fn Writers) Close() error {
+	return nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package panel
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// CornerStyle selects how a corner of a panel outline is finished
+type CornerStyle int
+
+// FormatCorner et al specify corner styles. FormatCorner keeps the format's
+// own corner radius, and is intentionally the zero-value/default.
+const (
+	FormatCorner CornerStyle = iota // this MUST be the first item
+	// RoundCorner rounds the corner with a radius of the corner's Size
+	RoundCorner
+	// ChamferCorner cuts the corner off at 45 degrees, removing Size from
+	// each of the two edges meeting there
+	ChamferCorner // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s CornerStyle) String() string {
+	switch s {
+	case FormatCorner:
+		return "format"
+	case RoundCorner:
+		return "round"
+	case ChamferCorner:
+		return "chamfer"
+	}
+	panic(fmt.Sprintf("invalid CornerStyle value (valid range is %d..%d): %d",
+		int(FormatCorner), int(ChamferCorner), int(s)))
+}
+
+// ParseCornerStyle converts a corner style name, as returned by
+// CornerStyle.String, to a CornerStyle
+func ParseCornerStyle(s string) (CornerStyle, error) {
+	for cs := FormatCorner; cs <= ChamferCorner; cs++ {
+		if cs.String() == s {
+			return cs, nil
+		}
+	}
+	return FormatCorner, fmt.Errorf("invalid corner style %q (valid values: format round chamfer)", s)
+}
+
+// Corner overrides the finish of one corner of a panel. Size is in
+// millimetres, and is ignored for FormatCorner.
+type Corner struct {
+	Style CornerStyle
+	Size  float64
+}
+
+// Corners overrides the finish of each corner of a panel outline,
+// independently of the format's corner radius, eg. to chamfer only the top
+// corners. The zero value changes nothing.
+type Corners struct {
+	BottomLeft, BottomRight, TopRight, TopLeft Corner
+}
+
+// size returns the distance a corner takes from each of its edges
+func (c Corner) size(p Panel) float64 {
+	if c.Style == FormatCorner {
+		return p.CornerRadius()
+	}
+	return c.Size
+}
+
+// CheckCorners returns an error if any corner override has a negative size,
+// if the corners at either end of any edge of the panel together take up
+// more than its length, if a corner cuts within MinMountingHoleWeb of a
+// mounting hole, or if a notch cuts into a corner
+func CheckCorners(p Panel, c Corners, notches []Notch) error {
+	for _, corner := range []struct {
+		name string
+		Corner
+	}{
+		{"bottom-left", c.BottomLeft},
+		{"bottom-right", c.BottomRight},
+		{"top-right", c.TopRight},
+		{"top-left", c.TopLeft},
+	} {
+		if corner.Size < 0 {
+			return fmt.Errorf("%s corner: size must not be negative", corner.name)
+		}
+	}
+	width, height := RightX(p)-LeftX(p), TopY(p)-BottomY(p)
+	for _, edge := range []struct {
+		name   string
+		a, b   Corner
+		length float64
+	}{
+		{"bottom", c.BottomLeft, c.BottomRight, width},
+		{"right", c.BottomRight, c.TopRight, height},
+		{"top", c.TopRight, c.TopLeft, width},
+		{"left", c.TopLeft, c.BottomLeft, height},
+	} {
+		if edge.a.size(p)+edge.b.size(p) > edge.length {
+			return fmt.Errorf("corners of the %s edge are larger than the %.2fmm edge", edge.name, edge.length)
+		}
+	}
+	for _, n := range notches {
+		left, right := c.BottomLeft, c.BottomRight
+		if n.Edge == TopEdge {
+			left, right = c.TopLeft, c.TopRight
+		}
+		if n.X-n.Width/2 < LeftX(p)+left.size(p) || n.X+n.Width/2 > RightX(p)-right.size(p) {
+			return fmt.Errorf("%s notch at x=%.2f cuts into a corner", n.Edge, n.X)
+		}
+	}
+	// only webs narrowed by the corners are reported here; see
+	// CheckMountingHoleWeb for the rest
+	r := p.MountingHoleDiameter() / 2
+	rect := geometry.RoundedRect(BottomLeft(p), TopRight(p), 0, geometry.DefaultTolerance)
+	contour := c.Contour(p, geometry.DefaultTolerance)
+	for _, h := range p.MountingHoles() {
+		web := geometry.EdgeDistance(contour, h) - r
+		if !geometry.Contains(contour, h) {
+			web = -r
+		}
+		if web < MinMountingHoleWeb && web < geometry.EdgeDistance(rect, h)-r-1e-9 {
+			return fmt.Errorf("corners leave %.2fmm of panel material around the mounting hole at (%.2f, %.2f), less than %.2fmm",
+				math.Max(web, 0), h.X, h.Y, MinMountingHoleWeb)
+		}
+	}
+	return nil
+}
+
+// Contour returns the anticlockwise contour of a panel's rectangle, adjusted
+// for horizontal fit, with its corners finished as overridden and rounded
+// corners flattened within tolerance
+func (c Corners) Contour(p Panel, tolerance float64) []geometry.Point {
+	bl, tr := BottomLeft(p), TopRight(p)
+	if c == (Corners{}) {
+		return geometry.RoundedRect(bl, tr, p.CornerRadius(), tolerance)
+	}
+	contour := []geometry.Point{}
+	for _, v := range []struct {
+		Corner
+		vertex, in, out geometry.Point
+		start           float64
+	}{
+		{c.BottomRight, geometry.Point{X: tr.X, Y: bl.Y}, geometry.Point{X: 1}, geometry.Point{Y: 1}, -90},
+		{c.TopRight, tr, geometry.Point{Y: 1}, geometry.Point{X: -1}, 0},
+		{c.TopLeft, geometry.Point{X: bl.X, Y: tr.Y}, geometry.Point{X: -1}, geometry.Point{Y: -1}, 90},
+		{c.BottomLeft, bl, geometry.Point{Y: -1}, geometry.Point{X: 1}, 180},
+	} {
+		size := v.size(p)
+		switch {
+		case size <= 0:
+			contour = append(contour, v.vertex)
+		case v.Style == ChamferCorner:
+			contour = append(contour, v.vertex.Sub(v.in.Scale(size)), v.vertex.Add(v.out.Scale(size)))
+		default:
+			centre := v.vertex.Sub(v.in.Scale(size)).Add(v.out.Scale(size))
+			contour = append(contour, geometry.Arc(centre, size, v.start, v.start+90, tolerance)...)
+		}
+	}
+	return contour
+}
+
+// ShapedOutline returns the outline of a panel with its corners finished as
+// overridden and notches cut into it, for panel formats implementing
+// Outliner. The panel's rectangle is used, so formats must not pass
+// themselves to Outline. With neither overrides nor notches, or if the
+// notches split the panel, it returns nil, selecting the default rectangle;
+// CheckNotches catches the latter.
+func ShapedOutline(p Panel, corners Corners, notches []Notch) []geometry.Point {
+	if corners == (Corners{}) && len(notches) == 0 {
+		return nil
+	}
+	outline, err := CutNotches(corners.Contour(p, geometry.DefaultTolerance), notches)
+	if err != nil {
+		return nil
+	}
+	return outline
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package panel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FitOptions overrides the horizontal fit of a panel format: the amounts
+// trimmed from its left and right edges so that it fits between its
+// neighbours despite fab tolerances. Builders' experience of tolerances
+// differs, and the panels at the ends of a row may need a different fit on
+// one side. The zero value changes nothing.
+type FitOptions struct {
+	// Fit replaces the format's horizontal fit, split evenly between the
+	// left and right edges, unless nil
+	Fit *float64
+	// Left and Right replace the amounts trimmed from the left and right
+	// edges, unless nil
+	Left, Right *float64
+}
+
+// Edges returns the amounts trimmed from the left and right edges of a
+// panel, given the format's own horizontal fit
+func (o FitOptions) Edges(fit float64) (left, right float64) {
+	if o.Fit != nil {
+		fit = *o.Fit
+	}
+	left, right = fit/2, fit/2
+	if o.Left != nil {
+		left = *o.Left
+	}
+	if o.Right != nil {
+		right = *o.Right
+	}
+	return left, right
+}
+
+// Total returns the horizontal fit of a panel, the sum of the amounts
+// trimmed from its edges, given the format's own horizontal fit
+func (o FitOptions) Total(fit float64) float64 {
+	left, right := o.Edges(fit)
+	return left + right
+}
+
+// CheckFit returns an error if either edge of the panel is trimmed by a
+// negative amount, or the trimmed panel has no width left
+func CheckFit(p Panel) error {
+	left, right := EdgeFits(p)
+	if left < 0 || right < 0 {
+		return fmt.Errorf("horizontal fit of %.2fmm left and %.2fmm right must not be negative", left, right)
+	}
+	if RightX(p) <= LeftX(p) {
+		return errors.New("horizontal fit leaves no panel")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package panel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// MinMountingHoleWeb is the narrowest panel material that should be left
+// between a mounting hole and the panel edge, or a neighbouring mounting
+// hole, in millimetres. Narrower webs are prone to cracking or tearing out
+// when the screw is tightened. Standard Eurorack holes leave 1.4mm.
+const MinMountingHoleWeb = 1.0
+
+// HoleCount selects how many mounting holes a panel format generates,
+// overriding the format's own width threshold
+type HoleCount int
+
+// AutoHoles et al specify mounting hole counts. AutoHoles leaves the decision
+// to the format, and is intentionally the zero-value/default.
+const (
+	AutoHoles HoleCount = iota // this MUST be the first item
+	TwoHoles
+	FourHoles // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (c HoleCount) String() string {
+	switch c {
+	case AutoHoles:
+		return "auto"
+	case TwoHoles:
+		return "two"
+	case FourHoles:
+		return "four"
+	}
+	panic(fmt.Sprintf("invalid HoleCount value (valid range is %d..%d): %d",
+		int(AutoHoles), int(FourHoles), int(c)))
+}
+
+// ParseHoleCount converts a hole count name, as returned by HoleCount.String,
+// to a HoleCount
+func ParseHoleCount(s string) (HoleCount, error) {
+	for c := AutoHoles; c <= FourHoles; c++ {
+		if c.String() == s {
+			return c, nil
+		}
+	}
+	return AutoHoles, fmt.Errorf("invalid mounting hole count %q (valid values: auto two four)", s)
+}
+
+// HoleOptions overrides the mounting hole placement of a panel format. Some
+// builders deliberately use fewer screws than the format calls for, or need
+// to move the holes clear of rack ears, or to fit screws other than M3. The
+// zero value changes nothing.
+type HoleOptions struct {
+	Count HoleCount
+	// LeftNudge and RightNudge move the left and right columns of mounting
+	// holes along the X axis, in millimetres. Positive values move right.
+	LeftNudge, RightNudge float64
+	// Diameter replaces the format's mounting hole diameter, in millimetres,
+	// unless zero
+	Diameter float64
+}
+
+// HoleDiameter returns the overridden mounting hole diameter, or the
+// format's own diameter d if it is not overridden
+func (o HoleOptions) HoleDiameter(d float64) float64 {
+	if o.Diameter > 0 {
+		return o.Diameter
+	}
+	return d
+}
+
+// screwClearances maps the names of screws commonly used to fix panels to
+// rails to the diameters of their clearance holes, in millimetres. The
+// self-tapping screws some cases use are #4 and #6 sheet metal screws.
+var screwClearances = map[string]float64{
+	"m2.5": 2.7,
+	"m3":   3.2,
+	"m3.5": 3.7,
+	"no4":  3.3,
+	"no6":  3.9,
+}
+
+// ScrewNames returns the names of the screws with known clearance hole
+// diameters, sorted
+func ScrewNames() []string {
+	names := []string{}
+	for name := range screwClearances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScrewClearance returns the clearance hole diameter for a screw named by
+// ScrewNames, in millimetres
+func ScrewClearance(name string) (float64, error) {
+	d, ok := screwClearances[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("invalid screw %q (valid values: %s)", name, strings.Join(ScrewNames(), " "))
+	}
+	return d, nil
+}
+
+// Columns generates mounting holes in a left column at lhsx and, if the
+// panel is wide enough or four holes are forced, a right column at rhsx. Each
+// column has a hole at bottomY and topY. Wide is the format's own decision
+// about the right column, which is overridden by a fixed Count.
+func (o HoleOptions) Columns(lhsx, rhsx float64, wide bool, bottomY, topY float64) []geometry.Point {
+	switch o.Count {
+	case TwoHoles:
+		wide = false
+	case FourHoles:
+		wide = true
+	}
+	lhsx += o.LeftNudge
+	holes := []geometry.Point{
+		{X: lhsx, Y: bottomY},
+		{X: lhsx, Y: topY},
+	}
+	if wide {
+		rhsx += o.RightNudge
+		holes = append(holes, geometry.Point{X: rhsx, Y: bottomY})
+		holes = append(holes, geometry.Point{X: rhsx, Y: topY})
+	}
+	return holes
+}
+
+// CheckMountingHoles returns an error if any mounting hole of the panel
+// extends past its outline, or its left or right edges if it has none, or
+// overlaps another mounting hole. Overridden hole positions should be
+// checked with this before use.
+func CheckMountingHoles(p Panel) error {
+	r := p.MountingHoleDiameter() / 2
+	holes := p.MountingHoles()
+	var outline []geometry.Point
+	if o, ok := p.(Outliner); ok {
+		outline = o.Outline()
+	}
+	for i, h := range holes {
+		if outline != nil && (!geometry.Contains(outline, h) || geometry.EdgeDistance(outline, h) < r) {
+			return fmt.Errorf("mounting hole at (%.2f, %.2f) extends past the panel outline", h.X, h.Y)
+		}
+		if outline == nil && (h.X-r < LeftX(p) || h.X+r > RightX(p)) {
+			return fmt.Errorf("mounting hole at (%.2f, %.2f) extends past the panel edge", h.X, h.Y)
+		}
+		for _, o := range holes[i+1:] {
+			if h.Distance(o) < 2*r {
+				return fmt.Errorf("mounting holes at (%.2f, %.2f) and (%.2f, %.2f) overlap", h.X, h.Y, o.X, o.Y)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckMountingHoleWeb reports a warning for each mounting hole leaving less
+// than minimum millimetres of panel material between its edge and the panel
+// outline, or the edge of another mounting hole. Holes overridden to suit
+// larger screws should be checked with this, as CheckMountingHoles only
+// catches holes that break out of the panel entirely.
+func CheckMountingHoleWeb(p Panel, minimum float64, r diag.Reporter) {
+	radius := p.MountingHoleDiameter() / 2
+	holes := p.MountingHoles()
+	outline := Outline(p, geometry.DefaultTolerance)
+	for i, h := range holes {
+		hole := features.NewCircle(h, radius)
+		hole.SetPurpose(features.Cutout)
+		if web := geometry.EdgeDistance(outline, h) - radius; web < minimum {
+			diag.Warnf(r, hole, "mounting hole at (%.2f, %.2f) leaves %.2fmm of panel material to the panel edge, less than %.2fmm",
+				h.X, h.Y, web, minimum)
+		}
+		for _, o := range holes[i+1:] {
+			if web := h.Distance(o) - 2*radius; web < minimum {
+				diag.Warnf(r, hole, "mounting holes at (%.2f, %.2f) and (%.2f, %.2f) leave %.2fmm of panel material between them, less than %.2fmm",
+					h.X, h.Y, o.X, o.Y, web, minimum)
+			}
+		}
+	}
+}
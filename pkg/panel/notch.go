@@ -0,0 +1,134 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package panel
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Edge identifies the top or bottom edge of a panel
+type Edge int
+
+// BottomEdge et al specify panel edges
+const (
+	BottomEdge Edge = iota // this MUST be the first item
+	TopEdge                // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (e Edge) String() string {
+	switch e {
+	case BottomEdge:
+		return "bottom"
+	case TopEdge:
+		return "top"
+	}
+	panic(fmt.Sprintf("invalid Edge value (valid range is %d..%d): %d",
+		int(BottomEdge), int(TopEdge), int(e)))
+}
+
+// ParseEdge converts an edge name, as returned by Edge.String, to an Edge
+func ParseEdge(s string) (Edge, error) {
+	for e := BottomEdge; e <= TopEdge; e++ {
+		if e.String() == s {
+			return e, nil
+		}
+	}
+	return BottomEdge, fmt.Errorf("invalid edge %q (valid values: bottom top)", s)
+}
+
+// Notch is a rectangular slot cut into the top or bottom edge of a panel,
+// eg. to clear the sliding nuts of a rail, or the fixings of some 1U
+// systems. Distances are in millimetres.
+type Notch struct {
+	Edge Edge
+	// X is the horizontal position of the centre of the notch
+	X float64
+	// Width is the size of the notch along the edge, and Depth its size into
+	// the panel
+	Width, Depth float64
+}
+
+// CheckNotches returns an error if any notch has no width or depth, lies
+// partly beyond the left or right edge of a panel, reaches the opposite edge
+// or would cut into a mounting hole, or if the notches split the panel
+func CheckNotches(p Panel, notches []Notch) error {
+	r := p.MountingHoleDiameter() / 2
+	for _, n := range notches {
+		if n.Width <= 0 || n.Depth <= 0 {
+			return fmt.Errorf("%s notch at x=%.2f: width and depth must be greater than 0", n.Edge, n.X)
+		}
+		if n.X-n.Width/2 < LeftX(p) || n.X+n.Width/2 > RightX(p) {
+			return fmt.Errorf("%s notch at x=%.2f extends past the panel edge", n.Edge, n.X)
+		}
+		if n.Depth >= p.Height() {
+			return fmt.Errorf("%s notch at x=%.2f is deeper than the panel is high", n.Edge, n.X)
+		}
+		bl, tr := n.corners(BottomY(p), TopY(p), 0)
+		for _, h := range p.MountingHoles() {
+			closest := geometry.Point{X: math.Max(bl.X, math.Min(h.X, tr.X)), Y: math.Max(bl.Y, math.Min(h.Y, tr.Y))}
+			if closest.Distance(h) < r {
+				return fmt.Errorf("%s notch at x=%.2f cuts into the mounting hole at (%.2f, %.2f)", n.Edge, n.X, h.X, h.Y)
+			}
+		}
+	}
+	_, err := CutNotches(geometry.RoundedRect(BottomLeft(p), TopRight(p), p.CornerRadius(), geometry.DefaultTolerance), notches)
+	return err
+}
+
+// corners returns the bottom-left and top-right corners of the rectangle
+// cut away by a notch from a panel spanning bottom to top, extended beyond
+// the edge by overcut so that it cleanly breaks through it
+func (n Notch) corners(bottom, top, overcut float64) (bl, tr geometry.Point) {
+	bl = geometry.Point{X: n.X - n.Width/2, Y: bottom - overcut}
+	tr = geometry.Point{X: n.X + n.Width/2, Y: bottom + n.Depth}
+	if n.Edge == TopEdge {
+		bl.Y, tr.Y = top-n.Depth, top+overcut
+	}
+	return bl, tr
+}
+
+// CutNotches returns an outline with notches cut into its top and bottom
+// edges, which are taken to be its highest and lowest points. It fails if
+// the notches split the outline in two.
+func CutNotches(outline []geometry.Point, notches []Notch) ([]geometry.Point, error) {
+	if len(notches) == 0 {
+		return outline, nil
+	}
+	bottom, top := math.Inf(1), math.Inf(-1)
+	for _, pt := range outline {
+		bottom, top = math.Min(bottom, pt.Y), math.Max(top, pt.Y)
+	}
+	cuts := [][][]geometry.Point{}
+	for _, n := range notches {
+		bl, tr := n.corners(bottom, top, 1)
+		cuts = append(cuts, [][]geometry.Point{{bl, {X: tr.X, Y: bl.Y}, tr, {X: bl.X, Y: tr.Y}}})
+	}
+	result := geometry.Boolean(geometry.Difference, [][]geometry.Point{outline}, geometry.Merge(cuts...))
+	if len(result) != 1 {
+		return nil, errors.New("notches split the panel outline")
+	}
+	return result[0], nil
+}
@@ -23,6 +23,8 @@
 package panel
 
 import (
+	"fmt"
+
 	"github.com/jsleeio/frontpanels/pkg/geometry"
 )
 
@@ -87,19 +89,60 @@ type Panel interface {
 	FooterLocation() geometry.Point
 }
 
+// Outliner panels have an explicit outline, eg. with notches or steps,
+// overriding the default rectangle
+type Outliner interface {
+	// Outline returns the outline as a closed contour, without repeating the
+	// first point. A nil result selects the default rectangle.
+	Outline() []geometry.Point
+}
+
+// EdgeFitter panels trim different amounts from their left and right edges,
+// rather than half of their horizontal fit from each
+type EdgeFitter interface {
+	// EdgeFits returns the amounts trimmed from the left and right edges,
+	// which sum to the horizontal fit
+	EdgeFits() (left, right float64)
+}
+
+// Describer panels can describe their format and width in the terms a
+// builder would use, eg. "eurorack 8hp"
+type Describer interface {
+	// Name returns the name of the panel format, eg. "eurorack"
+	Name() string
+
+	// Units returns the unit the panel width is conventionally given in, eg.
+	// "hp", or "mm" for formats without a horizontal pitch
+	Units() string
+
+	// WidthUnits returns the panel width in Units
+	WidthUnits() float64
+}
+
 // The following functions are probably appropriate for many front panel types,
 // but not all, and so are provided here to be used as required.
 
+// EdgeFits returns the amounts trimmed from the left and right edges of a
+// panel: those of an EdgeFitter, or else half of its horizontal fit each
+func EdgeFits(spec Panel) (left, right float64) {
+	if f, ok := spec.(EdgeFitter); ok {
+		return f.EdgeFits()
+	}
+	return spec.HorizontalFit() / 2, spec.HorizontalFit() / 2
+}
+
 // LeftX returns the left edge coordinate of a panel, adjusted for horizontal
 // fit
 func LeftX(spec Panel) float64 {
-	return spec.HorizontalFit() / 2
+	left, _ := EdgeFits(spec)
+	return left
 }
 
 // RightX returns the right edge coordinate of a panel, adjusted for horizontal
 // fit
 func RightX(spec Panel) float64 {
-	return spec.Width() - spec.HorizontalFit()/2
+	_, right := EdgeFits(spec)
+	return spec.Width() - right
 }
 
 // TopY returns the top edge coordinate of a panel
@@ -135,3 +178,34 @@ func BottomLeft(spec Panel) geometry.Point {
 func BottomRight(spec Panel) geometry.Point {
 	return geometry.Point{X: RightX(spec), Y: BottomY(spec)}
 }
+
+// Description returns a short human-readable description of a panel, eg.
+// "eurorack 8hp", or its dimensions if it does not describe itself
+func Description(spec Panel) string {
+	if d, ok := spec.(Describer); ok {
+		return fmt.Sprintf("%s %g%s", d.Name(), d.WidthUnits(), d.Units())
+	}
+	return fmt.Sprintf("%.2f x %.2f mm", spec.Width(), spec.Height())
+}
+
+// UsableArea returns the space between the top and bottom mounting rails of
+// a panel, across its full width inside the horizontal fit. For panels
+// spanning several rows this includes the rails between them; see Regions.
+func UsableArea(spec Panel) geometry.Rect {
+	return geometry.Rect{
+		BottomLeft: geometry.Point{X: LeftX(spec), Y: spec.MountingHoleBottomY() + spec.RailHeightFromMountingHole()},
+		TopRight:   geometry.Point{X: RightX(spec), Y: spec.MountingHoleTopY() - spec.RailHeightFromMountingHole()},
+	}
+}
+
+// Outline returns the outline of a panel as a closed contour: the panel's
+// own outline if it provides one, or else a rectangle adjusted for
+// horizontal fit, with any rounded corners flattened within tolerance
+func Outline(spec Panel, tolerance float64) []geometry.Point {
+	if o, ok := spec.(Outliner); ok {
+		if outline := o.Outline(); outline != nil {
+			return outline
+		}
+	}
+	return geometry.RoundedRect(BottomLeft(spec), TopRight(spec), spec.CornerRadius(), tolerance)
+}
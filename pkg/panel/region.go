@@ -0,0 +1,67 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package panel
+
+import (
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Region is a horizontal band of a panel spanning one row of a rack, with
+// its own pair of mounting rails. Panels covering several rows are divided
+// into regions.
+type Region struct {
+	// Name identifies the region, eg. "3U" or "row 2"
+	Name string
+	// MountingHoleBottomY and MountingHoleTopY are the Y coordinates of the
+	// region's bottom and top rows of mounting holes
+	MountingHoleBottomY, MountingHoleTopY float64
+	// RailHeightFromMountingHole is the extent of the region's rails beyond
+	// its mounting holes; see Panel.RailHeightFromMountingHole
+	RailHeightFromMountingHole float64
+}
+
+// UsableArea returns the space between the region's rails, across the full
+// width of the panel inside the horizontal fit
+func (r Region) UsableArea(p Panel) geometry.Rect {
+	return geometry.Rect{
+		BottomLeft: geometry.Point{X: LeftX(p), Y: r.MountingHoleBottomY + r.RailHeightFromMountingHole},
+		TopRight:   geometry.Point{X: RightX(p), Y: r.MountingHoleTopY - r.RailHeightFromMountingHole},
+	}
+}
+
+// Regioner panels span more than one row of a rack
+type Regioner interface {
+	// Regions returns the rows spanned by the panel, from bottom to top
+	Regions() []Region
+}
+
+// Regions returns the rows spanned by a panel: those it declares, or else
+// a single region between its own mounting holes
+func Regions(p Panel) []Region {
+	if r, ok := p.(Regioner); ok {
+		return r.Regions()
+	}
+	return []Region{{
+		MountingHoleBottomY:        p.MountingHoleBottomY(),
+		MountingHoleTopY:           p.MountingHoleTopY(),
+		RailHeightFromMountingHole: p.RailHeightFromMountingHole(),
+	}}
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package panel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultThickness is the thickness of panels that do not give their own, in
+// millimetres. Panels made as PCBs are usually 1.6mm FR4, the default at just
+// about every fab.
+const DefaultThickness = 1.6
+
+// materials maps the names of common panel materials to their usual
+// thicknesses, in millimetres
+var materials = map[string]float64{
+	"acrylic":   3.0,
+	"aluminium": 2.0,
+	"fr4":       1.6,
+}
+
+// MaterialNames returns the names of the panel materials with known
+// thicknesses, sorted
+func MaterialNames() []string {
+	names := []string{}
+	for name := range materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MaterialThickness returns the usual thickness of a panel material named by
+// MaterialNames, in millimetres
+func MaterialThickness(name string) (float64, error) {
+	t, ok := materials[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("invalid material %q (valid values: %s)", name, strings.Join(MaterialNames(), " "))
+	}
+	return t, nil
+}
+
+// Thicknesser panels know their own thickness, eg. from a spec file
+type Thicknesser interface {
+	// Thickness returns the thickness of the panel, in millimetres, or zero
+	// if it does not give one
+	Thickness() float64
+}
+
+// Thickness returns the thickness of a panel: its own if it gives one, or
+// else DefaultThickness
+func Thickness(p Panel) float64 {
+	if t, ok := p.(Thicknesser); ok && t.Thickness() > 0 {
+		return t.Thickness()
+	}
+	return DefaultThickness
+}
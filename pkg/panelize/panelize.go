@@ -0,0 +1,249 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package panelize places several copies of a panel side by side on one
+// board for fabrication, joined by mouse-bite tabs so that they can be
+// snapped apart afterwards. Fabs typically charge per board rather than per
+// area, so this can make small runs of narrow panels much cheaper.
+package panelize
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/decor"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/sources/mousebite"
+)
+
+// Options configures a panelized board
+type Options struct {
+	// Copies is the number of copies of the panel, from left to right
+	Copies int
+	// Tabs configures the mouse-bite tabs joining adjacent copies. Their Gap
+	// is the distance between copies.
+	Tabs mousebite.Options
+}
+
+// DefaultOptions returns options for two copies joined by a tab near each
+// end of their long edges
+func DefaultOptions() Options {
+	tabs := mousebite.DefaultOptions()
+	tabs.TabCount = 2
+	return Options{Copies: 2, Tabs: tabs}
+}
+
+// Array implements the panel.Panel interface, describing a board holding
+// copies of a panel side by side. Each copy keeps its own horizontal fit,
+// so the copies are exactly the size of the panel once snapped apart; the
+// board as a whole has none.
+type Array struct {
+	Panel panel.Panel
+	Options
+	outline []geometry.Point
+	// slots are the routed gaps enclosed between tabs
+	slots [][]geometry.Point
+}
+
+// New constructs an Array of copies of a panel, checking that the tabs fit
+// along its edges and join the copies into a single board
+func New(p panel.Panel, opts Options) (*Array, error) {
+	if opts.Copies < 1 {
+		return nil, errors.New("panelize: need at least one copy")
+	}
+	a := &Array{Panel: p, Options: opts}
+	outline := panel.Outline(p, geometry.DefaultTolerance)
+	region := [][][]geometry.Point{}
+	for i := 0; i < opts.Copies; i++ {
+		region = append(region, [][]geometry.Point{translate(outline, a.Offset(i))})
+	}
+	for i := 1; i < opts.Copies; i++ {
+		start, end := a.edge(i)
+		tabs, err := mousebite.Tabs(start, end, opts.Tabs)
+		if err != nil {
+			return nil, err
+		}
+		region = append(region, tabs)
+	}
+	// the merged outline runs anticlockwise around the board, and clockwise
+	// around the slots between tabs
+	for _, contour := range geometry.Merge(region...) {
+		if geometry.SignedArea(contour) < 0 {
+			a.slots = append(a.slots, contour)
+		} else if a.outline == nil {
+			a.outline = contour
+		} else {
+			return nil, fmt.Errorf("panelize: tabs do not join the %d copies into one board", opts.Copies)
+		}
+	}
+	return a, nil
+}
+
+// translate returns a copy of the points moved by an offset
+func translate(pts []geometry.Point, offset geometry.Point) []geometry.Point {
+	moved := make([]geometry.Point, len(pts))
+	for i, p := range pts {
+		moved[i] = p.Add(offset)
+	}
+	return moved
+}
+
+// pitch returns the distance between the left edges of adjacent copies
+func (a Array) pitch() float64 {
+	return a.Panel.Width() - a.Panel.HorizontalFit() + a.Tabs.Gap
+}
+
+// Offset returns the position of the nth copy, counting from 0 at the left,
+// relative to the panel's own coordinates
+func (a Array) Offset(n int) geometry.Point {
+	return geometry.Point{X: float64(n)*a.pitch() - panel.LeftX(a.Panel)}
+}
+
+// edge returns the bottom and top of the right edge of the copy to the left
+// of the nth copy, where the joint between them starts
+func (a Array) edge(n int) (start, end geometry.Point) {
+	x := a.Offset(n-1).X + panel.RightX(a.Panel)
+	return geometry.Point{X: x, Y: panel.BottomY(a.Panel)}, geometry.Point{X: x, Y: panel.TopY(a.Panel)}
+}
+
+// Features returns copies of a panel's features for each copy on the board,
+// followed by the slots routed between the copies and the perforations of
+// the tabs joining them
+func (a Array) Features(feats []features.Feature) ([]features.Feature, error) {
+	all := []features.Feature{}
+	for i := 0; i < a.Copies; i++ {
+		moved, err := features.Translate(feats, a.Offset(i))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, moved...)
+	}
+	for _, s := range a.slots {
+		slot := features.NewPolygon(s)
+		slot.SetPurpose(features.Cutout)
+		all = append(all, slot)
+	}
+	for i := 1; i < a.Copies; i++ {
+		start, end := a.edge(i)
+		holes, err := mousebite.Perforations(start, end, a.Tabs)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, holes...)
+	}
+	return all, nil
+}
+
+// Width returns the width of the board, in millimetres
+func (a Array) Width() float64 {
+	return float64(a.Copies)*a.pitch() - a.Tabs.Gap
+}
+
+// Height returns the height of the board, in millimetres
+func (a Array) Height() float64 {
+	return a.Panel.Height()
+}
+
+// MountingHoleDiameter returns the mounting hole size of the panel, in
+// millimetres
+func (a Array) MountingHoleDiameter() float64 {
+	return a.Panel.MountingHoleDiameter()
+}
+
+// MountingHoles returns the mounting holes of every copy
+func (a Array) MountingHoles() []geometry.Point {
+	holes := []geometry.Point{}
+	for i := 0; i < a.Copies; i++ {
+		holes = append(holes, translate(a.Panel.MountingHoles(), a.Offset(i))...)
+	}
+	return holes
+}
+
+// HorizontalFit returns 0, as each copy is already adjusted for fit
+func (a Array) HorizontalFit() float64 {
+	return 0
+}
+
+// Thickness returns the thickness of the panel, in millimetres
+func (a Array) Thickness() float64 {
+	return panel.Thickness(a.Panel)
+}
+
+// Outline returns the outline of the board: the outline of each copy,
+// joined by the tabs
+func (a Array) Outline() []geometry.Point {
+	return a.outline
+}
+
+// DecorationZones returns the decoration zones of every copy, for panels
+// declaring any
+func (a Array) DecorationZones() []decor.Zone {
+	z, ok := a.Panel.(decor.Zoner)
+	if !ok {
+		return nil
+	}
+	zones := []decor.Zone{}
+	for i := 0; i < a.Copies; i++ {
+		for _, zone := range z.DecorationZones() {
+			zone.Region = translate(zone.Region, a.Offset(i))
+			zones = append(zones, zone)
+		}
+	}
+	return zones
+}
+
+// CornerRadius returns the corner radius of the panel
+func (a Array) CornerRadius() float64 {
+	return a.Panel.CornerRadius()
+}
+
+// Regions returns the rows spanned by the panel
+func (a Array) Regions() []panel.Region {
+	return panel.Regions(a.Panel)
+}
+
+// RailHeightFromMountingHole is used to calculate space between rails
+func (a Array) RailHeightFromMountingHole() float64 {
+	return a.Panel.RailHeightFromMountingHole()
+}
+
+// MountingHoleTopY returns the Y coordinate for the top row of mounting
+// holes
+func (a Array) MountingHoleTopY() float64 {
+	return a.Panel.MountingHoleTopY()
+}
+
+// MountingHoleBottomY returns the Y coordinate for the bottom row of
+// mounting holes
+func (a Array) MountingHoleBottomY() float64 {
+	return a.Panel.MountingHoleBottomY()
+}
+
+// HeaderLocation returns the location of the header text of the first copy
+func (a Array) HeaderLocation() geometry.Point {
+	return a.Panel.HeaderLocation().Add(a.Offset(0))
+}
+
+// FooterLocation returns the location of the footer text of the first copy
+func (a Array) FooterLocation() geometry.Point {
+	return a.Panel.FooterLocation().Add(a.Offset(0))
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package prepare
+
+import (
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// extent returns points whose enclosing polygon covers a feature, or false
+// if the feature cannot be knocked out of an inversion
+func extent(f features.Feature) ([]geometry.Point, bool, error) {
+	switch k := f.(type) {
+	case *features.Line:
+		return flatten.Stroke(k.Start, k.End, k.Thickness/2, flatten.Fine), true, nil
+	case *features.Circle:
+		return flatten.Circle(k.Origin, k.Radius, flatten.Fine), true, nil
+	case *features.Polygon:
+		return k.Points, true, nil
+	case *features.Text:
+		contours, err := textpath.Contours(k, k.Side == features.BottomSide)
+		if err != nil {
+			return nil, false, err
+		}
+		points := []geometry.Point{}
+		for _, c := range contours {
+			points = append(points, c.Points...)
+		}
+		return points, true, nil
+	case *features.Image:
+		points := []geometry.Point{}
+		for _, run := range k.Runs() {
+			points = append(points, features.NewRectangle(run[0], run[1]).Points...)
+		}
+		return points, true, nil
+	}
+	return nil, false, nil
+}
+
+// knocksOut reports whether an inversion knocks out a feature: whether the
+// feature has the same purpose, side and layer hint, and lies wholly within
+// the region
+func knocksOut(inv *features.Inversion, f features.Feature) (bool, error) {
+	if f.GetPurpose() != inv.Purpose || features.LayerHint(f) != features.LayerHint(inv) {
+		return false, nil
+	}
+	if s, ok := f.(features.Sided); !ok || s.GetSide() != inv.Side {
+		return false, nil
+	}
+	points, ok, err := extent(f)
+	if err != nil || !ok || len(points) == 0 {
+		return false, err
+	}
+	for _, p := range points {
+		if !geometry.Contains(inv.Region, p) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Knockouts moves each feature lying wholly within the region of an
+// Inversion, with the same purpose, side and layer hint, into the knockouts
+// of the first such inversion. The inversions are copied rather than
+// modified, and keep any knockouts they already had.
+func Knockouts(feats []features.Feature) ([]features.Feature, error) {
+	inversions := []*features.Inversion{}
+	copied := make([]features.Feature, len(feats))
+	for i, f := range feats {
+		copied[i] = f
+		if inv, ok := f.(*features.Inversion); ok {
+			c := *inv
+			c.Knockouts = append([]features.Feature{}, inv.Knockouts...)
+			inversions = append(inversions, &c)
+			copied[i] = &c
+		}
+	}
+	if len(inversions) == 0 {
+		return feats, nil
+	}
+	kept := make([]features.Feature, 0, len(feats))
+	for _, f := range copied {
+		moved := false
+		for _, inv := range inversions {
+			ok, err := knocksOut(inv, f)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				inv.Knockouts = append(inv.Knockouts, f)
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			kept = append(kept, f)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package prepare turns a panel and its features into features every
+// renderer can draw directly: it generates the panel outline and mounting
+// holes, resolves placeholders, expands custom features, snaps holes to
+// standard drill sizes, fills decoration zones clear of the cutouts, expands
+// stroke text and knocks features out of inversions. It
+// has no renderer dependencies, so that lightweight front ends such as
+// in-browser previews can share it.
+package prepare
+
+import (
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/decor"
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/fab"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+	panelsource "github.com/jsleeio/frontpanels/pkg/sources/panel"
+)
+
+// Options configures feature preparation
+type Options struct {
+	// Drills is the fab profile whose standard drill sizes hole diameters are
+	// snapped to. If nil, holes are drilled at the requested sizes.
+	Drills *fab.Profile
+	// Placeholders holds the values substituted into the fields of
+	// Placeholder features, keyed by field name without braces
+	Placeholders map[string]string
+	// Decorations configures the patterns filling the decoration zones
+	// declared by the panel, eg. in a spec file. If nil,
+	// decor.DefaultOptions are used.
+	Decorations *decor.Options
+	// Diagnostics receives reports about snapped drills. If nil, they are
+	// written to the standard logger.
+	Diagnostics diag.Reporter
+	// KeepCustom reports whether a custom feature is passed to renderers
+	// unexpanded, as some renderer has its own render function for its kind.
+	// If nil, every custom feature is expanded.
+	KeepCustom func(features.Custom) bool
+}
+
+// ExpandCustom returns the features with each custom feature that keep does
+// not report true for replaced by its expansion, recursively, so that it is
+// prepared and checked like standard features. A nil keep expands them all.
+// Kept custom features are checked to expand, as renderers without their
+// own render function for the kind draw the expansion.
+func ExpandCustom(feats []features.Feature, keep func(features.Custom) bool) ([]features.Feature, error) {
+	expanded := make([]features.Feature, 0, len(feats))
+	for _, f := range feats {
+		c, ok := f.(features.Custom)
+		if !ok {
+			expanded = append(expanded, f)
+			continue
+		}
+		// renderers warn and carry on, so fail early instead
+		sub, err := c.Expand()
+		if err != nil {
+			return nil, fmt.Errorf("%s feature: %v", c.Kind(), err)
+		}
+		if keep != nil && keep(c) {
+			expanded = append(expanded, c)
+			continue
+		}
+		if sub, err = ExpandCustom(sub, keep); err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, sub...)
+	}
+	return expanded, nil
+}
+
+// Features generates the panel outline features, and prepares them and the
+// additional features for rendering. The outline features are returned
+// separately as some renderers draw the outline themselves.
+func Features(p panel.Panel, feats []features.Feature, opts Options) (outline, prepared []features.Feature, err error) {
+	outline = panelsource.GeneratePanelOutlineFeatures(p)
+	if feats, err = features.ResolvePlaceholders(feats, opts.Placeholders); err != nil {
+		return nil, nil, err
+	}
+	if feats, err = ExpandCustom(feats, opts.KeepCustom); err != nil {
+		return nil, nil, err
+	}
+	if opts.Drills != nil {
+		var r diag.Reporter = diag.Logger{}
+		if opts.Diagnostics != nil {
+			r = opts.Diagnostics
+		}
+		if outline, err = opts.Drills.SnapDrills(outline, r); err != nil {
+			return nil, nil, err
+		}
+		if feats, err = opts.Drills.SnapDrills(feats, r); err != nil {
+			return nil, nil, err
+		}
+	}
+	if z, ok := p.(decor.Zoner); ok {
+		decorOptions := decor.DefaultOptions()
+		if opts.Decorations != nil {
+			decorOptions = *opts.Decorations
+		}
+		decoration, err := decor.Zones(p, z.DecorationZones(), feats, decorOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		feats = append(feats, decoration...)
+	}
+	if feats, err = textpath.ExpandStrokeText(feats); err != nil {
+		return nil, nil, err
+	}
+	if feats, err = Knockouts(feats); err != nil {
+		return nil, nil, err
+	}
+	return outline, feats, nil
+}
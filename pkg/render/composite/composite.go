@@ -0,0 +1,204 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package composite renders a PNG image approximating the fabricated
+// appearance of the front of a panel, by compositing its layers in order:
+// soldermask over the board, copper under the mask, silkscreen, then copper
+// exposed through mask openings. Drilled holes and cutouts are transparent.
+// Colours are taken from preview.Options, so the SVG preview presets apply.
+package composite
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/preview"
+	"github.com/jsleeio/frontpanels/pkg/render/raster"
+)
+
+// Options configures the image
+type Options struct {
+	Colours preview.Options
+	// Resolution is the number of pixels per millimetre
+	Resolution float64
+	// Supersample is the number of samples taken along each axis of every
+	// pixel, for antialiasing
+	Supersample int
+	// Tolerance is the maximum deviation of flattened curves, in
+	// millimetres. If zero, flatten.Coarse is used.
+	Tolerance float64
+}
+
+// DefaultOptions returns options for a black panel at roughly 250 DPI
+func DefaultOptions() Options {
+	return Options{
+		Colours:     preview.DefaultOptions(),
+		Resolution:  10,
+		Supersample: 3,
+		Tolerance:   flatten.Coarse,
+	}
+}
+
+// band is the number of image rows rasterised at a time, bounding the
+// memory used for large panels
+const band = 64
+
+// Composite collects panel features for compositing
+type Composite struct {
+	Options
+	*raster.Layers
+	hardware []preview.Shape
+}
+
+// New constructs a new Composite of a panel with no features, initially
+// with the panel's default outline
+func New(p panel.Panel, opts Options) *Composite {
+	return &Composite{Options: opts, Layers: raster.NewLayers(p, flatten.Or(opts.Tolerance, flatten.Coarse))}
+}
+
+// AddHardware draws hardware over the panel, as preview.AddHardware does
+func (c *Composite) AddHardware(hw []preview.Hardware) {
+	for _, h := range hw {
+		c.hardware = append(c.hardware, h.Shapes(c.Colours, flatten.Or(c.Tolerance, flatten.Coarse))...)
+	}
+}
+
+// palette returns the layer colours, in compositing order: the board
+// layers, followed by each hardware shape
+func (c *Composite) palette() ([]color.NRGBA, error) {
+	colours := []string{c.Colours.Soldermask, c.Colours.MaskedCopper, c.Colours.Silkscreen, c.Colours.Copper}
+	for _, s := range c.hardware {
+		colours = append(colours, s.Colour)
+	}
+	p := make([]color.NRGBA, len(colours))
+	for i, s := range colours {
+		var err error
+		if p[i], err = preview.ParseColour(s); err != nil {
+			return nil, fmt.Errorf("composite: %v", err)
+		}
+	}
+	return p, nil
+}
+
+// Image composites the layers into an image
+func (c *Composite) Image() (*image.NRGBA, error) {
+	if c.Resolution <= 0 || c.Supersample < 1 {
+		return nil, fmt.Errorf("composite: resolution must be positive and supersampling at least 1, not %g and %d", c.Resolution, c.Supersample)
+	}
+	palette, err := c.palette()
+	if err != nil {
+		return nil, err
+	}
+	bounds := c.Bounds()
+	width := int(math.Ceil(bounds.Width()*c.Resolution - 1e-9))
+	height := int(math.Ceil(bounds.Height()*c.Resolution - 1e-9))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	n := c.Supersample
+	hardware := make([]int, width*n*band*n)
+	for top := 0; top < height; top += band {
+		rows := band
+		if top+rows > height {
+			rows = height - top
+		}
+		area := geometry.Rect{
+			BottomLeft: geometry.Point{X: bounds.BottomLeft.X, Y: bounds.TopRight.Y - float64(top+rows)/c.Resolution},
+			TopRight:   geometry.Point{X: bounds.BottomLeft.X + float64(width)/c.Resolution, Y: bounds.TopRight.Y - float64(top)/c.Resolution},
+		}
+		board := raster.New(area, width*n, rows*n)
+		c.FillBoard(board)
+		layers := []*raster.Raster{board}
+		for _, layer := range [][]raster.Shape{c.MaskedCopper, c.Silkscreen, c.Copper} {
+			r := raster.New(area, width*n, rows*n)
+			raster.FillLayer(r, layer)
+			layers = append(layers, r)
+		}
+		// hardware shapes are painted in order into a single buffer, each
+		// sample recording the last shape covering it
+		for i := range hardware {
+			hardware[i] = 0
+		}
+		for i, s := range c.hardware {
+			board.Scan(func(col, row int) { hardware[row*board.Cols+col] = i + 1 }, s.Contours...)
+		}
+		for y := 0; y < rows; y++ {
+			for x := 0; x < width; x++ {
+				img.SetNRGBA(x, top+y, pixel(layers, hardware, board.Cols, palette, x*n, y*n, n))
+			}
+		}
+	}
+	return img, nil
+}
+
+// boardLayers is the number of layers drawn only on the board, rather than
+// over it like hardware
+const boardLayers = 4
+
+// pixel averages the colours of the n by n samples at col, row. Each
+// sample takes the colour of the last layer covering it; the layers after
+// the board are clipped to it, but hardware is not. hardware holds, for
+// each sample in rows of cols, one more than the index of the hardware
+// shape covering it, or zero.
+func pixel(layers []*raster.Raster, hardware []int, cols int, palette []color.NRGBA, col, row, n int) color.NRGBA {
+	var r, g, b, count int
+	for y := row; y < row+n; y++ {
+		for x := col; x < col+n; x++ {
+			covered := false
+			var c color.NRGBA
+			for i, layer := range layers {
+				if !layer.At(x, y) || (i > 0 && i < boardLayers && !layers[0].At(x, y)) {
+					continue
+				}
+				c, covered = palette[i], true
+			}
+			if h := hardware[y*cols+x]; h > 0 {
+				c, covered = palette[boardLayers+h-1], true
+			}
+			if !covered {
+				continue
+			}
+			r, g, b, count = r+int(c.R), g+int(c.G), b+int(c.B), count+1
+		}
+	}
+	if count == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8(r / count),
+		G: uint8(g / count),
+		B: uint8(b / count),
+		A: uint8(count * 0xff / (n * n)),
+	}
+}
+
+// WritePNG writes the composite as a PNG image
+func (c *Composite) WritePNG(w io.Writer) error {
+	img, err := c.Image()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package excellon writes Excellon NC drill files, as expected by PCB
+// fabricators alongside Gerber layers. Plated and non-plated holes are kept
+// in separate files, as most fabs require.
+package excellon
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Hole describes a single drill hit. All dimensions are in millimetres.
+type Hole struct {
+	X, Y     float64
+	Diameter float64
+}
+
+// Drill collects holes for a single Excellon file
+type Drill struct {
+	// Plated indicates whether the holes in this file are plated
+	// through-holes (PTH) or not (NPTH)
+	Plated bool
+	Holes  []Hole
+	// Comments are written in the header, following the file attributes
+	Comments []string
+}
+
+// NewDrill constructs a new, empty Drill
+func NewDrill(plated bool) *Drill {
+	return &Drill{Plated: plated, Holes: []Hole{}}
+}
+
+// Add appends holes to the drill file
+func (d *Drill) Add(holes ...Hole) {
+	d.Holes = append(d.Holes, holes...)
+}
+
+// Empty indicates whether there are any holes in the drill file
+func (d *Drill) Empty() bool {
+	return len(d.Holes) == 0
+}
+
+// toolKey rounds a diameter to the micron, so that floating point noise
+// doesn't result in spurious extra tools
+func toolKey(diameter float64) int {
+	return int(math.Round(diameter * 1000))
+}
+
+// Tools returns the distinct tool diameters used, smallest first
+func (d *Drill) Tools() []float64 {
+	seen := map[int]bool{}
+	tools := []float64{}
+	for _, h := range d.Holes {
+		k := toolKey(h.Diameter)
+		if !seen[k] {
+			seen[k] = true
+			tools = append(tools, float64(k)/1000.0)
+		}
+	}
+	sort.Float64s(tools)
+	return tools
+}
+
+// toolHoles returns the holes drilled with a given tool, sorted by X and
+// then Y coordinate so that output does not depend on the order holes were
+// added
+func (d *Drill) toolHoles(tool float64) []Hole {
+	holes := []Hole{}
+	for _, h := range d.Holes {
+		if toolKey(h.Diameter) == toolKey(tool) {
+			holes = append(holes, h)
+		}
+	}
+	sort.SliceStable(holes, func(i, j int) bool {
+		if holes[i].X != holes[j].X {
+			return holes[i].X < holes[j].X
+		}
+		return holes[i].Y < holes[j].Y
+	})
+	return holes
+}
+
+// FileFunction returns the X2-style .FileFunction attribute value for the
+// drill file
+func (d *Drill) FileFunction() string {
+	if d.Plated {
+		return "Plated,1,2,PTH"
+	}
+	return "NonPlated,1,2,NPTH"
+}
+
+// WriteExcellon writes the drill file in metric, absolute, decimal-coordinate
+// Excellon format
+func (d *Drill) WriteExcellon(w io.Writer) error {
+	tools := d.Tools()
+	header := []string{
+		"M48",
+		"; DRILL file generated by github.com/jsleeio/frontpanels",
+		"; FORMAT={-:-/ absolute / metric / decimal}",
+		"; #@! TF.FileFunction," + d.FileFunction(),
+	}
+	for _, c := range d.Comments {
+		header = append(header, "; "+strings.ReplaceAll(c, "\n", " "))
+	}
+	header = append(header, "FMAT,2", "METRIC")
+	for _, line := range header {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	for i, t := range tools {
+		if _, err := fmt.Fprintf(w, "T%dC%.3f\n", i+1, t); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "%\nG90\nG05\n"); err != nil {
+		return err
+	}
+	for i, t := range tools {
+		if _, err := fmt.Fprintf(w, "T%d\n", i+1); err != nil {
+			return err
+		}
+		for _, h := range d.toolHoles(t) {
+			if _, err := fmt.Fprintf(w, "X%.4fY%.4f\n", h.X, h.Y); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(w, "T0\nM30\n")
+	return err
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package excellon
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// kind returns the PTH/NPTH label for the holes in a drill file
+func (d *Drill) kind() string {
+	if d.Plated {
+		return "PTH"
+	}
+	return "NPTH"
+}
+
+// WriteReport writes a human-readable drill table for the given drill
+// files: a summary of tools and hole counts, followed by the coordinates of
+// every hole. Tool numbers match those in the Excellon files.
+func WriteReport(w io.Writer, drills ...*Drill) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Drill report generated by github.com/jsleeio/frontpanels")
+	fmt.Fprintln(tw, "All dimensions in millimetres")
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TYPE\tTOOL\tDIAMETER\tCOUNT\t")
+	total := 0
+	for _, d := range drills {
+		for i, t := range d.Tools() {
+			n := len(d.toolHoles(t))
+			total += n
+			fmt.Fprintf(tw, "%s\tT%d\t%.3f\t%d\t\n", d.kind(), i+1, t, n)
+		}
+	}
+	fmt.Fprintf(tw, "\t\tTOTAL\t%d\t\n", total)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TYPE\tTOOL\tX\tY\t")
+	for _, d := range drills {
+		for i, t := range d.Tools() {
+			for _, h := range d.toolHoles(t) {
+				fmt.Fprintf(tw, "%s\tT%d\t%.4f\t%.4f\t\n", d.kind(), i+1, h.X, h.Y)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes a drill table in CSV format for the given drill files, one
+// row per hole
+func WriteCSV(w io.Writer, drills ...*Drill) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"type", "tool", "diameter", "x", "y"}); err != nil {
+		return err
+	}
+	format := func(v float64, prec int) string {
+		return strconv.FormatFloat(v, 'f', prec, 64)
+	}
+	for _, d := range drills {
+		for i, t := range d.Tools() {
+			for _, h := range d.toolHoles(t) {
+				record := []string{d.kind(), fmt.Sprintf("T%d", i+1), format(t, 3), format(h.X, 4), format(h.Y, 4)}
+				if err := cw.Write(record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
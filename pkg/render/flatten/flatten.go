@@ -0,0 +1,118 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package flatten converts the curved features of panels into straight
+// segments, for renderers and file formats that cannot describe arcs
+// natively. Each backend chooses a chord tolerance suited to its output:
+// fabrication files need Fine, while previews drawn at screen resolution
+// can use Coarse and produce far fewer points. Glyph outlines are flattened
+// by the font renderer, and are not affected by the tolerance.
+package flatten
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+const (
+	// Fine is the chord tolerance, in millimetres, for fabrication output
+	// such as Gerber regions
+	Fine = 0.002
+	// Coarse is the chord tolerance, in millimetres, for previews, whose
+	// pixels are far larger than any fabrication tolerance
+	Coarse = 0.025
+)
+
+// Or returns tolerance, or def if tolerance is not positive, for options
+// whose zero value selects a backend's default tolerance
+func Or(tolerance, def float64) float64 {
+	if tolerance <= 0 {
+		return def
+	}
+	return tolerance
+}
+
+// Circle returns the contour of a circle, anticlockwise from its rightmost
+// point
+func Circle(centre geometry.Point, radius, tolerance float64) []geometry.Point {
+	return geometry.Arc(centre, radius, 0, 360, tolerance)
+}
+
+// Stroke returns the stadium-shaped contour of a line of the given half
+// width with rounded ends
+func Stroke(start, end geometry.Point, r, tolerance float64) []geometry.Point {
+	if start == end {
+		return Circle(start, r, tolerance)
+	}
+	d := end.Sub(start)
+	a := math.Atan2(d.Y, d.X) * 180.0 / math.Pi
+	return append(geometry.Arc(end, r, a-90, a+90, tolerance), geometry.Arc(start, r, a+90, a+270, tolerance)...)
+}
+
+// Contours returns closed contours covering the area of a feature, as seen
+// from the front of the panel, to be filled under the even-odd rule. Lines
+// are given their thickness and rounded ends.
+func Contours(item features.Feature, tolerance float64) ([][]geometry.Point, error) {
+	switch f := item.(type) {
+	case *features.Line:
+		return [][]geometry.Point{Stroke(f.Start, f.End, f.Thickness/2, tolerance)}, nil
+	case *features.Circle:
+		return [][]geometry.Point{Circle(f.Origin, f.Radius, tolerance)}, nil
+	case *features.Polygon:
+		return [][]geometry.Point{f.Points}, nil
+	case *features.Outline:
+		return [][]geometry.Point{f.Points}, nil
+	case *features.Text:
+		contours, err := textpath.Contours(f, false)
+		if err != nil {
+			return nil, err
+		}
+		points := [][]geometry.Point{}
+		for _, c := range contours {
+			points = append(points, c.Points)
+		}
+		return points, nil
+	case *features.Image:
+		points := [][]geometry.Point{}
+		for _, run := range f.Runs() {
+			points = append(points, features.NewRectangle(run[0], run[1]).Points)
+		}
+		return points, nil
+	case features.Custom:
+		expansion, err := f.Expand()
+		if err != nil {
+			return nil, err
+		}
+		points := [][]geometry.Point{}
+		for _, e := range expansion {
+			contours, err := Contours(e, tolerance)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, contours...)
+		}
+		return points, nil
+	}
+	return nil, fmt.Errorf("flatten: unsupported feature type: %T", item)
+}
@@ -0,0 +1,322 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package gcode writes 2.5D G-code programs for machining panels on desktop
+// CNC machines. Two programs are generated, so that different tools can be
+// used: an engraving program tracing Marking features at a shallow depth,
+// and a profile program drilling and cutting out Cutout features through
+// the full thickness of the panel, finishing with the panel outline.
+//
+// The work origin is the bottom-left corner of the panel, on its top
+// surface.
+package gcode
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/plate"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// Options configures the machining operations. Distances are in
+// millimetres, feeds in millimetres per minute and speeds in RPM.
+type Options struct {
+	// Thickness of the panel material
+	Thickness float64
+	// Breakthrough is the additional depth cut below the panel, to ensure
+	// cutouts are cut cleanly
+	Breakthrough float64
+	// StepDown is the maximum depth of each profile pass
+	StepDown float64
+	// SafeZ is the height for rapid moves above the panel
+	SafeZ float64
+	// EngraveDepth is the depth of engraving passes
+	EngraveDepth float64
+	// ToolDiameter is the diameter of the profile cutting tool
+	ToolDiameter float64
+	// Compensation offsets profile cuts by the tool radius, so that
+	// cutouts and the panel outline are cut to size. Without it the tool
+	// centre follows the nominal geometry.
+	Compensation bool
+	// EngraveFeed, CutFeed and PlungeFeed are the feed rates for engraving,
+	// profile cutting and vertical moves
+	EngraveFeed, CutFeed, PlungeFeed float64
+	// SpindleSpeed is the spindle speed for both programs
+	SpindleSpeed float64
+	// Tolerance is the maximum deviation of flattened curves
+	Tolerance float64
+}
+
+// DefaultOptions returns conservative options for cutting 2mm aluminium
+// with a 2mm single-flute end mill
+func DefaultOptions() Options {
+	return Options{
+		Thickness:    2.0,
+		Breakthrough: 0.2,
+		StepDown:     0.3,
+		SafeZ:        5.0,
+		EngraveDepth: 0.1,
+		ToolDiameter: 2.0,
+		Compensation: true,
+		EngraveFeed:  300,
+		CutFeed:      200,
+		PlungeFeed:   50,
+		SpindleSpeed: 12000,
+		Tolerance:    geometry.DefaultTolerance,
+	}
+}
+
+// Program collects the toolpaths for machining a panel
+type Program struct {
+	Options
+	// Diagnostics receives warnings about features that cannot be machined
+	Diagnostics diag.Reporter
+
+	plate   *plate.Plate
+	engrave [][]geometry.Point
+	// engraveCircles are traced with arc moves rather than flattened
+	engraveCircles []*features.Circle
+}
+
+// NewProgram constructs a new Program for machining a panel
+func NewProgram(p panel.Panel, opts Options) *Program {
+	return &Program{
+		Options:     opts,
+		Diagnostics: diag.Logger{},
+		plate:       plate.New(p),
+	}
+}
+
+// AddFeatures adds the features to be machined. Marking features on the top
+// side are engraved along their outlines, or along their centre lines for
+// lines; cutouts are added to the profile.
+func (pr *Program) AddFeatures(feats []features.Feature) {
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(pr.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	pr.plate.AddFeatures(feats, pr.Diagnostics)
+	for _, item := range feats {
+		if item.GetPurpose() != features.Marking {
+			continue
+		}
+		if s, ok := item.(features.Sided); ok && s.GetSide() == features.BottomSide {
+			continue
+		}
+		switch f := item.(type) {
+		case *features.Line:
+			pr.engrave = append(pr.engrave, []geometry.Point{f.Start, f.End})
+		case *features.Circle:
+			pr.engraveCircles = append(pr.engraveCircles, f)
+		case *features.Polygon:
+			pr.engrave = append(pr.engrave, closed(f.Points))
+		case *features.Text:
+			contours, err := textpath.Contours(f, false)
+			if err != nil {
+				diag.Warnf(pr.Diagnostics, f, "cannot engrave text, ignoring: %v", err)
+				continue
+			}
+			for _, c := range contours {
+				pr.engrave = append(pr.engrave, closed(c.Points))
+			}
+		default:
+			diag.Warnf(pr.Diagnostics, item, "cannot engrave feature, ignoring: %v", item)
+		}
+	}
+}
+
+// closed returns a contour with its first point repeated at the end
+func closed(contour []geometry.Point) []geometry.Point {
+	return append(append([]geometry.Point{}, contour...), contour[0])
+}
+
+// writer accumulates G-code
+type writer struct {
+	strings.Builder
+	opts Options
+}
+
+func (w *writer) line(format string, args ...interface{}) {
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+func (w *writer) header(operation string) {
+	w.line("(panel %s generated by github.com/jsleeio/frontpanels)", operation)
+	w.line("G21 (millimetres)")
+	w.line("G90 (absolute positioning)")
+	w.line("G17 (XY plane)")
+	w.line("G0 Z%s", geometry.FormatNumber(w.opts.SafeZ, 4))
+	w.line("M3 S%s", geometry.FormatNumber(w.opts.SpindleSpeed, 4))
+}
+
+func (w *writer) footer() {
+	w.line("G0 Z%s", geometry.FormatNumber(w.opts.SafeZ, 4))
+	w.line("M5")
+	w.line("M2")
+}
+
+// rapid moves above a point at the safe height
+func (w *writer) rapid(p geometry.Point) {
+	w.line("G0 Z%s", geometry.FormatNumber(w.opts.SafeZ, 4))
+	w.line("G0 X%s Y%s", geometry.FormatNumber(p.X, 4), geometry.FormatNumber(p.Y, 4))
+}
+
+// plunge moves vertically to a depth below the surface
+func (w *writer) plunge(depth float64) {
+	w.line("G1 Z%s F%s", geometry.FormatNumber(-depth, 4), geometry.FormatNumber(w.opts.PlungeFeed, 4))
+}
+
+// path cuts along a sequence of points, starting at the current position
+func (w *writer) path(points []geometry.Point, feed float64) {
+	for i, p := range points {
+		if i == 0 {
+			w.line("G1 X%s Y%s F%s", geometry.FormatNumber(p.X, 4), geometry.FormatNumber(p.Y, 4), geometry.FormatNumber(feed, 4))
+			continue
+		}
+		w.line("G1 X%s Y%s", geometry.FormatNumber(p.X, 4), geometry.FormatNumber(p.Y, 4))
+	}
+}
+
+// circle cuts a full clockwise circle, starting and ending at its
+// rightmost point
+func (w *writer) circle(centre geometry.Point, radius, feed float64) {
+	w.line("G2 X%s Y%s I%s J0 F%s", geometry.FormatNumber(centre.X+radius, 4), geometry.FormatNumber(centre.Y, 4), geometry.FormatNumber(-radius, 4), geometry.FormatNumber(feed, 4))
+}
+
+// depths returns the depth of each pass needed to reach a final depth
+func (w *writer) depths(final float64) []float64 {
+	step := w.opts.StepDown
+	if step <= 0 {
+		step = final
+	}
+	depths := []float64{}
+	for d := step; d < final-1e-9; d += step {
+		depths = append(depths, d)
+	}
+	return append(depths, final)
+}
+
+// WriteEngraving writes the engraving program
+func (pr *Program) WriteEngraving(out io.Writer) error {
+	w := &writer{opts: pr.Options}
+	w.header("engraving")
+	for _, c := range pr.engraveCircles {
+		start := c.Origin.Add(geometry.Point{X: c.Radius})
+		w.rapid(start)
+		w.plunge(pr.EngraveDepth)
+		w.circle(c.Origin, c.Radius, pr.EngraveFeed)
+	}
+	for _, points := range pr.engrave {
+		w.rapid(points[0])
+		w.plunge(pr.EngraveDepth)
+		w.path(points[1:], pr.EngraveFeed)
+	}
+	w.footer()
+	_, err := io.WriteString(out, w.String())
+	return err
+}
+
+// contour cuts a closed contour in passes down to the full depth
+func (w *writer) contour(points []geometry.Point, depth float64) {
+	points = closed(points)
+	w.rapid(points[0])
+	for _, d := range w.depths(depth) {
+		w.plunge(d)
+		w.path(points[1:], w.opts.CutFeed)
+	}
+}
+
+// WriteProfile writes the profile program: holes, then internal cutouts,
+// then the panel outline, so that the panel stays attached to the stock for
+// as long as possible
+func (pr *Program) WriteProfile(out io.Writer) error {
+	if pr.Thickness <= 0 {
+		return fmt.Errorf("gcode: plate thickness must be greater than zero, not %v", pr.Thickness)
+	}
+	w := &writer{opts: pr.Options}
+	w.header("profile")
+	depth := pr.Thickness + pr.Breakthrough
+	toolRadius := pr.ToolDiameter / 2
+	compensation := 0.0
+	if pr.Compensation {
+		compensation = toolRadius
+	}
+	const eps = 0.01
+	for _, h := range pr.plate.Holes {
+		r := h.Radius - compensation
+		if h.Radius*2 < pr.ToolDiameter-eps {
+			diag.Warnf(pr.Diagnostics, h, "hole is smaller than the %smm tool: %v", geometry.FormatNumber(pr.ToolDiameter, 4), h)
+		}
+		if r < eps {
+			// drill it, pecking to clear chips
+			w.rapid(h.Origin)
+			for _, d := range w.depths(depth) {
+				w.plunge(d)
+				w.line("G0 Z%s", geometry.FormatNumber(pr.SafeZ, 4))
+			}
+			continue
+		}
+		start := h.Origin.Add(geometry.Point{X: r})
+		w.rapid(start)
+		for _, d := range w.depths(depth) {
+			w.plunge(d)
+			w.circle(h.Origin, r, pr.CutFeed)
+		}
+	}
+	contours := [][]geometry.Point{}
+	for _, c := range pr.plate.Cutouts {
+		contours = append(contours, c.Points)
+	}
+	for _, l := range pr.plate.Slots {
+		if math.Abs(l.Thickness-pr.ToolDiameter) < eps || !pr.Compensation {
+			// a slot the width of the tool is cut along its centre line
+			w.rapid(l.Start)
+			for _, d := range w.depths(depth) {
+				w.plunge(d)
+				w.path([]geometry.Point{l.End, l.Start}, pr.CutFeed)
+			}
+			continue
+		}
+		slot := &plate.Plate{Slots: []*features.Line{l}}
+		contours = append(contours, slot.HoleContours(pr.Tolerance)...)
+	}
+	for _, c := range contours {
+		insets := geometry.Offset([][]geometry.Point{c}, -compensation, geometry.MiterJoin, pr.Tolerance)
+		if len(insets) == 0 {
+			diag.Warnf(pr.Diagnostics, nil, "cutout is too small for the %smm tool, skipping", geometry.FormatNumber(pr.ToolDiameter, 4))
+			continue
+		}
+		for _, inset := range insets {
+			w.contour(inset, depth)
+		}
+	}
+	for _, c := range geometry.Offset([][]geometry.Point{pr.plate.Outline(pr.Tolerance)}, compensation, geometry.MiterJoin, pr.Tolerance) {
+		w.contour(c, depth)
+	}
+	w.footer()
+	_, err := io.WriteString(out, w.String())
+	return err
+}
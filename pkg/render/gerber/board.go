@@ -0,0 +1,527 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package gerber
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/output"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/excellon"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+
+	gogerber "github.com/gmlewis/go-gerber/gerber"
+)
+
+// Board collects panel features into Gerber layers and drill files
+type Board struct {
+	// Name is the basename used for generating filenames
+	Name string
+	// FilenameTemplate is used to generate output filenames. See
+	// output.ExpandTemplate.
+	FilenameTemplate string
+	// Width and Height are the overall board dimensions, in millimetres
+	Width, Height float64
+
+	// Soldermask indicates whether soldermask layers should be generated.
+	// Without them, fabs may assume there is no soldermask at all.
+	Soldermask bool
+	// Paste indicates whether a top paste (stencil) layer should be generated
+	// from the mask openings
+	Paste bool
+	// DrillReport indicates whether human-readable and CSV drill tables
+	// should be generated alongside the drill files
+	DrillReport bool
+	// Annotations indicates whether a drawing layer should be generated from
+	// Annotation features, eg. dimensions. It is not part of the fabrication
+	// data, so is not listed in the job file.
+	Annotations bool
+
+	Outline, TopSilkscreen, BottomSilkscreen, TopCopper, BottomCopper *Layer
+
+	TopSoldermask, BottomSoldermask, TopPaste *Layer
+
+	Drawing *Layer
+
+	PlatedDrills, NonplatedDrills *excellon.Drill
+
+	// Diagnostics receives warnings about features that cannot be rendered
+	// or are probably mistakes
+	Diagnostics diag.Reporter
+
+	// CreationDate is recorded in the job file. If zero, the current time
+	// is used; fix it for byte-identical output across runs.
+	CreationDate time.Time
+
+	// MetalCore indicates a single-layer aluminium board: no rear copper,
+	// soldermask or silkscreen layers are written, and the job file
+	// describes a metal-core stackup
+	MetalCore bool
+
+	// Thickness is the overall board thickness recorded in the job file, in
+	// millimetres. If zero, DefaultBoardThickness is assumed.
+	Thickness float64
+	// EdgePlating and Castellated record in the job file that the board
+	// edges are plated, or have plated half-holes, so that fabs need no
+	// separate notes
+	EdgePlating, Castellated bool
+
+	// Revision is recorded as the project revision in the job file
+	Revision string
+	// Comments are written into the header of every Gerber and drill file,
+	// eg. to identify the spec the board was generated from
+	Comments []string
+
+	// Tolerance is the maximum deviation, in millimetres, of circles
+	// flattened into outline regions. If zero, flatten.Fine is used.
+	Tolerance float64
+
+	// cutouts are retained so that copper pour clearances can be generated
+	cutouts []features.Feature
+	// pourClearance is the margin kept between the pour and any cutout
+	pourClearance float64
+	// pour is retained so that the pour can be described
+	pour []features.Feature
+}
+
+// NewBoard constructs a new Board sized to fit a panel
+func NewBoard(name string, p panel.Panel) *Board {
+	return &Board{
+		Name:             name,
+		FilenameTemplate: output.DefaultFilenameTemplate,
+		Width:            p.Width(),
+		Height:           p.Height(),
+		Outline:          NewLayer("outline", "gko", "Profile,NP", "Positive"),
+		TopSilkscreen:    NewLayer("silkscreen-top", "gto", "Legend,Top", "Positive"),
+		BottomSilkscreen: NewLayer("silkscreen-bottom", "gbo", "Legend,Bot", "Positive"),
+		TopCopper:        NewLayer("copper-top", "gtl", "Copper,L1,Top", "Positive"),
+		BottomCopper:     NewLayer("copper-bottom", "gbl", "Copper,L2,Bot", "Positive"),
+		// soldermask images describe the openings, hence negative polarity
+		TopSoldermask:    NewLayer("soldermask-top", "gts", "Soldermask,Top", "Negative"),
+		BottomSoldermask: NewLayer("soldermask-bottom", "gbs", "Soldermask,Bot", "Negative"),
+		TopPaste:         NewLayer("paste-top", "gtp", "Paste,Top", "Positive"),
+		Drawing:          NewLayer("drawing", "gbr", "FabricationDrawing", "Positive"),
+		Soldermask:       true,
+		DrillReport:      true,
+		PlatedDrills:     excellon.NewDrill(true),
+		NonplatedDrills:  excellon.NewDrill(false),
+		Diagnostics:      diag.Logger{},
+	}
+}
+
+// Layers returns all Gerber layers of the board to be written, in stackup
+// order. The bottom silkscreen is only written when it has markings, and
+// metal-core boards have no rear layers at all.
+func (b *Board) Layers() []*Layer {
+	layers := []*Layer{}
+	if b.Paste {
+		layers = append(layers, b.TopPaste)
+	}
+	layers = append(layers, b.TopSilkscreen)
+	if b.Soldermask {
+		layers = append(layers, b.TopSoldermask)
+	}
+	layers = append(layers, b.TopCopper)
+	if b.MetalCore {
+		return append(layers, b.Outline)
+	}
+	layers = append(layers, b.BottomCopper)
+	if b.Soldermask {
+		layers = append(layers, b.BottomSoldermask)
+	}
+	if !b.BottomSilkscreen.Empty() {
+		layers = append(layers, b.BottomSilkscreen)
+	}
+	return append(layers, b.Outline)
+}
+
+func (b *Board) adddrill(c *features.Circle) {
+	if c.Plated {
+		b.PlatedDrills.Add(mkhole(c))
+	} else {
+		b.NonplatedDrills.Add(mkhole(c))
+	}
+}
+
+// AddPour renders copper pour features into the copper layers according to
+// their side. Every Cutout feature on the board, whether added before or
+// after the pour, is subtracted from the pour along with a surrounding
+// margin of clearance.
+func (b *Board) AddPour(feats []features.Feature, clearance float64) {
+	b.pourClearance = clearance
+	for _, item := range feats {
+		var prims []gogerber.Primitive
+		switch f := item.(type) {
+		case *features.Line:
+			prims = []gogerber.Primitive{mkline(f)}
+		case *features.Circle:
+			prims = []gogerber.Primitive{mkcircle(f)}
+		case *features.Polygon:
+			prims = []gogerber.Primitive{mkpolygon(f)}
+		default:
+			diag.Warnf(b.Diagnostics, item, "unsupported copper pour feature type: %s", reflect.TypeOf(f).String())
+			continue
+		}
+		b.pour = append(b.pour, item)
+		b.pourLayer(item).AddPour(prims...)
+	}
+}
+
+// Pour returns the copper pour features rendered by AddPour
+func (b *Board) Pour() []features.Feature {
+	return append([]features.Feature{}, b.pour...)
+}
+
+// pourLayer returns the copper layer a pour feature is rendered into
+func (b *Board) pourLayer(f features.Feature) *Layer {
+	if s, ok := f.(features.Sided); ok && s.GetSide() == features.BottomSide {
+		return b.BottomCopper
+	}
+	return b.TopCopper
+}
+
+// applyClearance generates the copper pour clearances for all cutouts
+func (b *Board) applyClearance() {
+	prims := []gogerber.Primitive{}
+	for _, f := range b.cutouts {
+		prims = append(prims, mkclearance(f, b.pourClearance)...)
+	}
+	b.TopCopper.SetClearance(prims...)
+	b.BottomCopper.SetClearance(prims...)
+}
+
+// cutout reports whether a feature is rendered into the outline or a drill
+// file, according to its layer hint or, failing that, its purpose
+func cutout(f features.Feature) bool {
+	switch features.LayerHint(f) {
+	case features.AutoLayer:
+		return f.GetPurpose() == features.Cutout
+	case features.OutlineLayer, features.DrillLayer:
+		return true
+	}
+	return false
+}
+
+// side returns the side of the panel a feature is rendered on, according to
+// its layer hint or, failing that, its side
+func side(f features.Feature) features.Side {
+	if hint := features.LayerHint(f); hint != features.AutoLayer {
+		return hint.Side()
+	}
+	if s, ok := f.(features.Sided); ok {
+		return s.GetSide()
+	}
+	return features.TopSide
+}
+
+// route returns the layers that primitives rendered from a non-cutout
+// feature belong in, according to its layer hint or, failing that, its
+// purpose and side. Exposed copper gets a matching soldermask opening, and
+// top side openings are added to the paste layer too.
+func (b *Board) route(f features.Feature) []*Layer {
+	switch features.LayerHint(f) {
+	case features.SilkTopLayer:
+		return []*Layer{b.TopSilkscreen}
+	case features.SilkBottomLayer:
+		return []*Layer{b.BottomSilkscreen}
+	case features.MaskTopLayer:
+		return []*Layer{b.TopSoldermask}
+	case features.MaskBottomLayer:
+		return []*Layer{b.BottomSoldermask}
+	case features.CopperTopLayer:
+		return []*Layer{b.TopCopper}
+	case features.CopperBottomLayer:
+		return []*Layer{b.BottomCopper}
+	}
+	silk, copper, mask := b.TopSilkscreen, b.TopCopper, []*Layer{b.TopSoldermask, b.TopPaste}
+	if side(f) == features.BottomSide {
+		silk, copper, mask = b.BottomSilkscreen, b.BottomCopper, []*Layer{b.BottomSoldermask}
+	}
+	switch f.GetPurpose() {
+	case features.MaskOpening:
+		return mask
+	case features.ExposedCopper:
+		return append([]*Layer{copper}, mask...)
+	case features.MaskedCopper:
+		return []*Layer{copper}
+	case features.Annotation:
+		return []*Layer{b.Drawing}
+	}
+	return []*Layer{silk}
+}
+
+// add adds non-cutout primitives to the layers the feature they were
+// rendered from is routed to
+func (b *Board) add(f features.Feature, prims ...gogerber.Primitive) {
+	for _, l := range b.route(f) {
+		l.Add(prims...)
+	}
+}
+
+// Destinations returns the names of the output layers and drill files that
+// a feature would be rendered into, omitting layers that are not written
+func (b *Board) Destinations(f features.Feature) []string {
+	for _, p := range b.pour {
+		if p == f {
+			return []string{b.pourLayer(f).Name}
+		}
+	}
+	var parts []features.Feature
+	switch c := f.(type) {
+	case *features.Dimension:
+		parts = c.Features()
+	case features.Custom:
+		// the expansion approximates where a registered render function
+		// would draw the feature
+		parts = features.ExpandCustom([]features.Feature{c}, func(features.Custom, error) {})
+	}
+	if parts != nil {
+		names := []string{}
+		seen := map[string]bool{}
+		for _, part := range parts {
+			for _, name := range b.Destinations(part) {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+		return names
+	}
+	if cutout(f) {
+		switch c := f.(type) {
+		case *features.Circle:
+			if features.LayerHint(c) == features.OutlineLayer {
+				return []string{b.Outline.Name}
+			}
+			if c.Plated {
+				return []string{"drill-pth"}
+			}
+			return []string{"drill-npth"}
+		case *features.Image:
+			return nil
+		}
+		return []string{b.Outline.Name}
+	}
+	written := map[*Layer]bool{}
+	for _, l := range b.Layers() {
+		written[l] = true
+	}
+	written[b.Drawing] = b.Annotations
+	// the bottom silkscreen is written as soon as anything is routed to it
+	written[b.BottomSilkscreen] = true
+	names := []string{}
+	for _, l := range b.route(f) {
+		if written[l] {
+			names = append(names, l.Name)
+		}
+	}
+	return names
+}
+
+// AddFeatures renders features into the appropriate board layers according
+// to their type, layer hint, purpose and side. Annotation features are only
+// rendered in the drawing layer. Text on the bottom side is mirrored so that
+// it reads correctly from the rear of the panel.
+func (b *Board) AddFeatures(feats []features.Feature) {
+	v := boardVisitor{b}
+	for _, item := range feats {
+		if _, custom := item.(features.Custom); cutout(item) && !custom {
+			b.cutouts = append(b.cutouts, item)
+			if _, ok := item.(*features.Circle); !ok && features.LayerHint(item) == features.DrillLayer {
+				diag.Warnf(b.Diagnostics, item, "only circles can be drilled, rendering in outline layer: %v", item)
+			}
+		}
+		item.Accept(v)
+	}
+}
+
+// boardVisitor renders each type of feature into the layers of a board
+type boardVisitor struct {
+	b *Board
+}
+
+var _ features.Visitor = boardVisitor{}
+
+func (v boardVisitor) VisitLine(f *features.Line) {
+	if cutout(f) {
+		v.b.Outline.Add(mkline(f))
+	} else {
+		v.b.add(f, mkline(f))
+	}
+}
+
+func (v boardVisitor) VisitText(f *features.Text) {
+	if cutout(f) {
+		// text in outline layer is pretty much guaranteed to be a mistake
+		diag.Warnf(v.b.Diagnostics, f, "text feature in outline layer is probably an error: %v", f.String())
+		v.b.Outline.Add(mktext(f))
+	} else {
+		v.b.add(f, mktext(f))
+	}
+}
+
+func (v boardVisitor) VisitPlaceholder(f *features.Placeholder) {
+	diag.Warnf(v.b.Diagnostics, f, "unresolved placeholder, ignoring: %v", f.String())
+}
+
+func (v boardVisitor) VisitCircle(f *features.Circle) {
+	if cutout(f) && features.LayerHint(f) == features.OutlineLayer {
+		v.b.Outline.Add(mkoutline(features.NewPolygon(
+			flatten.Circle(f.Origin, f.Radius, flatten.Or(v.b.Tolerance, flatten.Fine))))...)
+	} else if cutout(f) {
+		// FIXME: fabs have upper limits on drill sizes, eg. 6.3mm for JLCPCB
+		//        at this time of writing --- may need to drop larger ones in
+		//        the outline layer instead. But this will be fab-dependent...
+		v.b.adddrill(f)
+	} else {
+		v.b.add(f, mkcircle(f))
+	}
+}
+
+func (v boardVisitor) VisitPolygon(f *features.Polygon) {
+	if cutout(f) {
+		v.b.Outline.Add(mkoutline(f)...)
+	} else {
+		v.b.add(f, mkpolygon(f))
+	}
+}
+
+func (v boardVisitor) VisitImage(f *features.Image) {
+	if cutout(f) {
+		diag.Warnf(v.b.Diagnostics, f, "image features cannot be cutouts, ignoring: %v", f.String())
+	} else {
+		v.b.add(f, mkimage(f)...)
+	}
+}
+
+func (v boardVisitor) VisitOutline(f *features.Outline) {
+	v.b.Outline.Add(mkcontour(f))
+}
+
+func (v boardVisitor) VisitInversion(f *features.Inversion) {
+	if cutout(f) {
+		diag.Warnf(v.b.Diagnostics, f, "inversion features cannot be cutouts, ignoring: %v", f.String())
+		return
+	}
+	prims, unsupported, err := mkinversion(f, flatten.Or(v.b.Tolerance, flatten.Fine))
+	if err != nil {
+		diag.Warnf(v.b.Diagnostics, f, "cannot render inversion, ignoring: %v", err)
+		return
+	}
+	for _, k := range unsupported {
+		diag.Warnf(v.b.Diagnostics, k, "cannot knock out feature, ignoring: %v", k)
+	}
+	v.b.add(f, prims...)
+}
+
+func (v boardVisitor) VisitDimension(f *features.Dimension) {
+	v.b.AddFeatures(f.Features())
+}
+
+func (v boardVisitor) VisitCustom(f features.Custom) {
+	if fn, ok := customRenderers[f.Kind()]; ok {
+		if err := fn(v.b, f); err != nil {
+			diag.Warnf(v.b.Diagnostics, f, "cannot render %s feature, ignoring: %v", f.Kind(), err)
+		}
+		return
+	}
+	feats, err := f.Expand()
+	if err != nil {
+		diag.Warnf(v.b.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+		return
+	}
+	v.b.AddFeatures(feats)
+}
+
+// filename returns the output filename for a given layer name and extension
+func (b *Board) filename(layer, ext string) string {
+	return output.ExpandTemplate(b.FilenameTemplate, b.Name, layer, ext)
+}
+
+// layerFilename returns the output filename for a Gerber layer
+func (b *Board) layerFilename(l *Layer) string {
+	return b.filename(l.Name, l.Ext)
+}
+
+// drillFile pairs a drill file with its output filename
+type drillFile struct {
+	filename string
+	drill    *excellon.Drill
+}
+
+// drills returns the drill files to be written
+func (b *Board) drills() []drillFile {
+	// mounting holes are always non-plated, so the NPTH file is never empty
+	drills := []drillFile{{filename: b.filename("drill-npth", "drl"), drill: b.NonplatedDrills}}
+	if !b.PlatedDrills.Empty() {
+		drills = append(drills, drillFile{filename: b.filename("drill-pth", "drl"), drill: b.PlatedDrills})
+	}
+	return drills
+}
+
+// Files returns every output file for the board: Gerber layers, drill files
+// and the Gerber job file
+func (b *Board) Files() []output.File {
+	b.applyClearance()
+	for _, layer := range append(b.Layers(), b.Drawing) {
+		layer.Comments = b.Comments
+	}
+	for _, df := range b.drills() {
+		df.drill.Comments = b.Comments
+	}
+	files := []output.File{}
+	for _, layer := range b.Layers() {
+		files = append(files, output.File{Filename: b.layerFilename(layer), Write: layer.WriteGerber})
+	}
+	for _, df := range b.drills() {
+		files = append(files, output.File{Filename: df.filename, Write: df.drill.WriteExcellon})
+	}
+	if b.DrillReport {
+		drills := []*excellon.Drill{}
+		for _, df := range b.drills() {
+			drills = append(drills, df.drill)
+		}
+		files = append(files,
+			output.File{Filename: b.filename("drill-report", "txt"), Write: func(w io.Writer) error {
+				return excellon.WriteReport(w, drills...)
+			}},
+			output.File{Filename: b.filename("drill-table", "csv"), Write: func(w io.Writer) error {
+				return excellon.WriteCSV(w, drills...)
+			}},
+		)
+	}
+	if b.Annotations {
+		files = append(files, output.File{Filename: b.layerFilename(b.Drawing), Write: b.Drawing.WriteGerber})
+	}
+	files = append(files, output.File{Filename: b.filename("job", "gbrjob"), Write: b.WriteJob})
+	return files
+}
+
+// Write writes every output file for the board to a sink, such as a
+// directory, a ZIP archive or memory. The sink is not closed.
+func (b *Board) Write(sink output.Sink) error {
+	return output.WriteFiles(sink, b.Files())
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package gerber
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/format/eurorack"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	panelsource "github.com/jsleeio/frontpanels/pkg/sources/panel"
+)
+
+// TestDrillSplit checks that mounting holes are drilled from the
+// non-plated file with their own tool, and plated holes from a separate
+// plated file
+func TestDrillSplit(t *testing.T) {
+	p := eurorack.NewEurorack(12)
+	board := NewBoard("split", p)
+	board.AddFeatures(panelsource.GeneratePanelOutlineFeatures(p))
+	pth := features.NewCircle(geometry.Point{X: 30, Y: 64}, 0.5)
+	pth.SetPurpose(features.Cutout)
+	pth.SetPlated(true)
+	board.AddFeatures([]features.Feature{pth})
+	files := map[string]string{}
+	for _, f := range board.Files() {
+		var buf bytes.Buffer
+		if err := f.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		files[f.Filename] = buf.String()
+	}
+	npth, ok := files["split.drill-npth.drl"]
+	if !ok {
+		t.Fatal("no non-plated drill file")
+	}
+	if !strings.Contains(npth, "TF.FileFunction,NonPlated,1,2,NPTH") {
+		t.Error("non-plated drill file is not marked as NPTH")
+	}
+	tool := fmt.Sprintf("T1C%.3f\n", p.MountingHoleDiameter())
+	if !strings.Contains(npth, tool) || strings.Contains(npth, "T2C") {
+		t.Errorf("non-plated tool table is not the single mounting hole tool %q:\n%s", tool, npth)
+	}
+	for _, h := range p.MountingHoles() {
+		hit := fmt.Sprintf("X%.4fY%.4f\n", h.X, h.Y)
+		if !strings.Contains(npth, hit) {
+			t.Errorf("mounting hole %s is not in the non-plated drill file", strings.TrimSpace(hit))
+		}
+	}
+	plated, ok := files["split.drill-pth.drl"]
+	if !ok {
+		t.Fatal("no plated drill file")
+	}
+	if !strings.Contains(plated, "TF.FileFunction,Plated,1,2,PTH") || !strings.Contains(plated, "T1C1.000\n") || !strings.Contains(plated, "X30.0000Y64.0000\n") {
+		t.Errorf("plated drill file does not drill the plated hole alone:\n%s", plated)
+	}
+	if strings.Contains(npth, "X30.0000Y64.0000") {
+		t.Error("plated hole is in the non-plated drill file")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package gerber
+
+import "github.com/jsleeio/frontpanels/pkg/features"
+
+// CustomRenderer renders a custom feature into the layers of a board, eg.
+// with Layer.Add, for custom feature kinds whose standard-feature expansion
+// cannot describe them exactly
+type CustomRenderer func(b *Board, f features.Custom) error
+
+// customRenderers are the registered custom feature render functions, by
+// kind
+var customRenderers = map[string]CustomRenderer{}
+
+// RegisterCustomRenderer makes a board render custom features of the given
+// kind with fn, rather than rendering their expansions. It is intended to be
+// called from the init function of the package defining the kind.
+func RegisterCustomRenderer(kind string, fn CustomRenderer) {
+	customRenderers[kind] = fn
+}
+
+// HasCustomRenderer reports whether custom features of the given kind have
+// a registered render function
+func HasCustomRenderer(kind string) bool {
+	_, ok := customRenderers[kind]
+	return ok
+}
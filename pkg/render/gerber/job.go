@@ -0,0 +1,215 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package gerber
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+const (
+	// DefaultBoardThickness is the overall board thickness assumed in the job
+	// file, in millimetres: the default panel thickness
+	DefaultBoardThickness = panel.DefaultThickness
+
+	// copperThickness is 1oz copper, in millimetres
+	copperThickness = 0.035
+
+	// soldermaskThickness is a typical soldermask thickness, in millimetres
+	soldermaskThickness = 0.01
+
+	// metalCoreDielectricThickness is a typical thickness of the thermally
+	// conductive insulation between the copper and base of a metal-core
+	// board, in millimetres
+	metalCoreDielectricThickness = 0.1
+)
+
+// BoardThicknesses are the standard overall board thicknesses offered by
+// most fabs, in millimetres, ascending
+var BoardThicknesses = []float64{0.4, 0.6, 0.8, 1.0, 1.2, 1.6, 2.0}
+
+// ValidateThickness checks that a board thickness is one of the standard
+// thicknesses
+func ValidateThickness(thickness float64) error {
+	values := []string{}
+	for _, t := range BoardThicknesses {
+		if math.Abs(t-thickness) < 1e-6 {
+			return nil
+		}
+		values = append(values, strconv.FormatFloat(t, 'f', 1, 64))
+	}
+	return fmt.Errorf("invalid board thickness %gmm (valid values: %s)", thickness, strings.Join(values, " "))
+}
+
+// job describes the structure of a Gerber job file (.gbrjob) as defined by
+// Ucamco. Only the subset of fields useful for front panels is included.
+type job struct {
+	Header          jobHeader          `json:"Header"`
+	GeneralSpecs    jobGeneralSpecs    `json:"GeneralSpecs"`
+	FilesAttributes []jobFileAttribute `json:"FilesAttributes"`
+	MaterialStackup []jobMaterial      `json:"MaterialStackup"`
+}
+
+type jobHeader struct {
+	GenerationSoftware jobSoftware `json:"GenerationSoftware"`
+	CreationDate       string      `json:"CreationDate"`
+}
+
+type jobSoftware struct {
+	Vendor      string `json:"Vendor"`
+	Application string `json:"Application"`
+}
+
+type jobGeneralSpecs struct {
+	ProjectID      jobProject `json:"ProjectId"`
+	Size           jobSize    `json:"Size"`
+	LayerNumber    int        `json:"LayerNumber"`
+	BoardThickness float64    `json:"BoardThickness"`
+	EdgePlating    bool       `json:"EdgePlating,omitempty"`
+	Castellated    bool       `json:"Castellated,omitempty"`
+}
+
+type jobProject struct {
+	Name     string `json:"Name"`
+	Revision string `json:"Revision,omitempty"`
+}
+
+type jobSize struct {
+	X float64 `json:"X"`
+	Y float64 `json:"Y"`
+}
+
+type jobFileAttribute struct {
+	Path         string `json:"Path"`
+	FileFunction string `json:"FileFunction"`
+	FilePolarity string `json:"FilePolarity"`
+}
+
+type jobMaterial struct {
+	Type      string  `json:"Type"`
+	Name      string  `json:"Name"`
+	Thickness float64 `json:"Thickness,omitempty"`
+	Material  string  `json:"Material,omitempty"`
+}
+
+// stackup describes a plain two-layer FR4 board, top to bottom, with a
+// bottom silkscreen if there are rear markings
+func stackup(thickness float64, bottomLegend bool) []jobMaterial {
+	core := thickness - 2*copperThickness - 2*soldermaskThickness
+	materials := []jobMaterial{
+		{Type: "Legend", Name: "Top Silkscreen"},
+		{Type: "SolderMask", Name: "Top Solder Mask", Thickness: soldermaskThickness},
+		{Type: "Copper", Name: "Top Copper", Thickness: copperThickness},
+		{Type: "Dielectric", Name: "Core", Thickness: core, Material: "FR4"},
+		{Type: "Copper", Name: "Bottom Copper", Thickness: copperThickness},
+		{Type: "SolderMask", Name: "Bottom Solder Mask", Thickness: soldermaskThickness},
+	}
+	if bottomLegend {
+		materials = append(materials, jobMaterial{Type: "Legend", Name: "Bottom Silkscreen"})
+	}
+	return materials
+}
+
+// metalCoreStackup describes a single-layer aluminium board, top to bottom
+func metalCoreStackup(thickness float64) []jobMaterial {
+	base := thickness - copperThickness - soldermaskThickness - metalCoreDielectricThickness
+	return []jobMaterial{
+		{Type: "Legend", Name: "Top Silkscreen"},
+		{Type: "SolderMask", Name: "Top Solder Mask", Thickness: soldermaskThickness},
+		{Type: "Copper", Name: "Top Copper", Thickness: copperThickness},
+		{Type: "Dielectric", Name: "Insulation", Thickness: metalCoreDielectricThickness, Material: "Thermally conductive dielectric"},
+		{Type: "Dielectric", Name: "Base", Thickness: base, Material: "Aluminium"},
+	}
+}
+
+// thickness returns the overall board thickness
+func (b *Board) thickness() float64 {
+	if b.Thickness > 0 {
+		return b.Thickness
+	}
+	return DefaultBoardThickness
+}
+
+// jobRelativePath returns the path of an output file relative to the job
+// file, as filename templates may place files in different directories
+func (b *Board) jobRelativePath(filename string) string {
+	rel, err := filepath.Rel(filepath.Dir(b.filename("job", "gbrjob")), filename)
+	if err != nil {
+		return filename
+	}
+	return filepath.ToSlash(rel)
+}
+
+// WriteJob writes a Gerber job file describing the board dimensions, layer
+// stackup and the function of each Gerber file, so that fabricators can
+// classify uploaded files without manual mapping
+func (b *Board) WriteJob(w io.Writer) error {
+	created := b.CreationDate
+	if created.IsZero() {
+		created = time.Now()
+	}
+	thickness := b.thickness()
+	j := job{
+		Header: jobHeader{
+			GenerationSoftware: jobSoftware{Vendor: "jsleeio", Application: "frontpanels"},
+			CreationDate:       created.UTC().Format(time.RFC3339),
+		},
+		GeneralSpecs: jobGeneralSpecs{
+			ProjectID:      jobProject{Name: b.Name, Revision: b.Revision},
+			Size:           jobSize{X: b.Width, Y: b.Height},
+			LayerNumber:    2,
+			BoardThickness: thickness,
+			EdgePlating:    b.EdgePlating,
+			Castellated:    b.Castellated,
+		},
+		FilesAttributes: []jobFileAttribute{},
+		MaterialStackup: stackup(thickness, !b.BottomSilkscreen.Empty()),
+	}
+	if b.MetalCore {
+		j.GeneralSpecs.LayerNumber = 1
+		j.MaterialStackup = metalCoreStackup(thickness)
+	}
+	for _, layer := range b.Layers() {
+		j.FilesAttributes = append(j.FilesAttributes, jobFileAttribute{
+			Path:         b.jobRelativePath(b.layerFilename(layer)),
+			FileFunction: layer.FileFunction,
+			FilePolarity: layer.FilePolarity,
+		})
+	}
+	for _, df := range b.drills() {
+		j.FilesAttributes = append(j.FilesAttributes, jobFileAttribute{
+			Path:         b.jobRelativePath(df.filename),
+			FileFunction: df.drill.FileFunction(),
+			FilePolarity: "Positive",
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j)
+}
@@ -0,0 +1,231 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package gerber renders panel features as Gerber X2 layers and Excellon
+// drill files, plus a Gerber job file describing the whole board. The
+// primitives themselves come from github.com/gmlewis/go-gerber, but layers
+// are written here so that X2 file attributes can be included.
+package gerber
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	gogerber "github.com/gmlewis/go-gerber/gerber"
+)
+
+const (
+	// GenerationSoftware is included in the X2 attributes of every file
+	GenerationSoftware = "jsleeio,frontpanels"
+
+	// defaultAperture is defined in every layer header and used by polygon
+	// and text primitives
+	defaultAperture = 11
+
+	// firstAperture is the first aperture number available for primitives
+	firstAperture = 12
+)
+
+// Layer describes a single Gerber file and collects its primitives
+type Layer struct {
+	// Name is a short human-readable name for the layer, eg. "outline"
+	Name string
+	// Ext is the traditional (Protel-style) filename extension for the layer
+	Ext string
+	// FileFunction is the X2 .FileFunction attribute value, eg. "Legend,Top"
+	FileFunction string
+	// FilePolarity is the X2 .FilePolarity attribute value, eg. "Positive"
+	FilePolarity string
+	// Comments are written as G04 comments at the end of the header
+	Comments []string
+
+	// pour primitives are written first, followed by clearance primitives in
+	// clear polarity, so that clearances only affect the pour
+	pour, clearance []gogerber.Primitive
+
+	primitives []gogerber.Primitive
+	// apertures are keyed by ID, and numbered in ID order when written so
+	// that numbering does not depend on the order primitives were added
+	apertures map[string]*gogerber.Aperture
+}
+
+// NewLayer constructs a new, empty Layer
+func NewLayer(name, ext, function, polarity string) *Layer {
+	return &Layer{
+		Name:         name,
+		Ext:          ext,
+		FileFunction: function,
+		FilePolarity: polarity,
+		pour:         []gogerber.Primitive{},
+		clearance:    []gogerber.Primitive{},
+		primitives:   []gogerber.Primitive{},
+		apertures:    map[string]*gogerber.Aperture{},
+	}
+}
+
+// defineApertures defines new apertures for primitives as necessary
+func (l *Layer) defineApertures(primitives []gogerber.Primitive) {
+	for _, p := range primitives {
+		a := p.Aperture()
+		if a == nil {
+			continue // uses the default aperture
+		}
+		l.apertures[a.ID()] = a
+	}
+}
+
+// Add adds primitives to a layer
+func (l *Layer) Add(primitives ...gogerber.Primitive) {
+	l.defineApertures(primitives)
+	l.primitives = append(l.primitives, primitives...)
+}
+
+// AddPour adds copper pour primitives to a layer. These are written before
+// all other primitives, and are the only primitives affected by clearances.
+func (l *Layer) AddPour(primitives ...gogerber.Primitive) {
+	l.defineApertures(primitives)
+	l.pour = append(l.pour, primitives...)
+}
+
+// SetClearance replaces the set of clearance primitives for a layer. These
+// are subtracted from the pour.
+func (l *Layer) SetClearance(primitives ...gogerber.Primitive) {
+	l.defineApertures(primitives)
+	l.clearance = primitives
+}
+
+// Empty indicates whether any primitives have been added to the layer
+func (l *Layer) Empty() bool {
+	return len(l.primitives) == 0 && len(l.pour) == 0
+}
+
+// apertureNumbers returns the aperture IDs of the layer in the order they
+// are defined, and the number of each
+func (l *Layer) apertureNumbers() ([]string, map[string]int) {
+	ids := []string{}
+	for id := range l.apertures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	numbers := map[string]int{}
+	for i, id := range ids {
+		numbers[id] = firstAperture + i
+	}
+	return ids, numbers
+}
+
+// writePrimitives writes primitives using the given aperture numbers.
+//
+// So that output is byte-identical however features were ordered, each run
+// of primitives is written sorted by its Gerber text. Primitives that switch
+// polarity, such as text with counters, erase whatever was drawn before
+// them or change how what follows is drawn, so they keep their position and
+// end each run.
+func writePrimitives(w io.Writer, primitives []gogerber.Primitive, numbers map[string]int) error {
+	run := []string{}
+	flush := func() error {
+		sort.Strings(run)
+		for _, text := range run {
+			if _, err := io.WriteString(w, text); err != nil {
+				return err
+			}
+		}
+		run = run[:0]
+		return nil
+	}
+	for _, p := range primitives {
+		index := defaultAperture
+		if a := p.Aperture(); a != nil {
+			index = numbers[a.ID()]
+		}
+		var buf bytes.Buffer
+		if err := p.WriteGerber(&buf, index); err != nil {
+			return err
+		}
+		if strings.Contains(buf.String(), "%LP") {
+			if err := flush(); err != nil {
+				return err
+			}
+			if _, err := buf.WriteTo(w); err != nil {
+				return err
+			}
+			continue
+		}
+		run = append(run, buf.String())
+	}
+	return flush()
+}
+
+// commentText makes a string safe for use in a G04 comment, which may not
+// contain the Gerber delimiters '*' and '%'
+func commentText(s string) string {
+	return strings.NewReplacer("*", " ", "%", " ", "\n", " ").Replace(s)
+}
+
+// WriteGerber writes the layer in Gerber X2 format
+func (l *Layer) WriteGerber(w io.Writer) error {
+	header := []string{
+		"%TF.GenerationSoftware," + GenerationSoftware + "*%",
+		"%TF.Part,Single*%",
+		"%TF.SameCoordinates,Original*%",
+		"%TF.FileFunction," + l.FileFunction + "*%",
+		"%TF.FilePolarity," + l.FilePolarity + "*%",
+		"%FSLAX36Y36*%",
+		"%MOMM*%",
+		"%LPD*%",
+		fmt.Sprintf("%%ADD%dC,0.00100*%%", defaultAperture),
+	}
+	for _, c := range l.Comments {
+		header = append(header, "G04 "+commentText(c)+"*")
+	}
+	for _, line := range header {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	ids, numbers := l.apertureNumbers()
+	for _, id := range ids {
+		if err := l.apertures[id].WriteGerber(w, numbers[id]); err != nil {
+			return err
+		}
+	}
+	if err := writePrimitives(w, l.pour, numbers); err != nil {
+		return err
+	}
+	if len(l.pour) > 0 && len(l.clearance) > 0 {
+		if _, err := io.WriteString(w, "%LPC*%\n"); err != nil {
+			return err
+		}
+		if err := writePrimitives(w, l.clearance, numbers); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "%LPD*%\n"); err != nil {
+			return err
+		}
+	}
+	if err := writePrimitives(w, l.primitives, numbers); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "M02*\n")
+	return err
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/render/excellon"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+
+	gogerber "github.com/gmlewis/go-gerber/gerber"
+)
+
+const (
+	// FontName is the default font for text features
+	FontName = textpath.FontName
+
+	// outlineThickness is the line thickness used for board outlines
+	outlineThickness = 0.1
+)
+
+// mkline renders a line feature as a gerber primitive
+func mkline(l *features.Line) gogerber.Primitive {
+	return gogerber.Line(
+		l.Start.X, l.Start.Y,
+		l.End.X, l.End.Y,
+		gogerber.CircleShape, // gerber aperture stuff, probably leave it as-is
+		l.Thickness,
+	)
+}
+
+// mkcircle renders a circle feature as a gerber primitive
+func mkcircle(c *features.Circle) gogerber.Primitive {
+	return gogerber.Circle(gogerber.Point(c.Origin.X, c.Origin.Y), c.Radius*2.0)
+}
+
+// mkpolygon renders a polygon feature as a filled gerber region. go-gerber
+// ends a region with a move rather than a draw back to its first point, so
+// the contour is closed here.
+func mkpolygon(p *features.Polygon) gogerber.Primitive {
+	pts := []gogerber.Pt{}
+	for _, pt := range p.Points {
+		pts = append(pts, gogerber.Point(pt.X, pt.Y))
+	}
+	if n := len(p.Points); n > 0 && p.Points[n-1] != p.Points[0] {
+		pts = append(pts, gogerber.Point(p.Points[0].X, p.Points[0].Y))
+	}
+	return gogerber.Polygon(gogerber.Point(0, 0), true, pts, 0.0)
+}
+
+// mkimage renders an image feature as a set of filled gerber regions, one
+// for each horizontal run of ink
+func mkimage(i *features.Image) []gogerber.Primitive {
+	prims := []gogerber.Primitive{}
+	for _, run := range i.Runs() {
+		prims = append(prims, mkpolygon(features.NewRectangle(run[0], run[1])))
+	}
+	return prims
+}
+
+// mkoutline renders a polygon feature as its outline, for use in the board
+// outline layer
+func mkoutline(p *features.Polygon) []gogerber.Primitive {
+	prims := []gogerber.Primitive{}
+	for _, e := range p.Edges() {
+		prims = append(prims, gogerber.Line(e[0].X, e[0].Y, e[1].X, e[1].Y, gogerber.CircleShape, outlineThickness))
+	}
+	return prims
+}
+
+// mkcontour renders an outline feature as a single closed path, for use in
+// the board outline layer
+func mkcontour(o *features.Outline) gogerber.Primitive {
+	return &contour{points: o.Points, thickness: outlineThickness}
+}
+
+// mkclearance renders a cutout feature, expanded by a margin on all sides,
+// as gerber primitives suitable for clearing copper around it. Text is not
+// supported, as cutout text is almost certainly a mistake anyway.
+func mkclearance(f features.Feature, margin float64) []gogerber.Primitive {
+	switch c := f.(type) {
+	case *features.Circle:
+		return []gogerber.Primitive{
+			gogerber.Circle(gogerber.Point(c.Origin.X, c.Origin.Y), (c.Radius+margin)*2.0),
+		}
+	case *features.Line:
+		return []gogerber.Primitive{
+			gogerber.Line(c.Start.X, c.Start.Y, c.End.X, c.End.Y, gogerber.CircleShape, c.Thickness+margin*2.0),
+		}
+	case *features.Outline:
+		prims := []gogerber.Primitive{}
+		for _, e := range c.Edges() {
+			prims = append(prims, gogerber.Line(e[0].X, e[0].Y, e[1].X, e[1].Y, gogerber.CircleShape, outlineThickness+margin*2.0))
+		}
+		return prims
+	case *features.Polygon:
+		prims := []gogerber.Primitive{mkpolygon(c)}
+		if margin > 0 {
+			for _, e := range c.Edges() {
+				prims = append(prims, gogerber.Line(e[0].X, e[0].Y, e[1].X, e[1].Y, gogerber.CircleShape, margin*2.0))
+			}
+		}
+		return prims
+	}
+	return nil
+}
+
+// mkhole renders a circle feature as an Excellon drill hit
+func mkhole(c *features.Circle) excellon.Hole {
+	return excellon.Hole{X: c.Origin.X, Y: c.Origin.Y, Diameter: c.Radius * 2.0}
+}
+
+// mktext renders a text feature as a gerber primitive, mirrored on the
+// bottom side
+func mktext(t *features.Text) gogerber.Primitive {
+	xScale := 1.0
+	if side(t) == features.BottomSide {
+		xScale = -1.0
+	}
+	return gogerber.Text(
+		t.Origin.X, t.Origin.Y,
+		xScale,
+		t.Text,
+		textpath.Font(t),
+		geometry.MMToPoints(textpath.EmSize(t)),
+		textpath.Options(t),
+	)
+}
+
+// contour is a closed path drawn with a circular aperture, written as a
+// single connected sequence of draws so that CAM tools see one closed
+// outline rather than independent segments
+type contour struct {
+	points    []geometry.Point
+	thickness float64
+}
+
+// coordinate formats a point in the layer's 3.6 coordinate format
+func coordinate(p geometry.Point) string {
+	return fmt.Sprintf("X%06dY%06d", int(math.Round(p.X*1e6)), int(math.Round(p.Y*1e6)))
+}
+
+// WriteGerber writes the contour to the Gerber file
+func (c *contour) WriteGerber(w io.Writer, apertureIndex int) error {
+	lines := []string{
+		fmt.Sprintf("G54D%d*", apertureIndex),
+		coordinate(c.points[0]) + "D02*",
+	}
+	for _, p := range c.points[1:] {
+		lines = append(lines, coordinate(p)+"D01*")
+	}
+	lines = append(lines, coordinate(c.points[0])+"D01*")
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Aperture returns the circular aperture the contour is drawn with
+func (c *contour) Aperture() *gogerber.Aperture {
+	return &gogerber.Aperture{Shape: gogerber.CircleShape, Size: c.thickness}
+}
+
+// MBB returns the bounding box of the contour, in millimetres
+func (c *contour) MBB() gogerber.MBB {
+	mbb := gogerber.MBB{Min: gogerber.Point(c.points[0].X, c.points[0].Y), Max: gogerber.Point(c.points[0].X, c.points[0].Y)}
+	for _, p := range c.points[1:] {
+		mbb.Join(&gogerber.MBB{Min: gogerber.Point(p.X, p.Y), Max: gogerber.Point(p.X, p.Y)})
+	}
+	return mbb
+}
+
+// mkinversion renders an inversion feature as filled gerber regions
+// covering its region less its knockouts, returning any knockouts that
+// could not be rendered. Holes are joined to their outer contours by cut-in
+// channels rather than drawn in clear polarity, so that the knockouts clear
+// only the inversion and not anything drawn on the layer before it.
+func mkinversion(i *features.Inversion, tolerance float64) ([]gogerber.Primitive, []features.Feature, error) {
+	knockouts := [][][]geometry.Point{}
+	unsupported := []features.Feature{}
+	for _, item := range i.Knockouts {
+		var contours [][]geometry.Point
+		var err error
+		if t, ok := item.(*features.Text); ok {
+			// text is mirrored on the bottom side, as mktext does
+			var paths []textpath.Contour
+			if paths, err = textpath.Contours(t, i.Side == features.BottomSide); err == nil {
+				for _, c := range paths {
+					contours = append(contours, c.Points)
+				}
+			}
+		} else {
+			contours, err = flatten.Contours(item, tolerance)
+		}
+		if err != nil {
+			unsupported = append(unsupported, item)
+			continue
+		}
+		knockouts = append(knockouts, contours)
+	}
+	prims := []gogerber.Primitive{}
+	region := geometry.Boolean(geometry.Difference, [][]geometry.Point{i.Region}, geometry.Merge(knockouts...))
+	for _, island := range geometry.Islands(region) {
+		points, err := geometry.Bridge(island[0], island[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		prims = append(prims, mkpolygon(features.NewPolygon(points)))
+	}
+	return prims, unsupported, nil
+}
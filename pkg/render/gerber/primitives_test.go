@@ -0,0 +1,60 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// TestPolygonRegionClosed checks that a polygon's region draws back to its
+// first point before the region ends, whether or not the polygon repeats
+// that point itself
+func TestPolygonRegionClosed(t *testing.T) {
+	rect := features.NewRectangle(geometry.Point{X: 0, Y: 0}, geometry.Point{X: 10, Y: 5})
+	closed := features.NewPolygon(append(append([]geometry.Point{}, rect.Points...), rect.Points[0]))
+	for _, p := range []*features.Polygon{rect, closed} {
+		var buf bytes.Buffer
+		if err := mkpolygon(p).WriteGerber(&buf, 10); err != nil {
+			t.Fatal(err)
+		}
+		start, last, draws := "", "", 0
+		for _, line := range strings.Split(buf.String(), "\n") {
+			switch {
+			case start == "" && strings.HasSuffix(line, "D02*"):
+				start = strings.TrimSuffix(line, "D02*")
+			case strings.HasSuffix(line, "D01*"):
+				last = strings.TrimSuffix(line, "D01*")
+				draws++
+			}
+		}
+		if last != start {
+			t.Errorf("region of %d points ends its draws at %s, want its start %s", len(p.Points), last, start)
+		}
+		if draws != 4 {
+			t.Errorf("region of %d points has %d draws, want 4", len(p.Points), draws)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package hpgl writes the Marking features of panels as HPGL, so that vinyl
+// cutters and pen plotters can be used to produce panel overlays and
+// stencils.
+package hpgl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/plate"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// unitsPerMM is the HPGL plotter unit resolution: 0.025mm per unit
+const unitsPerMM = 40.0
+
+// Options configures plotting
+type Options struct {
+	// Pen is the pen (or cutting tool) number to select
+	Pen int
+	// Strokes draws lines along their centres, for pen plotters. Otherwise
+	// their outlines are cut, for vinyl cutters.
+	Strokes bool
+	// Outline includes the panel outline and cutouts, for trimming the
+	// overlay and aligning it with the panel
+	Outline bool
+	// Mirror flips the plot horizontally, for overlays applied from behind
+	// transparent material
+	Mirror bool
+	// Tolerance is the maximum deviation of flattened curves
+	Tolerance float64
+}
+
+// DefaultOptions returns options for cutting an overlay with pen 1
+func DefaultOptions() Options {
+	return Options{Pen: 1, Tolerance: geometry.DefaultTolerance}
+}
+
+// Plot collects the paths to be plotted or cut
+type Plot struct {
+	Options
+	// Diagnostics receives warnings about features that cannot be plotted
+	Diagnostics diag.Reporter
+
+	width float64
+	plate *plate.Plate
+	paths [][]geometry.Point
+}
+
+// NewPlot constructs a new, empty Plot for a panel
+func NewPlot(p panel.Panel, opts Options) *Plot {
+	return &Plot{
+		Options:     opts,
+		Diagnostics: diag.Logger{},
+		width:       p.Width(),
+		plate:       plate.New(p),
+	}
+}
+
+// closed returns a contour with its first point repeated at the end
+func closed(contour []geometry.Point) []geometry.Point {
+	return append(append([]geometry.Point{}, contour...), contour[0])
+}
+
+// stroke returns the path for a line: its centre line if plotting strokes,
+// otherwise its outline
+func (pl *Plot) stroke(l *features.Line) []geometry.Point {
+	if pl.Strokes || l.Thickness <= 0 {
+		return []geometry.Point{l.Start, l.End}
+	}
+	return closed(flatten.Stroke(l.Start, l.End, l.Thickness/2, pl.Tolerance))
+}
+
+// AddFeatures adds the Marking features on the top side of the panel to the
+// plot, along with the cutouts if Outline is set
+func (pl *Plot) AddFeatures(feats []features.Feature) {
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(pl.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	pl.plate.AddFeatures(feats, diag.Discard)
+	for _, item := range feats {
+		if item.GetPurpose() != features.Marking {
+			continue
+		}
+		if s, ok := item.(features.Sided); ok && s.GetSide() == features.BottomSide {
+			continue
+		}
+		switch f := item.(type) {
+		case *features.Line:
+			pl.paths = append(pl.paths, pl.stroke(f))
+		case *features.Circle:
+			pl.paths = append(pl.paths, flatten.Circle(f.Origin, f.Radius, pl.Tolerance))
+		case *features.Polygon:
+			pl.paths = append(pl.paths, closed(f.Points))
+		case *features.Text:
+			contours, err := textpath.Contours(f, false)
+			if err != nil {
+				diag.Warnf(pl.Diagnostics, f, "cannot plot text, ignoring: %v", err)
+				continue
+			}
+			for _, c := range contours {
+				pl.paths = append(pl.paths, closed(c.Points))
+			}
+		case *features.Image:
+			for _, run := range f.Runs() {
+				pl.paths = append(pl.paths, closed(features.NewRectangle(run[0], run[1]).Points))
+			}
+		default:
+			diag.Warnf(pl.Diagnostics, item, "cannot plot feature, ignoring: %v", item)
+		}
+	}
+}
+
+// xy converts a panel point to plotter units
+func (pl *Plot) xy(p geometry.Point) string {
+	if pl.Mirror {
+		p.X = pl.width - p.X
+	}
+	return fmt.Sprintf("%d,%d", int(math.Round(p.X*unitsPerMM)), int(math.Round(p.Y*unitsPerMM)))
+}
+
+// WriteHPGL writes the plot as HPGL
+func (pl *Plot) WriteHPGL(w io.Writer) error {
+	var b strings.Builder
+	paths := pl.paths
+	if pl.Outline {
+		paths = append(paths, closed(pl.plate.Outline(pl.Tolerance)))
+		for _, c := range pl.plate.HoleContours(pl.Tolerance) {
+			paths = append(paths, closed(c))
+		}
+	}
+	fmt.Fprintf(&b, "IN;SP%d;\n", pl.Pen)
+	for _, path := range paths {
+		fmt.Fprintf(&b, "PU%s;", pl.xy(path[0]))
+		points := []string{}
+		for _, p := range path[1:] {
+			points = append(points, pl.xy(p))
+		}
+		fmt.Fprintf(&b, "PD%s;\n", strings.Join(points, ","))
+	}
+	fmt.Fprintln(&b, "PU;SP0;")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,300 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package kicad writes panels as KiCad footprints (.kicad_mod), so that
+// panels can be finished in KiCad starting from a generated base rather
+// than from uneditable Gerbers. The footprint origin is the top-left corner
+// of the panel.
+package kicad
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+const (
+	// annularRing is the copper ring width around plated holes
+	annularRing = 0.25
+
+	// outlineThickness is the line width used for Edge.Cuts graphics
+	outlineThickness = 0.1
+)
+
+// Footprint collects panel features as KiCad footprint items
+type Footprint struct {
+	Name string
+	// Diagnostics receives warnings about features that cannot be exported
+	Diagnostics diag.Reporter
+
+	height float64
+	items  []string
+}
+
+// NewFootprint constructs a new, empty Footprint for a panel
+func NewFootprint(name string, p panel.Panel) *Footprint {
+	return &Footprint{
+		Name:        name,
+		Diagnostics: diag.Logger{},
+		height:      panel.TopY(p),
+	}
+}
+
+// xy converts a panel point to KiCad coordinates, in which Y increases
+// downwards from the top of the panel
+func (fp *Footprint) xy(p geometry.Point) string {
+	return geometry.FormatNumber(p.X, 6) + " " + geometry.FormatNumber(fp.height-p.Y, 6)
+}
+
+// layers returns the KiCad layers for a feature's purpose and side
+func layers(f features.Feature) []string {
+	prefix := "F."
+	if s, ok := f.(features.Sided); ok && s.GetSide() == features.BottomSide {
+		prefix = "B."
+	}
+	switch f.GetPurpose() {
+	case features.Cutout:
+		return []string{"Edge.Cuts"}
+	case features.MaskOpening:
+		return []string{prefix + "Mask"}
+	case features.ExposedCopper:
+		return []string{prefix + "Cu", prefix + "Mask"}
+	case features.MaskedCopper:
+		return []string{prefix + "Cu"}
+	case features.Annotation:
+		return []string{"Dwgs.User"}
+	}
+	return []string{prefix + "SilkS"}
+}
+
+func (fp *Footprint) add(format string, args ...interface{}) {
+	fp.items = append(fp.items, fmt.Sprintf(format, args...))
+}
+
+// AddItem adds a footprint item, as a KiCad s-expression, for custom
+// feature render functions. Use Coordinates to convert panel points.
+func (fp *Footprint) AddItem(item string) {
+	fp.items = append(fp.items, item)
+}
+
+// Coordinates formats a panel point as footprint coordinates, eg. for the
+// at, start and end fields of items given to AddItem
+func (fp *Footprint) Coordinates(p geometry.Point) string {
+	return fp.xy(p)
+}
+
+// CustomRenderer renders a custom feature into a footprint with AddItem,
+// for custom feature kinds whose standard-feature expansion cannot describe
+// them well
+type CustomRenderer func(fp *Footprint, f features.Custom) error
+
+// customRenderers are the registered custom feature render functions, by
+// kind
+var customRenderers = map[string]CustomRenderer{}
+
+// RegisterCustomRenderer makes footprints render custom features of the
+// given kind with fn, rather than rendering their expansions. It is intended
+// to be called from the init function of the package defining the kind.
+func RegisterCustomRenderer(kind string, fn CustomRenderer) {
+	customRenderers[kind] = fn
+}
+
+// HasCustomRenderer reports whether custom features of the given kind have
+// a registered render function
+func HasCustomRenderer(kind string) bool {
+	_, ok := customRenderers[kind]
+	return ok
+}
+
+func (fp *Footprint) line(a, b geometry.Point, width float64, layer string) {
+	fp.add("(fp_line (start %s) (end %s) (layer %q) (width %s))", fp.xy(a), fp.xy(b), layer, geometry.FormatNumber(width, 6))
+}
+
+func (fp *Footprint) poly(points []geometry.Point, width float64, fill bool, layer string) {
+	pts := []string{}
+	for _, p := range points {
+		pts = append(pts, "(xy "+fp.xy(p)+")")
+	}
+	style := "none"
+	if fill {
+		style = "solid"
+	}
+	fp.add("(fp_poly (pts %s) (layer %q) (width %s) (fill %s))", strings.Join(pts, " "), layer, geometry.FormatNumber(width, 6), style)
+}
+
+// justify returns the KiCad justification for a text alignment, mirrored
+// for the bottom side
+func justify(a features.Alignment, mirror bool) string {
+	j := []string{}
+	switch a {
+	case features.TopLeft, features.CentreLeft, features.BottomLeft:
+		j = append(j, "left")
+	case features.TopRight, features.CentreRight, features.BottomRight:
+		j = append(j, "right")
+	}
+	switch a {
+	case features.TopLeft, features.TopCentre, features.TopRight:
+		j = append(j, "top")
+	case features.BottomLeft, features.BottomCentre, features.BottomRight:
+		j = append(j, "bottom")
+	}
+	if mirror {
+		j = append(j, "mirror")
+	}
+	if len(j) == 0 {
+		return ""
+	}
+	return " (justify " + strings.Join(j, " ") + ")"
+}
+
+func (fp *Footprint) text(t *features.Text, layer string) {
+	// KiCad text size is the height of capital letters
+	size := textpath.CapHeight(t)
+	fp.add("(fp_text user %s (at %s %s) (layer %q) (effects (font (size %s %s) (thickness %s))%s))",
+		strconv.Quote(t.Text), fp.xy(t.Origin), geometry.FormatNumber(t.Rotate*180.0/math.Pi, 6), layer,
+		geometry.FormatNumber(size, 6), geometry.FormatNumber(size, 6), geometry.FormatNumber(size/8, 6), justify(t.Alignment, strings.HasPrefix(layer, "B.")))
+}
+
+// AddFeatures converts features into footprint items on the layers matching
+// their purpose and side. Cutout circles become non-plated (or, if plated,
+// plated) through-hole pads.
+func (fp *Footprint) AddFeatures(feats []features.Feature) {
+	features.Walk(feats, footprintVisitor{fp})
+}
+
+// footprintVisitor converts each type of feature into footprint items
+type footprintVisitor struct {
+	fp *Footprint
+}
+
+var _ features.Visitor = footprintVisitor{}
+
+func (v footprintVisitor) VisitLine(f *features.Line) {
+	for _, l := range layers(f) {
+		v.fp.line(f.Start, f.End, f.Thickness, l)
+	}
+}
+
+func (v footprintVisitor) VisitCircle(f *features.Circle) {
+	fp := v.fp
+	if f.GetPurpose() == features.Cutout {
+		d := geometry.FormatNumber(f.Radius*2, 6)
+		if f.Plated {
+			size := geometry.FormatNumber(f.Radius*2+annularRing*2, 6)
+			fp.add("(pad \"\" thru_hole circle (at %s) (size %s %s) (drill %s) (layers \"*.Cu\" \"*.Mask\"))", fp.xy(f.Origin), size, size, d)
+		} else {
+			fp.add("(pad \"\" np_thru_hole circle (at %s) (size %s %s) (drill %s) (layers \"*.Cu\" \"*.Mask\"))", fp.xy(f.Origin), d, d, d)
+		}
+		return
+	}
+	for _, l := range layers(f) {
+		fp.add("(fp_circle (center %s) (end %s) (layer %q) (width 0) (fill solid))",
+			fp.xy(f.Origin), fp.xy(f.Origin.Add(geometry.Point{X: f.Radius})), l)
+	}
+}
+
+func (v footprintVisitor) VisitPolygon(f *features.Polygon) {
+	cutout := f.GetPurpose() == features.Cutout
+	width := 0.0
+	if cutout {
+		width = outlineThickness
+	}
+	for _, l := range layers(f) {
+		v.fp.poly(f.Points, width, !cutout, l)
+	}
+}
+
+func (v footprintVisitor) VisitOutline(f *features.Outline) {
+	v.fp.poly(f.Points, outlineThickness, false, "Edge.Cuts")
+}
+
+func (v footprintVisitor) VisitText(f *features.Text) {
+	if f.GetPurpose() == features.Cutout {
+		diag.Warnf(v.fp.Diagnostics, f, "text cannot be a cutout, ignoring: %v", f.String())
+		return
+	}
+	for _, l := range layers(f) {
+		v.fp.text(f, l)
+	}
+}
+
+func (v footprintVisitor) VisitPlaceholder(f *features.Placeholder) {
+	diag.Warnf(v.fp.Diagnostics, f, "unresolved placeholder, ignoring: %v", f.String())
+}
+
+func (v footprintVisitor) VisitImage(f *features.Image) {
+	if f.GetPurpose() == features.Cutout {
+		diag.Warnf(v.fp.Diagnostics, f, "image features cannot be cutouts, ignoring: %v", f.String())
+		return
+	}
+	ls := layers(f)
+	for _, run := range f.Runs() {
+		for _, l := range ls {
+			v.fp.poly(features.NewRectangle(run[0], run[1]).Points, 0, true, l)
+		}
+	}
+}
+
+func (v footprintVisitor) VisitDimension(f *features.Dimension) {
+	v.fp.AddFeatures(f.Features())
+}
+
+func (v footprintVisitor) VisitCustom(f features.Custom) {
+	if fn, ok := customRenderers[f.Kind()]; ok {
+		if err := fn(v.fp, f); err != nil {
+			diag.Warnf(v.fp.Diagnostics, f, "cannot render %s feature, ignoring: %v", f.Kind(), err)
+		}
+		return
+	}
+	feats, err := f.Expand()
+	if err != nil {
+		diag.Warnf(v.fp.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+		return
+	}
+	v.fp.AddFeatures(feats)
+}
+
+func (v footprintVisitor) VisitInversion(f *features.Inversion) {
+	diag.Warnf(v.fp.Diagnostics, f, "inversion features are not supported in footprints, ignoring: %v", f.String())
+}
+
+// WriteKicadMod writes the footprint in KiCad 6 .kicad_mod format
+func (fp *Footprint) WriteKicadMod(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "(footprint %s (version 20211014) (generator frontpanels)\n", strconv.Quote(fp.Name))
+	fmt.Fprintln(&b, "  (layer \"F.Cu\")")
+	fmt.Fprintln(&b, "  (attr board_only exclude_from_pos_files exclude_from_bom)")
+	fmt.Fprintln(&b, "  (fp_text reference \"REF**\" (at 0 -2) (layer \"F.SilkS\") hide (effects (font (size 1 1) (thickness 0.15))))")
+	fmt.Fprintf(&b, "  (fp_text value %s (at 0 -4) (layer \"F.Fab\") hide (effects (font (size 1 1) (thickness 0.15))))\n", strconv.Quote(fp.Name))
+	for _, item := range fp.items {
+		fmt.Fprintf(&b, "  %s\n", item)
+	}
+	fmt.Fprintln(&b, ")")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package laser writes SVG jobs for cutting panels from sheet materials such
+// as acrylic with a laser cutter. Marking features on the front of the panel
+// are filled on an engrave layer, and the holes, cutouts and panel outline
+// are traced as hairlines on a cut layer. There is nothing to drill with, so
+// holes are cut as circles. Cuts are compensated for the kerf of the beam so
+// that parts come out to size.
+//
+// Following the usual convention of laser cutter software, the engrave
+// layer is black and the cut layer red, and cut last so that the panel is
+// not freed from the sheet before it is engraved.
+package laser
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/plate"
+)
+
+// DefaultKerf is the default width of material removed by the beam, in
+// millimetres, typical of CO2 lasers cutting 3mm acrylic
+const DefaultKerf = 0.2
+
+// hairline is the stroke width of cut paths, in millimetres. Laser cutter
+// software treats strokes this thin as vector cuts.
+const hairline = 0.01
+
+// Options configures the laser job. Distances are in millimetres.
+type Options struct {
+	// Kerf is the width of material removed by the beam. Holes and cutouts
+	// are shrunk, and the outline grown, by half of it.
+	Kerf float64
+	// Tolerance is the maximum deviation of flattened curves
+	Tolerance float64
+}
+
+// DefaultOptions returns options for cutting with the default kerf
+func DefaultOptions() Options {
+	return Options{Kerf: DefaultKerf, Tolerance: geometry.DefaultTolerance}
+}
+
+// Job collects the engraving and cutting paths for a panel
+type Job struct {
+	Options
+	// Diagnostics receives warnings about features that cannot be engraved
+	// or cut
+	Diagnostics diag.Reporter
+
+	plate   *plate.Plate
+	engrave [][][]geometry.Point
+}
+
+// NewJob constructs a new Job for cutting a panel
+func NewJob(p panel.Panel, opts Options) *Job {
+	return &Job{
+		Options:     opts,
+		Diagnostics: diag.Logger{},
+		plate:       plate.New(p),
+	}
+}
+
+// AddFeatures adds the features to the job. Marking features on the top
+// side are engraved; cutouts are cut.
+func (j *Job) AddFeatures(feats []features.Feature) {
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(j.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	j.plate.AddFeatures(feats, j.Diagnostics)
+	for _, item := range feats {
+		if item.GetPurpose() != features.Marking {
+			continue
+		}
+		if s, ok := item.(features.Sided); ok && s.GetSide() == features.BottomSide {
+			continue
+		}
+		contours, err := flatten.Contours(item, j.Tolerance)
+		if err != nil {
+			diag.Warnf(j.Diagnostics, item, "cannot engrave feature, ignoring: %v", err)
+			continue
+		}
+		j.engrave = append(j.engrave, contours)
+	}
+}
+
+// holes returns the hole circles, shrunk by half the kerf
+func (j *Job) holes() []*features.Circle {
+	compensation := j.Kerf / 2
+	holes := []*features.Circle{}
+	for _, h := range j.plate.Holes {
+		if h.Radius <= compensation {
+			diag.Warnf(j.Diagnostics, h, "%.3fmm hole at (%.2f, %.2f) is too small to cut with a %smm kerf, skipping",
+				h.Radius*2, h.Origin.X, h.Origin.Y, geometry.FormatNumber(j.Kerf, 4))
+			continue
+		}
+		holes = append(holes, features.NewCircle(h.Origin, h.Radius-compensation))
+	}
+	return holes
+}
+
+// cutouts returns the cutout and slot contours, shrunk by half the kerf
+func (j *Job) cutouts() [][]geometry.Point {
+	compensation := j.Kerf / 2
+	contours := [][]geometry.Point{}
+	cutouts := &plate.Plate{Cutouts: j.plate.Cutouts, Slots: j.plate.Slots}
+	for _, c := range cutouts.HoleContours(j.Tolerance) {
+		insets := geometry.Offset([][]geometry.Point{c}, -compensation, geometry.MiterJoin, j.Tolerance)
+		if len(insets) == 0 {
+			diag.Warnf(j.Diagnostics, nil, "cutout is too small to cut with a %smm kerf, skipping", geometry.FormatNumber(j.Kerf, 4))
+			continue
+		}
+		contours = append(contours, insets...)
+	}
+	return contours
+}
+
+// path returns SVG path data for closed contours, flipping the Y axis
+func path(contours [][]geometry.Point) string {
+	var d strings.Builder
+	for _, contour := range contours {
+		for i, p := range contour {
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			d.WriteString(cmd + geometry.FormatNumber(p.X, 4) + " " + geometry.FormatNumber(-p.Y, 4))
+		}
+		d.WriteString("Z")
+	}
+	return d.String()
+}
+
+// WriteSVG writes the job as an SVG sized in millimetres, with engrave and
+// cut layers. Holes are cut first, then other cutouts, then the outline.
+func (j *Job) WriteSVG(w io.Writer) error {
+	outline := geometry.Offset([][]geometry.Point{j.plate.Outline(j.Tolerance)}, j.Kerf/2, geometry.MiterJoin, j.Tolerance)
+	min := geometry.Point{X: math.Inf(1), Y: math.Inf(1)}
+	max := geometry.Point{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, c := range outline {
+		for _, p := range c {
+			min.X, min.Y = math.Min(min.X, p.X), math.Min(min.Y, p.Y)
+			max.X, max.Y = math.Max(max.X, p.X), math.Max(max.Y, p.Y)
+		}
+	}
+	width, height := geometry.FormatNumber(max.X-min.X, 4), geometry.FormatNumber(max.Y-min.Y, 4)
+	var b strings.Builder
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(&b, `<!-- panel laser job generated by github.com/jsleeio/frontpanels -->`)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape" version="1.1" width="%smm" height="%smm" viewBox="%s %s %s %s">`+"\n",
+		width, height, geometry.FormatNumber(min.X, 4), geometry.FormatNumber(-max.Y, 4), width, height)
+	fmt.Fprintln(&b, `  <g id="engrave" inkscape:groupmode="layer" inkscape:label="engrave" fill="#000000" fill-rule="evenodd" stroke="none">`)
+	for _, contours := range j.engrave {
+		fmt.Fprintf(&b, `    <path d="%s"/>`+"\n", path(contours))
+	}
+	fmt.Fprintln(&b, `  </g>`)
+	fmt.Fprintf(&b, `  <g id="cut" inkscape:groupmode="layer" inkscape:label="cut" fill="none" stroke="#ff0000" stroke-width="%s">`+"\n", geometry.FormatNumber(hairline, 4))
+	for _, h := range j.holes() {
+		fmt.Fprintf(&b, `    <circle cx="%s" cy="%s" r="%s"/>`+"\n", geometry.FormatNumber(h.Origin.X, 4), geometry.FormatNumber(-h.Origin.Y, 4), geometry.FormatNumber(h.Radius, 4))
+	}
+	for _, c := range append(j.cutouts(), outline...) {
+		fmt.Fprintf(&b, `    <path d="%s"/>`+"\n", path([][]geometry.Point{c}))
+	}
+	fmt.Fprintln(&b, `  </g>`)
+	fmt.Fprintln(&b, `</svg>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
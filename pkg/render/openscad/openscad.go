@@ -0,0 +1,128 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package openscad writes OpenSCAD scripts modelling a panel as a solid
+// plate with its cutouts, so that prototype panels can be 3D-printed to test
+// fit before ordering aluminium or FR4.
+package openscad
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/plate"
+)
+
+// DefaultThickness is the default plate thickness, in millimetres
+const DefaultThickness = panel.DefaultThickness
+
+// DefaultSegments is the default number of segments used by OpenSCAD to
+// approximate each circle
+const DefaultSegments = 64
+
+// Model is an OpenSCAD model of a panel plate and its cutouts
+type Model struct {
+	// Thickness of the plate, in millimetres
+	Thickness float64
+	// Segments is the number of segments approximating each circle
+	Segments int
+	// Diagnostics receives warnings about features that cannot be modelled
+	Diagnostics diag.Reporter
+
+	plate *plate.Plate
+}
+
+// NewModel constructs a new Model of a panel's plate
+func NewModel(p panel.Panel) *Model {
+	return &Model{
+		Thickness:   DefaultThickness,
+		Segments:    DefaultSegments,
+		Diagnostics: diag.Logger{},
+		plate:       plate.New(p),
+	}
+}
+
+// AddFeatures adds the cutouts among the features to the model. Cutout
+// lines other than the panel outline are modelled as slots as wide as the
+// line is thick.
+func (m *Model) AddFeatures(feats []features.Feature) {
+	m.plate.AddFeatures(feats, m.Diagnostics)
+}
+
+// points formats the points of a polygon as an OpenSCAD vector
+func points(pts []geometry.Point) string {
+	s := []string{}
+	for _, p := range pts {
+		s = append(s, fmt.Sprintf("[%s, %s]", geometry.FormatNumber(p.X, 4), geometry.FormatNumber(p.Y, 4)))
+	}
+	return strings.Join(s, ", ")
+}
+
+// WriteSCAD writes the model as an OpenSCAD script
+func (m *Model) WriteSCAD(w io.Writer) error {
+	if m.Thickness <= 0 {
+		return fmt.Errorf("openscad: plate thickness must be greater than zero, not %v", m.Thickness)
+	}
+	var b strings.Builder
+	pl := m.plate
+	size := pl.TopRight.Sub(pl.BottomLeft)
+	// cutouts extend beyond both faces of the plate to avoid coincident
+	// faces in the rendered model
+	const overcut = 1.0
+	fmt.Fprintln(&b, "// panel model generated by github.com/jsleeio/frontpanels")
+	fmt.Fprintln(&b, "// all dimensions in millimetres")
+	fmt.Fprintf(&b, "$fn = %d;\n", m.Segments)
+	fmt.Fprintf(&b, "thickness = %s;\n\n", geometry.FormatNumber(m.Thickness, 4))
+	fmt.Fprintln(&b, "difference() {")
+	plate := fmt.Sprintf("square([%s, %s])", geometry.FormatNumber(size.X, 4), geometry.FormatNumber(size.Y, 4))
+	if pl.CornerRadius > 0 {
+		plate = fmt.Sprintf("offset(r = %s) offset(delta = -%s) %s",
+			geometry.FormatNumber(pl.CornerRadius, 4), geometry.FormatNumber(pl.CornerRadius, 4), plate)
+	}
+	if pl.Contour != nil {
+		fmt.Fprintf(&b, "  linear_extrude(height = thickness) polygon([%s]);\n", points(pl.Contour))
+	} else {
+		fmt.Fprintf(&b, "  translate([%s, %s, 0]) linear_extrude(height = thickness) %s;\n",
+			geometry.FormatNumber(pl.BottomLeft.X, 4), geometry.FormatNumber(pl.BottomLeft.Y, 4), plate)
+	}
+	for _, h := range pl.Holes {
+		fmt.Fprintf(&b, "  translate([%s, %s, -%s]) cylinder(d = %s, h = thickness + %s);\n",
+			geometry.FormatNumber(h.Origin.X, 4), geometry.FormatNumber(h.Origin.Y, 4), geometry.FormatNumber(overcut, 4), geometry.FormatNumber(h.Radius*2, 4), geometry.FormatNumber(overcut*2, 4))
+	}
+	for _, c := range pl.Cutouts {
+		fmt.Fprintf(&b, "  translate([0, 0, -%s]) linear_extrude(height = thickness + %s) polygon([%s]);\n",
+			geometry.FormatNumber(overcut, 4), geometry.FormatNumber(overcut*2, 4), points(c.Points))
+	}
+	for _, l := range pl.Slots {
+		fmt.Fprintf(&b, "  translate([0, 0, -%s]) linear_extrude(height = thickness + %s) hull() {"+
+			" translate([%s, %s]) circle(d = %s); translate([%s, %s]) circle(d = %s); }\n",
+			geometry.FormatNumber(overcut, 4), geometry.FormatNumber(overcut*2, 4),
+			geometry.FormatNumber(l.Start.X, 4), geometry.FormatNumber(l.Start.Y, 4), geometry.FormatNumber(l.Thickness, 4),
+			geometry.FormatNumber(l.End.X, 4), geometry.FormatNumber(l.End.Y, 4), geometry.FormatNumber(l.Thickness, 4))
+	}
+	fmt.Fprintln(&b, "}")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
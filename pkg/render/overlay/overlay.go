@@ -0,0 +1,454 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package overlay renders the Marking features of panels at exact scale,
+// with crop marks, as PDF or SVG sheets suitable for printing onto adhesive
+// label stock or waterslide decal paper such as Lazertran. Annotations, eg.
+// dimensions, may be drawn alongside in a second colour.
+package overlay
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/preview"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// PageSize is a paper size, in millimetres. A zero PageSize fits the page
+// to the panel and its crop marks.
+type PageSize struct {
+	Width, Height float64
+}
+
+// A4 et al are common paper sizes
+var (
+	A4     = PageSize{Width: 210, Height: 297}
+	Letter = PageSize{Width: 215.9, Height: 279.4}
+	Fit    = PageSize{}
+)
+
+// ParsePageSize converts a paper size name to a PageSize
+func ParsePageSize(s string) (PageSize, error) {
+	switch s {
+	case "a4":
+		return A4, nil
+	case "letter":
+		return Letter, nil
+	case "fit":
+		return Fit, nil
+	}
+	return Fit, fmt.Errorf("invalid page size %q (valid values: a4 letter fit)", s)
+}
+
+const (
+	// cropMarkGap is the distance between the panel edge and crop marks
+	cropMarkGap = 2.0
+	// cropMarkLength is the length of each crop mark
+	cropMarkLength = 5.0
+	// cropMarkThickness is the line width of crop marks
+	cropMarkThickness = 0.1
+	// fitMargin is the margin around the crop marks for fitted pages
+	fitMargin = 5.0
+	// annotationColour is the colour of annotations, in SVG and as PDF RGB
+	// components, so that they are not mistaken for markings
+	annotationColour    = "#0066ff"
+	annotationColourPDF = "0 0.4 1"
+)
+
+// Options configures the overlay sheet
+type Options struct {
+	// Page is the paper size. The panel is centred on the page.
+	Page PageSize
+	// Mirror flips the overlay horizontally, for transfers applied face
+	// down, or prints on the back of transparent material
+	Mirror bool
+	// CropMarks draws marks outside the corners of the panel, for trimming
+	CropMarks bool
+	// Annotations draws Annotation features, eg. dimensions, in blue, for
+	// documentation. They are not part of the markings, so should be
+	// trimmed off or left out of the print.
+	Annotations bool
+	// Colour is the SVG colour of the markings. PDF output is always black.
+	Colour string
+	// Tolerance is the maximum deviation of flattened curves, in
+	// millimetres. If zero, geometry.DefaultTolerance is used.
+	Tolerance float64
+}
+
+// DefaultOptions returns options for an A4 sheet with crop marks
+func DefaultOptions() Options {
+	return Options{Page: A4, CropMarks: true, Colour: "#000000", Tolerance: geometry.DefaultTolerance}
+}
+
+// stroke is a line drawn with round caps
+type stroke struct {
+	start, end geometry.Point
+	width      float64
+}
+
+// layer collects fills and strokes drawn in one colour
+type layer struct {
+	// fills are filled with the even-odd rule, so that clear glyph contours
+	// become holes
+	fills   [][][]geometry.Point
+	strokes []stroke
+}
+
+// Sheet collects the markings of a panel for printing
+type Sheet struct {
+	Options
+	// Diagnostics receives warnings about features that cannot be printed
+	Diagnostics diag.Reporter
+
+	bottomLeft, topRight  geometry.Point
+	markings, annotations layer
+	hardware              []preview.Shape
+}
+
+// NewSheet constructs a new, empty Sheet for a panel
+func NewSheet(p panel.Panel, opts Options) *Sheet {
+	return &Sheet{
+		Options:     opts,
+		Diagnostics: diag.Logger{},
+		bottomLeft:  panel.BottomLeft(p),
+		topRight:    panel.TopRight(p),
+	}
+}
+
+// AddFeatures adds the Marking features on the top side of the panel, and
+// its Annotation features if enabled
+func (s *Sheet) AddFeatures(feats []features.Feature) {
+	v := sheetVisitor{s: s, tolerance: flatten.Or(s.Tolerance, geometry.DefaultTolerance)}
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(s.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	for _, item := range feats {
+		switch {
+		case item.GetPurpose() == features.Marking:
+			v.l = &s.markings
+		case item.GetPurpose() == features.Annotation && s.Annotations:
+			v.l = &s.annotations
+		default:
+			continue
+		}
+		if sided, ok := item.(features.Sided); ok && sided.GetSide() == features.BottomSide {
+			continue
+		}
+		item.Accept(v)
+	}
+}
+
+// AddHardware draws hardware over the markings in its preview colours, so
+// that the sheet can be checked against the assembled module. It should be
+// left off sheets that are to be applied to the panel.
+func (s *Sheet) AddHardware(hw []preview.Hardware, colours preview.Options) {
+	for _, h := range hw {
+		s.hardware = append(s.hardware, h.Shapes(colours, flatten.Or(s.Tolerance, geometry.DefaultTolerance))...)
+	}
+}
+
+// sheetVisitor converts each type of marking into fills and strokes on a
+// layer
+type sheetVisitor struct {
+	s         *Sheet
+	l         *layer
+	tolerance float64
+}
+
+var _ features.Visitor = sheetVisitor{}
+
+func (v sheetVisitor) VisitLine(f *features.Line) {
+	v.l.strokes = append(v.l.strokes, stroke{start: f.Start, end: f.End, width: f.Thickness})
+}
+
+func (v sheetVisitor) VisitCircle(f *features.Circle) {
+	v.l.fills = append(v.l.fills, [][]geometry.Point{flatten.Circle(f.Origin, f.Radius, v.tolerance)})
+}
+
+func (v sheetVisitor) VisitPolygon(f *features.Polygon) {
+	v.l.fills = append(v.l.fills, [][]geometry.Point{f.Points})
+}
+
+func (v sheetVisitor) VisitOutline(f *features.Outline) {
+	diag.Warnf(v.s.Diagnostics, f, "cannot render feature on overlay, ignoring: %v", f)
+}
+
+func (v sheetVisitor) VisitText(f *features.Text) {
+	contours, err := textpath.Contours(f, false)
+	if err != nil {
+		diag.Warnf(v.s.Diagnostics, f, "cannot render text, ignoring: %v", err)
+		return
+	}
+	fill := [][]geometry.Point{}
+	for _, c := range contours {
+		fill = append(fill, c.Points)
+	}
+	v.l.fills = append(v.l.fills, fill)
+}
+
+func (v sheetVisitor) VisitPlaceholder(f *features.Placeholder) {
+	diag.Warnf(v.s.Diagnostics, f, "unresolved placeholder, ignoring: %v", f.String())
+}
+
+func (v sheetVisitor) VisitImage(f *features.Image) {
+	fill := [][]geometry.Point{}
+	for _, run := range f.Runs() {
+		fill = append(fill, features.NewRectangle(run[0], run[1]).Points)
+	}
+	v.l.fills = append(v.l.fills, fill)
+}
+
+func (v sheetVisitor) VisitDimension(f *features.Dimension) {
+	for _, part := range f.Features() {
+		part.Accept(v)
+	}
+}
+
+func (v sheetVisitor) VisitCustom(f features.Custom) {
+	v.s.AddFeatures([]features.Feature{f})
+}
+
+func (v sheetVisitor) VisitInversion(f *features.Inversion) {
+	knockouts := [][][]geometry.Point{}
+	for _, k := range f.Knockouts {
+		contours, err := flatten.Contours(k, v.tolerance)
+		if err != nil {
+			diag.Warnf(v.s.Diagnostics, k, "cannot knock out feature, ignoring: %v", err)
+			continue
+		}
+		knockouts = append(knockouts, contours)
+	}
+	v.l.fills = append(v.l.fills, geometry.Boolean(geometry.Difference, [][]geometry.Point{f.Region}, geometry.Merge(knockouts...)))
+}
+
+// overhang returns the furthest distance annotations extend beyond the
+// panel edges
+func (s *Sheet) overhang() float64 {
+	overhang := 0.0
+	extend := func(p geometry.Point, r float64) {
+		overhang = math.Max(overhang, math.Max(
+			math.Max(s.bottomLeft.X-p.X, p.X-s.topRight.X),
+			math.Max(s.bottomLeft.Y-p.Y, p.Y-s.topRight.Y))+r)
+	}
+	for _, fill := range s.annotations.fills {
+		for _, c := range fill {
+			for _, p := range c {
+				extend(p, 0)
+			}
+		}
+	}
+	for _, st := range s.annotations.strokes {
+		extend(st.start, st.width/2)
+		extend(st.end, st.width/2)
+	}
+	return overhang
+}
+
+// page returns the page size, fitting it to the panel, its crop marks and
+// any annotations if necessary
+func (s *Sheet) page() PageSize {
+	if s.Page != Fit {
+		return s.Page
+	}
+	border := 2 * (math.Max(cropMarkGap+cropMarkLength, s.overhang()) + fitMargin)
+	size := s.topRight.Sub(s.bottomLeft)
+	return PageSize{Width: size.X + border, Height: size.Y + border}
+}
+
+// CheckPage warns if the panel, with any crop marks, does not fit on the
+// page, as it would be printed cropped
+func (s *Sheet) CheckPage() {
+	page := s.page()
+	size, what := s.topRight.Sub(s.bottomLeft), "panel"
+	if s.CropMarks {
+		border := 2 * (cropMarkGap + cropMarkLength)
+		size, what = size.Add(geometry.Point{X: border, Y: border}), "panel and its crop marks"
+	}
+	if size.X > page.Width || size.Y > page.Height {
+		diag.Warnf(s.Diagnostics, nil, "%.2fx%.2fmm %s will not fit on the %.2fx%.2fmm page, and will be cropped",
+			size.X, size.Y, what, page.Width, page.Height)
+	}
+}
+
+// place converts a panel point to page coordinates, in millimetres from the
+// bottom-left of the page, centring the panel and mirroring if requested
+func (s *Sheet) place(p geometry.Point) geometry.Point {
+	page := s.page()
+	size := s.topRight.Sub(s.bottomLeft)
+	p = p.Sub(s.bottomLeft)
+	if s.Mirror {
+		p.X = size.X - p.X
+	}
+	return p.Add(geometry.Point{X: (page.Width - size.X) / 2, Y: (page.Height - size.Y) / 2})
+}
+
+// cropMarks returns the crop marks, in panel coordinates
+func (s *Sheet) cropMarks() []stroke {
+	if !s.CropMarks {
+		return nil
+	}
+	marks := []stroke{}
+	for _, x := range []float64{s.bottomLeft.X, s.topRight.X} {
+		for _, y := range []float64{s.bottomLeft.Y, s.topRight.Y} {
+			// marks point away from the panel
+			dx := math.Copysign(1, x-(s.bottomLeft.X+s.topRight.X)/2)
+			dy := math.Copysign(1, y-(s.bottomLeft.Y+s.topRight.Y)/2)
+			corner := geometry.Point{X: x, Y: y}
+			marks = append(marks,
+				stroke{
+					start: corner.Add(geometry.Point{X: dx * cropMarkGap}),
+					end:   corner.Add(geometry.Point{X: dx * (cropMarkGap + cropMarkLength)}),
+					width: cropMarkThickness,
+				},
+				stroke{
+					start: corner.Add(geometry.Point{Y: dy * cropMarkGap}),
+					end:   corner.Add(geometry.Point{Y: dy * (cropMarkGap + cropMarkLength)}),
+					width: cropMarkThickness,
+				},
+			)
+		}
+	}
+	return marks
+}
+
+// WriteSVG writes the sheet as an SVG, sized in millimetres
+func (s *Sheet) WriteSVG(w io.Writer) error {
+	var b strings.Builder
+	page := s.page()
+	xy := func(p geometry.Point) string {
+		p = s.place(p)
+		return geometry.FormatNumber(p.X, 4) + " " + geometry.FormatNumber(page.Height-p.Y, 4)
+	}
+	path := func(contours [][]geometry.Point) string {
+		var d strings.Builder
+		for _, contour := range contours {
+			for i, p := range contour {
+				cmd := "L"
+				if i == 0 {
+					cmd = "M"
+				}
+				d.WriteString(cmd + xy(p))
+			}
+			d.WriteString("Z")
+		}
+		return d.String()
+	}
+	width, height := geometry.FormatNumber(page.Width, 4), geometry.FormatNumber(page.Height, 4)
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(&b, `<!-- panel overlay generated by github.com/jsleeio/frontpanels -->`)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%smm" height="%smm" viewBox="0 0 %s %s">`+"\n",
+		width, height, width, height)
+	draw := func(l layer, colour string) {
+		for _, fill := range l.fills {
+			fmt.Fprintf(&b, `  <path d="%s" fill="%s" fill-rule="evenodd"/>`+"\n", path(fill), colour)
+		}
+		for _, st := range l.strokes {
+			fmt.Fprintf(&b, `  <path d="M%sL%s" stroke="%s" stroke-width="%s" stroke-linecap="round" fill="none"/>`+"\n",
+				xy(st.start), xy(st.end), colour, geometry.FormatNumber(st.width, 4))
+		}
+	}
+	draw(s.markings, s.Colour)
+	for _, sh := range s.hardware {
+		draw(layer{fills: [][][]geometry.Point{sh.Contours}}, sh.Colour)
+	}
+	draw(s.annotations, annotationColour)
+	for _, st := range s.cropMarks() {
+		fmt.Fprintf(&b, `  <path d="M%sL%s" stroke="#000000" stroke-width="%s" fill="none"/>`+"\n",
+			xy(st.start), xy(st.end), geometry.FormatNumber(st.width, 4))
+	}
+	fmt.Fprintln(&b, `</svg>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WritePDF writes the sheet as a single-page PDF
+func (s *Sheet) WritePDF(w io.Writer) error {
+	page := s.page()
+	xy := func(p geometry.Point) string {
+		// PDF user space units are points
+		p = s.place(p).Scale(geometry.MMToPoints(1))
+		return geometry.FormatNumber(p.X, 4) + " " + geometry.FormatNumber(p.Y, 4)
+	}
+	type pdfLayer struct {
+		layer  layer
+		colour string
+	}
+	layers := []pdfLayer{{s.markings, "0 0 0"}}
+	for _, sh := range s.hardware {
+		c, err := preview.ParseColour(sh.Colour)
+		if err != nil {
+			return fmt.Errorf("overlay: %v", err)
+		}
+		colour := fmt.Sprintf("%s %s %s", geometry.FormatNumber(float64(c.R)/0xff, 4), geometry.FormatNumber(float64(c.G)/0xff, 4), geometry.FormatNumber(float64(c.B)/0xff, 4))
+		layers = append(layers, pdfLayer{layer{fills: [][][]geometry.Point{sh.Contours}}, colour})
+	}
+	layers = append(layers, pdfLayer{s.annotations, annotationColourPDF}, pdfLayer{layer{strokes: s.cropMarks()}, "0 0 0"})
+	var content strings.Builder
+	content.WriteString("1 J\n")
+	for _, l := range layers {
+		fmt.Fprintf(&content, "%s rg %s RG\n", l.colour, l.colour)
+		for _, fill := range l.layer.fills {
+			for _, contour := range fill {
+				for i, p := range contour {
+					op := "l"
+					if i == 0 {
+						op = "m"
+					}
+					fmt.Fprintf(&content, "%s %s\n", xy(p), op)
+				}
+				content.WriteString("h\n")
+			}
+			content.WriteString("f*\n")
+		}
+		for _, st := range l.layer.strokes {
+			fmt.Fprintf(&content, "%s w %s m %s l S\n", geometry.FormatNumber(geometry.MMToPoints(st.width), 4), xy(st.start), xy(st.end))
+		}
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Contents 4 0 R /Resources << >> >>",
+			geometry.FormatNumber(geometry.MMToPoints(page.Width), 4), geometry.FormatNumber(geometry.MMToPoints(page.Height), 4)),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, obj := range objects {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package plate describes a panel as a flat plate with cutouts, the common
+// basis of the 3D model backends
+package plate
+
+import (
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// Plate collects the cutouts of a panel. Only Cutout features affect the
+// plate; markings are ignored.
+type Plate struct {
+	BottomLeft, TopRight geometry.Point
+	CornerRadius         float64
+	// Contour is the custom outline of the panel, if it has one. Otherwise
+	// the outline is a rectangle with the corner radius.
+	Contour []geometry.Point
+	Holes   []*features.Circle
+	Cutouts []*features.Polygon
+	// Slots are cutout lines, as wide as the line is thick
+	Slots []*features.Line
+}
+
+// New constructs a new Plate of a panel's size and outline, without cutouts
+func New(p panel.Panel) *Plate {
+	pl := &Plate{
+		BottomLeft:   panel.BottomLeft(p),
+		TopRight:     panel.TopRight(p),
+		CornerRadius: p.CornerRadius(),
+	}
+	if o, ok := p.(panel.Outliner); ok {
+		pl.Contour = o.Outline()
+	}
+	return pl
+}
+
+// AddFeatures adds the cutouts among the features to the plate. Outline
+// features are ignored, as the plate takes its outline from the panel.
+// Features that cannot be cut from the plate are reported as warnings.
+func (pl *Plate) AddFeatures(feats []features.Feature, r diag.Reporter) {
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(r, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	for _, item := range feats {
+		if item.GetPurpose() != features.Cutout {
+			continue
+		}
+		switch f := item.(type) {
+		case *features.Circle:
+			pl.Holes = append(pl.Holes, f)
+		case *features.Polygon:
+			pl.Cutouts = append(pl.Cutouts, f)
+		case *features.Outline:
+			// the plate already has the panel outline
+		case *features.Line:
+			if f.Thickness <= 0 {
+				diag.Warnf(r, item, "cutout line without thickness cannot be modelled in 3D: %v", f)
+				continue
+			}
+			pl.Slots = append(pl.Slots, f)
+		default:
+			diag.Warnf(r, item, "cutout cannot be modelled in 3D: %v", item)
+		}
+	}
+}
+
+// degrees returns the angle of a vector, in degrees anticlockwise from the
+// positive X axis
+func degrees(v geometry.Point) float64 {
+	return math.Atan2(v.Y, v.X) * 180.0 / math.Pi
+}
+
+// arc flattens an arc, dropping its final point so that consecutive arcs
+// can be concatenated
+func arc(centre geometry.Point, radius, start, end, tolerance float64) []geometry.Point {
+	points := geometry.Arc(centre, radius, start, end, tolerance)
+	return points[:len(points)-1]
+}
+
+// Outline returns the plate outline as an anticlockwise contour, with any
+// rounded corners flattened within tolerance
+func (pl *Plate) Outline(tolerance float64) []geometry.Point {
+	if pl.Contour != nil {
+		if geometry.SignedArea(pl.Contour) >= 0 {
+			return pl.Contour
+		}
+		r := make([]geometry.Point, len(pl.Contour))
+		for i, p := range pl.Contour {
+			r[len(r)-1-i] = p
+		}
+		return r
+	}
+	return geometry.RoundedRect(pl.BottomLeft, pl.TopRight, pl.CornerRadius, tolerance)
+}
+
+// slot returns the stadium-shaped contour of a slot
+func slot(l *features.Line, tolerance float64) []geometry.Point {
+	r := l.Thickness / 2
+	if l.Start == l.End {
+		return arc(l.Start, r, 0, 360, tolerance)
+	}
+	a := degrees(l.End.Sub(l.Start))
+	return append(arc(l.End, r, a-90, a+90, tolerance), arc(l.Start, r, a+90, a+270, tolerance)...)
+}
+
+// HoleContours returns the contours of every cutout, flattened within
+// tolerance. Their orientation is not normalised.
+func (pl *Plate) HoleContours(tolerance float64) [][]geometry.Point {
+	contours := [][]geometry.Point{}
+	for _, h := range pl.Holes {
+		contours = append(contours, arc(h.Origin, h.Radius, 0, 360, tolerance))
+	}
+	for _, c := range pl.Cutouts {
+		contours = append(contours, c.Points)
+	}
+	for _, l := range pl.Slots {
+		contours = append(contours, slot(l, tolerance))
+	}
+	return contours
+}
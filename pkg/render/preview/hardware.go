@@ -0,0 +1,183 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package preview
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+)
+
+// HardwareKind identifies a type of assembled hardware
+type HardwareKind int
+
+// Washer et al specify hardware kinds
+const (
+	// Washer is a flat washer under a panel mounting screw
+	Washer HardwareKind = iota // this MUST be the first item
+	// ScrewHead is the head of a panel mounting screw
+	ScrewHead
+	// Nut is the hex nut fixing a component to the panel
+	Nut
+	// Knob is a knob fitted to a potentiometer or encoder shaft
+	Knob
+	// Silhouette outlines the widest extent of a knob, so that knobs too
+	// close to turn comfortably can be spotted; see KnobSilhouettes
+	Silhouette
+	// CollidingSilhouette outlines a knob overlapping another knob
+	CollidingSilhouette // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (k HardwareKind) String() string {
+	switch k {
+	case Washer:
+		return "washer"
+	case ScrewHead:
+		return "screw-head"
+	case Nut:
+		return "nut"
+	case Knob:
+		return "knob"
+	case Silhouette:
+		return "silhouette"
+	case CollidingSilhouette:
+		return "colliding-silhouette"
+	}
+	panic(fmt.Sprintf("invalid HardwareKind value (valid range is %d..%d): %d",
+		int(Washer), int(CollidingSilhouette), int(k)))
+}
+
+// Hardware is an item of assembled hardware drawn over a preview, so that
+// it approximates the finished module. It is never part of fabrication
+// output. Diameter is the outside diameter in millimetres, or for nuts the
+// width across corners.
+type Hardware struct {
+	Kind     HardwareKind
+	Origin   geometry.Point
+	Diameter float64
+}
+
+// Shape is an area of a single colour, filled with the even-odd rule
+type Shape struct {
+	Contours [][]geometry.Point
+	Colour   string
+}
+
+// circle returns a circular contour
+func circle(centre geometry.Point, diameter, tolerance float64) []geometry.Point {
+	return flatten.Circle(centre, diameter/2, tolerance)
+}
+
+// hexagon returns a hexagonal contour, flat at the top and bottom, with
+// the given width across corners
+func hexagon(centre geometry.Point, diameter float64) []geometry.Point {
+	points := []geometry.Point{}
+	for i := 0; i < 6; i++ {
+		points = append(points, centre.Add(geometry.Point{X: diameter / 2}.Rotate(float64(i)*60)))
+	}
+	return points
+}
+
+// Shapes returns the shapes drawing the hardware, in painting order, with
+// circles flattened within tolerance
+func (h Hardware) Shapes(opts Options, tolerance float64) []Shape {
+	d := h.Diameter
+	switch h.Kind {
+	case Washer:
+		return []Shape{{Contours: [][]geometry.Point{circle(h.Origin, d, tolerance), circle(h.Origin, d*0.45, tolerance)}, Colour: opts.Metal}}
+	case ScrewHead:
+		return []Shape{
+			{Contours: [][]geometry.Point{circle(h.Origin, d, tolerance)}, Colour: opts.Metal},
+			{Contours: [][]geometry.Point{hexagon(h.Origin, d*0.5)}, Colour: opts.Shadow},
+		}
+	case Nut:
+		return []Shape{
+			{Contours: [][]geometry.Point{hexagon(h.Origin, d)}, Colour: opts.Metal},
+			{Contours: [][]geometry.Point{circle(h.Origin, d*0.6, tolerance)}, Colour: opts.Shadow},
+		}
+	case Knob:
+		// a pointer line pointing straight up
+		w := math.Max(d*0.06, 0.4)
+		pointer := geometry.RoundedRect(h.Origin.Add(geometry.Point{X: -w / 2, Y: d * 0.1}), h.Origin.Add(geometry.Point{X: w / 2, Y: d * 0.45}), 0, 0)
+		return []Shape{
+			{Contours: [][]geometry.Point{circle(h.Origin, d, tolerance)}, Colour: opts.Knob},
+			{Contours: [][]geometry.Point{pointer}, Colour: opts.Silkscreen},
+		}
+	case Silhouette:
+		return []Shape{{Contours: [][]geometry.Point{circle(h.Origin, d, tolerance), circle(h.Origin, d-0.8, tolerance)}, Colour: opts.Silhouette}}
+	case CollidingSilhouette:
+		return []Shape{{Contours: [][]geometry.Point{circle(h.Origin, d, tolerance), circle(h.Origin, d-1.2, tolerance)}, Colour: opts.Collision}}
+	}
+	return nil
+}
+
+// KnobSilhouettes returns a silhouette outlining each of the knobs in hw,
+// ignoring other hardware. Knobs overlapping any other knob are outlined
+// as CollidingSilhouette.
+func KnobSilhouettes(hw []Hardware) []Hardware {
+	silhouettes := []Hardware{}
+	for i, h := range hw {
+		if h.Kind != Knob {
+			continue
+		}
+		kind := Silhouette
+		for j, o := range hw {
+			if j != i && o.Kind == Knob && h.Origin.Distance(o.Origin) < (h.Diameter+o.Diameter)/2 {
+				kind = CollidingSilhouette
+				break
+			}
+		}
+		silhouettes = append(silhouettes, Hardware{Kind: kind, Origin: h.Origin, Diameter: h.Diameter})
+	}
+	return silhouettes
+}
+
+// ScrewHardware returns a washer and screw head at each mounting hole,
+// sized for the largest metric screw that clears holes of the given
+// diameter, using typical ISO 7089 washer and ISO 7045 pan head sizes
+func ScrewHardware(holes []geometry.Point, holeDiameter float64) []Hardware {
+	hw := []Hardware{}
+	for _, size := range []struct{ nominal, washer, head float64 }{
+		{6, 12, 12}, {5, 10, 9.5}, {4, 9, 8}, {3, 7, 5.6}, {2.5, 6, 5}, {2, 5, 4},
+	} {
+		if size.nominal >= holeDiameter {
+			continue
+		}
+		for _, h := range holes {
+			hw = append(hw, Hardware{Kind: Washer, Origin: h, Diameter: size.washer}, Hardware{Kind: ScrewHead, Origin: h, Diameter: size.head})
+		}
+		break
+	}
+	return hw
+}
+
+// AddHardware draws hardware over the panel. Unlike markings it is not
+// clipped to the board, as knobs may overhang its edges.
+func (pv *Preview) AddHardware(hw []Hardware) {
+	for _, h := range hw {
+		for _, s := range h.Shapes(pv.Options, pv.tolerance()) {
+			pv.hardware = append(pv.hardware, fmt.Sprintf(`<path d="%s" fill="%s" fill-rule="evenodd"/>`, pv.path(s.Contours...), s.Colour))
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package preview draws an SVG approximation of the finished front of a
+// panel: the board in soldermask colour with its holes and cutouts, overlaid
+// with silkscreen and copper markings. It is intended for quick visual checks
+// and web pages rather than fabrication, and has no Gerber dependencies.
+package preview
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// Options configures the preview colours, as SVG colour values. Metal,
+// Shadow and Knob colour assembled hardware, and Silhouette and Collision
+// the outlines of knobs; see Hardware. Annotation colours Annotation
+// features, eg. dimensions, which are only drawn if Annotations is set.
+// Tolerance is the maximum deviation of flattened holes and outlines, in
+// millimetres; if zero, flatten.Coarse is used.
+type Options struct {
+	Soldermask   string
+	Silkscreen   string
+	Copper       string
+	MaskedCopper string
+	Metal        string
+	Shadow       string
+	Knob         string
+	Silhouette   string
+	Collision    string
+	Annotation   string
+	Annotations  bool
+	Tolerance    float64
+}
+
+// DefaultOptions returns colours for a black panel with white silkscreen
+// and gold-plated copper
+func DefaultOptions() Options {
+	return Options{
+		Soldermask:   "#1a1a1a",
+		Silkscreen:   "#f2f2f2",
+		Copper:       "#d4a84a",
+		MaskedCopper: "#333333",
+		Metal:        "#b4b4b4",
+		Shadow:       "#3c3c3c",
+		Knob:         "#262626",
+		Silhouette:   "#1e90ff",
+		Collision:    "#ff3030",
+		Annotation:   "#0066ff",
+		Tolerance:    flatten.Coarse,
+	}
+}
+
+// ParseColour converts an SVG hex colour, as #rgb or #rrggbb, to a colour
+func ParseColour(s string) (color.NRGBA, error) {
+	if len(s) == 4 && s[0] == '#' {
+		s = string([]byte{'#', s[1], s[1], s[2], s[2], s[3], s[3]})
+	}
+	if len(s) != 7 || s[0] != '#' {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q; must be #rgb or #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q; must be #rgb or #rrggbb", s)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// presets holds colours approximating panels as commonly fabricated, by
+// soldermask colour
+var presets = map[string]Options{
+	"black": DefaultOptions(),
+	"white": {
+		Soldermask:   "#f4f4f0",
+		Silkscreen:   "#1a1a1a",
+		Copper:       "#d4a84a",
+		MaskedCopper: "#e4e4dc",
+		Metal:        "#b4b4b4",
+		Shadow:       "#3c3c3c",
+		Knob:         "#262626",
+		Silhouette:   "#1e90ff",
+		Collision:    "#ff3030",
+		Annotation:   "#0066ff",
+		Tolerance:    flatten.Coarse,
+	},
+	"yellow": {
+		Soldermask:   "#e3b81e",
+		Silkscreen:   "#f2f2f2",
+		Copper:       "#d4a84a",
+		MaskedCopper: "#efcc4a",
+		Metal:        "#b4b4b4",
+		Shadow:       "#3c3c3c",
+		Knob:         "#262626",
+		Silhouette:   "#1e90ff",
+		Collision:    "#ff3030",
+		Annotation:   "#0066ff",
+		Tolerance:    flatten.Coarse,
+	},
+}
+
+// PresetNames returns the names of the colour presets, sorted
+func PresetNames() []string {
+	names := []string{}
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Preset returns the colours of a preset, named for its soldermask colour
+func Preset(name string) (Options, error) {
+	if opts, ok := presets[name]; ok {
+		return opts, nil
+	}
+	return Options{}, fmt.Errorf("invalid preview colour preset %q (valid values: %s)", name, strings.Join(PresetNames(), " "))
+}
+
+// Preview collects panel features for drawing
+type Preview struct {
+	Options
+	// Diagnostics receives warnings about features that cannot be drawn
+	Diagnostics diag.Reporter
+
+	width, height float64
+	outline       []geometry.Point
+	holes         [][]geometry.Point
+	elements      []string
+	hardware      []string
+	annotations   []string
+	// min and max bound the annotations, which may lie beyond the panel
+	min, max geometry.Point
+	// masks counts the masks defined for inversions, to number them
+	masks int
+}
+
+// New constructs a new Preview of a panel with no features, initially with
+// the panel's default outline
+func New(p panel.Panel, opts Options) *Preview {
+	return &Preview{
+		Options:     opts,
+		Diagnostics: diag.Logger{},
+		width:       p.Width(),
+		height:      p.Height(),
+		outline:     panel.Outline(p, flatten.Or(opts.Tolerance, flatten.Coarse)),
+		max:         geometry.Point{X: p.Width(), Y: p.Height()},
+	}
+}
+
+// tolerance returns the maximum deviation of flattened curves
+func (pv *Preview) tolerance() float64 {
+	return flatten.Or(pv.Tolerance, flatten.Coarse)
+}
+
+// xy converts a panel point to SVG coordinates, in which Y increases
+// downwards from the top of the panel
+func (pv *Preview) xy(p geometry.Point) (string, string) {
+	return geometry.FormatNumber(p.X, 4), geometry.FormatNumber(pv.height-p.Y, 4)
+}
+
+// path converts closed contours to SVG path data
+func (pv *Preview) path(contours ...[]geometry.Point) string {
+	var b strings.Builder
+	for _, c := range contours {
+		for i, p := range c {
+			x, y := pv.xy(p)
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&b, "%s%s %s", cmd, x, y)
+		}
+		b.WriteString("Z")
+	}
+	return b.String()
+}
+
+// colour returns the colour of a feature, or false if it is not visible on
+// the front of the panel
+func (pv *Preview) colour(f features.Feature) (string, bool) {
+	if s, ok := f.(features.Sided); ok && s.GetSide() == features.BottomSide {
+		return "", false
+	}
+	switch f.GetPurpose() {
+	case features.Marking:
+		return pv.Silkscreen, true
+	case features.ExposedCopper, features.MaskOpening:
+		return pv.Copper, true
+	case features.MaskedCopper:
+		return pv.MaskedCopper, true
+	}
+	return "", false
+}
+
+// cutout records a cutout feature as a hole in the board, or as its outline
+func (pv *Preview) cutout(item features.Feature) {
+	switch f := item.(type) {
+	case *features.Outline:
+		pv.outline = f.Points
+	case *features.Circle:
+		pv.holes = append(pv.holes, flatten.Circle(f.Origin, f.Radius, pv.tolerance()))
+	case *features.Polygon:
+		pv.holes = append(pv.holes, f.Points)
+	}
+}
+
+// element converts a feature into an SVG element of the given colour
+func (pv *Preview) element(item features.Feature, colour string) (string, error) {
+	switch f := item.(type) {
+	case *features.Line:
+		x1, y1 := pv.xy(f.Start)
+		x2, y2 := pv.xy(f.End)
+		return fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s" stroke-linecap="round"/>`,
+			x1, y1, x2, y2, colour, geometry.FormatNumber(f.Thickness, 4)), nil
+	case *features.Circle:
+		x, y := pv.xy(f.Origin)
+		return fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="%s"/>`, x, y, geometry.FormatNumber(f.Radius, 4), colour), nil
+	case *features.Polygon:
+		return fmt.Sprintf(`<path d="%s" fill="%s"/>`, pv.path(f.Points), colour), nil
+	case *features.Text:
+		contours, err := textpath.Contours(f, false)
+		if err != nil {
+			return "", fmt.Errorf("cannot render text: %v", err)
+		}
+		points := [][]geometry.Point{}
+		for _, c := range contours {
+			points = append(points, c.Points)
+		}
+		return fmt.Sprintf(`<path d="%s" fill="%s" fill-rule="evenodd"/>`, pv.path(points...), colour), nil
+	case *features.Image:
+		points := [][]geometry.Point{}
+		for _, run := range f.Runs() {
+			points = append(points, features.NewRectangle(run[0], run[1]).Points)
+		}
+		return fmt.Sprintf(`<path d="%s" fill="%s"/>`, pv.path(points...), colour), nil
+	case *features.Inversion:
+		// the knockouts are drawn in black in a mask hiding them from the
+		// filled region
+		pv.masks++
+		id := fmt.Sprintf("inversion%d", pv.masks)
+		var b strings.Builder
+		fmt.Fprintf(&b, `<mask id="%s"><path d="%s" fill="white"/>`, id, pv.path(f.Region))
+		for _, k := range f.Knockouts {
+			e, err := pv.element(k, "black")
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(e)
+		}
+		fmt.Fprintf(&b, `</mask><path d="%s" fill="%s" mask="url(#%s)"/>`, pv.path(f.Region), colour, id)
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("unsupported feature type: %T", item)
+}
+
+// annotate converts an annotation into SVG elements, unclipped by the
+// board, and extends the preview to include it
+func (pv *Preview) annotate(item features.Feature) {
+	parts := []features.Feature{item}
+	if d, ok := item.(*features.Dimension); ok {
+		parts = d.Features()
+	}
+	for _, part := range parts {
+		e, err := pv.element(part, pv.Annotation)
+		if err != nil {
+			diag.Warnf(pv.Diagnostics, part, "%v, ignoring", err)
+			continue
+		}
+		pv.annotations = append(pv.annotations, e)
+		contours, err := flatten.Contours(part, pv.tolerance())
+		if err != nil {
+			continue
+		}
+		for _, c := range contours {
+			for _, p := range c {
+				pv.min = geometry.Point{X: math.Min(pv.min.X, p.X), Y: math.Min(pv.min.Y, p.Y)}
+				pv.max = geometry.Point{X: math.Max(pv.max.X, p.X), Y: math.Max(pv.max.Y, p.Y)}
+			}
+		}
+	}
+}
+
+// AddFeatures converts features into SVG elements. Cutouts become holes in
+// the board; markings and copper on the front of the panel are drawn over
+// it, and annotations beside it if enabled. Features on the rear are not
+// drawn.
+func (pv *Preview) AddFeatures(feats []features.Feature) {
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(pv.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	for _, item := range feats {
+		if item.GetPurpose() == features.Cutout {
+			pv.cutout(item)
+			continue
+		}
+		if item.GetPurpose() == features.Annotation {
+			if pv.Annotations {
+				pv.annotate(item)
+			}
+			continue
+		}
+		colour, visible := pv.colour(item)
+		if !visible {
+			continue
+		}
+		e, err := pv.element(item, colour)
+		if err != nil {
+			diag.Warnf(pv.Diagnostics, item, "%v, ignoring", err)
+			continue
+		}
+		pv.elements = append(pv.elements, e)
+	}
+}
+
+// WriteSVG writes the preview as an SVG document sized in millimetres.
+// Markings are clipped to the board, so holes stay open. The document is
+// extended beyond the panel to include any annotations.
+func (pv *Preview) WriteSVG(w io.Writer) error {
+	var b strings.Builder
+	width, height := geometry.FormatNumber(pv.max.X-pv.min.X, 4), geometry.FormatNumber(pv.max.Y-pv.min.Y, 4)
+	left, top := pv.xy(geometry.Point{X: pv.min.X, Y: pv.max.Y})
+	board := pv.path(append([][]geometry.Point{pv.outline}, pv.holes...)...)
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(&b, `<!-- panel preview generated by github.com/jsleeio/frontpanels -->`)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%smm" height="%smm" viewBox="%s %s %s %s">`+"\n",
+		width, height, left, top, width, height)
+	fmt.Fprintf(&b, `  <clipPath id="board"><path d="%s" clip-rule="evenodd"/></clipPath>`+"\n", board)
+	fmt.Fprintf(&b, `  <path d="%s" fill="%s" fill-rule="evenodd"/>`+"\n", board, pv.Soldermask)
+	fmt.Fprintln(&b, `  <g clip-path="url(#board)">`)
+	for _, e := range pv.elements {
+		fmt.Fprintf(&b, "    %s\n", e)
+	}
+	fmt.Fprintln(&b, `  </g>`)
+	for _, e := range pv.hardware {
+		fmt.Fprintf(&b, "  %s\n", e)
+	}
+	for _, e := range pv.annotations {
+		fmt.Fprintf(&b, "  %s\n", e)
+	}
+	fmt.Fprintln(&b, `</svg>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
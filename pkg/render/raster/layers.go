@@ -0,0 +1,171 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package raster
+
+import (
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+)
+
+// Shape is the contours of one feature, filled with the even-odd rule
+// independently of any others it overlaps, less the areas of any features
+// knocked out of it
+type Shape struct {
+	Contours  [][]geometry.Point
+	Knockouts [][][]geometry.Point
+}
+
+// Layers holds the shapes of the visible layers of the front of a panel,
+// ready for rasterising
+type Layers struct {
+	// Diagnostics receives warnings about features that cannot be drawn
+	Diagnostics diag.Reporter
+
+	Outline      []geometry.Point
+	Holes        [][]geometry.Point
+	Silkscreen   []Shape
+	Copper       []Shape
+	MaskedCopper []Shape
+	// tolerance is the maximum deviation of flattened curves from the
+	// features they approximate, in millimetres
+	tolerance float64
+}
+
+// NewLayers constructs Layers for a panel with no features, initially with
+// the panel's default outline, flattening curves within tolerance
+func NewLayers(p panel.Panel, tolerance float64) *Layers {
+	return &Layers{
+		Diagnostics: diag.Logger{},
+		tolerance:   tolerance,
+		Outline:     panel.Outline(p, tolerance),
+	}
+}
+
+// layer returns the layer a feature is drawn on, or nil if it is not
+// visible on the front of the panel
+func (l *Layers) layer(f features.Feature) *[]Shape {
+	if s, ok := f.(features.Sided); ok && s.GetSide() == features.BottomSide {
+		return nil
+	}
+	switch f.GetPurpose() {
+	case features.Marking:
+		return &l.Silkscreen
+	case features.ExposedCopper, features.MaskOpening:
+		return &l.Copper
+	case features.MaskedCopper:
+		return &l.MaskedCopper
+	}
+	return nil
+}
+
+// AddFeatures adds features to the layers. Cutouts become holes in the
+// board, or replace its outline; markings and copper on the front of the
+// panel are added to their layers. Features on the rear and annotations are
+// ignored.
+func (l *Layers) AddFeatures(feats []features.Feature) {
+	feats = features.ExpandCustom(feats, func(f features.Custom, err error) {
+		diag.Warnf(l.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+	})
+	for _, item := range feats {
+		var layer *[]Shape
+		if item.GetPurpose() != features.Cutout {
+			if layer = l.layer(item); layer == nil {
+				continue
+			}
+		}
+		if inv, ok := item.(*features.Inversion); ok && layer != nil {
+			*layer = append(*layer, l.inversion(inv))
+			continue
+		}
+		contours, err := flatten.Contours(item, l.tolerance)
+		if err != nil {
+			diag.Warnf(l.Diagnostics, item, "cannot rasterise feature, ignoring: %v", err)
+			continue
+		}
+		if layer != nil {
+			*layer = append(*layer, Shape{Contours: contours})
+		} else if _, ok := item.(*features.Outline); ok {
+			l.Outline = contours[0]
+		} else {
+			l.Holes = append(l.Holes, contours...)
+		}
+	}
+}
+
+// inversion returns the shape of an inversion: its region, less its
+// knockouts
+func (l *Layers) inversion(inv *features.Inversion) Shape {
+	shape := Shape{Contours: [][]geometry.Point{inv.Region}}
+	for _, k := range inv.Knockouts {
+		contours, err := flatten.Contours(k, l.tolerance)
+		if err != nil {
+			diag.Warnf(l.Diagnostics, k, "cannot rasterise knockout, ignoring: %v", err)
+			continue
+		}
+		shape.Knockouts = append(shape.Knockouts, contours)
+	}
+	return shape
+}
+
+// Bounds returns the bounding rectangle of the board outline
+func (l *Layers) Bounds() geometry.Rect {
+	r := geometry.Rect{
+		BottomLeft: geometry.Point{X: math.Inf(1), Y: math.Inf(1)},
+		TopRight:   geometry.Point{X: math.Inf(-1), Y: math.Inf(-1)},
+	}
+	for _, p := range l.Outline {
+		r.BottomLeft.X = math.Min(r.BottomLeft.X, p.X)
+		r.BottomLeft.Y = math.Min(r.BottomLeft.Y, p.Y)
+		r.TopRight.X = math.Max(r.TopRight.X, p.X)
+		r.TopRight.Y = math.Max(r.TopRight.Y, p.Y)
+	}
+	return r
+}
+
+// FillBoard fills a raster with the board, excluding its holes
+func (l *Layers) FillBoard(r *Raster) {
+	r.Fill(append([][]geometry.Point{l.Outline}, l.Holes...)...)
+}
+
+// FillLayer fills a raster with every shape of a layer
+func FillLayer(r *Raster, layer []Shape) {
+	for _, s := range layer {
+		if len(s.Knockouts) == 0 {
+			r.Fill(s.Contours...)
+			continue
+		}
+		// knockouts only clear the shape they belong to, so the shape is
+		// filled separately and then merged
+		shape := New(r.Area, r.Cols, r.Rows)
+		shape.Fill(s.Contours...)
+		for _, k := range s.Knockouts {
+			shape.Clear(k...)
+		}
+		for i, set := range shape.pix {
+			r.pix[i] = r.pix[i] || set
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package raster converts panel features into coarse bitmaps, for previews
+// drawn with characters or pixels rather than vectors. It has no Gerber
+// dependencies.
+package raster
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Raster is a bitmap covering a rectangular area of a panel. Row 0 is at
+// the top of the area, as in most image formats.
+type Raster struct {
+	Cols, Rows int
+	Area       geometry.Rect
+	pix        []bool
+}
+
+// New constructs an empty raster of cols by rows pixels covering area
+func New(area geometry.Rect, cols, rows int) *Raster {
+	return &Raster{Cols: cols, Rows: rows, Area: area, pix: make([]bool, cols*rows)}
+}
+
+// At reports whether a pixel is set. Pixels outside the raster are unset.
+func (r *Raster) At(col, row int) bool {
+	if col < 0 || col >= r.Cols || row < 0 || row >= r.Rows {
+		return false
+	}
+	return r.pix[row*r.Cols+col]
+}
+
+// Centre returns the panel coordinates of the centre of a pixel
+func (r *Raster) Centre(col, row int) geometry.Point {
+	return geometry.Point{
+		X: r.Area.BottomLeft.X + (float64(col)+0.5)*r.Area.Width()/float64(r.Cols),
+		Y: r.Area.TopRight.Y - (float64(row)+0.5)*r.Area.Height()/float64(r.Rows),
+	}
+}
+
+// edge is a contour edge, with its ends ordered so that a is at the top
+type edge struct {
+	a, b geometry.Point
+}
+
+// Fill sets every pixel whose centre lies inside the closed contours under
+// the even-odd rule, so that contours nested within others are holes.
+// Pixels already set stay set.
+func (r *Raster) Fill(contours ...[]geometry.Point) {
+	r.paint(true, contours)
+}
+
+// Clear unsets every pixel that Fill would set for the same contours
+func (r *Raster) Clear(contours ...[]geometry.Point) {
+	r.paint(false, contours)
+}
+
+// paint sets every pixel inside the contours to v
+func (r *Raster) paint(v bool, contours [][]geometry.Point) {
+	r.Scan(func(col, row int) { r.pix[row*r.Cols+col] = v }, contours...)
+}
+
+// Scan calls visit with every pixel that Fill would set for the contours,
+// without changing the raster, so that other buffers of the same size can
+// be painted
+func (r *Raster) Scan(visit func(col, row int), contours ...[]geometry.Point) {
+	edges := []edge{}
+	for _, c := range contours {
+		for i, a := range c {
+			b := c[(i+1)%len(c)]
+			switch {
+			case a.Y > b.Y:
+				edges = append(edges, edge{a, b})
+			case b.Y > a.Y:
+				edges = append(edges, edge{b, a})
+			}
+		}
+	}
+	// scan from the top down, keeping a list of the edges spanning the
+	// current row
+	sort.Slice(edges, func(i, j int) bool { return edges[i].a.Y > edges[j].a.Y })
+	active := []edge{}
+	crossings := []float64{}
+	next := 0
+	for row := 0; row < r.Rows; row++ {
+		y := r.Centre(0, row).Y
+		for ; next < len(edges) && edges[next].a.Y > y; next++ {
+			active = append(active, edges[next])
+		}
+		crossings = crossings[:0]
+		kept := active[:0]
+		for _, e := range active {
+			if e.b.Y > y {
+				continue
+			}
+			kept = append(kept, e)
+			crossings = append(crossings, e.a.X+(y-e.a.Y)*(e.b.X-e.a.X)/(e.b.Y-e.a.Y))
+		}
+		active = kept
+		sort.Float64s(crossings)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			for col := r.column(crossings[i]); col < r.Cols; col++ {
+				if r.Centre(col, row).X >= crossings[i+1] {
+					break
+				}
+				visit(col, row)
+			}
+		}
+	}
+}
+
+// column returns the first column whose centre is at or to the right of x
+func (r *Raster) column(x float64) int {
+	pitch := r.Area.Width() / float64(r.Cols)
+	col := int(math.Ceil((x-r.Area.BottomLeft.X)/pitch - 0.5))
+	if col < 0 {
+		return 0
+	}
+	return col
+}
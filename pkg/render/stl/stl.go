@@ -0,0 +1,174 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package stl writes binary STL meshes of a panel plate and its cutouts, for
+// importing into mechanical CAD assemblies or slicing for 3D printing.
+// Circles are tessellated finely, to a configurable tolerance, so that
+// holes remain true when checked against hardware models.
+package stl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/plate"
+)
+
+// DefaultTolerance is the default maximum deviation of tessellated curves
+// from the true curves, in millimetres
+const DefaultTolerance = 0.005
+
+// Model is a 3D mesh model of a panel plate and its cutouts. Cutouts must
+// not overlap each other or the plate edges.
+type Model struct {
+	// Thickness of the plate, in millimetres
+	Thickness float64
+	// Tolerance is the maximum deviation of tessellated curves
+	Tolerance float64
+	// Diagnostics receives warnings about features that cannot be modelled
+	Diagnostics diag.Reporter
+
+	plate *plate.Plate
+}
+
+// NewModel constructs a new Model of a panel's plate
+func NewModel(p panel.Panel, thickness float64) *Model {
+	return &Model{
+		Thickness:   thickness,
+		Tolerance:   DefaultTolerance,
+		Diagnostics: diag.Logger{},
+		plate:       plate.New(p),
+	}
+}
+
+// AddFeatures adds the cutouts among the features to the model. Cutout
+// lines other than the panel outline are modelled as slots as wide as the
+// line is thick.
+func (m *Model) AddFeatures(feats []features.Feature) {
+	m.plate.AddFeatures(feats, m.Diagnostics)
+}
+
+// vertex is a point in 3D space
+type vertex [3]float64
+
+// triangle is a mesh facet, with its vertices anticlockwise when viewed
+// from outside the solid
+type triangle [3]vertex
+
+// normal returns the unit normal of a facet
+func (t triangle) normal() vertex {
+	u := vertex{t[1][0] - t[0][0], t[1][1] - t[0][1], t[1][2] - t[0][2]}
+	v := vertex{t[2][0] - t[0][0], t[2][1] - t[0][1], t[2][2] - t[0][2]}
+	n := vertex{u[1]*v[2] - u[2]*v[1], u[2]*v[0] - u[0]*v[2], u[0]*v[1] - u[1]*v[0]}
+	l := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])
+	if l == 0 {
+		return vertex{}
+	}
+	return vertex{n[0] / l, n[1] / l, n[2] / l}
+}
+
+func at(p geometry.Point, z float64) vertex {
+	return vertex{p.X, p.Y, z}
+}
+
+// walls returns the facets of the side walls extruded from a contour. The
+// material must lie to the left of each edge.
+func walls(contour []geometry.Point, thickness float64) []triangle {
+	tris := []triangle{}
+	for i, a := range contour {
+		b := contour[(i+1)%len(contour)]
+		tris = append(tris,
+			triangle{at(a, 0), at(b, 0), at(b, thickness)},
+			triangle{at(a, 0), at(b, thickness), at(a, thickness)},
+		)
+	}
+	return tris
+}
+
+// mesh returns the facets of the model
+func (m *Model) mesh() ([]triangle, error) {
+	if m.Thickness <= 0 {
+		return nil, fmt.Errorf("plate thickness must be greater than zero, not %v", m.Thickness)
+	}
+	outline := m.plate.Outline(m.Tolerance)
+	holes := m.plate.HoleContours(m.Tolerance)
+	faces, err := geometry.Triangulate(outline, holes)
+	if err != nil {
+		return nil, err
+	}
+	tris := []triangle{}
+	for _, f := range faces {
+		tris = append(tris,
+			triangle{at(f[0], m.Thickness), at(f[1], m.Thickness), at(f[2], m.Thickness)},
+			triangle{at(f[0], 0), at(f[2], 0), at(f[1], 0)},
+		)
+	}
+	tris = append(tris, walls(outline, m.Thickness)...)
+	for _, h := range holes {
+		// hole walls face into the hole, so run clockwise
+		if geometry.SignedArea(h) > 0 {
+			r := make([]geometry.Point, len(h))
+			for i, p := range h {
+				r[len(h)-1-i] = p
+			}
+			h = r
+		}
+		tris = append(tris, walls(h, m.Thickness)...)
+	}
+	return tris, nil
+}
+
+// WriteSTL writes the model in binary STL format
+func (m *Model) WriteSTL(w io.Writer) error {
+	tris, err := m.mesh()
+	if err != nil {
+		return fmt.Errorf("stl: %v", err)
+	}
+	header := make([]byte, 80)
+	copy(header, "panel model generated by github.com/jsleeio/frontpanels, units mm")
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(tris))); err != nil {
+		return err
+	}
+	for _, t := range tris {
+		facet := [12]float32{}
+		for i, v := range append([]vertex{t.normal()}, t[:]...) {
+			for j := range v {
+				facet[i*3+j] = float32(v[j])
+			}
+		}
+		if err := binary.Write(w, binary.LittleEndian, facet); err != nil {
+			return err
+		}
+		// attribute byte count, unused
+		if err := binary.Write(w, binary.LittleEndian, uint16(0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
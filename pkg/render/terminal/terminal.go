@@ -0,0 +1,202 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package terminal draws a rough character-cell sketch of the front of a
+// panel, for checking layouts without leaving the terminal. Braille mode
+// packs 2x4 dots into each character for finer detail; ASCII mode uses one
+// character per cell for terminals and fonts without braille patterns.
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+	"github.com/jsleeio/frontpanels/pkg/render/raster"
+)
+
+// Mode selects the characters used to draw the sketch
+type Mode int
+
+// Braille et al specify sketch modes
+const (
+	// Braille draws the board as raised dots, leaving holes and markings
+	// blank. This MUST be the first item.
+	Braille Mode = iota
+	// ASCII draws one character per cell: '.' for bare board, '#' for
+	// silkscreen and '+' for copper
+	ASCII
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (m Mode) String() string {
+	switch m {
+	case Braille:
+		return "braille"
+	case ASCII:
+		return "ascii"
+	}
+	panic(fmt.Sprintf("invalid Mode value (valid range is %d..%d): %d",
+		int(Braille), int(ASCII), int(m)))
+}
+
+// ParseMode converts a mode name, as returned by Mode.String, to a Mode
+func ParseMode(s string) (Mode, error) {
+	for m := Braille; m <= ASCII; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return Braille, fmt.Errorf("invalid terminal preview mode %q (valid values: braille ascii)", s)
+}
+
+// Options configures the sketch. The panel is scaled to fit within Columns
+// by Rows characters, assuming characters twice as tall as they are wide.
+type Options struct {
+	Mode    Mode
+	Columns int
+	Rows    int
+	// Tolerance is the maximum deviation of flattened curves, in
+	// millimetres. If zero, flatten.Coarse is used.
+	Tolerance float64
+}
+
+// DefaultOptions returns options fitting a standard 80x24 terminal
+func DefaultOptions() Options {
+	return Options{
+		Mode:      Braille,
+		Columns:   80,
+		Rows:      24,
+		Tolerance: flatten.Coarse,
+	}
+}
+
+// Terminal collects panel features for sketching
+type Terminal struct {
+	Options
+	*raster.Layers
+}
+
+// New constructs a new Terminal sketch of a panel with no features,
+// initially with the panel's default outline
+func New(p panel.Panel, opts Options) *Terminal {
+	return &Terminal{Options: opts, Layers: raster.NewLayers(p, flatten.Or(opts.Tolerance, flatten.Coarse))}
+}
+
+// rasters returns rasters of the board, its silkscreen and its copper,
+// with pixels of the given aspect ratio (height over width) scaled so that
+// the bounds fit within cols by rows pixels
+func (t *Terminal) rasters(cols, rows int, aspect float64) (board, silk, copper *raster.Raster) {
+	area := t.Bounds()
+	pitch := math.Max(area.Width()/float64(cols), area.Height()/(float64(rows)*aspect))
+	cols = int(math.Ceil(area.Width()/pitch - 1e-9))
+	rows = int(math.Ceil(area.Height()/(pitch*aspect) - 1e-9))
+	// extend the area to a whole number of pixels, keeping the board at the
+	// top left
+	area.TopRight.X = area.BottomLeft.X + float64(cols)*pitch
+	area.BottomLeft.Y = area.TopRight.Y - float64(rows)*pitch*aspect
+	board = raster.New(area, cols, rows)
+	t.FillBoard(board)
+	silk = raster.New(area, cols, rows)
+	raster.FillLayer(silk, t.Silkscreen)
+	copper = raster.New(area, cols, rows)
+	raster.FillLayer(copper, t.Copper)
+	return board, silk, copper
+}
+
+// braille returns the sketch in braille patterns, with a dot for each
+// pixel of bare board
+func (t *Terminal) braille() string {
+	board, silk, copper := t.rasters(t.Columns*2, t.Rows*4, 1)
+	// dot numbering of the Unicode braille patterns block, by row and column
+	// within each character
+	bits := [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+	var b strings.Builder
+	for row := 0; row < board.Rows; row += 4 {
+		line := []rune{}
+		for col := 0; col < board.Cols; col += 2 {
+			r := rune(0x2800)
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 2; x++ {
+					if board.At(col+x, row+y) && !silk.At(col+x, row+y) && !copper.At(col+x, row+y) {
+						r |= bits[y][x]
+					}
+				}
+			}
+			line = append(line, r)
+		}
+		b.WriteString(strings.TrimRight(string(line), "⠀"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ascii returns the sketch with one character per cell. Each cell is
+// sampled several times, so that markings narrower than a cell still show.
+func (t *Terminal) ascii() string {
+	const n = 4
+	board, silk, copper := t.rasters(t.Columns*n, t.Rows*n, 2)
+	var b strings.Builder
+	for row := 0; row < board.Rows; row += n {
+		line := []byte{}
+		for col := 0; col < board.Cols; col += n {
+			var area int
+			var isSilk, isCopper bool
+			for y := row; y < row+n; y++ {
+				for x := col; x < col+n; x++ {
+					if board.At(x, y) {
+						area++
+						isSilk = isSilk || silk.At(x, y)
+						isCopper = isCopper || copper.At(x, y)
+					}
+				}
+			}
+			c := byte('.')
+			switch {
+			case area < n*n/2:
+				c = ' '
+			case isCopper:
+				c = '+'
+			case isSilk:
+				c = '#'
+			}
+			line = append(line, c)
+		}
+		b.WriteString(strings.TrimRight(string(line), " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Write writes the sketch
+func (t *Terminal) Write(w io.Writer) error {
+	if t.Columns < 1 || t.Rows < 1 {
+		return fmt.Errorf("terminal: sketch size must be at least 1x1, not %dx%d", t.Columns, t.Rows)
+	}
+	s := t.braille()
+	if t.Mode == ASCII {
+		s = t.ascii()
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package textpath
+
+import (
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Limits are the smallest text a fab will reproduce legibly, by the
+// purpose of the text, for checking text against. The fab is named in
+// diagnostics by its String method. fab.Profile implements it.
+type Limits interface {
+	MinTextHeight(features.Purpose) float64
+	MinFeatureWidth(features.Purpose) float64
+	String() string
+}
+
+// checked returns the text of a text or placeholder feature subject to
+// minimum size checks, or nil for other features and for cutout and
+// annotation text, which are never fabricated as markings
+func checked(f features.Feature) *features.Text {
+	var t *features.Text
+	switch v := f.(type) {
+	case *features.Text:
+		t = v
+	case *features.Placeholder:
+		t = &v.Text
+	default:
+		return nil
+	}
+	if t.Purpose == features.Cutout || t.Purpose == features.Annotation {
+		return nil
+	}
+	return t
+}
+
+// ScaleText returns a copy of the features with text smaller than the fab
+// can reproduce legibly enlarged to its minimum cap height, and stroke text
+// drawn narrower than its minimum feature width thickened to it, reporting
+// a Warning for each change. Text is enlarged about its origin, so that its
+// alignment is kept.
+func ScaleText(feats []features.Feature, l Limits, r diag.Reporter) []features.Feature {
+	scaled := make([]features.Feature, len(feats))
+	for i, f := range feats {
+		scaled[i] = f
+		t := checked(f)
+		if t == nil {
+			continue
+		}
+		text := *t
+		changed := false
+		if h, minimum := CapHeight(&text), l.MinTextHeight(text.Purpose); h < minimum {
+			diag.Warnf(r, f, "text %q enlarged from %.2fmm to the %s %s minimum of %.2fmm", text.Text, h, l, text.Purpose, minimum)
+			text.CapHeight = minimum
+			changed = true
+		}
+		if text.Mode == features.StrokeText {
+			if w, minimum := StrokeWidth(&text), l.MinFeatureWidth(text.Purpose); w < minimum {
+				diag.Warnf(r, f, "text %q strokes thickened from %.3fmm to the %s %s minimum of %.3fmm", text.Text, w, l, text.Purpose, minimum)
+				text.StrokeWidth = minimum
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if _, ok := f.(*features.Placeholder); ok {
+			scaled[i] = &features.Placeholder{Text: text}
+		} else {
+			scaled[i] = &text
+		}
+	}
+	return scaled
+}
+
+// CheckTextOverflow reports a Warning for each text feature extending
+// beyond a panel outline, as text does that has been enlarged to a fab's
+// minimum on a narrow panel. Placeholders are not checked, as their final
+// text is not yet known.
+func CheckTextOverflow(feats []features.Feature, outline []geometry.Point, r diag.Reporter) {
+	for _, f := range feats {
+		t, ok := f.(*features.Text)
+		if !ok || checked(f) == nil {
+			continue
+		}
+		bl, tr, err := Bounds(t)
+		if err != nil {
+			diag.Warnf(r, f, "text %q: %v", t.Text, err)
+			continue
+		}
+		for _, corner := range []geometry.Point{bl, {X: tr.X, Y: bl.Y}, tr, {X: bl.X, Y: tr.Y}} {
+			if !geometry.Contains(outline, corner) {
+				diag.Warnf(r, f, "text %q at (%.2f, %.2f) extends beyond the panel outline", t.Text, t.Origin.X, t.Origin.Y)
+				break
+			}
+		}
+	}
+}
+
+// CheckText reports a diagnostic for each text feature smaller than the fab
+// can reproduce legibly, or drawn with strokes narrower than its minimum
+// feature width. Copper text that is too small is an Error, as it is
+// etched rather than printed and fails entirely; silkscreen text is merely
+// a Warning.
+func CheckText(feats []features.Feature, l Limits, r diag.Reporter) {
+	for _, f := range feats {
+		t := checked(f)
+		if t == nil {
+			continue
+		}
+		severity := diag.Warning
+		if t.Purpose == features.ExposedCopper || t.Purpose == features.MaskedCopper {
+			severity = diag.Error
+		}
+		if h, minimum := CapHeight(t), l.MinTextHeight(t.Purpose); h < minimum {
+			r.Report(diag.Diagnostic{
+				Severity: severity,
+				Message:  fmt.Sprintf("text %q is %.2fmm high, below the %s %s minimum of %.2fmm", t.Text, h, l, t.Purpose, minimum),
+				Feature:  f,
+			})
+		}
+		if t.Mode != features.StrokeText {
+			continue
+		}
+		if w, minimum := StrokeWidth(t), l.MinFeatureWidth(t.Purpose); w < minimum {
+			r.Report(diag.Diagnostic{
+				Severity: severity,
+				Message:  fmt.Sprintf("text %q has %.3fmm strokes, below the %s %s minimum of %.3fmm", t.Text, w, l, t.Purpose, minimum),
+				Feature:  f,
+			})
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package textpath
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Hershey fonts are the public domain single-stroke fonts drawn by Allen
+// Hershey, widely distributed in JHF format. Each glyph is a line holding a
+// five-digit glyph number, a three-digit vertex count, and that many pairs
+// of characters, each character a coordinate offset from 'R'. The first
+// pair holds the left and right bounds of the glyph, and a pair of " R"
+// lifts the pen. Long glyphs continue onto following lines. Y increases
+// downwards.
+
+// hersheyFirst is the rune of the first glyph in a JHF file; the files in
+// common circulation hold the printable ASCII characters in order
+const hersheyFirst = ' '
+
+// hersheyBaseline and hersheyCapHeight are used for fonts without an H to
+// measure, and suit the Hershey roman fonts
+const (
+	hersheyBaseline  = 9.0
+	hersheyCapHeight = 21.0
+)
+
+// hersheyGlyph is a glyph as read from a JHF file, in Hershey coordinates
+type hersheyGlyph struct {
+	left, right float64
+	strokes     [][]geometry.Point
+}
+
+// parseHersheyGlyph parses the vertex pairs of a glyph
+func parseHersheyGlyph(pairs string) (hersheyGlyph, error) {
+	if len(pairs) < 2 || len(pairs)%2 != 0 {
+		return hersheyGlyph{}, errors.New("truncated vertex data")
+	}
+	coord := func(c byte) float64 { return float64(int(c) - int('R')) }
+	g := hersheyGlyph{left: coord(pairs[0]), right: coord(pairs[1])}
+	stroke := []geometry.Point{}
+	for i := 2; i < len(pairs); i += 2 {
+		if pairs[i:i+2] == " R" {
+			if len(stroke) > 0 {
+				g.strokes = append(g.strokes, stroke)
+			}
+			stroke = []geometry.Point{}
+			continue
+		}
+		stroke = append(stroke, geometry.Point{X: coord(pairs[i]), Y: coord(pairs[i+1])})
+	}
+	if len(stroke) > 0 {
+		g.strokes = append(g.strokes, stroke)
+	}
+	return g, nil
+}
+
+// readHershey reads the glyphs of a JHF file, in file order
+func readHershey(r io.Reader) ([]hersheyGlyph, error) {
+	glyphs := []hersheyGlyph{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		if len(entry) < 8 {
+			return nil, fmt.Errorf("line %d: truncated glyph header", line)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(entry[5:8]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid vertex count %q", line, entry[5:8])
+		}
+		// glyphs with many vertices are wrapped onto following lines
+		for len(entry) < 8+2*n && scanner.Scan() {
+			line++
+			entry += strings.TrimRight(scanner.Text(), "\r")
+		}
+		if len(entry) < 8+2*n {
+			return nil, fmt.Errorf("line %d: expected %d vertices", line, n)
+		}
+		g, err := parseHersheyGlyph(entry[8 : 8+2*n])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		glyphs = append(glyphs, g)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(glyphs) == 0 {
+		return nil, errors.New("no glyphs found")
+	}
+	return glyphs, nil
+}
+
+// hersheyMetrics returns the baseline and cap height of a font, measured
+// from its H where there is one
+func hersheyMetrics(glyphs []hersheyGlyph) (baseline, capHeight float64) {
+	index := int('H' - hersheyFirst)
+	if index >= len(glyphs) || len(glyphs[index].strokes) == 0 {
+		return hersheyBaseline, hersheyCapHeight
+	}
+	top, bottom := math.Inf(1), math.Inf(-1)
+	for _, s := range glyphs[index].strokes {
+		for _, p := range s {
+			top = math.Min(top, p.Y)
+			bottom = math.Max(bottom, p.Y)
+		}
+	}
+	if bottom <= top {
+		return hersheyBaseline, hersheyCapHeight
+	}
+	return bottom, bottom - top
+}
+
+// ParseHershey reads a stroke font in Hershey JHF format. Glyphs are
+// assigned to runes in order from the space character.
+func ParseHershey(name string, r io.Reader) (*StrokeFont, error) {
+	glyphs, err := readHershey(r)
+	if err != nil {
+		return nil, fmt.Errorf("hershey font %s: %v", name, err)
+	}
+	baseline, capHeight := hersheyMetrics(glyphs)
+	f := &StrokeFont{Name: name, CapHeight: capHeight, Glyphs: map[rune]StrokeGlyph{}, Fallback: '?'}
+	for i, g := range glyphs {
+		// move the origin to the left bound on the baseline, with Y upwards
+		strokes := make([][]geometry.Point, len(g.strokes))
+		for j, s := range g.strokes {
+			strokes[j] = make([]geometry.Point, len(s))
+			for k, p := range s {
+				strokes[j][k] = geometry.Point{X: p.X - g.left, Y: baseline - p.Y}
+			}
+		}
+		f.Glyphs[hersheyFirst+rune(i)] = StrokeGlyph{Strokes: strokes, Advance: g.right - g.left}
+	}
+	return f, nil
+}
+
+// LoadHershey reads a stroke font from a Hershey JHF file, named for the
+// file without its extension, and registers it for use by text features
+func LoadHershey(filename string) (*StrokeFont, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	f, err := ParseHershey(name, file)
+	if err != nil {
+		return nil, err
+	}
+	RegisterStrokeFont(f)
+	return f, nil
+}
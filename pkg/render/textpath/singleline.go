@@ -0,0 +1,127 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package textpath
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// singleline is a simple built-in stroke font, drawn on a grid four units
+// wide with capital letters six units high. Each glyph is a list of strokes
+// separated by semicolons, each stroke a list of x,y points. Lower case
+// letters are drawn as capitals.
+var singleline = map[rune]string{
+	'A':  "0,0 0,4 2,6 4,4 4,0; 0,3 4,3",
+	'B':  "0,0 0,6 3,6 4,5 4,4 3,3 0,3; 3,3 4,2 4,1 3,0 0,0",
+	'C':  "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1",
+	'D':  "0,0 0,6 2,6 4,4 4,2 2,0 0,0",
+	'E':  "4,6 0,6 0,0 4,0; 0,3 3,3",
+	'F':  "4,6 0,6 0,0; 0,3 3,3",
+	'G':  "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1 4,3 2,3",
+	'H':  "0,0 0,6; 4,0 4,6; 0,3 4,3",
+	'I':  "1,6 3,6; 2,6 2,0; 1,0 3,0",
+	'J':  "4,6 4,1 3,0 1,0 0,1",
+	'K':  "0,0 0,6; 4,6 0,2; 1,3 4,0",
+	'L':  "0,6 0,0 4,0",
+	'M':  "0,0 0,6 2,3 4,6 4,0",
+	'N':  "0,0 0,6 4,0 4,6",
+	'O':  "1,0 0,1 0,5 1,6 3,6 4,5 4,1 3,0 1,0",
+	'P':  "0,0 0,6 3,6 4,5 4,4 3,3 0,3",
+	'Q':  "1,0 0,1 0,5 1,6 3,6 4,5 4,1 3,0 1,0; 2,2 4,0",
+	'R':  "0,0 0,6 3,6 4,5 4,4 3,3 0,3; 2,3 4,0",
+	'S':  "4,5 3,6 1,6 0,5 0,4 1,3 3,3 4,2 4,1 3,0 1,0 0,1",
+	'T':  "0,6 4,6; 2,6 2,0",
+	'U':  "0,6 0,1 1,0 3,0 4,1 4,6",
+	'V':  "0,6 2,0 4,6",
+	'W':  "0,6 1,0 2,3 3,0 4,6",
+	'X':  "0,0 4,6; 0,6 4,0",
+	'Y':  "0,6 2,3 4,6; 2,3 2,0",
+	'Z':  "0,6 4,6 0,0 4,0",
+	'0':  "1,0 0,1 0,5 1,6 3,6 4,5 4,1 3,0 1,0; 4,5 0,1",
+	'1':  "1,5 2,6 2,0; 1,0 3,0",
+	'2':  "0,5 1,6 3,6 4,5 4,4 0,0 4,0",
+	'3':  "0,5 1,6 3,6 4,5 4,4 3,3 4,2 4,1 3,0 1,0 0,1; 1,3 3,3",
+	'4':  "3,0 3,6 0,2 4,2",
+	'5':  "4,6 0,6 0,3 3,3 4,2 4,1 3,0 1,0 0,1",
+	'6':  "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1 4,2 3,3 0,3",
+	'7':  "0,6 4,6 1,0",
+	'8':  "1,3 0,4 0,5 1,6 3,6 4,5 4,4 3,3 1,3 0,2 0,1 1,0 3,0 4,1 4,2 3,3",
+	'9':  "4,3 1,3 0,4 0,5 1,6 3,6 4,5 4,1 3,0 1,0 0,1",
+	' ':  "",
+	'.':  "2,0",
+	',':  "2,0 1,-1",
+	':':  "2,1; 2,4",
+	';':  "2,4; 2,1 1,0",
+	'!':  "2,6 2,2; 2,0",
+	'?':  "0,5 1,6 3,6 4,5 4,4 2,3 2,2; 2,0",
+	'\'': "2,6 2,5",
+	'"':  "1,6 1,5; 3,6 3,5",
+	'-':  "1,3 3,3",
+	'+':  "0,3 4,3; 2,1 2,5",
+	'=':  "0,2 4,2; 0,4 4,4",
+	'*':  "2,1 2,5; 0,2 4,4; 0,4 4,2",
+	'/':  "0,0 4,6",
+	'\\': "0,6 4,0",
+	'|':  "2,0 2,6",
+	'_':  "0,-1 4,-1",
+	'(':  "3,6 1,4 1,2 3,0",
+	')':  "1,6 3,4 3,2 1,0",
+	'[':  "3,6 1,6 1,0 3,0",
+	']':  "1,6 3,6 3,0 1,0",
+	'<':  "4,5 0,3 4,1",
+	'>':  "0,5 4,3 0,1",
+	'^':  "1,4 2,6 3,4",
+	'~':  "0,3 1,4 3,2 4,3",
+	'#':  "1,0 1,6; 3,0 3,6; 0,2 4,2; 0,4 4,4",
+	'%':  "0,0 4,6; 1,5; 3,1",
+	'$':  "4,5 3,6 1,6 0,5 0,4 1,3 3,3 4,2 4,1 3,0 1,0 0,1; 2,7 2,-1",
+	'&':  "4,0 1,4 1,5 2,6 3,5 3,4 0,2 0,1 1,0 2,0 4,2",
+	'@':  "3,2 3,4 1,4 1,2 3,2 4,3 4,5 3,6 1,6 0,5 0,1 1,0 4,0",
+}
+
+// parseStrokes parses the strokes of a glyph in the format of singleline
+func parseStrokes(s string) [][]geometry.Point {
+	strokes := [][]geometry.Point{}
+	for _, stroke := range strings.Split(s, ";") {
+		points := []geometry.Point{}
+		for _, xy := range strings.Fields(stroke) {
+			v := strings.Split(xy, ",")
+			x, _ := strconv.ParseFloat(v[0], 64)
+			y, _ := strconv.ParseFloat(v[1], 64)
+			points = append(points, geometry.Point{X: x, Y: y})
+		}
+		if len(points) > 0 {
+			strokes = append(strokes, points)
+		}
+	}
+	return strokes
+}
+
+func init() {
+	f := &StrokeFont{Name: StrokeFontName, CapHeight: 6, Glyphs: map[rune]StrokeGlyph{}, Fallback: '?'}
+	for r, s := range singleline {
+		f.Glyphs[r] = StrokeGlyph{Strokes: parseStrokes(s), Advance: 6}
+	}
+	RegisterStrokeFont(f)
+}
@@ -0,0 +1,211 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package textpath
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// StrokeFontName is the default font for stroke text
+const StrokeFontName = "singleline"
+
+// StrokeGlyph is a glyph of a stroke font
+type StrokeGlyph struct {
+	// Strokes are polylines in font units, with the origin at the left end
+	// of the baseline. A stroke of a single point is a dot.
+	Strokes [][]geometry.Point
+	// Advance is the distance to the origin of the next glyph
+	Advance float64
+}
+
+// StrokeFont is a single-stroke font, whose glyphs are drawn as lines rather
+// than filled outlines
+type StrokeFont struct {
+	Name string
+	// CapHeight is the height of capital letters above the baseline, in font
+	// units
+	CapHeight float64
+	Glyphs    map[rune]StrokeGlyph
+	// Fallback is drawn for runes without a glyph
+	Fallback rune
+}
+
+// strokeFonts are the registered stroke fonts, by name
+var strokeFonts = map[string]*StrokeFont{}
+
+// RegisterStrokeFont makes a stroke font available to text features
+func RegisterStrokeFont(f *StrokeFont) {
+	strokeFonts[f.Name] = f
+}
+
+// StrokeFonts returns the names of the available stroke fonts, sorted
+func StrokeFonts() []string {
+	names := []string{}
+	for name := range strokeFonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// glyph returns the glyph for a rune, falling back to upper case and then
+// to the font's fallback glyph
+func (f *StrokeFont) glyph(r rune) (StrokeGlyph, bool) {
+	for _, c := range []rune{r, []rune(strings.ToUpper(string(r)))[0], f.Fallback} {
+		if g, ok := f.Glyphs[c]; ok {
+			return g, true
+		}
+	}
+	return StrokeGlyph{}, false
+}
+
+// Strokes renders a stroke text feature as polylines, in panel coordinates.
+// Mirrored text reads correctly when viewed from the other side of the
+// panel.
+func Strokes(t *features.Text, mirror bool) ([][]geometry.Point, error) {
+	f, ok := strokeFonts[Font(t)]
+	if !ok {
+		return nil, fmt.Errorf("invalid stroke font %q (valid values: %s)", Font(t), strings.Join(StrokeFonts(), " "))
+	}
+	scale := CapHeight(t) / f.CapHeight
+	// lay the glyphs out from the origin in font units, then align
+	strokes := [][]geometry.Point{}
+	x := 0.0
+	for _, r := range t.Text {
+		g, ok := f.glyph(r)
+		if !ok {
+			continue
+		}
+		for _, s := range g.Strokes {
+			moved := make([]geometry.Point, len(s))
+			for i, p := range s {
+				moved[i] = p.Add(geometry.Point{X: x})
+			}
+			strokes = append(strokes, moved)
+		}
+		x += g.Advance
+	}
+	// align on the extent of the strokes, excluding the side bearings of
+	// the first and last glyphs
+	left, right := math.Inf(1), math.Inf(-1)
+	for _, s := range strokes {
+		for _, p := range s {
+			left, right = math.Min(left, p.X), math.Max(right, p.X)
+		}
+	}
+	if len(strokes) == 0 {
+		left, right = 0, 0
+	}
+	offset := geometry.Point{X: -left}
+	switch t.Alignment {
+	case features.TopCentre, features.Centre, features.BottomCentre:
+		offset.X = -(left + right) / 2
+	case features.TopRight, features.CentreRight, features.BottomRight:
+		offset.X = -right
+	}
+	switch t.Alignment {
+	case features.TopLeft, features.TopCentre, features.TopRight:
+		offset.Y = -f.CapHeight
+	case features.CentreLeft, features.Centre, features.CentreRight:
+		offset.Y = -f.CapHeight / 2
+	}
+	for _, s := range strokes {
+		for i, p := range s {
+			p = p.Add(offset).Scale(scale)
+			if mirror {
+				p.X = -p.X
+			}
+			s[i] = t.Origin.Add(p.Rotate(t.Rotate * 180 / math.Pi))
+		}
+	}
+	return strokes, nil
+}
+
+// StrokeWidth returns the line thickness of a stroke text feature, in
+// millimetres
+func StrokeWidth(t *features.Text) float64 {
+	if t.StrokeWidth > 0 {
+		return t.StrokeWidth
+	}
+	return CapHeight(t) / 10
+}
+
+// StrokeLines renders a stroke text feature as Line features, with the
+// purpose and side of the text. Text on the bottom side is mirrored.
+func StrokeLines(t *features.Text) ([]features.Feature, error) {
+	strokes, err := Strokes(t, t.Side == features.BottomSide)
+	if err != nil {
+		return nil, err
+	}
+	lines := []features.Feature{}
+	for _, s := range strokes {
+		// a single point is a dot, drawn as a zero-length line
+		for i := 0; i == 0 || i+1 < len(s); i++ {
+			end := s[i]
+			if i+1 < len(s) {
+				end = s[i+1]
+			}
+			l := features.NewLine(s[i], end, StrokeWidth(t))
+			l.SetPurpose(t.Purpose)
+			l.SetSide(t.Side)
+			l.Meta = t.Meta
+			lines = append(lines, l)
+		}
+	}
+	return lines, nil
+}
+
+// ExpandStrokeText returns the features with each stroke text feature
+// replaced by the Line features it is drawn with, so that every renderer
+// can draw it, including stroke text knocked out of an inversion. Other
+// features are returned unchanged.
+func ExpandStrokeText(feats []features.Feature) ([]features.Feature, error) {
+	expanded := make([]features.Feature, 0, len(feats))
+	for _, f := range feats {
+		if inv, ok := f.(*features.Inversion); ok && len(inv.Knockouts) > 0 {
+			knockouts, err := ExpandStrokeText(inv.Knockouts)
+			if err != nil {
+				return nil, err
+			}
+			c := *inv
+			c.Knockouts = knockouts
+			expanded = append(expanded, &c)
+			continue
+		}
+		t, ok := f.(*features.Text)
+		if !ok || t.Mode != features.StrokeText {
+			expanded = append(expanded, f)
+			continue
+		}
+		lines, err := StrokeLines(t)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, lines...)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package textpath converts text features to outline contours using the
+// same font as the Gerber renderer, for output formats without usable text
+// support of their own, such as VCV Rack panels and plotters. It also draws
+// stroke text with single-stroke fonts, for engraving, including Hershey
+// fonts loaded from JHF files.
+package textpath
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gmlewis/go-fonts/fonts"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+
+	// fonts available to text features must be registered with go-fonts
+	_ "github.com/gmlewis/go-fonts/fonts/bitstreamverasansmono_bold"
+	_ "github.com/gmlewis/go-fonts/fonts/bitstreamverasansmono_roman"
+	_ "github.com/gmlewis/go-fonts/fonts/freemono"
+	_ "github.com/gmlewis/go-fonts/fonts/freemonobold"
+	_ "github.com/gmlewis/go-fonts/fonts/freesans"
+	_ "github.com/gmlewis/go-fonts/fonts/freesansbold"
+	_ "github.com/gmlewis/go-fonts/fonts/freeserif"
+	_ "github.com/gmlewis/go-fonts/fonts/freeserifbold"
+)
+
+// FontName is the default font for text features
+const FontName = "bitstreamverasansmono_bold"
+
+// Fonts returns the names of the available fonts, sorted
+func Fonts() []string {
+	names := []string{}
+	for name := range fonts.Fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateFont returns an error if a font is not available for a text
+// mode. An empty name selects the default font for the mode.
+func ValidateFont(name string, mode features.TextMode) error {
+	if name == "" {
+		return nil
+	}
+	if mode == features.StrokeText {
+		if _, ok := strokeFonts[name]; !ok {
+			return fmt.Errorf("invalid stroke font %q (valid values: %s)", name, strings.Join(StrokeFonts(), " "))
+		}
+		return nil
+	}
+	if _, ok := fonts.Fonts[name]; !ok {
+		return fmt.Errorf("invalid font %q (valid values: %s)", name, strings.Join(Fonts(), " "))
+	}
+	return nil
+}
+
+// Font returns the name of the font for a text feature
+func Font(t *features.Text) string {
+	if t.Font != "" {
+		return t.Font
+	}
+	if t.Mode == features.StrokeText {
+		return StrokeFontName
+	}
+	return FontName
+}
+
+// capRatio returns the height of capital letters in a font, as a fraction
+// of its em size
+func capRatio(name string) float64 {
+	font, ok := fonts.Fonts[name]
+	if !ok {
+		font = fonts.Fonts[FontName]
+	}
+	h, ok := font.Glyphs['H']
+	if !ok || font.UnitsPerEm == 0 {
+		// typical of sans-serif fonts
+		return 0.7
+	}
+	return (h.MBB.Max[1] - h.MBB.Min[1]) / font.UnitsPerEm
+}
+
+// EmSize returns the em size of a text feature's font, in millimetres
+func EmSize(t *features.Text) float64 {
+	if t.CapHeight > 0 {
+		return t.CapHeight / capRatio(Font(t))
+	}
+	return geometry.PointsToMM(t.Size)
+}
+
+// CapHeight returns the height of capital letters of a text feature, in
+// millimetres
+func CapHeight(t *features.Text) float64 {
+	if t.CapHeight > 0 {
+		return t.CapHeight
+	}
+	return geometry.PointsToMM(t.Size) * capRatio(Font(t))
+}
+
+// Contour is a closed outline of part of a glyph. Dark contours are filled;
+// clear contours are holes in the dark contours enclosing them, eg. the
+// middle of an O.
+type Contour struct {
+	Points []geometry.Point
+	Dark   bool
+}
+
+// Options returns the go-fonts options positioning a text feature relative
+// to its origin
+func Options(t *features.Text) *fonts.TextOpts {
+	m := map[features.Alignment]fonts.TextOpts{
+		features.TopLeft:      fonts.TopLeft,
+		features.CentreLeft:   fonts.CenterLeft,
+		features.BottomLeft:   fonts.BottomLeft,
+		features.TopCentre:    fonts.TopCenter,
+		features.Centre:       fonts.Center,
+		features.BottomCentre: fonts.BottomCenter,
+		features.TopRight:     fonts.TopRight,
+		features.CentreRight:  fonts.CenterRight,
+		features.BottomRight:  fonts.BottomRight,
+	}
+	opts, ok := m[t.Alignment]
+	if !ok {
+		panic("invalid text alignment value")
+	}
+	opts.Rotate = t.Rotate
+	return &opts
+}
+
+// Contours renders a text feature as glyph outlines, in panel coordinates.
+// Mirrored text reads correctly when viewed from the other side of the
+// panel.
+func Contours(t *features.Text, mirror bool) ([]Contour, error) {
+	yScale := EmSize(t)
+	xScale := yScale
+	if mirror {
+		xScale = -xScale
+	}
+	render, err := fonts.Text(t.Origin.X, t.Origin.Y, xScale, yScale, t.Text, Font(t), Options(t))
+	if err != nil {
+		return nil, err
+	}
+	contours := []Contour{}
+	for _, poly := range render.Polygons {
+		c := Contour{Dark: poly.Dark}
+		for _, pt := range poly.Pts {
+			c.Points = append(c.Points, geometry.Point{X: pt[0], Y: pt[1]})
+		}
+		contours = append(contours, c)
+	}
+	return contours, nil
+}
+
+// Bounds returns the bottom-left and top-right corners of the axis-aligned
+// box enclosing the glyphs of a text feature, in panel coordinates
+func Bounds(t *features.Text) (bl, tr geometry.Point, err error) {
+	points := []geometry.Point{}
+	pad := 0.0
+	if t.Mode == features.StrokeText {
+		strokes, err := Strokes(t, false)
+		if err != nil {
+			return bl, tr, err
+		}
+		for _, s := range strokes {
+			points = append(points, s...)
+		}
+		pad = StrokeWidth(t) / 2
+	} else {
+		contours, err := Contours(t, false)
+		if err != nil {
+			return bl, tr, err
+		}
+		for _, c := range contours {
+			points = append(points, c.Points...)
+		}
+	}
+	if len(points) == 0 {
+		return t.Origin, t.Origin, nil
+	}
+	bl, tr = points[0], points[0]
+	for _, p := range points[1:] {
+		bl = geometry.Point{X: math.Min(bl.X, p.X), Y: math.Min(bl.Y, p.Y)}
+		tr = geometry.Point{X: math.Max(tr.X, p.X), Y: math.Max(tr.Y, p.Y)}
+	}
+	return bl.Sub(geometry.Point{X: pad, Y: pad}), tr.Add(geometry.Point{X: pad, Y: pad}), nil
+}
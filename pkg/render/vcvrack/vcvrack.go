@@ -0,0 +1,372 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package vcvrack writes panels as SVG files following VCV Rack's panel
+// conventions, so that hardware module panels and their virtual
+// counterparts can be generated from the same description.
+//
+// The SVG is sized in millimetres, which Rack converts at its fixed 75px
+// per inch. Rack cannot render SVG text, so text is converted to outlines.
+// Cutouts other than mounting holes are written as placeholder circles in
+// a hidden "components" layer, coloured according to VCV's helper script
+// conventions so that C++ widget positions can be generated from them.
+package vcvrack
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	"github.com/jsleeio/frontpanels/pkg/render/textpath"
+)
+
+// Component is a kind of Rack widget, indicated by placeholder colour
+type Component int
+
+// Param et al are the component kinds understood by VCV's helper script
+const (
+	Param Component = iota
+	Input
+	Output
+	Light
+	Custom
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (c Component) String() string {
+	switch c {
+	case Param:
+		return "param"
+	case Input:
+		return "input"
+	case Output:
+		return "output"
+	case Light:
+		return "light"
+	case Custom:
+		return "widget"
+	}
+	panic(fmt.Sprintf("invalid Component value (valid range is %d..%d): %d",
+		int(Param), int(Custom), int(c)))
+}
+
+// colour returns the placeholder colour for a component kind
+func (c Component) colour() string {
+	return map[Component]string{
+		Param:  "#ff0000",
+		Input:  "#00ff00",
+		Output: "#0000ff",
+		Light:  "#ff00ff",
+		Custom: "#ffff00",
+	}[c]
+}
+
+// Classify guesses the kind of component fitted to a cutout from its size:
+// small holes are LEDs, jack-sized holes are inputs, and anything larger is
+// a knob or switch
+func Classify(c *features.Circle) Component {
+	switch d := c.Radius * 2; {
+	case d <= 5.5:
+		return Light
+	case d <= 6.5:
+		return Input
+	}
+	return Param
+}
+
+// Panel collects panel features as SVG elements
+type Panel struct {
+	// Background and Foreground are the SVG colours of the panel face and
+	// its markings
+	Background, Foreground string
+	// Copper is the SVG colour of exposed copper features
+	Copper string
+	// Classify determines the kind of component fitted to each cutout
+	Classify func(*features.Circle) Component
+	// Diagnostics receives warnings about features that cannot be exported
+	Diagnostics diag.Reporter
+
+	width, height  float64
+	mountingHoles  []geometry.Point
+	elements       []string
+	components     []string
+	componentCount map[Component]int
+}
+
+// NewPanel constructs a new, blank Panel
+func NewPanel(p panel.Panel) *Panel {
+	return &Panel{
+		Background:     "#e6e6e6",
+		Foreground:     "#000000",
+		Copper:         "#c8a040",
+		Classify:       Classify,
+		Diagnostics:    diag.Logger{},
+		width:          p.Width(),
+		height:         p.Height(),
+		mountingHoles:  p.MountingHoles(),
+		componentCount: map[Component]int{},
+	}
+}
+
+// xy converts a panel point to SVG coordinates, in which Y increases
+// downwards from the top of the panel
+func (vp *Panel) xy(p geometry.Point) (string, string) {
+	return geometry.FormatNumber(p.X, 4), geometry.FormatNumber(vp.height-p.Y, 4)
+}
+
+// AddElement adds an SVG element to the panel face, for custom feature
+// render functions. Use Coordinates to convert panel points.
+func (vp *Panel) AddElement(element string) {
+	vp.elements = append(vp.elements, element)
+}
+
+// Coordinates converts a panel point to SVG coordinates, formatted for
+// elements given to AddElement
+func (vp *Panel) Coordinates(p geometry.Point) (x, y string) {
+	return vp.xy(p)
+}
+
+// CustomRenderer renders a custom feature onto a panel with AddElement, for
+// custom feature kinds whose standard-feature expansion cannot describe
+// them well
+type CustomRenderer func(vp *Panel, f features.Custom) error
+
+// customRenderers are the registered custom feature render functions, by
+// kind
+var customRenderers = map[string]CustomRenderer{}
+
+// RegisterCustomRenderer makes panels render custom features of the given
+// kind with fn, rather than rendering their expansions. It is intended to be
+// called from the init function of the package defining the kind.
+func RegisterCustomRenderer(kind string, fn CustomRenderer) {
+	customRenderers[kind] = fn
+}
+
+// HasCustomRenderer reports whether custom features of the given kind have
+// a registered render function
+func HasCustomRenderer(kind string) bool {
+	_, ok := customRenderers[kind]
+	return ok
+}
+
+// path converts closed contours to SVG path data
+func (vp *Panel) path(contours ...[]geometry.Point) string {
+	var b strings.Builder
+	for _, c := range contours {
+		for i, p := range c {
+			x, y := vp.xy(p)
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&b, "%s%s %s", cmd, x, y)
+		}
+		b.WriteString("Z")
+	}
+	return b.String()
+}
+
+// colour returns the fill colour for a feature, or false if the feature is
+// not visible on the front of the panel
+func (vp *Panel) colour(f features.Feature) (string, bool) {
+	if s, ok := f.(features.Sided); ok && s.GetSide() == features.BottomSide {
+		return "", false
+	}
+	switch f.GetPurpose() {
+	case features.Marking:
+		return vp.Foreground, true
+	case features.ExposedCopper, features.MaskOpening:
+		return vp.Copper, true
+	}
+	return "", false
+}
+
+// isMountingHole reports whether a circle is one of the panel's mounting
+// holes, which Rack draws screws over rather than components
+func (vp *Panel) isMountingHole(c *features.Circle) bool {
+	for _, h := range vp.mountingHoles {
+		if h.Distance(c.Origin) < 0.01 {
+			return true
+		}
+	}
+	return false
+}
+
+// component adds a placeholder circle to the components layer
+func (vp *Panel) component(c *features.Circle) {
+	kind := vp.Classify(c)
+	vp.componentCount[kind]++
+	x, y := vp.xy(c.Origin)
+	vp.components = append(vp.components, fmt.Sprintf(`<circle id="%s%d" cx="%s" cy="%s" r="%s" fill="%s"/>`,
+		kind, vp.componentCount[kind], x, y, geometry.FormatNumber(c.Radius, 4), kind.colour()))
+}
+
+// AddFeatures converts features into SVG elements. Markings and exposed
+// copper on the front of the panel are drawn; cutout circles become
+// component placeholders, and other cutouts are drawn in black as openings
+// onto the dark interior of the rack.
+func (vp *Panel) AddFeatures(feats []features.Feature) {
+	features.Walk(feats, panelVisitor{vp})
+}
+
+// fill returns the colour a feature is drawn in, or false if it is not
+// drawn. Cutout polygons are openings; the panel outline and other cutouts
+// are implied by the SVG canvas.
+func (vp *Panel) fill(f features.Feature) (string, bool) {
+	if f.GetPurpose() == features.Cutout {
+		if _, ok := f.(*features.Polygon); ok {
+			return "#000000", true
+		}
+		return "", false
+	}
+	return vp.colour(f)
+}
+
+// panelVisitor converts each type of feature into SVG elements
+type panelVisitor struct {
+	vp *Panel
+}
+
+var _ features.Visitor = panelVisitor{}
+
+func (v panelVisitor) VisitLine(f *features.Line) {
+	colour, ok := v.vp.fill(f)
+	if !ok {
+		return
+	}
+	x1, y1 := v.vp.xy(f.Start)
+	x2, y2 := v.vp.xy(f.End)
+	v.vp.elements = append(v.vp.elements, fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s" stroke-linecap="round"/>`,
+		x1, y1, x2, y2, colour, geometry.FormatNumber(f.Thickness, 4)))
+}
+
+func (v panelVisitor) VisitCircle(f *features.Circle) {
+	if f.GetPurpose() == features.Cutout {
+		if !v.vp.isMountingHole(f) {
+			v.vp.component(f)
+		}
+		return
+	}
+	colour, ok := v.vp.fill(f)
+	if !ok {
+		return
+	}
+	x, y := v.vp.xy(f.Origin)
+	v.vp.elements = append(v.vp.elements, fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="%s"/>`,
+		x, y, geometry.FormatNumber(f.Radius, 4), colour))
+}
+
+func (v panelVisitor) VisitPolygon(f *features.Polygon) {
+	colour, ok := v.vp.fill(f)
+	if !ok {
+		return
+	}
+	v.vp.elements = append(v.vp.elements, fmt.Sprintf(`<path d="%s" fill="%s"/>`, v.vp.path(f.Points), colour))
+}
+
+func (v panelVisitor) VisitOutline(f *features.Outline) {
+	// the panel outline is implied by the SVG canvas
+}
+
+func (v panelVisitor) VisitText(f *features.Text) {
+	colour, ok := v.vp.fill(f)
+	if !ok {
+		return
+	}
+	contours, err := textpath.Contours(f, false)
+	if err != nil {
+		diag.Warnf(v.vp.Diagnostics, f, "cannot render text, ignoring: %v", err)
+		return
+	}
+	points := [][]geometry.Point{}
+	for _, c := range contours {
+		points = append(points, c.Points)
+	}
+	v.vp.elements = append(v.vp.elements, fmt.Sprintf(`<path d="%s" fill="%s" fill-rule="evenodd"/>`, v.vp.path(points...), colour))
+}
+
+func (v panelVisitor) VisitPlaceholder(f *features.Placeholder) {
+	diag.Warnf(v.vp.Diagnostics, f, "unresolved placeholder, ignoring: %v", f.String())
+}
+
+func (v panelVisitor) VisitImage(f *features.Image) {
+	colour, ok := v.vp.fill(f)
+	if !ok {
+		return
+	}
+	points := [][]geometry.Point{}
+	for _, run := range f.Runs() {
+		points = append(points, features.NewRectangle(run[0], run[1]).Points)
+	}
+	v.vp.elements = append(v.vp.elements, fmt.Sprintf(`<path d="%s" fill="%s"/>`, v.vp.path(points...), colour))
+}
+
+func (v panelVisitor) VisitDimension(f *features.Dimension) {
+	v.vp.AddFeatures(f.Features())
+}
+
+func (v panelVisitor) VisitCustom(f features.Custom) {
+	if fn, ok := customRenderers[f.Kind()]; ok {
+		if err := fn(v.vp, f); err != nil {
+			diag.Warnf(v.vp.Diagnostics, f, "cannot render %s feature, ignoring: %v", f.Kind(), err)
+		}
+		return
+	}
+	feats, err := f.Expand()
+	if err != nil {
+		diag.Warnf(v.vp.Diagnostics, f, "cannot expand %s feature, ignoring: %v", f.Kind(), err)
+		return
+	}
+	v.vp.AddFeatures(feats)
+}
+
+func (v panelVisitor) VisitInversion(f *features.Inversion) {
+	if _, ok := v.vp.fill(f); ok {
+		diag.Warnf(v.vp.Diagnostics, f, "inversion features are not supported in VCV Rack panels, ignoring: %v", f.String())
+	}
+}
+
+// WriteSVG writes the panel as a VCV Rack panel SVG
+func (vp *Panel) WriteSVG(w io.Writer) error {
+	var b strings.Builder
+	width, height := geometry.FormatNumber(vp.width, 4), geometry.FormatNumber(vp.height, 4)
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(&b, `<!-- panel generated by github.com/jsleeio/frontpanels -->`)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%smm" height="%smm" viewBox="0 0 %s %s">`+"\n",
+		width, height, width, height)
+	fmt.Fprintln(&b, `  <g id="panel">`)
+	fmt.Fprintf(&b, `    <rect width="%s" height="%s" fill="%s"/>`+"\n", width, height, vp.Background)
+	for _, e := range vp.elements {
+		fmt.Fprintf(&b, "    %s\n", e)
+	}
+	fmt.Fprintln(&b, `  </g>`)
+	fmt.Fprintln(&b, `  <g id="components" style="display:none">`)
+	for _, c := range vp.components {
+		fmt.Fprintf(&b, "    %s\n", c)
+	}
+	fmt.Fprintln(&b, `  </g>`)
+	fmt.Fprintln(&b, `</svg>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,278 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package retarget moves the features of a panel designed for one format
+// and width onto a panel of another, such as between the incompatible
+// Intellijel and Pulplogic 1U formats or from a 3U panel to its 1U version,
+// and reports those that no longer fit
+package retarget
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+)
+
+// Mode selects how positions are mapped between panels of different sizes
+type Mode int
+
+// Proportional et al are the ways of mapping positions
+const (
+	// Proportional scales positions so that the usable area of the source
+	// panel, inside its edges and between its rails, fills that of the
+	// destination
+	Proportional Mode = iota // this MUST be the first item
+	// Anchored keeps the distance of each position from the nearer edge of
+	// the usable area, horizontally and vertically, so that the extra space
+	// of a larger panel, or the missing space of a smaller one, is in the
+	// middle
+	Anchored // this MUST be the last item
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (m Mode) String() string {
+	switch m {
+	case Proportional:
+		return "proportional"
+	case Anchored:
+		return "anchored"
+	}
+	panic(fmt.Sprintf("invalid Mode value (valid range is %d..%d): %d",
+		int(Proportional), int(Anchored), int(m)))
+}
+
+// ParseMode converts a mode name, as returned by Mode.String, to a Mode
+func ParseMode(s string) (Mode, error) {
+	for m := Proportional; m <= Anchored; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return Proportional, fmt.Errorf("invalid retarget mode %q (valid values: proportional anchored)", s)
+}
+
+// Mapping maps positions on a panel designed for one format and width onto
+// a panel of another
+type Mapping struct {
+	From, To panel.Panel
+	Mode     Mode
+}
+
+// New returns the mapping from one panel to another
+func New(from, to panel.Panel, mode Mode) (*Mapping, error) {
+	for _, p := range []panel.Panel{from, to} {
+		if a := panel.UsableArea(p); a.Width() <= 0 || a.Height() <= 0 {
+			return nil, fmt.Errorf("retarget: the %s panel has no usable area", panel.Description(p))
+		}
+	}
+	return &Mapping{From: from, To: to, Mode: mode}, nil
+}
+
+// Point maps a position on the source panel to the destination panel
+func (m Mapping) Point(p geometry.Point) geometry.Point {
+	from, to := panel.UsableArea(m.From), panel.UsableArea(m.To)
+	return geometry.Point{
+		X: m.axis(p.X, from.BottomLeft.X, from.TopRight.X, to.BottomLeft.X, to.TopRight.X),
+		Y: m.axis(p.Y, from.BottomLeft.Y, from.TopRight.Y, to.BottomLeft.Y, to.TopRight.Y),
+	}
+}
+
+// axis maps a coordinate v between lo and hi on the source panel to one
+// between tlo and thi on the destination
+func (m Mapping) axis(v, lo, hi, tlo, thi float64) float64 {
+	switch {
+	case m.Mode == Proportional:
+		return tlo + (v-lo)*(thi-tlo)/(hi-lo)
+	case v-lo <= hi-v:
+		return tlo + (v - lo)
+	}
+	return thi - (hi - v)
+}
+
+// String describes the mapping, eg. "eurorack 8hp to intellijel 8hp
+// (proportional)"
+func (m Mapping) String() string {
+	return fmt.Sprintf("%s to %s (%s)", panel.Description(m.From), panel.Description(m.To), m.Mode)
+}
+
+// Group returns copies of features moved together by the offset of the
+// centre of their bounding box, so that a group such as a component's hole
+// and label keeps its shape. Custom features are replaced by their moved
+// expansions.
+func (m Mapping) Group(feats []features.Feature) ([]features.Feature, error) {
+	b, ok, err := bounds(feats)
+	if err != nil || !ok {
+		return feats, err
+	}
+	c := b.Centre()
+	return features.Translate(feats, m.Point(c).Sub(c))
+}
+
+// Features returns copies of the features, each moved as a group of its
+// own
+func (m Mapping) Features(feats []features.Feature) ([]features.Feature, error) {
+	moved := []features.Feature{}
+	for _, f := range feats {
+		g, err := m.Group([]features.Feature{f})
+		if err != nil {
+			return nil, err
+		}
+		moved = append(moved, g...)
+	}
+	return moved, nil
+}
+
+// Check reports a Warning for each cutout, and each annotation on the rear
+// of the panel such as a component body courtyard, that no longer fits
+// between the rails of one of the rows of the destination panel or inside
+// its edges, as features moved closer together may. Mounting holes are
+// expected on the rails, and are not reported, and only the first of
+// several circles sharing a centre, such as a component's hole and body, is
+// reported.
+func (m Mapping) Check(feats []features.Feature, r diag.Reporter) {
+	regions := panel.Regions(m.To)
+	left, right := panel.LeftX(m.To), panel.RightX(m.To)
+	seen := map[geometry.Point]bool{}
+	for _, h := range m.To.MountingHoles() {
+		seen[h] = true
+	}
+	for _, f := range feats {
+		rear := false
+		if s, ok := f.(features.Sided); ok {
+			rear = s.GetSide() == features.BottomSide && f.GetPurpose() == features.Annotation
+		}
+		if f.GetPurpose() != features.Cutout && !rear {
+			continue
+		}
+		b, ok, err := bounds([]features.Feature{f})
+		if err != nil || !ok {
+			continue
+		}
+		region := nearest(regions, m.To, b.Centre().Y)
+		bottom, top := region.BottomLeft.Y-b.BottomLeft.Y, b.TopRight.Y-region.TopRight.Y
+		beyondLeft, beyondRight := left-b.BottomLeft.X, b.TopRight.X-right
+		if bottom <= 0 && top <= 0 && beyondLeft <= 0 && beyondRight <= 0 {
+			continue
+		}
+		if c, ok := f.(*features.Circle); ok {
+			if seen[c.Origin] {
+				continue
+			}
+			seen[c.Origin] = true
+		}
+		for _, e := range []struct {
+			d     float64
+			where string
+		}{
+			{bottom, "into the bottom rail"},
+			{top, "into the top rail"},
+			{beyondLeft, "beyond the left edge"},
+			{beyondRight, "beyond the right edge"},
+		} {
+			if e.d > 0 {
+				diag.Warnf(r, f, "%s no longer fits, extending %.2fmm %s of the %s", describe(f), e.d, e.where, panel.Description(m.To))
+			}
+		}
+	}
+}
+
+// nearest returns the usable area of the region of a panel nearest to a Y
+// coordinate
+func nearest(regions []panel.Region, p panel.Panel, y float64) geometry.Rect {
+	var best geometry.Rect
+	distance := math.Inf(1)
+	for _, region := range regions {
+		a := region.UsableArea(p)
+		d := math.Max(a.BottomLeft.Y-y, y-a.TopRight.Y)
+		if d < distance {
+			best, distance = a, d
+		}
+	}
+	return best
+}
+
+// describe returns a short description of a feature for diagnostics
+func describe(f features.Feature) string {
+	if c, ok := f.(*features.Circle); ok {
+		return fmt.Sprintf("%.2fmm circle at (%.2f, %.2f)", c.Radius*2, c.Origin.X, c.Origin.Y)
+	}
+	return fmt.Sprint(f)
+}
+
+// extent accumulates the bounding box of the features it visits. Text
+// contributes only its origin, as its extent depends on the font it is
+// rendered in.
+type extent struct {
+	min, max geometry.Point
+	found    bool
+	err      error
+}
+
+// add extends the bounding box to include squares of half-width r centred
+// on the points
+func (e *extent) add(r float64, pts ...geometry.Point) {
+	for _, p := range pts {
+		if !e.found {
+			e.min, e.max, e.found = p, p, true
+		}
+		e.min = geometry.Point{X: math.Min(e.min.X, p.X-r), Y: math.Min(e.min.Y, p.Y-r)}
+		e.max = geometry.Point{X: math.Max(e.max.X, p.X+r), Y: math.Max(e.max.Y, p.Y+r)}
+	}
+}
+
+func (e *extent) VisitLine(l *features.Line)               { e.add(l.Thickness/2, l.Start, l.End) }
+func (e *extent) VisitCircle(c *features.Circle)           { e.add(c.Radius, c.Origin) }
+func (e *extent) VisitPolygon(p *features.Polygon)         { e.add(0, p.Points...) }
+func (e *extent) VisitOutline(o *features.Outline)         { e.add(0, o.Points...) }
+func (e *extent) VisitText(t *features.Text)               { e.add(0, t.Origin) }
+func (e *extent) VisitPlaceholder(p *features.Placeholder) { e.add(0, p.Origin) }
+func (e *extent) VisitDimension(d *features.Dimension)     { e.add(0, d.Start, d.End) }
+func (e *extent) VisitInversion(i *features.Inversion)     { e.add(0, i.Region...) }
+
+func (e *extent) VisitImage(i *features.Image) {
+	size := geometry.Point{Y: float64(len(i.Ink)) * i.PixelSize}
+	if len(i.Ink) > 0 {
+		size.X = float64(len(i.Ink[0])) * i.PixelSize
+	}
+	e.add(0, i.Origin, i.Origin.Add(size))
+}
+
+func (e *extent) VisitCustom(c features.Custom) {
+	sub, err := c.Expand()
+	if err != nil {
+		if e.err == nil {
+			e.err = fmt.Errorf("%s: %v", c.Kind(), err)
+		}
+		return
+	}
+	features.Walk(sub, e)
+}
+
+// bounds returns the bounding box of the features, and false if there are
+// none with a position
+func bounds(feats []features.Feature) (geometry.Rect, bool, error) {
+	e := &extent{}
+	features.Walk(feats, e)
+	return geometry.Rect{BottomLeft: e.min, TopRight: e.max}, e.found, e.err
+}
@@ -0,0 +1,232 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package copper generates copper pour features for panels. PCB shops tend
+// to get confused if there is no copper at all, and a pour also changes the
+// appearance of the panel through the soldermask.
+package copper
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/panel"
+	hatchfill "github.com/jsleeio/frontpanels/pkg/sources/hatch"
+)
+
+const (
+	// DefaultHatchPitch is the default centre-to-centre distance between
+	// hatch lines, in millimetres
+	DefaultHatchPitch = 1.0
+
+	// DefaultHatchWidth is the default thickness of hatch lines, in
+	// millimetres
+	DefaultHatchWidth = 0.25
+
+	// DefaultClearance is the default margin kept between the pour and any
+	// holes or cutouts, in millimetres
+	DefaultClearance = 0.5
+)
+
+// Extent indicates how much of the panel is covered by a copper pour
+type Extent int
+
+// InterRail et al specify copper pour extents
+const (
+	// InterRail pours cover the space between the mounting rails, across the
+	// full panel width
+	InterRail Extent = iota
+	// FullPanel pours cover the entire panel
+	FullPanel
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (e Extent) String() string {
+	switch e {
+	case InterRail:
+		return "inter-rail"
+	case FullPanel:
+		return "full"
+	}
+	panic(fmt.Sprintf("invalid Extent value (valid range is %d..%d): %d",
+		int(InterRail), int(FullPanel), int(e)))
+}
+
+// ParseExtent converts a string as returned by Extent.String to an Extent
+func ParseExtent(s string) (Extent, error) {
+	for _, e := range []Extent{InterRail, FullPanel} {
+		if e.String() == s {
+			return e, nil
+		}
+	}
+	return InterRail, fmt.Errorf("invalid copper pour extent %q (valid values: inter-rail full)", s)
+}
+
+// Fill indicates how the copper pour region is filled
+type Fill int
+
+// Solid et al specify copper pour fill styles
+const (
+	Solid Fill = iota
+	Hatched
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (f Fill) String() string {
+	switch f {
+	case Solid:
+		return "solid"
+	case Hatched:
+		return "hatched"
+	}
+	panic(fmt.Sprintf("invalid Fill value (valid range is %d..%d): %d",
+		int(Solid), int(Hatched), int(f)))
+}
+
+// ParseFill converts a string as returned by Fill.String to a Fill
+func ParseFill(s string) (Fill, error) {
+	for _, f := range []Fill{Solid, Hatched} {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return Solid, fmt.Errorf("invalid copper pour fill %q (valid values: solid hatched)", s)
+}
+
+// Options configures copper pour generation. A pour with neither Top nor
+// Bottom set is disabled.
+type Options struct {
+	// Top and Bottom indicate which sides of the panel receive a pour
+	Top, Bottom bool
+	Extent      Extent
+	Fill        Fill
+	// HatchPitch and HatchWidth configure Hatched fills, in millimetres
+	HatchPitch, HatchWidth float64
+	// Clearance is the margin kept between the pour and any holes or
+	// cutouts, in millimetres. Subtracting the cutouts is the job of the
+	// renderer, as only it knows about every feature on the panel.
+	Clearance float64
+}
+
+// DefaultOptions returns the traditional pour: solid, top side only, between
+// the rails
+func DefaultOptions() Options {
+	return Options{
+		Top:        true,
+		Extent:     InterRail,
+		Fill:       Solid,
+		HatchPitch: DefaultHatchPitch,
+		HatchWidth: DefaultHatchWidth,
+		Clearance:  DefaultClearance,
+	}
+}
+
+// ParseSides configures which sides receive a pour from a string: one of
+// top, bottom, both or none
+func (o *Options) ParseSides(s string) error {
+	switch s {
+	case "top":
+		o.Top, o.Bottom = true, false
+	case "bottom":
+		o.Top, o.Bottom = false, true
+	case "both":
+		o.Top, o.Bottom = true, true
+	case "none":
+		o.Top, o.Bottom = false, false
+	default:
+		return fmt.Errorf("invalid copper pour sides %q (valid values: top bottom both none)", s)
+	}
+	return nil
+}
+
+// Region returns the bottom-left and top-right corners of the area to be
+// covered by the pour
+func Region(p panel.Panel, extent Extent) (geometry.Point, geometry.Point) {
+	if extent == FullPanel {
+		return panel.BottomLeft(p), panel.TopRight(p)
+	}
+	r := panel.UsableArea(p)
+	return r.BottomLeft, r.TopRight
+}
+
+// hatch generates a grid of horizontal and vertical lines, plus a border,
+// covering a rectangular region
+func hatch(bl, tr geometry.Point, pitch, width float64) ([]features.Feature, error) {
+	rect := features.NewRectangle(bl, tr)
+	f, err := hatchfill.Polygon(rect.Points, hatchfill.Options{
+		Pattern:   hatchfill.Crosshatch,
+		Pitch:     pitch,
+		Thickness: width,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range rect.Edges() {
+		f = append(f, features.NewLine(e[0], e[1], width))
+	}
+	return f, nil
+}
+
+// validate checks that the options make sense
+func (o Options) validate() error {
+	if o.Clearance < 0 {
+		return errors.New("copper: clearance must not be negative")
+	}
+	if o.Fill == Hatched && (o.HatchPitch <= 0 || o.HatchWidth <= 0) {
+		return errors.New("copper: hatch pitch and width must be greater than 0")
+	}
+	return nil
+}
+
+// GenerateFeatures generates MaskedCopper features for the pour, on each
+// configured side
+func GenerateFeatures(p panel.Panel, opts Options) ([]features.Feature, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	f := []features.Feature{}
+	sides := []features.Side{}
+	if opts.Top {
+		sides = append(sides, features.TopSide)
+	}
+	if opts.Bottom {
+		sides = append(sides, features.BottomSide)
+	}
+	bl, tr := Region(p, opts.Extent)
+	for _, side := range sides {
+		var pour []features.Feature
+		if opts.Fill == Hatched {
+			var err error
+			if pour, err = hatch(bl, tr, opts.HatchPitch, opts.HatchWidth); err != nil {
+				return nil, err
+			}
+		} else {
+			pour = []features.Feature{features.NewRectangle(bl, tr)}
+		}
+		for _, item := range pour {
+			item.SetPurpose(features.MaskedCopper)
+			item.(features.Sided).SetSide(side)
+		}
+		f = append(f, pour...)
+	}
+	return f, nil
+}
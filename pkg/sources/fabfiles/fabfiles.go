@@ -0,0 +1,253 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package fabfiles reads back the drill and board outline files written for
+// a panel, eg. the Gerbers of a revision already sent to a fab, so that they
+// can be compared against a revised spec file. Only decimal Excellon
+// coordinates and the drilled holes, not slots, are understood.
+package fabfiles
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Drill is a hole drilled by an Excellon drill file
+type Drill struct {
+	Position geometry.Point
+	Diameter float64
+	Plated   bool
+}
+
+var (
+	// toolDefinition matches an Excellon tool definition, eg. T1C3.200
+	toolDefinition = regexp.MustCompile(`^T([0-9]+)C([0-9.]+)`)
+	// toolSelection matches an Excellon tool change, eg. T1
+	toolSelection = regexp.MustCompile(`^T([0-9]+)$`)
+	// hit matches Excellon drill hit coordinates, either of which may be
+	// omitted to keep its previous value
+	hit = regexp.MustCompile(`^(?:X([-+0-9.]+))?(?:Y([-+0-9.]+))?$`)
+)
+
+// ReadDrills reads the holes drilled by an Excellon drill file, in
+// millimetres. Holes are plated unless the file's function comment says
+// otherwise or the name of the file contains NPTH.
+func ReadDrills(r io.Reader, filename string) ([]Drill, error) {
+	plated := !strings.Contains(strings.ToUpper(filepath.Base(filename)), "NPTH")
+	scale := 1.0
+	tools := map[string]float64{}
+	tool := ""
+	var at geometry.Point
+	drills := []Drill{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(s, ";"):
+			if strings.Contains(s, "TF.FileFunction,NonPlated") {
+				plated = false
+			} else if strings.Contains(s, "TF.FileFunction,Plated") {
+				plated = true
+			}
+		case strings.HasPrefix(s, "INCH"):
+			scale = 25.4
+		case strings.HasPrefix(s, "METRIC"):
+			scale = 1.0
+		case toolDefinition.MatchString(s):
+			m := toolDefinition.FindStringSubmatch(s)
+			d, err := strconv.ParseFloat(m[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid tool diameter %q", filename, line, m[2])
+			}
+			tools[strings.TrimLeft(m[1], "0")] = d * scale
+		case toolSelection.MatchString(s):
+			tool = strings.TrimLeft(toolSelection.FindStringSubmatch(s)[1], "0")
+		case s != "" && (s[0] == 'X' || s[0] == 'Y'):
+			m := hit.FindStringSubmatch(s)
+			if m == nil {
+				return nil, fmt.Errorf("%s:%d: unsupported drill command %q", filename, line, s)
+			}
+			for i, v := range []*float64{&at.X, &at.Y} {
+				if m[i+1] == "" {
+					continue
+				}
+				if !strings.Contains(m[i+1], ".") {
+					return nil, fmt.Errorf("%s:%d: only decimal coordinates are supported, found %q", filename, line, s)
+				}
+				n, err := strconv.ParseFloat(m[i+1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid coordinate in %q", filename, line, s)
+				}
+				*v = n * scale
+			}
+			d, ok := tools[tool]
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: drill hit without a defined tool", filename, line)
+			}
+			drills = append(drills, Drill{Position: at, Diameter: d, Plated: plated})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return drills, nil
+}
+
+var (
+	// formatSpecification matches a Gerber format specification,
+	// capturing the number of decimal places in coordinates
+	formatSpecification = regexp.MustCompile(`%FSLAX[0-9]([0-9])Y[0-9][0-9]\*%`)
+	// coordinates matches the X and Y coordinates of a Gerber operation,
+	// either of which may be omitted to keep its previous value
+	coordinates = regexp.MustCompile(`^(?:G0?[123])?(?:X([-+]?[0-9]+))?(?:Y([-+]?[0-9]+))?(?:I[-+]?[0-9]+)?(?:J[-+]?[0-9]+)?D0?[123]\*`)
+)
+
+// ReadExtents returns the bounding box of every point reached by the
+// operations of a Gerber file, in millimetres. The width of the apertures
+// is not included, so for a board outline file the result is the size of
+// the board.
+func ReadExtents(r io.Reader, filename string) (geometry.Rect, error) {
+	extents := geometry.Rect{
+		BottomLeft: geometry.Point{X: math.Inf(1), Y: math.Inf(1)},
+		TopRight:   geometry.Point{X: math.Inf(-1), Y: math.Inf(-1)},
+	}
+	scale := 0.0
+	unit := 1.0
+	var at geometry.Point
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case formatSpecification.MatchString(s):
+			decimals, _ := strconv.Atoi(formatSpecification.FindStringSubmatch(s)[1])
+			scale = math.Pow(10, -float64(decimals))
+		case strings.HasPrefix(s, "%MOIN"):
+			unit = 25.4
+		case strings.HasPrefix(s, "%MOMM"):
+			unit = 1.0
+		case coordinates.MatchString(s):
+			if scale == 0 {
+				return extents, fmt.Errorf("%s: coordinates before the format specification", filename)
+			}
+			m := coordinates.FindStringSubmatch(s)
+			if m[1] == "" && m[2] == "" {
+				continue
+			}
+			for i, v := range []*float64{&at.X, &at.Y} {
+				if m[i+1] == "" {
+					continue
+				}
+				n, _ := strconv.ParseFloat(m[i+1], 64)
+				*v = n * scale * unit
+			}
+			extents.BottomLeft = geometry.Point{X: math.Min(extents.BottomLeft.X, at.X), Y: math.Min(extents.BottomLeft.Y, at.Y)}
+			extents.TopRight = geometry.Point{X: math.Max(extents.TopRight.X, at.X), Y: math.Max(extents.TopRight.Y, at.Y)}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return extents, fmt.Errorf("%s: %v", filename, err)
+	}
+	if extents.Width() < 0 {
+		return extents, fmt.Errorf("%s: no coordinates found", filename)
+	}
+	return extents, nil
+}
+
+// outlineExtensions are the extensions of board outline Gerber files
+// written by common tools, for files without a file function attribute
+var outlineExtensions = []string{".gko", ".gm1", ".gml"}
+
+// isOutline reports whether a Gerber file is a board outline: its file
+// function is Profile, or it has one of outlineExtensions
+func isOutline(filename string, text []byte) bool {
+	if strings.Contains(string(text), "%TF.FileFunction,Profile") {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range outlineExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads the holes drilled by every Excellon file (.drl or .xln) in a
+// directory, and the extents of its board outline Gerber file
+func Load(dir string) ([]Drill, geometry.Rect, error) {
+	var outline geometry.Rect
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, outline, err
+	}
+	drills := []Drill{}
+	found := false
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		filename := filepath.Join(dir, e.Name())
+		ext := strings.ToLower(filepath.Ext(filename))
+		switch ext {
+		case ".drl", ".xln":
+			f, err := os.Open(filename)
+			if err != nil {
+				return nil, outline, err
+			}
+			d, err := ReadDrills(f, filename)
+			f.Close()
+			if err != nil {
+				return nil, outline, err
+			}
+			drills = append(drills, d...)
+		case ".gbr", ".gko", ".gm1", ".gml":
+			text, err := os.ReadFile(filename)
+			if err != nil {
+				return nil, outline, err
+			}
+			if !isOutline(filename, text) {
+				continue
+			}
+			if found {
+				return nil, outline, fmt.Errorf("%s: more than one board outline file", dir)
+			}
+			if outline, err = ReadExtents(strings.NewReader(string(text)), filename); err != nil {
+				return nil, outline, err
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil, outline, errors.New(dir + ": no board outline Gerber file")
+	}
+	return drills, outline, nil
+}
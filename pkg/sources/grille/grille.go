@@ -0,0 +1,183 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package grille generates grids of drilled holes within a bounded region,
+// for speaker outputs and ventilation. Holes are emitted as Cutout circles.
+package grille
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/fab"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Layout describes the arrangement of holes in a grille
+type Layout int
+
+// Square et al specify grille layouts
+const (
+	// Square layouts place holes in rows and columns
+	Square Layout = iota
+	// Hexagonal layouts offset alternate rows by half the pitch, packing the
+	// holes more tightly for the same web width
+	Hexagonal
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (l Layout) String() string {
+	switch l {
+	case Square:
+		return "square"
+	case Hexagonal:
+		return "hex"
+	}
+	panic(fmt.Sprintf("invalid Layout value (valid range is %d..%d): %d",
+		int(Square), int(Hexagonal), int(l)))
+}
+
+// ParseLayout converts a layout name, as returned by Layout.String, to a
+// Layout
+func ParseLayout(s string) (Layout, error) {
+	for l := Square; l <= Hexagonal; l++ {
+		if l.String() == s {
+			return l, nil
+		}
+	}
+	return Square, fmt.Errorf("invalid grille layout %q (valid values: square hex)", s)
+}
+
+// Options configures grille generation. Distances are in millimetres.
+type Options struct {
+	Layout       Layout
+	HoleDiameter float64
+	// Pitch is the distance between the centres of neighbouring holes
+	Pitch float64
+	// EdgeClearance is the minimum distance between the edge of any hole and
+	// the boundary of the region
+	EdgeClearance float64
+}
+
+// DefaultOptions returns the default grille options
+func DefaultOptions() Options {
+	return Options{
+		Layout:        Hexagonal,
+		HoleDiameter:  2.0,
+		Pitch:         3.0,
+		EdgeClearance: 1.0,
+	}
+}
+
+// validate checks the options, including that the web between holes is no
+// narrower than the fab can reliably drill
+func (o Options) validate(profile fab.Profile) error {
+	if o.HoleDiameter <= 0 {
+		return errors.New("grille: hole diameter must be greater than 0")
+	}
+	if web := o.Pitch - o.HoleDiameter; web < profile.MinHoleWeb {
+		return fmt.Errorf("grille: web between holes of %.3fmm is below the %s minimum of %.3fmm; need a pitch of at least %.2fmm",
+			web, profile.Name, profile.MinHoleWeb, o.HoleDiameter+profile.MinHoleWeb)
+	}
+	return nil
+}
+
+// grid returns hole centres covering a square of the given half-width
+// around centre, so that grilles are symmetrical about their centre
+func (o Options) grid(centre geometry.Point, half float64) []geometry.Point {
+	rowPitch := o.Pitch
+	if o.Layout == Hexagonal {
+		rowPitch = o.Pitch * math.Sqrt(3) / 2
+	}
+	rows := int(math.Ceil(half / rowPitch))
+	cols := int(math.Ceil(half/o.Pitch)) + 1
+	points := []geometry.Point{}
+	for j := -rows; j <= rows; j++ {
+		offset := 0.0
+		if o.Layout == Hexagonal && j%2 != 0 {
+			offset = o.Pitch / 2
+		}
+		for i := -cols; i <= cols; i++ {
+			points = append(points, centre.Add(geometry.Point{X: offset + float64(i)*o.Pitch, Y: float64(j) * rowPitch}))
+		}
+	}
+	return points
+}
+
+// holes generates Cutout circles at those of the given centres satisfying
+// fits
+func (o Options) holes(points []geometry.Point, fits func(geometry.Point) bool) ([]features.Feature, error) {
+	f := []features.Feature{}
+	for _, p := range points {
+		if !fits(p) {
+			continue
+		}
+		hole := features.NewCircle(p, o.HoleDiameter/2.0)
+		hole.SetPurpose(features.Cutout)
+		f = append(f, hole)
+	}
+	if len(f) == 0 {
+		return nil, errors.New("grille: region is too small for any holes")
+	}
+	return f, nil
+}
+
+// Circle generates a grille within a circle
+func Circle(centre geometry.Point, radius float64, opts Options, profile fab.Profile) ([]features.Feature, error) {
+	if err := opts.validate(profile); err != nil {
+		return nil, err
+	}
+	limit := radius - opts.HoleDiameter/2 - opts.EdgeClearance
+	return opts.holes(opts.grid(centre, radius), func(p geometry.Point) bool {
+		return p.Distance(centre) <= limit
+	})
+}
+
+// Rectangle generates a grille within an axis-aligned rectangle with
+// opposite corners a and b
+func Rectangle(a, b geometry.Point, opts Options, profile fab.Profile) ([]features.Feature, error) {
+	if err := opts.validate(profile); err != nil {
+		return nil, err
+	}
+	inset := opts.HoleDiameter/2 + opts.EdgeClearance
+	bl := geometry.Point{X: math.Min(a.X, b.X) + inset, Y: math.Min(a.Y, b.Y) + inset}
+	tr := geometry.Point{X: math.Max(a.X, b.X) - inset, Y: math.Max(a.Y, b.Y) - inset}
+	half := math.Max(math.Abs(a.X-b.X), math.Abs(a.Y-b.Y)) / 2
+	return opts.holes(opts.grid(a.Add(b).Scale(0.5), half), func(p geometry.Point) bool {
+		return p.X >= bl.X && p.X <= tr.X && p.Y >= bl.Y && p.Y <= tr.Y
+	})
+}
+
+// Region generates a grille within the region described by a Circle
+// feature, or an axis-aligned rectangle Polygon as created by
+// features.NewRectangle
+func Region(region features.Feature, opts Options, profile fab.Profile) ([]features.Feature, error) {
+	switch r := region.(type) {
+	case *features.Circle:
+		return Circle(r.Origin, r.Radius, opts, profile)
+	case *features.Polygon:
+		if p := r.Points; len(p) == 4 && p[0].Y == p[1].Y && p[1].X == p[2].X && p[2].Y == p[3].Y && p[3].X == p[0].X {
+			return Rectangle(p[0], p[2], opts, profile)
+		}
+	}
+	return nil, fmt.Errorf("grille: unsupported region %v", region)
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package hatch fills closed regions with parallel-line or crosshatch
+// patterns, for decorative textures or to differentiate functional zones of
+// a panel. Lines are emitted as Marking features.
+package hatch
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Pattern describes the style of hatching
+type Pattern int
+
+// Parallel et al specify hatching patterns
+const (
+	// Parallel hatching is a single set of parallel lines
+	Parallel Pattern = iota
+	// Crosshatch is two sets of parallel lines at right angles
+	Crosshatch
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (p Pattern) String() string {
+	switch p {
+	case Parallel:
+		return "parallel"
+	case Crosshatch:
+		return "crosshatch"
+	}
+	panic(fmt.Sprintf("invalid Pattern value (valid range is %d..%d): %d",
+		int(Parallel), int(Crosshatch), int(p)))
+}
+
+// Options configures hatching. Distances are in millimetres.
+type Options struct {
+	Pattern Pattern
+	// Pitch is the distance between adjacent lines
+	Pitch float64
+	// Angle of the lines in degrees, anticlockwise from horizontal
+	Angle float64
+	// Thickness of each line
+	Thickness float64
+}
+
+func (o Options) validate() error {
+	if o.Pitch <= 0 {
+		return errors.New("hatch: pitch must be greater than 0")
+	}
+	if o.Thickness < 0 {
+		return errors.New("hatch: thickness must not be negative")
+	}
+	return nil
+}
+
+// angles returns the line angles required for the pattern
+func (o Options) angles() []float64 {
+	if o.Pattern == Crosshatch {
+		return []float64{o.Angle, o.Angle + 90.0}
+	}
+	return []float64{o.Angle}
+}
+
+// scanlines returns the Y coordinates of hatch lines covering the range
+// minY..maxY. Lines are aligned to multiples of the pitch, so that adjacent
+// regions hatched with the same options line up neatly.
+func scanlines(minY, maxY, pitch float64) []float64 {
+	ys := []float64{}
+	for y := math.Ceil(minY/pitch) * pitch; y <= maxY; y += pitch {
+		ys = append(ys, y)
+	}
+	return ys
+}
+
+func line(a, b geometry.Point, angle, thickness float64) features.Feature {
+	return features.NewLine(a.Rotate(angle), b.Rotate(angle), thickness)
+}
+
+// Polygon hatches a closed polygon. Holes are not supported, but concave
+// polygons are handled correctly.
+func Polygon(points []geometry.Point, opts Options) ([]features.Feature, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if len(points) < 3 {
+		return nil, errors.New("hatch: polygon must have at least three points")
+	}
+	f := []features.Feature{}
+	for _, angle := range opts.angles() {
+		// work in a rotated frame where hatch lines are horizontal
+		rotated := make([]geometry.Point, len(points))
+		minY, maxY := math.Inf(1), math.Inf(-1)
+		for i, p := range points {
+			rotated[i] = p.Rotate(-angle)
+			minY = math.Min(minY, rotated[i].Y)
+			maxY = math.Max(maxY, rotated[i].Y)
+		}
+		for _, y := range scanlines(minY, maxY, opts.Pitch) {
+			xs := []float64{}
+			for i, a := range rotated {
+				b := rotated[(i+1)%len(rotated)]
+				// half-open interval avoids double-counting shared vertices
+				if (a.Y <= y && b.Y > y) || (b.Y <= y && a.Y > y) {
+					xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+				}
+			}
+			sort.Float64s(xs)
+			for i := 0; i+1 < len(xs); i += 2 {
+				f = append(f, line(geometry.Point{X: xs[i], Y: y}, geometry.Point{X: xs[i+1], Y: y}, angle, opts.Thickness))
+			}
+		}
+	}
+	return f, nil
+}
+
+// Circle hatches a circle
+func Circle(centre geometry.Point, radius float64, opts Options) ([]features.Feature, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	f := []features.Feature{}
+	for _, angle := range opts.angles() {
+		c := centre.Rotate(-angle)
+		for _, y := range scanlines(c.Y-radius, c.Y+radius, opts.Pitch) {
+			half := math.Sqrt(radius*radius - (y-c.Y)*(y-c.Y))
+			if math.IsNaN(half) || half < 1e-6 {
+				continue
+			}
+			f = append(f, line(geometry.Point{X: c.X - half, Y: y}, geometry.Point{X: c.X + half, Y: y}, angle, opts.Thickness))
+		}
+	}
+	return f, nil
+}
+
+// Region hatches the region described by a Polygon or Circle feature
+func Region(region features.Feature, opts Options) ([]features.Feature, error) {
+	switch r := region.(type) {
+	case *features.Polygon:
+		return Polygon(r.Points, opts)
+	case *features.Circle:
+		return Circle(r.Origin, r.Radius, opts)
+	}
+	return nil, fmt.Errorf("hatch: unsupported region type %T", region)
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package holes turns simple CSV tables of control positions, as commonly
+// kept in spreadsheets while designing a panel, into panel features.
+//
+// The first row must name the columns, in any order. The x, y and diameter
+// columns are required; purpose, label, tag and layer are optional.
+// Coordinates and diameters are in millimetres unless given a unit suffix
+// such as "in" or "mil", with the origin at the bottom-left of the panel. An
+// empty purpose means cutout. A tag is given to both the hole and its label,
+// and a layer hint, eg. outline, to the hole. For example:
+//
+//	x,y,diameter,purpose,label,tag,layer
+//	7.5,100,6,cutout,IN,input,
+//	7.5,80,7,,FREQ,,
+//	20,80,3,,,,outline
+package holes
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Options configures how table rows are converted to features
+type Options struct {
+	// Offset is added to every position in the table
+	Offset geometry.Point
+	// LabelSize is the height of capital letters in labels, in millimetres
+	LabelSize float64
+	// LabelGap is the distance between the edge of a hole and the bottom of
+	// its label, in millimetres
+	LabelGap float64
+}
+
+// DefaultOptions returns a reasonable set of defaults
+func DefaultOptions() Options {
+	return Options{LabelSize: 2.0, LabelGap: 1.5}
+}
+
+// Load reads a CSV hole table from a file
+func Load(filename string, opts Options) ([]features.Feature, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, opts)
+}
+
+// Parse reads a CSV hole table, generating a circle for each row and a
+// marking label above it if one is given
+func Parse(r io.Reader, opts Options) ([]features.Feature, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	// blank and comment lines are skipped, so the line of each record is
+	// kept for reporting errors
+	records, lines := [][]string{}, []int{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("holes: %v", err)
+		}
+		line, _ := cr.FieldPos(0)
+		records, lines = append(records, rec), append(lines, line)
+	}
+	if len(records) < 1 {
+		return nil, errors.New("holes: empty table")
+	}
+	col := map[string]int{}
+	for i, name := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"x", "y", "diameter"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("holes: missing column %q", name)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	feats := []features.Feature{}
+	for n, rec := range records[1:] {
+		line := lines[n+1]
+		var v [3]float64
+		var err error
+		for i, name := range []string{"x", "y", "diameter"} {
+			if v[i], err = geometry.ParseLength(field(rec, name)); err != nil {
+				return nil, fmt.Errorf("holes: line %d: invalid %s: %v", line, name, err)
+			}
+		}
+		if v[2] <= 0 {
+			return nil, fmt.Errorf("holes: line %d: diameter must be greater than 0", line)
+		}
+		purpose := features.Cutout
+		if s := field(rec, "purpose"); s != "" {
+			if purpose, err = features.ParsePurpose(s); err != nil {
+				return nil, fmt.Errorf("holes: line %d: %v", line, err)
+			}
+		}
+		centre := opts.Offset.Add(geometry.Point{X: v[0], Y: v[1]})
+		hole := features.NewCircle(centre, v[2]/2.0)
+		hole.SetPurpose(purpose)
+		hole.SetTag(field(rec, "tag"))
+		if s := field(rec, "layer"); s != "" {
+			layer, err := features.ParseLayer(s)
+			if err != nil {
+				return nil, fmt.Errorf("holes: line %d: %v", line, err)
+			}
+			features.Route(hole, layer)
+		}
+		feats = append(feats, hole)
+		if label := field(rec, "label"); label != "" {
+			t := features.NewText(
+				geometry.Point{X: centre.X, Y: centre.Y + v[2]/2.0 + opts.LabelGap},
+				label,
+				features.WithAlignment(features.BottomCentre),
+				features.WithSizeMM(opts.LabelSize),
+			)
+			t.SetTag(hole.Tag)
+			feats = append(feats, t)
+		}
+	}
+	return feats, nil
+}
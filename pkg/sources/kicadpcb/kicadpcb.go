@@ -0,0 +1,282 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package kicadpcb generates panel cutouts aligned with panel-mounted
+// components (jacks, pots, LEDs and so on) on a KiCad PCB, eliminating the
+// manual transcription of coordinates between PCB and panel. Components are
+// read from a .kicad_pcb file or a KiCad footprint position CSV export.
+package kicadpcb
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/diag"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Component is a footprint placed on a PCB. Positions are in millimetres,
+// with Y increasing upwards as on panels.
+type Component struct {
+	Reference, Value, Footprint string
+	Position                    geometry.Point
+	// Rotation in degrees, anticlockwise
+	Rotation float64
+	// Bottom indicates the component is on the bottom of the PCB
+	Bottom bool
+}
+
+// Rule maps components to panel hole diameters
+type Rule struct {
+	// Pattern is matched against the footprint name and value of each
+	// component, case-insensitively
+	Pattern *regexp.Regexp
+	// Diameter of the panel hole, in millimetres
+	Diameter float64
+	// Offset of the hole from the footprint origin, in millimetres with Y
+	// increasing upwards, for footprints whose origin is not at the centre
+	// of the part's bushing, eg. at a pin. The offset turns with the
+	// component, and is mirrored left to right with components on the
+	// bottom of the PCB as KiCad mirrors their footprints.
+	Offset geometry.Point
+}
+
+// DefaultRules returns rules for common panel-mounted Eurorack parts. The
+// diameters include a little clearance for easy fitting.
+func DefaultRules() []Rule {
+	rule := func(pattern string, diameter float64) Rule {
+		return Rule{Pattern: regexp.MustCompile("(?i)" + pattern), Diameter: diameter}
+	}
+	return []Rule{
+		rule(`PJ301M|Thonkiconn|WQP5|Jack_3\.5mm`, 6.0),
+		rule(`Alpha.*9mm|RD901F|Potentiometer|Pot_`, 7.0),
+		rule(`Toggle|MTS-|SW_E-Switch`, 6.4),
+		rule(`LED.*3(\.0)?mm`, 3.2),
+		rule(`LED.*5(\.0)?mm`, 5.2),
+	}
+}
+
+// Options configures the generation of cutouts from components
+type Options struct {
+	// Offset is the panel position of the PCB origin
+	Offset geometry.Point
+	// Rules are tried in order; the first matching rule gives the hole
+	// diameter. Components matching no rule are skipped.
+	Rules []Rule
+	// Bottom indicates the bottom of the PCB faces the panel. Positions
+	// are mirrored left to right, as the PCB is seen from behind, and
+	// components on the top are skipped, as are components on the bottom
+	// otherwise.
+	Bottom bool
+	// Diagnostics receives warnings about skipped components
+	Diagnostics diag.Reporter
+}
+
+// DefaultOptions returns an Options using the default rules
+func DefaultOptions() Options {
+	return Options{Rules: DefaultRules(), Diagnostics: diag.Logger{}}
+}
+
+// Load reads components from a .kicad_pcb file or, for any other
+// extension, a position CSV export
+func Load(filename string) ([]Component, error) {
+	if filepath.Ext(filename) == ".kicad_pcb" {
+		text, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return ParsePCB(string(text))
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParsePositions(csv.NewReader(f))
+}
+
+// ParsePCB reads components from the text of a .kicad_pcb file. Both the
+// KiCad 6+ footprint and KiCad 5 module syntax are supported.
+func ParsePCB(text string) ([]Component, error) {
+	root, err := parseSexpr(text)
+	if err != nil {
+		return nil, fmt.Errorf("kicad_pcb: %v", err)
+	}
+	if root.head() != "kicad_pcb" {
+		return nil, errors.New("kicad_pcb: not a KiCad PCB file")
+	}
+	comps := []Component{}
+	for _, fp := range append(root.children("footprint"), root.children("module")...) {
+		c := Component{Footprint: fp.arg(0)}
+		if _, name, ok := strings.Cut(c.Footprint, ":"); ok {
+			c.Footprint = name
+		}
+		if l := fp.child("layer"); l != nil {
+			c.Bottom = l.arg(0) == "B.Cu"
+		}
+		if at := fp.child("at"); at != nil {
+			x, _ := strconv.ParseFloat(at.arg(0), 64)
+			y, _ := strconv.ParseFloat(at.arg(1), 64)
+			c.Rotation, _ = strconv.ParseFloat(at.arg(2), 64)
+			// PCB coordinates have Y increasing downwards
+			c.Position = geometry.Point{X: x, Y: -y}
+		}
+		for _, prop := range fp.children("property") {
+			switch prop.arg(0) {
+			case "Reference":
+				c.Reference = prop.arg(1)
+			case "Value":
+				c.Value = prop.arg(1)
+			}
+		}
+		for _, text := range fp.children("fp_text") {
+			switch text.arg(0) {
+			case "reference":
+				c.Reference = text.arg(1)
+			case "value":
+				c.Value = text.arg(1)
+			}
+		}
+		comps = append(comps, c)
+	}
+	return comps, nil
+}
+
+// ParsePositions reads components from a KiCad footprint position CSV
+// export, with columns Ref, Val, Package, PosX, PosY, Rot and Side
+func ParsePositions(r *csv.Reader) ([]Component, error) {
+	// blank lines are skipped, so the line of each record is kept for
+	// reporting errors
+	records, lines := [][]string{}, []int{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		records, lines = append(records, rec), append(lines, line)
+	}
+	if len(records) < 1 {
+		return nil, errors.New("positions: empty file")
+	}
+	col := map[string]int{}
+	for i, name := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"ref", "val", "package", "posx", "posy"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("positions: missing column %q", name)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	comps := []Component{}
+	for n, rec := range records[1:] {
+		x, err := strconv.ParseFloat(field(rec, "posx"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("positions: line %d: invalid PosX: %v", lines[n+1], err)
+		}
+		y, err := strconv.ParseFloat(field(rec, "posy"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("positions: line %d: invalid PosY: %v", lines[n+1], err)
+		}
+		rot, _ := strconv.ParseFloat(field(rec, "rot"), 64)
+		comps = append(comps, Component{
+			Reference: field(rec, "ref"),
+			Value:     field(rec, "val"),
+			Footprint: field(rec, "package"),
+			// position files already have Y increasing upwards
+			Position: geometry.Point{X: x, Y: y},
+			Rotation: rot,
+			Bottom:   strings.EqualFold(field(rec, "side"), "bottom"),
+		})
+	}
+	return comps, nil
+}
+
+// rule returns the first rule matching a component, if any
+func (o Options) rule(c Component) (Rule, bool) {
+	for _, r := range o.Rules {
+		if r.Pattern.MatchString(c.Footprint) || r.Pattern.MatchString(c.Value) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// position returns the panel position of the hole for a component
+func (o Options) position(c Component, r Rule) geometry.Point {
+	offset := r.Offset
+	if c.Bottom {
+		offset.X = -offset.X
+	}
+	p := c.Position.Add(offset.Rotate(c.Rotation))
+	if o.Bottom {
+		p.X = -p.X
+	}
+	return o.Offset.Add(p)
+}
+
+// GenerateFeatures generates a Cutout circle for each component on the side
+// of the PCB facing the panel matching a rule, positioned relative to the
+// offset
+func GenerateFeatures(comps []Component, opts Options) []features.Feature {
+	if opts.Diagnostics == nil {
+		opts.Diagnostics = diag.Discard
+	}
+	feats := []features.Feature{}
+	for _, c := range comps {
+		if c.Bottom != opts.Bottom {
+			opts.Diagnostics.Report(diag.Diagnostic{
+				Severity: diag.Info,
+				Message:  fmt.Sprintf("%s is on the side of the PCB away from the panel, skipping", c.Reference),
+			})
+			continue
+		}
+		r, ok := opts.rule(c)
+		if !ok {
+			opts.Diagnostics.Report(diag.Diagnostic{
+				Severity: diag.Info,
+				Message:  fmt.Sprintf("no panel hole rule for %s (%s, %s), skipping", c.Reference, c.Footprint, c.Value),
+			})
+			continue
+		}
+		hole := features.NewCircle(opts.position(c, r), r.Diameter/2)
+		hole.SetPurpose(features.Cutout)
+		feats = append(feats, hole)
+	}
+	return feats
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package kicadpcb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// node is an S-expression: either an atom or a list
+type node struct {
+	atom string
+	list []*node
+	// isList distinguishes an empty list from an empty atom
+	isList bool
+}
+
+// head returns the first atom of a list, typically its keyword
+func (n *node) head() string {
+	if !n.isList || len(n.list) == 0 {
+		return ""
+	}
+	return n.list[0].atom
+}
+
+// children returns the sublists of a list with the given keyword
+func (n *node) children(keyword string) []*node {
+	found := []*node{}
+	for _, c := range n.list {
+		if c.head() == keyword {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// child returns the first sublist with the given keyword, or nil
+func (n *node) child(keyword string) *node {
+	if c := n.children(keyword); len(c) > 0 {
+		return c[0]
+	}
+	return nil
+}
+
+// arg returns the i'th argument (after the keyword) of a list as an atom
+func (n *node) arg(i int) string {
+	if i+1 < len(n.list) {
+		return n.list[i+1].atom
+	}
+	return ""
+}
+
+// parseSexpr parses a single S-expression, as used by KiCad files
+func parseSexpr(s string) (*node, error) {
+	p := &sexprParser{s: s}
+	n, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if !n.isList {
+		return nil, errors.New("expected a list")
+	}
+	return n, nil
+}
+
+type sexprParser struct {
+	s string
+	i int
+}
+
+func (p *sexprParser) skip() {
+	for p.i < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.i])) {
+		p.i++
+	}
+}
+
+func (p *sexprParser) parse() (*node, error) {
+	p.skip()
+	if p.i >= len(p.s) {
+		return nil, errors.New("unexpected end of input")
+	}
+	switch p.s[p.i] {
+	case '(':
+		p.i++
+		n := &node{isList: true}
+		for {
+			p.skip()
+			if p.i >= len(p.s) {
+				return nil, errors.New("unterminated list")
+			}
+			if p.s[p.i] == ')' {
+				p.i++
+				return n, nil
+			}
+			c, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			n.list = append(n.list, c)
+		}
+	case ')':
+		return nil, fmt.Errorf("unexpected ) at offset %d", p.i)
+	case '"':
+		p.i++
+		var b strings.Builder
+		for p.i < len(p.s) && p.s[p.i] != '"' {
+			if p.s[p.i] == '\\' && p.i+1 < len(p.s) {
+				p.i++
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		}
+		if p.i >= len(p.s) {
+			return nil, errors.New("unterminated string")
+		}
+		p.i++
+		return &node{atom: b.String()}, nil
+	}
+	start := p.i
+	for p.i < len(p.s) && !strings.ContainsRune(" \t\r\n()", rune(p.s[p.i])) {
+		p.i++
+	}
+	return &node{atom: p.s[start:p.i]}, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package matrixcode generates two-dimensional barcodes (QR codes and Data
+// Matrix symbols) as panel features, for linking to build guides or
+// carrying serial numbers.
+package matrixcode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/datamatrix"
+	"github.com/boombuler/barcode/qr"
+
+	"github.com/jsleeio/frontpanels/pkg/fab"
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// Symbology indicates the type of barcode to generate
+type Symbology int
+
+// QR et al specify barcode symbologies
+const (
+	// QR codes are readable by most phone cameras
+	QR Symbology = iota
+	// DataMatrix symbols are more compact than QR codes for short content
+	DataMatrix
+)
+
+// String satisfies the Stringer interface to aid debug printing
+func (s Symbology) String() string {
+	switch s {
+	case QR:
+		return "qr"
+	case DataMatrix:
+		return "datamatrix"
+	}
+	panic(fmt.Sprintf("invalid Symbology value (valid range is %d..%d): %d",
+		int(QR), int(DataMatrix), int(s)))
+}
+
+// ParseSymbology converts a string as returned by Symbology.String to a
+// Symbology
+func ParseSymbology(s string) (Symbology, error) {
+	for _, sym := range []Symbology{QR, DataMatrix} {
+		if sym.String() == s {
+			return sym, nil
+		}
+	}
+	return QR, fmt.Errorf("invalid barcode symbology %q (valid values: qr datamatrix)", s)
+}
+
+// QuietZone returns the number of blank modules a scanner needs around the
+// symbol. The generated features do not include the quiet zone, so no other
+// features should be placed there.
+func (s Symbology) QuietZone() int {
+	if s == DataMatrix {
+		return 1
+	}
+	return 4
+}
+
+// Options configures barcode generation. Distances are in millimetres.
+type Options struct {
+	Symbology Symbology
+	// Content is the text to encode, eg. a URL or serial number
+	Content string
+	// Origin is the bottom-left corner of the symbol
+	Origin geometry.Point
+	// Size is the width of the symbol, excluding the quiet zone
+	Size float64
+	// Purpose of the generated features. Cutout is not supported.
+	Purpose features.Purpose
+	// Side of the board to place the symbol on
+	Side features.Side
+}
+
+func (o Options) encode() (barcode.Barcode, error) {
+	if o.Symbology == DataMatrix {
+		return datamatrix.Encode(o.Content)
+	}
+	return qr.Encode(o.Content, qr.M, qr.Auto)
+}
+
+// GenerateFeatures generates filled polygons for the dark modules of a
+// barcode, merging horizontally adjacent modules. An error is returned if
+// the modules would be smaller than the fab can reliably reproduce.
+func GenerateFeatures(opts Options, profile fab.Profile) ([]features.Feature, error) {
+	if opts.Content == "" {
+		return nil, errors.New("barcode: content must not be empty")
+	}
+	if opts.Size <= 0 {
+		return nil, errors.New("barcode: size must be greater than 0")
+	}
+	if opts.Purpose == features.Cutout {
+		return nil, errors.New("barcode: cutout purpose is not supported")
+	}
+	code, err := opts.encode()
+	if err != nil {
+		return nil, fmt.Errorf("barcode: %v", err)
+	}
+	bounds := code.Bounds()
+	module := opts.Size / float64(bounds.Dx())
+	if min := profile.MinFeatureWidth(opts.Purpose); module < min {
+		return nil, fmt.Errorf("barcode: %s module size %.3fmm is below the %s minimum feature width of %.3fmm; need a size of at least %.2fmm",
+			opts.Symbology, module, profile.Name, min, min*float64(bounds.Dx()))
+	}
+	dark := func(x, y int) bool {
+		r, g, b, _ := code.At(x, y).RGBA()
+		return r+g+b < 0x8000*3
+	}
+	feats := []features.Feature{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		// image rows run top to bottom
+		top := opts.Origin.Y + float64(bounds.Max.Y-y)*module
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !dark(x, y) {
+				continue
+			}
+			start := x
+			for x+1 < bounds.Max.X && dark(x+1, y) {
+				x++
+			}
+			rect := features.NewRectangle(
+				geometry.Point{X: opts.Origin.X + float64(start-bounds.Min.X)*module, Y: top - module},
+				geometry.Point{X: opts.Origin.X + float64(x+1-bounds.Min.X)*module, Y: top},
+			)
+			rect.SetPurpose(opts.Purpose)
+			rect.SetSide(opts.Side)
+			feats = append(feats, rect)
+		}
+	}
+	return feats, nil
+}
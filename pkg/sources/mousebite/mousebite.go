@@ -0,0 +1,227 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package mousebite generates breakaway "mouse-bite" tabs between two
+// adjacent board edges, as used when panelizing several panels onto one
+// fabrication panel (or when a panel has a snap-off section).
+package mousebite
+
+import (
+	"errors"
+	"math"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+const (
+	// DefaultTabWidth is the default length of each tab along the board edge,
+	// in millimetres
+	DefaultTabWidth = 5.0
+
+	// DefaultHoleDiameter is the default perforation drill size, in
+	// millimetres. 0.5mm is about the smallest size most fabs will drill
+	// without surcharge.
+	DefaultHoleDiameter = 0.5
+
+	// DefaultHolePitch is the default centre-to-centre distance between
+	// perforation holes, in millimetres
+	DefaultHolePitch = 0.8
+
+	// DefaultGap is the default width of the routed slot between the two
+	// board edges, in millimetres. 2mm is a common router bit size.
+	DefaultGap = 2.0
+
+	// outlineThickness matches the line thickness used for panel outlines
+	outlineThickness = 0.1
+)
+
+// Options configures mouse-bite tab generation. All distances are in
+// millimetres.
+type Options struct {
+	// TabWidth is the length of each tab, measured along the board edge
+	TabWidth float64
+	// TabCount is the number of tabs, evenly distributed along the edge
+	TabCount int
+	// HoleDiameter is the diameter of each perforation hole
+	HoleDiameter float64
+	// HolePitch is the centre-to-centre distance between perforation holes
+	HolePitch float64
+	// Gap is the width of the routed slot separating the two board edges
+	Gap float64
+}
+
+// DefaultOptions returns a reasonable set of options for a single tab
+func DefaultOptions() Options {
+	return Options{
+		TabWidth:     DefaultTabWidth,
+		TabCount:     1,
+		HoleDiameter: DefaultHoleDiameter,
+		HolePitch:    DefaultHolePitch,
+		Gap:          DefaultGap,
+	}
+}
+
+func (o Options) validate(length float64) error {
+	switch {
+	case o.TabCount < 1:
+		return errors.New("mousebite: need at least one tab")
+	case o.TabWidth <= 0:
+		return errors.New("mousebite: tab width must be greater than 0")
+	case o.HoleDiameter <= 0:
+		return errors.New("mousebite: hole diameter must be greater than 0")
+	case o.HolePitch < o.HoleDiameter:
+		return errors.New("mousebite: hole pitch must be at least the hole diameter")
+	case o.Gap <= 0:
+		return errors.New("mousebite: gap must be greater than 0")
+	case o.HoleDiameter > o.TabWidth:
+		return errors.New("mousebite: holes do not fit within tab width")
+	case float64(o.TabCount)*o.TabWidth > length:
+		return errors.New("mousebite: tabs do not fit along edge")
+	}
+	return nil
+}
+
+// joint locates the board edges of a mouse-bite joint
+type joint struct {
+	length float64
+	// edgeA and edgeB return the point d millimetres along the edge of the
+	// first and second boards
+	edgeA, edgeB func(d float64) geometry.Point
+}
+
+// newJoint validates the options for a joint between the line from start
+// to end and the parallel edge of the second board
+func newJoint(start, end geometry.Point, opts Options) (joint, error) {
+	length := start.Distance(end)
+	if err := opts.validate(length); err != nil {
+		return joint{}, err
+	}
+	dir := end.Sub(start).Unit()
+	offset := dir.Perpendicular().Scale(opts.Gap)
+	j := joint{length: length}
+	j.edgeA = func(d float64) geometry.Point { return start.Add(dir.Scale(d)) }
+	j.edgeB = func(d float64) geometry.Point { return j.edgeA(d).Add(offset) }
+	return j, nil
+}
+
+// tabs returns the start and end of each tab, as distances along the edge
+func (j joint) tabs(opts Options) [][2]float64 {
+	tabs := [][2]float64{}
+	for i := 0; i < opts.TabCount; i++ {
+		centre := j.length * (float64(i) + 0.5) / float64(opts.TabCount)
+		tabs = append(tabs, [2]float64{centre - opts.TabWidth/2.0, centre + opts.TabWidth/2.0})
+	}
+	return tabs
+}
+
+// perforations returns the perforation holes along both board edges,
+// centred within a tab
+func (j joint) perforations(opts Options, tab [2]float64) []features.Feature {
+	f := []features.Feature{}
+	centre := (tab[0] + tab[1]) / 2.0
+	holes := int(math.Floor((opts.TabWidth-opts.HoleDiameter)/opts.HolePitch)) + 1
+	first := centre - opts.HolePitch*float64(holes-1)/2.0
+	for h := 0; h < holes; h++ {
+		d := first + opts.HolePitch*float64(h)
+		for _, centre := range []geometry.Point{j.edgeA(d), j.edgeB(d)} {
+			hole := features.NewCircle(centre, opts.HoleDiameter/2.0)
+			hole.SetPurpose(features.Cutout)
+			f = append(f, hole)
+		}
+	}
+	return f
+}
+
+// GenerateFeatures emits the routed slot edges and perforation holes for a
+// mouse-bite joint. The line from start to end describes the edge of the
+// first board; the edge of the second board is parallel to it, Gap
+// millimetres to the right when looking from start towards end.
+//
+// Slot edges are emitted as Cutout lines, and perforations as (non-plated)
+// Cutout circles centred on each board edge. Closing the remainder of each
+// board outline is left to the caller.
+func GenerateFeatures(start, end geometry.Point, opts Options) ([]features.Feature, error) {
+	j, err := newJoint(start, end, opts)
+	if err != nil {
+		return nil, err
+	}
+	cutline := func(a, b geometry.Point) features.Feature {
+		l := features.NewLine(a, b, outlineThickness)
+		l.SetPurpose(features.Cutout)
+		return l
+	}
+	f := []features.Feature{}
+	// slots run between the tabs, and from each end of the edge to the
+	// nearest tab
+	slotStart := 0.0
+	for _, tab := range j.tabs(opts) {
+		if tab[0] > slotStart {
+			f = append(f,
+				cutline(j.edgeA(slotStart), j.edgeA(tab[0])),
+				cutline(j.edgeB(slotStart), j.edgeB(tab[0])),
+			)
+		}
+		// sides of the tab, closing off the adjacent slots
+		f = append(f,
+			cutline(j.edgeA(tab[0]), j.edgeB(tab[0])),
+			cutline(j.edgeA(tab[1]), j.edgeB(tab[1])),
+		)
+		f = append(f, j.perforations(opts, tab)...)
+		slotStart = tab[1]
+	}
+	if j.length > slotStart {
+		f = append(f,
+			cutline(j.edgeA(slotStart), j.edgeA(j.length)),
+			cutline(j.edgeB(slotStart), j.edgeB(j.length)),
+		)
+	}
+	return f, nil
+}
+
+// Tabs returns the outline of each tab of a mouse-bite joint, bridging the
+// gap between the boards, for merging into the outline of a single board
+// holding both. The joint is described as for GenerateFeatures.
+func Tabs(start, end geometry.Point, opts Options) ([][]geometry.Point, error) {
+	j, err := newJoint(start, end, opts)
+	if err != nil {
+		return nil, err
+	}
+	tabs := [][]geometry.Point{}
+	for _, tab := range j.tabs(opts) {
+		tabs = append(tabs, []geometry.Point{j.edgeA(tab[0]), j.edgeB(tab[0]), j.edgeB(tab[1]), j.edgeA(tab[1])})
+	}
+	return tabs, nil
+}
+
+// Perforations returns only the perforation holes of a mouse-bite joint,
+// described as for GenerateFeatures, for boards whose outline already
+// includes the tabs
+func Perforations(start, end geometry.Point, opts Options) ([]features.Feature, error) {
+	j, err := newJoint(start, end, opts)
+	if err != nil {
+		return nil, err
+	}
+	f := []features.Feature{}
+	for _, tab := range j.tabs(opts) {
+		f = append(f, j.perforations(opts, tab)...)
+	}
+	return f, nil
+}
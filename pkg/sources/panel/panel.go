@@ -24,21 +24,16 @@ package panel
 
 import (
 	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
 	"github.com/jsleeio/frontpanels/pkg/panel"
 )
 
 // GeneratePanelOutlineFeatures generates the basic features for a blank panel:
-// an outline and some mounting holes
+// an outline, as a single closed contour with any rounded corners or custom
+// shape, and some mounting holes
 func GeneratePanelOutlineFeatures(p panel.Panel) []features.Feature {
-	top := features.NewLine(panel.TopLeft(p), panel.TopRight(p), 0.1)
-	top.SetPurpose(features.Cutout)
-	bottom := features.NewLine(panel.BottomLeft(p), panel.BottomRight(p), 0.1)
-	bottom.SetPurpose(features.Cutout)
-	left := features.NewLine(panel.TopLeft(p), panel.BottomLeft(p), 0.1)
-	left.SetPurpose(features.Cutout)
-	right := features.NewLine(panel.TopRight(p), panel.BottomRight(p), 0.1)
-	right.SetPurpose(features.Cutout)
-	f := []features.Feature{top, bottom, left, right}
+	outline := features.NewOutline(panel.Outline(p, geometry.DefaultTolerance))
+	f := []features.Feature{outline}
 	for _, centre := range p.MountingHoles() {
 		hole := features.NewCircle(centre, p.MountingHoleDiameter()/2.0)
 		hole.SetPurpose(features.Cutout)
@@ -46,3 +41,13 @@ func GeneratePanelOutlineFeatures(p panel.Panel) []features.Feature {
 	}
 	return f
 }
+
+// GeneratePanelDimensions generates Annotation dimensions for the overall
+// size of a panel, outside its outline: width below, height to the left
+func GeneratePanelDimensions(p panel.Panel) []features.Feature {
+	const offset = 5.0
+	return []features.Feature{
+		features.NewDimension(panel.BottomLeft(p), panel.BottomRight(p), -offset),
+		features.NewDimension(panel.BottomLeft(p), panel.TopLeft(p), offset),
+	}
+}
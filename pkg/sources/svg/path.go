@@ -0,0 +1,350 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+)
+
+// subpath is a flattened, transformed portion of an SVG path
+type subpath struct {
+	points []geometry.Point
+	closed bool
+}
+
+// scanner tokenises SVG path data
+type scanner struct {
+	s string
+	i int
+}
+
+func (sc *scanner) skip() {
+	for sc.i < len(sc.s) {
+		switch sc.s[sc.i] {
+		case ' ', '\t', '\n', '\r', ',':
+			sc.i++
+		default:
+			return
+		}
+	}
+}
+
+func (sc *scanner) done() bool {
+	sc.skip()
+	return sc.i >= len(sc.s)
+}
+
+// hasNumber indicates whether the next token is a number
+func (sc *scanner) hasNumber() bool {
+	sc.skip()
+	if sc.i >= len(sc.s) {
+		return false
+	}
+	c := sc.s[sc.i]
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.'
+}
+
+func (sc *scanner) command() (byte, error) {
+	sc.skip()
+	c := sc.s[sc.i]
+	if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+		return 0, fmt.Errorf("expected path command at offset %d, found %q", sc.i, c)
+	}
+	sc.i++
+	return c, nil
+}
+
+func (sc *scanner) number() (float64, error) {
+	sc.skip()
+	start := sc.i
+	digits := func() {
+		for sc.i < len(sc.s) && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+			sc.i++
+		}
+	}
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '-' || sc.s[sc.i] == '+') {
+		sc.i++
+	}
+	digits()
+	if sc.i < len(sc.s) && sc.s[sc.i] == '.' {
+		sc.i++
+		digits()
+	}
+	if sc.i < len(sc.s) && (sc.s[sc.i] == 'e' || sc.s[sc.i] == 'E') {
+		sc.i++
+		if sc.i < len(sc.s) && (sc.s[sc.i] == '-' || sc.s[sc.i] == '+') {
+			sc.i++
+		}
+		digits()
+	}
+	v, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number at offset %d: %q", start, sc.s[start:sc.i])
+	}
+	return v, nil
+}
+
+// flag reads an arc flag, which may not be separated from what follows
+func (sc *scanner) flag() (bool, error) {
+	sc.skip()
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '0' || sc.s[sc.i] == '1') {
+		sc.i++
+		return sc.s[sc.i-1] == '1', nil
+	}
+	return false, fmt.Errorf("invalid arc flag at offset %d", sc.i)
+}
+
+// numbers reads n numbers
+func (sc *scanner) numbers(n int) ([]float64, error) {
+	v := make([]float64, n)
+	for i := range v {
+		var err error
+		if v[i], err = sc.number(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// pathBuilder accumulates flattened subpaths
+type pathBuilder struct {
+	m         matrix
+	tolerance float64
+	paths     []subpath
+	current   *subpath
+}
+
+func (pb *pathBuilder) moveTo(p geometry.Point) {
+	pb.paths = append(pb.paths, subpath{points: []geometry.Point{pb.m.apply(p)}})
+	pb.current = &pb.paths[len(pb.paths)-1]
+}
+
+func (pb *pathBuilder) lineTo(p geometry.Point) {
+	pb.current.points = append(pb.current.points, pb.m.apply(p))
+}
+
+func (pb *pathBuilder) cubicTo(p0, p1, p2, p3 geometry.Point) {
+	pts := geometry.CubicBezier(pb.m.apply(p0), pb.m.apply(p1), pb.m.apply(p2), pb.m.apply(p3), pb.tolerance)
+	pb.current.points = append(pb.current.points, pts[1:]...)
+}
+
+// angle returns the angle between two vectors, in degrees
+func angle(u, v geometry.Point) float64 {
+	return math.Atan2(u.X*v.Y-u.Y*v.X, u.X*v.X+u.Y*v.Y) * 180.0 / math.Pi
+}
+
+// arcTo adds an SVG elliptical arc, converting from endpoint to centre
+// parameterisation as described in the SVG specification, appendix F.6.5
+func (pb *pathBuilder) arcTo(p1 geometry.Point, rx, ry, phi float64, large, sweep bool, p2 geometry.Point) {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || p1 == p2 {
+		pb.lineTo(p2)
+		return
+	}
+	d := p1.Sub(p2).Scale(0.5).Rotate(-phi)
+	if lambda := d.X*d.X/(rx*rx) + d.Y*d.Y/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*d.Y*d.Y - ry*ry*d.X*d.X
+	den := rx*rx*d.Y*d.Y + ry*ry*d.X*d.X
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cp := geometry.Point{X: coef * rx * d.Y / ry, Y: -coef * ry * d.X / rx}
+	centre := cp.Rotate(phi).Add(p1.Add(p2).Scale(0.5))
+	u := geometry.Point{X: (d.X - cp.X) / rx, Y: (d.Y - cp.Y) / ry}
+	v := geometry.Point{X: (-d.X - cp.X) / rx, Y: (-d.Y - cp.Y) / ry}
+	start := angle(geometry.Point{X: 1, Y: 0}, u)
+	delta := angle(u, v)
+	if !sweep && delta > 0 {
+		delta -= 360
+	} else if sweep && delta < 0 {
+		delta += 360
+	}
+	pts := geometry.EllipticalArc(centre, rx, ry, phi, start, start+delta, pb.tolerance/pb.m.scale())
+	for _, p := range pts[1:] {
+		pb.lineTo(p)
+	}
+}
+
+// parsePath parses SVG path data, returning flattened subpaths transformed
+// by m. The tolerance applies after transformation.
+func parsePath(d string, m matrix, tolerance float64) ([]subpath, error) {
+	pb := &pathBuilder{m: m, tolerance: tolerance}
+	sc := &scanner{s: d}
+	var cur, start, ctrl geometry.Point
+	var cmd, last byte
+	for !sc.done() {
+		if !sc.hasNumber() {
+			var err error
+			if cmd, err = sc.command(); err != nil {
+				return nil, err
+			}
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("path data must begin with a command")
+		}
+		rel := cmd >= 'a' && cmd <= 'z'
+		abs := func(x, y float64) geometry.Point {
+			if rel {
+				return geometry.Point{X: cur.X + x, Y: cur.Y + y}
+			}
+			return geometry.Point{X: x, Y: y}
+		}
+		if pb.current == nil && cmd != 'M' && cmd != 'm' {
+			return nil, fmt.Errorf("path data must begin with a moveto command")
+		}
+		switch cmd {
+		case 'M', 'm':
+			v, err := sc.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			cur = abs(v[0], v[1])
+			start = cur
+			pb.moveTo(cur)
+			// subsequent coordinate pairs are implicit lineto commands
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L', 'l':
+			v, err := sc.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			cur = abs(v[0], v[1])
+			pb.lineTo(cur)
+		case 'H', 'h':
+			v, err := sc.number()
+			if err != nil {
+				return nil, err
+			}
+			if rel {
+				cur.X += v
+			} else {
+				cur.X = v
+			}
+			pb.lineTo(cur)
+		case 'V', 'v':
+			v, err := sc.number()
+			if err != nil {
+				return nil, err
+			}
+			if rel {
+				cur.Y += v
+			} else {
+				cur.Y = v
+			}
+			pb.lineTo(cur)
+		case 'C', 'c', 'S', 's':
+			smooth := cmd == 'S' || cmd == 's'
+			n := 6
+			if smooth {
+				n = 4
+			}
+			v, err := sc.numbers(n)
+			if err != nil {
+				return nil, err
+			}
+			var c1 geometry.Point
+			if smooth {
+				// reflection of the previous control point, if there was one
+				c1 = cur
+				if last == 'C' || last == 'c' || last == 'S' || last == 's' {
+					c1 = cur.Scale(2).Sub(ctrl)
+				}
+				v = append([]float64{0, 0}, v...)
+			} else {
+				c1 = abs(v[0], v[1])
+			}
+			c2, end := abs(v[2], v[3]), abs(v[4], v[5])
+			pb.cubicTo(cur, c1, c2, end)
+			ctrl, cur = c2, end
+		case 'Q', 'q', 'T', 't':
+			smooth := cmd == 'T' || cmd == 't'
+			var c, end geometry.Point
+			if smooth {
+				v, err := sc.numbers(2)
+				if err != nil {
+					return nil, err
+				}
+				c = cur
+				if last == 'Q' || last == 'q' || last == 'T' || last == 't' {
+					c = cur.Scale(2).Sub(ctrl)
+				}
+				end = abs(v[0], v[1])
+			} else {
+				v, err := sc.numbers(4)
+				if err != nil {
+					return nil, err
+				}
+				c, end = abs(v[0], v[1]), abs(v[2], v[3])
+			}
+			// degree elevation to an equivalent cubic
+			c1 := cur.Add(c.Sub(cur).Scale(2.0 / 3.0))
+			c2 := end.Add(c.Sub(end).Scale(2.0 / 3.0))
+			pb.cubicTo(cur, c1, c2, end)
+			ctrl, cur = c, end
+		case 'A', 'a':
+			v, err := sc.numbers(3)
+			if err != nil {
+				return nil, err
+			}
+			large, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			e, err := sc.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			end := abs(e[0], e[1])
+			pb.arcTo(cur, v[0], v[1], v[2], large, sweep, end)
+			cur = end
+		case 'Z', 'z':
+			pb.current.closed = true
+			cur = start
+			// a new subpath implicitly starts at the same point
+			pb.moveTo(cur)
+		default:
+			return nil, fmt.Errorf("unsupported path command %q", cmd)
+		}
+		last = cmd
+	}
+	// drop subpaths consisting of only a moveto
+	paths := []subpath{}
+	for _, p := range pb.paths {
+		if len(p.points) > 1 {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
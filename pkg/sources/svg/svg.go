@@ -0,0 +1,343 @@
+// Copyright 2023 John Slee <jslee@jslee.io>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package svg imports simple SVG artwork as panel features. Paths and basic
+// shapes are flattened into line segments with a configurable tolerance;
+// styling, text, gradients and so on are ignored.
+package svg
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jsleeio/frontpanels/pkg/features"
+	"github.com/jsleeio/frontpanels/pkg/geometry"
+	"github.com/jsleeio/frontpanels/pkg/render/flatten"
+)
+
+// Options configures SVG import. Distances are in millimetres.
+type Options struct {
+	// Origin is the panel position of the top-left corner of the SVG canvas
+	Origin geometry.Point
+	// Scale is the size of one SVG user unit. If zero, it is derived from the
+	// width and viewBox attributes of the root element, assuming 96 pixels
+	// per inch where no unit is given.
+	Scale float64
+	// Tolerance is the maximum deviation of flattened curves from the true
+	// curves, in millimetres. The default is flatten.Fine, as the curves are
+	// fabricated.
+	Tolerance float64
+	// Thickness of lines generated from SVG paths
+	Thickness float64
+	// Purpose of the generated features
+	Purpose features.Purpose
+	// Fill renders closed paths as filled polygons rather than outlines.
+	// Closed cutout paths are always rendered as polygons.
+	Fill bool
+}
+
+// DefaultOptions returns an Options with sensible defaults for silkscreen
+// artwork
+func DefaultOptions() Options {
+	return Options{
+		Tolerance: flatten.Fine,
+		Thickness: 0.1,
+		Purpose:   features.Marking,
+	}
+}
+
+// units maps SVG length units to millimetres
+var units = map[string]float64{
+	"":   25.4 / 96.0,
+	"px": 25.4 / 96.0,
+	"pt": 25.4 / 72.0,
+	"pc": 25.4 / 6.0,
+	"in": 25.4,
+	"cm": 10.0,
+	"mm": 1.0,
+}
+
+// parseLength parses an SVG length, returning the value and its unit
+func parseLength(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') && s[i-1] != '.' {
+		i--
+	}
+	unit := s[i:]
+	if _, ok := units[unit]; !ok {
+		return 0, "", fmt.Errorf("unsupported length unit in %q", s)
+	}
+	v, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid length %q", s)
+	}
+	return v, unit, nil
+}
+
+// attrs provides convenient access to element attributes
+type attrs map[string]string
+
+func newAttrs(el xml.StartElement) attrs {
+	a := attrs{}
+	for _, attr := range el.Attr {
+		a[attr.Name.Local] = attr.Value
+	}
+	return a
+}
+
+// number returns a numeric attribute in user units, zero if absent
+func (a attrs) number(name string) (float64, error) {
+	s, ok := a[name]
+	if !ok {
+		return 0, nil
+	}
+	v, unit, err := parseLength(s)
+	if err != nil {
+		return 0, fmt.Errorf("attribute %s: %v", name, err)
+	}
+	if unit != "" && unit != "px" {
+		return 0, fmt.Errorf("attribute %s: units are only supported on the root element", name)
+	}
+	return v, nil
+}
+
+// numbers returns several numeric attributes
+func (a attrs) numbers(names ...string) ([]float64, error) {
+	v := make([]float64, len(names))
+	for i, name := range names {
+		var err error
+		if v[i], err = a.number(name); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// rootTransform maps SVG user units of the root element into panel
+// coordinates, flipping the Y axis
+func rootTransform(a attrs, opts Options) (matrix, error) {
+	var vb []float64
+	if s, ok := a["viewBox"]; ok {
+		for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return identity, fmt.Errorf("invalid viewBox %q", s)
+			}
+			vb = append(vb, v)
+		}
+		if len(vb) != 4 || vb[2] <= 0 || vb[3] <= 0 {
+			return identity, fmt.Errorf("invalid viewBox %q", s)
+		}
+	}
+	scale := opts.Scale
+	if scale == 0 {
+		scale = units[""]
+		if w, ok := a["width"]; ok {
+			v, unit, err := parseLength(w)
+			if err != nil {
+				return identity, fmt.Errorf("root element width: %v", err)
+			}
+			scale = units[unit]
+			if vb != nil {
+				scale = v * units[unit] / vb[2]
+			}
+		}
+	}
+	m := matrix{scale, 0, 0, -scale, opts.Origin.X, opts.Origin.Y}
+	if vb != nil {
+		m = m.multiply(matrix{1, 0, 0, 1, -vb[0], -vb[1]})
+	}
+	return m, nil
+}
+
+// shapePath converts a basic shape element into equivalent path data
+func shapePath(name string, a attrs) (string, error) {
+	switch name {
+	case "path":
+		return a["d"], nil
+	case "line":
+		v, err := a.numbers("x1", "y1", "x2", "y2")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("M%g,%g L%g,%g", v[0], v[1], v[2], v[3]), nil
+	case "polyline", "polygon":
+		d := "M" + a["points"]
+		if name == "polygon" {
+			d += "Z"
+		}
+		return d, nil
+	case "rect":
+		v, err := a.numbers("x", "y", "width", "height", "rx", "ry")
+		if err != nil {
+			return "", err
+		}
+		x, y, w, h, rx, ry := v[0], v[1], v[2], v[3], v[4], v[5]
+		if _, ok := a["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := a["rx"]; !ok {
+			rx = ry
+		}
+		if rx > w/2 {
+			rx = w / 2
+		}
+		if ry > h/2 {
+			ry = h / 2
+		}
+		if rx == 0 || ry == 0 {
+			return fmt.Sprintf("M%g,%g h%g v%g h%g Z", x, y, w, h, -w), nil
+		}
+		return fmt.Sprintf("M%g,%g H%g A%g,%g 0 0 1 %g,%g V%g A%g,%g 0 0 1 %g,%g H%g A%g,%g 0 0 1 %g,%g V%g A%g,%g 0 0 1 %g,%g Z",
+			x+rx, y, x+w-rx, rx, ry, x+w, y+ry, y+h-ry, rx, ry, x+w-rx, y+h,
+			x+rx, rx, ry, x, y+h-ry, y+ry, rx, ry, x+rx, y), nil
+	case "circle", "ellipse":
+		var v []float64
+		var err error
+		if name == "circle" {
+			v, err = a.numbers("cx", "cy", "r", "r")
+		} else {
+			v, err = a.numbers("cx", "cy", "rx", "ry")
+		}
+		if err != nil {
+			return "", err
+		}
+		cx, cy, rx, ry := v[0], v[1], v[2], v[3]
+		if rx <= 0 || ry <= 0 {
+			return "", nil
+		}
+		return fmt.Sprintf("M%g,%g A%g,%g 0 1 0 %g,%g A%g,%g 0 1 0 %g,%g Z",
+			cx-rx, cy, rx, ry, cx+rx, cy, rx, ry, cx-rx, cy), nil
+	}
+	return "", nil
+}
+
+// skipped elements do not render directly
+var skipped = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "symbol": true,
+	"marker": true, "pattern": true, "metadata": true, "title": true,
+	"desc": true, "style": true,
+}
+
+// features converts a subpath into features according to the options
+func (o Options) features(sp subpath) []features.Feature {
+	feats := []features.Feature{}
+	if sp.closed && (o.Fill || o.Purpose == features.Cutout) && len(sp.points) > 2 {
+		poly := features.NewPolygon(sp.points)
+		poly.SetPurpose(o.Purpose)
+		return append(feats, poly)
+	}
+	points := sp.points
+	if sp.closed && points[0] != points[len(points)-1] {
+		points = append(points, points[0])
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i] == points[i-1] {
+			continue
+		}
+		line := features.NewLine(points[i-1], points[i], o.Thickness)
+		line.SetPurpose(o.Purpose)
+		feats = append(feats, line)
+	}
+	return feats
+}
+
+// Parse reads an SVG document and converts its paths and basic shapes into
+// features
+func Parse(r io.Reader, opts Options) ([]features.Feature, error) {
+	if opts.Tolerance <= 0 {
+		return nil, errors.New("svg: tolerance must be greater than 0")
+	}
+	if opts.Thickness <= 0 && !(opts.Fill || opts.Purpose == features.Cutout) {
+		return nil, errors.New("svg: thickness must be greater than 0")
+	}
+	dec := xml.NewDecoder(r)
+	stack := []matrix{}
+	feats := []features.Feature{}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svg: %v", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			name := el.Name.Local
+			if skipped[name] {
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("svg: %v", err)
+				}
+				continue
+			}
+			a := newAttrs(el)
+			var m matrix
+			if len(stack) == 0 {
+				if name != "svg" {
+					return nil, fmt.Errorf("svg: root element is %q, not svg", name)
+				}
+				if m, err = rootTransform(a, opts); err != nil {
+					return nil, fmt.Errorf("svg: %v", err)
+				}
+			} else {
+				t, err := parseTransform(a["transform"])
+				if err != nil {
+					return nil, fmt.Errorf("svg: %s element: %v", name, err)
+				}
+				m = stack[len(stack)-1].multiply(t)
+			}
+			stack = append(stack, m)
+			d, err := shapePath(name, a)
+			if err != nil {
+				return nil, fmt.Errorf("svg: %s element: %v", name, err)
+			}
+			if d == "" {
+				continue
+			}
+			paths, err := parsePath(d, m, opts.Tolerance)
+			if err != nil {
+				return nil, fmt.Errorf("svg: %s element: %v", name, err)
+			}
+			for _, sp := range paths {
+				feats = append(feats, opts.features(sp)...)
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return feats, nil
+}
+
+// Load reads an SVG file and converts it into features
+func Load(filename string, opts Options) ([]features.Feature, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, opts)
+}